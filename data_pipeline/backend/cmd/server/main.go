@@ -63,7 +63,10 @@ func main() {
 		log.Println("Database configuration not provided, running without database")
 	}
 
-	r := router.SetupRouter(db)
+	r, err := router.SetupRouter(db)
+	if err != nil {
+		log.Fatalf("Failed to set up router: %v", err)
+	}
 
 	addr := cfg.Server.Address()
 	log.Printf("Server starting on %s", addr)
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/models"
+	"fmt"
+)
+
+// Verifier checks a bearer token and returns its claims. Implementations are
+// selected by AUTH_MODE.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (Claims, error)
+}
+
+// NewVerifier builds the verifier of the configured mode; nil for none. It
+// fails when no mode is configured rather than falling back to none.
+func NewVerifier(cfg config.AuthConfig) (Verifier, error) {
+	switch cfg.EffectiveMode() {
+	case config.AuthModeOIDC:
+		if cfg.Issuer == "" {
+			return nil, fmt.Errorf("AUTH_ISSUER is required for oidc")
+		}
+		return NewOIDCVerifier(cfg.Issuer, cfg.Audience), nil
+	case config.AuthModeKeyFile:
+		return NewKeyFileVerifier(cfg.KeyFile, cfg.Issuer, cfg.Audience)
+	case config.AuthModeNone:
+		return nil, nil
+	case "":
+		return nil, fmt.Errorf("authentication is not configured: set AUTH_ISSUER or AUTH_KEY_FILE, or AUTH_MODE=none to disable it")
+	}
+	return nil, fmt.Errorf("unknown AUTH_MODE %q (expected oidc, keyfile or none)", cfg.Mode)
+}
+
+type contextKey struct{}
+
+// WithUser attaches the authenticated user to the request context
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user or nil
+func UserFromContext(ctx context.Context) *models.User {
+	user, _ := ctx.Value(contextKey{}).(*models.User)
+	return user
+}
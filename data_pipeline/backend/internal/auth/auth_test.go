@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"data-pipeline-backend/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewVerifierMode(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.AuthConfig
+		wantNil bool // authentication disabled
+		wantErr bool
+	}{
+		{name: "nothing configured", cfg: config.AuthConfig{}, wantErr: true},
+		{name: "explicit none", cfg: config.AuthConfig{Mode: config.AuthModeNone}, wantNil: true},
+		{name: "key file derived", cfg: config.AuthConfig{KeyFile: keyFile}},
+		{name: "issuer derived", cfg: config.AuthConfig{Issuer: "https://issuer.example.com"}},
+		{name: "oidc without issuer", cfg: config.AuthConfig{Mode: config.AuthModeOIDC}, wantErr: true},
+		{name: "unknown mode", cfg: config.AuthConfig{Mode: "basic"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (v == nil) != tt.wantNil {
+				t.Fatalf("verifier = %v, want nil %v", v, tt.wantNil)
+			}
+		})
+	}
+}
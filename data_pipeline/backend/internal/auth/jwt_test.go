@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "data-pipeline"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                "user-1",
+		"preferred_username": "alice",
+		"iss":                testIssuer,
+		"aud":                testAudience,
+		"exp":                testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signToken builds a compact JWS; sign gets the signing input
+func signToken(t *testing.T, alg string, claims map[string]interface{}, sign func([]byte) []byte) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": alg, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(data []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+}
+
+func es256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+}
+
+func keys(k ...interface{}) keyLookup {
+	return func(string) []interface{} { return k }
+}
+
+func TestVerifyJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte(strings.Repeat("s", 32))
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+
+	with := func(name string, v interface{}) map[string]interface{} {
+		c := testClaims()
+		if v == nil {
+			delete(c, name)
+		} else {
+			c[name] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		lookup  keyLookup
+		wantErr error  // errors.Is target
+		wantMsg string // substring of the error when wantErr is nil
+	}{
+		{
+			name:   "rs256",
+			token:  signToken(t, "RS256", testClaims(), rs256(t, rsaKey)),
+			lookup: keys(&rsaKey.PublicKey),
+		},
+		{
+			name:   "es256",
+			token:  signToken(t, "ES256", testClaims(), es256(t, ecKey)),
+			lookup: keys(&ecKey.PublicKey),
+		},
+		{
+			name:   "hs256",
+			token:  signToken(t, "HS256", testClaims(), hs256(secret)),
+			lookup: keys(secret),
+		},
+		{
+			name:   "audience list",
+			token:  signToken(t, "HS256", with("aud", []string{"other", testAudience}), hs256(secret)),
+			lookup: keys(secret),
+		},
+		{
+			name:   "issuer trailing slash",
+			token:  signToken(t, "HS256", with("iss", testIssuer+"/"), hs256(secret)),
+			lookup: keys(secret),
+		},
+		{
+			name:    "signed by another key",
+			token:   signToken(t, "RS256", testClaims(), rs256(t, otherRSA)),
+			lookup:  keys(&rsaKey.PublicKey),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "wrong hmac secret",
+			token:   signToken(t, "HS256", testClaims(), hs256([]byte(strings.Repeat("x", 32)))),
+			lookup:  keys(secret),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name: "tampered payload",
+			token: func() string {
+				parts := strings.Split(signToken(t, "HS256", testClaims(), hs256(secret)), ".")
+				parts[1] = encodeSegment(t, with("preferred_username", "admin"))
+				return strings.Join(parts, ".")
+			}(),
+			lookup:  keys(secret),
+			wantErr: ErrBadSignature,
+		},
+		{
+			// HS256 keyed with the RSA public key must not pass as RS256
+			name:    "alg confusion hs256 with rsa public key",
+			token:   signToken(t, "HS256", testClaims(), hs256(rsaPEM)),
+			lookup:  keys(&rsaKey.PublicKey),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "alg confusion rs256 against hmac secret",
+			token:   signToken(t, "RS256", testClaims(), rs256(t, rsaKey)),
+			lookup:  keys(secret),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "alg none",
+			token:   signToken(t, "none", testClaims(), func([]byte) []byte { return nil }),
+			lookup:  keys(secret, &rsaKey.PublicKey),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "unsupported alg",
+			token:   signToken(t, "HS128", testClaims(), hs256(secret)),
+			lookup:  keys(secret),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "no keys",
+			token:   signToken(t, "HS256", testClaims(), hs256(secret)),
+			lookup:  keys(),
+			wantErr: ErrBadSignature,
+		},
+		{
+			name:    "expired",
+			token:   signToken(t, "HS256", with("exp", testNow.Add(-clockSkew-time.Second).Unix()), hs256(secret)),
+			lookup:  keys(secret),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:   "expired within skew",
+			token:  signToken(t, "HS256", with("exp", testNow.Add(-clockSkew/2).Unix()), hs256(secret)),
+			lookup: keys(secret),
+		},
+		{
+			name:    "missing exp",
+			token:   signToken(t, "HS256", with("exp", nil), hs256(secret)),
+			lookup:  keys(secret),
+			wantErr: ErrMalformedToken,
+		},
+		{
+			name:    "not valid yet",
+			token:   signToken(t, "HS256", with("nbf", testNow.Add(10*time.Minute).Unix()), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "not valid yet",
+		},
+		{
+			name:    "wrong issuer",
+			token:   signToken(t, "HS256", with("iss", "https://evil.example.com"), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "issuer",
+		},
+		{
+			name:    "missing issuer",
+			token:   signToken(t, "HS256", with("iss", nil), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "issuer",
+		},
+		{
+			name:    "wrong audience",
+			token:   signToken(t, "HS256", with("aud", "other"), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "audience",
+		},
+		{
+			name:    "audience list without ours",
+			token:   signToken(t, "HS256", with("aud", []string{"a", "b"}), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "audience",
+		},
+		{
+			name:    "missing audience",
+			token:   signToken(t, "HS256", with("aud", nil), hs256(secret)),
+			lookup:  keys(secret),
+			wantMsg: "audience",
+		},
+		{
+			name:    "two segments",
+			token:   "a.b",
+			lookup:  keys(secret),
+			wantErr: ErrMalformedToken,
+		},
+		{
+			name:    "bad header",
+			token:   "!!!.e30.c2ln",
+			lookup:  keys(secret),
+			wantErr: ErrMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifyJWT(tt.token, tt.lookup, testIssuer, testAudience, testNow)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("err = %v, want one mentioning %q", err, tt.wantMsg)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if claims.String("sub") != "user-1" {
+					t.Fatalf("sub = %q", claims.String("sub"))
+				}
+			}
+			if err != nil && claims != nil {
+				t.Fatalf("claims returned with error %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTSkipsEmptyIssuerAndAudience(t *testing.T) {
+	secret := []byte(strings.Repeat("s", 32))
+	claims := testClaims()
+	claims["iss"], claims["aud"] = "anyone", "anything"
+
+	token := signToken(t, "HS256", claims, hs256(secret))
+	if _, err := verifyJWT(token, keys(secret), "", "", testNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestKeyFileVerifier(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPath := filepath.Join(dir, "key.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(pemPath, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := testClaims()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+	v, err := NewKeyFileVerifier(pemPath, testIssuer, testAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(context.Background(), signToken(t, "RS256", claims, rs256(t, rsaKey))); err != nil {
+		t.Fatalf("rs256: %v", err)
+	}
+	// 공개키 PEM을 HMAC 키로 쓴 토큰은 거부되어야 한다
+	if _, err := v.Verify(context.Background(), signToken(t, "HS256", claims, hs256(pemData))); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("hs256 with public key: err = %v, want %v", err, ErrBadSignature)
+	}
+
+	shortPath := filepath.Join(dir, "short")
+	if err := os.WriteFile(shortPath, []byte("too-short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyFileVerifier(shortPath, "", ""); err == nil {
+		t.Fatal("short HMAC secret accepted")
+	}
+}
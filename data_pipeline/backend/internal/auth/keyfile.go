@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// KeyFileVerifier verifies tokens against keys read from a local file: PEM
+// public keys or certificates (RS*, PS*, ES*), or otherwise the whole file as
+// an HMAC secret (HS*). Meant for tests and single-node setups.
+type KeyFileVerifier struct {
+	keys     []interface{}
+	issuer   string
+	audience string
+}
+
+func NewKeyFileVerifier(path, issuer, audience string) (*KeyFileVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth key file: %w", err)
+	}
+
+	var keys []interface{}
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := parsePEMKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		secret := bytes.TrimSpace(data)
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("auth key file has no PEM key and is too short for an HMAC secret (min 32 bytes)")
+		}
+		keys = append(keys, secret)
+	}
+
+	return &KeyFileVerifier{keys: keys, issuer: issuer, audience: audience}, nil
+}
+
+func parsePEMKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q in auth key file", block.Type)
+}
+
+func (v *KeyFileVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	return verifyJWT(token, func(string) []interface{} { return v.keys }, v.issuer, v.audience, time.Now())
+}
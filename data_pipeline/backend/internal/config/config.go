@@ -4,15 +4,24 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all application configuration
 type Config struct {
-	DB      DBConfig
-	Server  ServerConfig
-	K8s     K8sConfig
-	Jupyter JupyterConfig
-	Logging LoggingConfig
+	DB        DBConfig
+	Server    ServerConfig
+	K8s       K8sConfig
+	Jupyter   JupyterConfig
+	Logging   LoggingConfig
+	Runtime   RuntimeConfig
+	Image     ImageConfig
+	Secrets   SecretsConfig
+	Auth      AuthConfig
+	Workspace WorkspaceConfig
+	Staging   StagingConfig
+	Scheduler SchedulerConfig
+	Triggers  TriggerConfig
 }
 
 // DBConfig holds database configuration
@@ -33,8 +42,9 @@ func (c DBConfig) ConnectionString() string {
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port           string
+	Host           string
+	TrustedProxies []string // IPs / CIDRs whose X-Forwarded-For is believed; empty = none
 }
 
 // Address returns server address (host:port)
@@ -59,6 +69,93 @@ type JupyterConfig struct {
 	Token  string
 }
 
+// RuntimeConfig holds flow execution backend configuration
+type RuntimeConfig struct {
+	Default   string // knative, local
+	PythonBin string
+	TraceURL  string // runner span ingestion endpoint; empty disables tracing
+	TraceKey  string // HMAC key of the runners' span ingest tokens; default SECRETS_KEY
+}
+
+// ImageConfig holds step image configuration
+type ImageConfig struct {
+	Default         string // runner image of steps without requirements
+	Registry        string // push target of built step images; empty disables builds
+	KanikoImage     string
+	PushSecret      string // docker config secret mounted into the Kaniko Job
+	BuildTimeoutSec int
+}
+
+// SecretsConfig holds the encryption key of stored connection values
+type SecretsConfig struct {
+	Key string // base64 encoded 32-byte AES key; empty disables connections
+}
+
+// Authentication modes
+const (
+	AuthModeOIDC    = "oidc"
+	AuthModeKeyFile = "keyfile"
+	AuthModeNone    = "none"
+)
+
+// AuthConfig holds bearer token authentication configuration
+type AuthConfig struct {
+	Mode          string // oidc, keyfile, none; empty = derived from Issuer / KeyFile, never none
+	Issuer        string // OIDC issuer URL; also checked against iss in keyfile mode
+	Audience      string
+	KeyFile       string // PEM public keys or an HMAC secret
+	UsernameClaim string // claim that names the user (and its user-<name> namespace)
+	Admins        []string
+	DevUser       string // identity of every request when auth is disabled
+}
+
+// EffectiveMode resolves an empty Mode from the other settings. It stays
+// empty when neither Issuer nor KeyFile is set: authentication is only
+// disabled by an explicit AUTH_MODE=none.
+func (c AuthConfig) EffectiveMode() string {
+	if c.Mode != "" {
+		return c.Mode
+	}
+	if c.Issuer != "" {
+		return AuthModeOIDC
+	}
+	if c.KeyFile != "" {
+		return AuthModeKeyFile
+	}
+	return ""
+}
+
+// WorkspaceConfig holds the file storage of the /api/data endpoints
+type WorkspaceConfig struct {
+	Root    string // users/<name> and projects/<id> are created below it
+	QuotaMB int    // per workspace; 0 = unlimited
+}
+
+// StagingConfig holds the directory through which the Python step of
+// /api/workflow/execute exchanges data with the executor
+type StagingConfig struct {
+	Dir        string
+	KernelDir  string // Dir as mounted in the executor; empty = same path
+	Format     string // jsonl or parquet
+	ChunkRows  int    // rows per chunk the user code sees; 0 = the whole dataset
+	TimeoutSec int
+}
+
+// SchedulerConfig holds the loop that fires flow schedules. Every replica
+// may run it; only the one holding the scheduler lock fires.
+type SchedulerConfig struct {
+	Enabled     bool
+	IntervalSec int // how often due schedules are checked
+}
+
+// TriggerConfig holds the watchers of file and Kafka flow triggers. Like
+// the scheduler, only the replica holding the trigger lock runs them;
+// webhooks are served by every replica.
+type TriggerConfig struct {
+	Enabled         bool
+	PollIntervalSec int // how often watched directories are listed
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string
@@ -79,8 +176,9 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Host:           getEnv("SERVER_HOST", "localhost"),
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES"),
 		},
 		K8s: K8sConfig{
 			InCluster:      getEnvAsBool("K8S_IN_CLUSTER", false),
@@ -99,6 +197,50 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "text"),
 		},
+		Runtime: RuntimeConfig{
+			Default:   getEnv("FLOW_RUNTIME", "knative"),
+			PythonBin: getEnv("LOCAL_PYTHON_BIN", "python3"),
+			TraceURL:  getEnv("TRACE_INGEST_URL", ""),
+			TraceKey:  getEnv("TRACE_INGEST_KEY", ""),
+		},
+		Image: ImageConfig{
+			Default:         getEnv("FLOW_PY_IMAGE", "ghcr.io/miribitsm3/python_image_build/python-pandas:1.0"),
+			Registry:        getEnv("IMAGE_REGISTRY", ""),
+			KanikoImage:     getEnv("KANIKO_IMAGE", "gcr.io/kaniko-project/executor:v1.23.2"),
+			PushSecret:      getEnv("IMAGE_PUSH_SECRET", ""),
+			BuildTimeoutSec: getEnvAsInt("IMAGE_BUILD_TIMEOUT_SEC", 900),
+		},
+		Secrets: SecretsConfig{
+			Key: getEnv("SECRETS_KEY", ""),
+		},
+		Auth: AuthConfig{
+			Mode:          getEnv("AUTH_MODE", ""),
+			Issuer:        getEnv("AUTH_ISSUER", ""),
+			Audience:      getEnv("AUTH_AUDIENCE", ""),
+			KeyFile:       getEnv("AUTH_KEY_FILE", ""),
+			UsernameClaim: getEnv("AUTH_USERNAME_CLAIM", "preferred_username"),
+			Admins:        getEnvAsList("AUTH_ADMINS"),
+			DevUser:       getEnv("AUTH_DEV_USER", "dev"),
+		},
+		Workspace: WorkspaceConfig{
+			Root:    getEnv("WORKSPACE_ROOT", "./workspaces"),
+			QuotaMB: getEnvAsInt("WORKSPACE_QUOTA_MB", 1024),
+		},
+		Staging: StagingConfig{
+			Dir:        getEnv("STAGING_DIR", "./staging"),
+			KernelDir:  getEnv("STAGING_KERNEL_DIR", ""),
+			Format:     getEnv("STAGING_FORMAT", "jsonl"),
+			ChunkRows:  getEnvAsInt("PYTHON_CHUNK_ROWS", 0),
+			TimeoutSec: getEnvAsInt("PYTHON_TIMEOUT_SEC", 600),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:     getEnvAsBool("SCHEDULER_ENABLED", true),
+			IntervalSec: getEnvAsInt("SCHEDULER_INTERVAL_SEC", 10),
+		},
+		Triggers: TriggerConfig{
+			Enabled:         getEnvAsBool("TRIGGERS_ENABLED", true),
+			PollIntervalSec: getEnvAsInt("TRIGGER_POLL_INTERVAL_SEC", 15),
+		},
 	}
 	globalConfig = cfg
 	return cfg, nil
@@ -128,6 +270,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -72,6 +72,22 @@ func ValidateSchema(db *sql.DB) (bool, []string, error) {
 	expectedTables := []string{
 		"flows",
 		"objects",
+		"edges",
+		"flow_versions",
+		"deployments",
+		"trace_spans",
+		"step_images",
+		"connections",
+		"users",
+		"projects",
+		"project_members",
+		"audit_events",
+		"workflow_runs",
+		"workflow_run_steps",
+		"flow_schedules",
+		"flow_triggers",
+		"trigger_firings",
+		"webhook_deliveries",
 	}
 
 	query := `
@@ -0,0 +1,3 @@
+-- Rollback: DAG edges
+
+DROP TABLE IF EXISTS edges;
@@ -0,0 +1,29 @@
+-- Migration: DAG edges between objects
+-- objects.target only allows a single successor per node, so flows are stored
+-- as an explicit edge list that supports fan-out and fan-in.
+
+CREATE TABLE IF NOT EXISTS edges (
+    e_id BIGSERIAL PRIMARY KEY,
+    flow BIGINT NOT NULL,
+    source BIGINT NOT NULL,
+    target BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_edges_flow FOREIGN KEY (flow) REFERENCES flows(f_id) ON DELETE CASCADE,
+    CONSTRAINT fk_edges_source FOREIGN KEY (source) REFERENCES objects(o_id) ON DELETE CASCADE,
+    CONSTRAINT fk_edges_target FOREIGN KEY (target) REFERENCES objects(o_id) ON DELETE CASCADE,
+    CONSTRAINT uq_edges_source_target UNIQUE (source, target),
+    CONSTRAINT chk_edges_no_self_loop CHECK (source <> target)
+);
+
+CREATE INDEX IF NOT EXISTS idx_edges_flow ON edges(flow);
+CREATE INDEX IF NOT EXISTS idx_edges_target ON edges(target);
+
+-- 기존 objects.target 단일 포인터를 엣지로 이관
+INSERT INTO edges (flow, source, target)
+SELECT o.flow, o.o_id, o.target
+FROM objects o
+JOIN objects t ON t.o_id = o.target AND t.flow = o.flow
+WHERE o.target IS NOT NULL
+  AND o.flow IS NOT NULL
+  AND o.target <> o.o_id
+ON CONFLICT (source, target) DO NOTHING;
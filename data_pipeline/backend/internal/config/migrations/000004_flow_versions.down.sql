@@ -0,0 +1,5 @@
+-- Rollback: Immutable flow versions
+
+DROP TRIGGER IF EXISTS trg_flow_versions_immutable ON flow_versions;
+DROP FUNCTION IF EXISTS flow_versions_immutable();
+DROP TABLE IF EXISTS flow_versions;
@@ -0,0 +1,36 @@
+-- Migration: Immutable flow versions
+-- Every save and every deploy records a full snapshot of the flow (nodes,
+-- params, code hashes, edges) so older versions can be diffed and redeployed.
+
+CREATE TABLE IF NOT EXISTS flow_versions (
+    v_id BIGSERIAL PRIMARY KEY,
+    flow BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    reason VARCHAR(20) NOT NULL,             -- save, deploy, rollback
+    snapshot JSONB NOT NULL,                 -- {"name", "run_type", "nodes": [...], "edges": [...]}
+    snapshot_hash VARCHAR(64) NOT NULL,
+    deploy_options JSONB,                    -- deploy 시점의 요청 옵션 (user, steps, ...)
+    deploy_status VARCHAR(20),               -- ok, ng
+    deploy_message TEXT,
+    parent_version INTEGER,                  -- rollback 원본 버전
+    created_by BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_flow_versions_flow FOREIGN KEY (flow) REFERENCES flows(f_id) ON DELETE CASCADE,
+    CONSTRAINT uq_flow_versions_flow_version UNIQUE (flow, version),
+    CONSTRAINT chk_flow_versions_reason CHECK (reason IN ('save', 'deploy', 'rollback')),
+    CONSTRAINT chk_flow_versions_deploy_status CHECK (deploy_status IS NULL OR deploy_status IN ('ok', 'ng'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_versions_flow ON flow_versions(flow, version DESC);
+
+-- 스냅샷은 생성 이후 변경할 수 없다
+CREATE OR REPLACE FUNCTION flow_versions_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'flow_versions rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_flow_versions_immutable ON flow_versions;
+CREATE TRIGGER trg_flow_versions_immutable
+    BEFORE UPDATE ON flow_versions
+    FOR EACH ROW EXECUTE FUNCTION flow_versions_immutable();
@@ -0,0 +1,3 @@
+-- Rollback: Deployment records
+
+DROP TABLE IF EXISTS deployments;
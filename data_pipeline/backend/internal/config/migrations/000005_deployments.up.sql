@@ -0,0 +1,25 @@
+-- Migration: Deployment records
+-- One row per deploy request with what was applied (namespace, ksvcs, topic,
+-- consumer groups, code hashes) and how each phase ended, so the live cluster
+-- state can be reconciled against the last deploy.
+
+CREATE TABLE IF NOT EXISTS deployments (
+    d_id BIGSERIAL PRIMARY KEY,
+    flow BIGINT NOT NULL,
+    version INTEGER,                         -- flow_versions.version recorded for this deploy
+    runtime VARCHAR(20) NOT NULL DEFAULT 'knative',
+    namespace VARCHAR(255) NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    deployed_by VARCHAR(255) NOT NULL,       -- deploy 요청의 user (namespace 소유자)
+    steps JSONB NOT NULL DEFAULT '[]',       -- [{"name", "object_id", "ksvc", "kafka_source", "consumer_group", "code_hash", "route"}]
+    phases JSONB NOT NULL DEFAULT '[]',      -- [{"phase", "message", "ok", "at"}]
+    status VARCHAR(20) NOT NULL,             -- running, ok, ng
+    verify_result TEXT,
+    created_by BIGINT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    CONSTRAINT fk_deployments_flow FOREIGN KEY (flow) REFERENCES flows(f_id) ON DELETE CASCADE,
+    CONSTRAINT chk_deployments_status CHECK (status IN ('running', 'ok', 'ng'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployments_flow ON deployments(flow, d_id DESC);
@@ -0,0 +1,3 @@
+-- Rollback: Trace spans
+
+DROP TABLE IF EXISTS trace_spans;
@@ -0,0 +1,27 @@
+-- Migration: Trace spans
+-- The runner reports one span per handled event (step, trace id, in type,
+-- emitted events, duration, error) so an event's path through a flow can be
+-- rebuilt by following event_id -> emitted ids.
+
+CREATE TABLE IF NOT EXISTS trace_spans (
+    s_id BIGSERIAL PRIMARY KEY,
+    flow BIGINT NOT NULL,
+    trace_id VARCHAR(128) NOT NULL,
+    event_id VARCHAR(128) NOT NULL,          -- 처리한 이벤트의 Ce-Id
+    step VARCHAR(255) NOT NULL,              -- runner APP_ID
+    producer VARCHAR(255),                   -- 이벤트를 발행한 스텝 (Ce-Producer)
+    in_type VARCHAR(255),
+    hops INTEGER NOT NULL DEFAULT 0,
+    items INTEGER NOT NULL DEFAULT 0,
+    emitted JSONB NOT NULL DEFAULT '[]',     -- [{"id", "type"}]
+    status VARCHAR(20) NOT NULL,             -- ok, error, dropped
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_trace_spans_flow FOREIGN KEY (flow) REFERENCES flows(f_id) ON DELETE CASCADE,
+    CONSTRAINT chk_trace_spans_status CHECK (status IN ('ok', 'error', 'dropped'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trace_spans_flow_trace ON trace_spans(flow, trace_id);
+CREATE INDEX IF NOT EXISTS idx_trace_spans_flow_started ON trace_spans(flow, started_at DESC);
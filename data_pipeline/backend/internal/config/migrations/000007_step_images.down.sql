@@ -0,0 +1,3 @@
+-- Rollback: Step images
+
+DROP TABLE IF EXISTS step_images;
@@ -0,0 +1,18 @@
+-- Migration: Step images
+-- Steps that declare params.requirements run in an image built once per
+-- unique (base image, requirements) set. The pushed digest is kept so the same
+-- dependencies always resolve to the same image.
+
+CREATE TABLE IF NOT EXISTS step_images (
+    i_id BIGSERIAL PRIMARY KEY,
+    dep_key VARCHAR(64) NOT NULL UNIQUE,     -- sha256(base image + 정렬된 requirements)
+    base_image TEXT NOT NULL,
+    requirements JSONB NOT NULL DEFAULT '[]',
+    image TEXT NOT NULL,                     -- <registry>/flow-step:<dep_key>
+    digest VARCHAR(100),                     -- sha256:... (빌드 성공 시)
+    status VARCHAR(20) NOT NULL,             -- building, ready, failed
+    log TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_step_images_status CHECK (status IN ('building', 'ready', 'failed'))
+);
@@ -0,0 +1,3 @@
+-- Rollback: Connections
+
+DROP TABLE IF EXISTS connections;
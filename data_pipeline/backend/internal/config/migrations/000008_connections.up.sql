@@ -0,0 +1,17 @@
+-- Migration: Connections
+-- Named credentials (DB passwords, API tokens) that steps reference from
+-- params.connections instead of hard-coding them in params.code. Values are
+-- stored AES-256-GCM encrypted; only the key names are readable.
+
+CREATE TABLE IF NOT EXISTS connections (
+    c_id BIGSERIAL PRIMARY KEY,
+    owner VARCHAR(100) NOT NULL,             -- K8s 요청의 user (네임스페이스 user-<owner>)
+    name VARCHAR(63) NOT NULL,
+    type VARCHAR(50) NOT NULL DEFAULT 'generic',
+    description TEXT,
+    keys JSONB NOT NULL DEFAULT '[]',        -- 값 없이 키 이름만
+    secret BYTEA NOT NULL,                   -- nonce || AES-256-GCM(JSON values)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_connections_owner_name UNIQUE (owner, name)
+);
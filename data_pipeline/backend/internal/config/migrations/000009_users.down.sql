@@ -0,0 +1,3 @@
+-- Rollback: Users
+
+DROP TABLE IF EXISTS users;
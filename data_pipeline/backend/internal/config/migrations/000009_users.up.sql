@@ -0,0 +1,13 @@
+-- Migration: Users
+-- Identities of authenticated callers, created on first request. u_id is what
+-- flows.created_by and the other created_by columns refer to; username names
+-- the user-<username> namespace the user deploys into.
+
+CREATE TABLE IF NOT EXISTS users (
+    u_id BIGSERIAL PRIMARY KEY,
+    subject VARCHAR(255) NOT NULL UNIQUE,    -- <iss>|<sub> of the token
+    username VARCHAR(57) NOT NULL UNIQUE,    -- DNS label; "user-" + username <= 63
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+-- Rollback: Projects
+
+ALTER TABLE training_runs DROP COLUMN IF EXISTS project_id;
+ALTER TABLE models DROP COLUMN IF EXISTS project_id;
+ALTER TABLE datasets DROP COLUMN IF EXISTS project_id;
+ALTER TABLE flows DROP COLUMN IF EXISTS project_id;
+
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
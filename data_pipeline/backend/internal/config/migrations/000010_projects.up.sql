@@ -0,0 +1,33 @@
+-- Migration: Projects
+-- Teams that share flows, datasets and models. Members hold one role each:
+-- viewer < editor < deployer < admin. Resources without a project stay
+-- personal to their creator.
+
+CREATE TABLE IF NOT EXISTS projects (
+    p_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    created_by BIGINT REFERENCES users(u_id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id BIGINT NOT NULL REFERENCES projects(p_id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(u_id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, user_id),
+    CONSTRAINT chk_project_member_role CHECK (role IN ('viewer', 'editor', 'deployer', 'admin'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user ON project_members(user_id);
+
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS project_id BIGINT REFERENCES projects(p_id) ON DELETE SET NULL;
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS project_id BIGINT REFERENCES projects(p_id) ON DELETE SET NULL;
+ALTER TABLE models ADD COLUMN IF NOT EXISTS project_id BIGINT REFERENCES projects(p_id) ON DELETE SET NULL;
+ALTER TABLE training_runs ADD COLUMN IF NOT EXISTS project_id BIGINT REFERENCES projects(p_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_flows_project ON flows(project_id);
+CREATE INDEX IF NOT EXISTS idx_datasets_project ON datasets(project_id);
+CREATE INDEX IF NOT EXISTS idx_models_project ON models(project_id);
+CREATE INDEX IF NOT EXISTS idx_training_runs_project ON training_runs(project_id);
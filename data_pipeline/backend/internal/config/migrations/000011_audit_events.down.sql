@@ -0,0 +1,3 @@
+-- Rollback: Audit events
+
+DROP TABLE IF EXISTS audit_events;
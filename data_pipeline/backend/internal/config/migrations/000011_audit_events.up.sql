@@ -0,0 +1,29 @@
+-- Migration: Audit events
+-- One row per mutating API request: who did what to which resource, with
+-- which (redacted) parameters and how it ended. Rows are inserted as
+-- 'pending' when the request starts so long operations (deploy streams) are
+-- recorded even if they never finish.
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    a_id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_id BIGINT,                          -- users.u_id; NULL without authentication
+    actor VARCHAR(100) NOT NULL,              -- username at the time of the request
+    action VARCHAR(100) NOT NULL,             -- e.g. flow.delete, model.activate
+    target_type VARCHAR(50),
+    target_id VARCHAR(100),
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',       -- query, path vars and body with secrets redacted
+    outcome VARCHAR(16) NOT NULL DEFAULT 'pending',
+    status INTEGER,
+    error TEXT,
+    duration_ms INTEGER,
+    remote_addr VARCHAR(100),
+    CONSTRAINT chk_audit_outcome CHECK (outcome IN ('pending', 'success', 'denied', 'failure'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred ON audit_events(occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, occurred_at DESC);
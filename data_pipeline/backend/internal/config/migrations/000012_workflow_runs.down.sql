@@ -0,0 +1,5 @@
+-- Rollback: Workflow runs
+
+ALTER TABLE flows DROP COLUMN IF EXISTS lastest_run_id;
+DROP TABLE IF EXISTS workflow_run_steps;
+DROP TABLE IF EXISTS workflow_runs;
@@ -0,0 +1,52 @@
+-- Migration: Workflow runs
+-- Every /api/workflow/execute request becomes a run that executes in the
+-- background. Steps are recorded as they progress so a run can be followed
+-- (or inspected afterwards) without holding the HTTP request open.
+
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    r_id BIGSERIAL PRIMARY KEY,
+    flow_id BIGINT REFERENCES flows(f_id) ON DELETE SET NULL,
+    project_id BIGINT REFERENCES projects(p_id) ON DELETE SET NULL,
+    created_by BIGINT REFERENCES users(u_id) ON DELETE SET NULL,
+    actor VARCHAR(100) NOT NULL,
+    trigger VARCHAR(32) NOT NULL DEFAULT 'manual',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    request JSONB NOT NULL DEFAULT '{}',      -- the submitted workflow
+    output_path TEXT,                         -- of the last save step
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms BIGINT,
+    heartbeat_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- refreshed by the executing server
+    CONSTRAINT chk_workflow_run_status CHECK (status IN ('pending', 'running', 'success', 'failed'))
+);
+
+CREATE TABLE IF NOT EXISTS workflow_run_steps (
+    run_id BIGINT NOT NULL REFERENCES workflow_runs(r_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,                -- execution order; inputs come first
+    step_id VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    rows BIGINT,
+    output_path TEXT,
+    message TEXT,
+    error TEXT,
+    logs TEXT,                                -- executor output of python steps
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms BIGINT,
+    PRIMARY KEY (run_id, position),
+    CONSTRAINT chk_workflow_run_step_status CHECK (status IN ('pending', 'running', 'success', 'failed', 'skipped'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_created ON workflow_runs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_flow ON workflow_runs(flow_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_project ON workflow_runs(project_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_created_by ON workflow_runs(created_by);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_unfinished ON workflow_runs(heartbeat_at) WHERE status IN ('pending', 'running');
+
+-- lastest_run keeps the time of the last run (or deployment); lastest_run_id
+-- names the workflow run
+ALTER TABLE flows ADD COLUMN IF NOT EXISTS lastest_run_id BIGINT REFERENCES workflow_runs(r_id) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+-- Rollback: Flow schedules
+
+DROP INDEX IF EXISTS idx_workflow_runs_schedule;
+UPDATE workflow_runs SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE workflow_runs DROP CONSTRAINT IF EXISTS chk_workflow_run_status;
+ALTER TABLE workflow_runs ADD CONSTRAINT chk_workflow_run_status
+    CHECK (status IN ('pending', 'running', 'success', 'failed'));
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS flow_schedules;
@@ -0,0 +1,37 @@
+-- Migration: Flow schedules
+-- A schedule runs a flow as a workflow run whenever its cron expression
+-- fires. Only the backend replica holding the scheduler lock fires
+-- schedules; next_run_at and queued_at are its bookkeeping.
+
+CREATE TABLE IF NOT EXISTS flow_schedules (
+    s_id BIGSERIAL PRIMARY KEY,
+    flow_id BIGINT NOT NULL REFERENCES flows(f_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    cron VARCHAR(100) NOT NULL,                       -- 5 fields or @daily, @every 1h ...
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',      -- IANA name the cron fields are read in
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    overlap_policy VARCHAR(16) NOT NULL DEFAULT 'skip',
+    catch_up VARCHAR(16) NOT NULL DEFAULT 'none',
+    next_run_at TIMESTAMP,                            -- UTC
+    queued_at TIMESTAMP,                              -- a firing waiting for the previous run (queue)
+    last_run_id BIGINT REFERENCES workflow_runs(r_id) ON DELETE SET NULL,
+    last_run_at TIMESTAMP,
+    last_error TEXT,
+    run_as BIGINT REFERENCES users(u_id) ON DELETE SET NULL, -- last user to save it; the runs execute as them
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_flow_schedule_overlap CHECK (overlap_policy IN ('skip', 'queue', 'cancel')),
+    CONSTRAINT chk_flow_schedule_catch_up CHECK (catch_up IN ('none', 'latest', 'all'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_schedules_flow ON flow_schedules(flow_id);
+CREATE INDEX IF NOT EXISTS idx_flow_schedules_due ON flow_schedules(next_run_at) WHERE enabled;
+
+-- Runs started by a schedule, and runs stopped before they finished
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS schedule_id BIGINT REFERENCES flow_schedules(s_id) ON DELETE SET NULL;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE workflow_runs DROP CONSTRAINT IF EXISTS chk_workflow_run_status;
+ALTER TABLE workflow_runs ADD CONSTRAINT chk_workflow_run_status
+    CHECK (status IN ('pending', 'running', 'success', 'failed', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_schedule ON workflow_runs(schedule_id) WHERE schedule_id IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Rollback: Flow triggers
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS trigger_firings;
+DROP INDEX IF EXISTS idx_workflow_runs_trigger;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS trigger_id;
+DROP TABLE IF EXISTS flow_triggers;
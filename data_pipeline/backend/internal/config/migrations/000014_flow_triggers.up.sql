@@ -0,0 +1,53 @@
+-- Migration: Flow triggers
+-- A trigger runs a flow when an event arrives: a new file under a watched
+-- workspace directory or S3 prefix, a signed webhook request or a message
+-- on a Kafka topic. The event is handed to the flow as the evt of its first
+-- Python step. Every firing is recorded with the run it started.
+
+CREATE TABLE IF NOT EXISTS flow_triggers (
+    t_id BIGSERIAL PRIMARY KEY,
+    flow_id BIGINT NOT NULL REFERENCES flows(f_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,                        -- file, webhook, kafka
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    config JSONB NOT NULL DEFAULT '{}',               -- watched location or topic, per kind
+    max_active INTEGER NOT NULL DEFAULT 4,            -- runs of the trigger at once
+    secret BYTEA,                                     -- webhook HMAC key, encrypted with SECRETS_KEY
+    state JSONB NOT NULL DEFAULT '{}',                -- watcher bookkeeping (files already seen)
+    last_fired_at TIMESTAMP,
+    last_error TEXT,
+    run_as BIGINT REFERENCES users(u_id) ON DELETE SET NULL, -- last user to save it; the runs execute as them
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_flow_trigger_kind CHECK (kind IN ('file', 'webhook', 'kafka')),
+    CONSTRAINT chk_flow_trigger_max_active CHECK (max_active > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flow_triggers_flow ON flow_triggers(flow_id);
+
+-- Runs started by a trigger
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS trigger_id BIGINT REFERENCES flow_triggers(t_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_trigger ON workflow_runs(trigger_id) WHERE trigger_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS trigger_firings (
+    fi_id BIGSERIAL PRIMARY KEY,
+    trigger_id BIGINT NOT NULL REFERENCES flow_triggers(t_id) ON DELETE CASCADE,
+    run_id BIGINT REFERENCES workflow_runs(r_id) ON DELETE SET NULL,
+    status VARCHAR(16) NOT NULL,                      -- started, skipped, failed
+    source TEXT NOT NULL DEFAULT '',                  -- file path, topic/partition@offset, remote address
+    payload JSONB,                                    -- the evt; omitted when too large
+    error TEXT,
+    fired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_trigger_firing_status CHECK (status IN ('started', 'skipped', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_trigger_firings_trigger ON trigger_firings(trigger_id, fi_id DESC);
+
+-- Webhook deliveries already accepted, by the sender's delivery ID or the
+-- signature; a replayed or retried request is not fired again
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    trigger_id BIGINT NOT NULL REFERENCES flow_triggers(t_id) ON DELETE CASCADE,
+    delivery_id TEXT NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (trigger_id, delivery_id)
+);
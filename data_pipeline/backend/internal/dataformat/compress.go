@@ -0,0 +1,133 @@
+package dataformat
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression names
+const (
+	None = "none"
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CompressionFromName returns the compression of a file name's extension
+// (.gz, .zst), or None
+func CompressionFromName(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".gz", ".gzip":
+		return Gzip
+	case ".zst", ".zstd":
+		return Zstd
+	}
+	return None
+}
+
+// DetectCompression recognises gzip and zstd by their magic bytes
+func DetectCompression(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(head, zstdMagic):
+		return Zstd
+	}
+	return None
+}
+
+// Peek returns up to n leading bytes of r and a reader that still yields
+// them. Seekable readers are rewound rather than wrapped, so they keep their
+// random access.
+func Peek(r io.Reader, n int) ([]byte, io.Reader, error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		head := make([]byte, n)
+		m, err := io.ReadFull(rs, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, nil, err
+		}
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		return head[:m], rs, nil
+	}
+	br := bufio.NewReaderSize(r, max(n, 4096))
+	head, err := br.Peek(n)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+	return head, br, nil
+}
+
+// Decompress unwraps r. compression is gzip, zstd, none, or "" to detect it
+// from the magic bytes. It returns the reader and the compression used; r
+// itself is returned when it is not compressed.
+func Decompress(r io.Reader, compression string) (io.ReadCloser, string, error) {
+	if compression == "" || compression == "auto" {
+		head, pr, err := Peek(r, len(zstdMagic))
+		if err != nil {
+			return nil, "", err
+		}
+		r, compression = pr, DetectCompression(head)
+	}
+	switch compression {
+	case None:
+		return nopReadCloser{r}, None, nil
+	case Gzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("gzip: %w", err)
+		}
+		return zr, Gzip, nil
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("zstd: %w", err)
+		}
+		return zr.IOReadCloser(), Zstd, nil
+	}
+	return nil, "", fmt.Errorf("unsupported compression: %s", compression)
+}
+
+// Compress wraps w; closing the result flushes the compressed stream but
+// does not close w
+func Compress(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "", None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported compression: %s", compression)
+}
+
+// nopReadCloser keeps the methods of the wrapped reader reachable for
+// newParquetReader
+type nopReadCloser struct {
+	io.Reader
+}
+
+func (nopReadCloser) Close() error {
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package dataformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// delimitedReader reads CSV or TSV with a header row. Values stay strings,
+// as the Python step has always received them; short rows leave the
+// remaining columns out.
+type delimitedReader struct {
+	r       *csv.Reader
+	headers []string
+	err     error
+}
+
+func newDelimitedReader(r io.Reader, comma rune) *delimitedReader {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	if comma == '\t' {
+		// TSV는 따옴표를 일반 문자로 취급하는 경우가 많다
+		cr.LazyQuotes = true
+	}
+	d := &delimitedReader{r: cr}
+	headers, err := cr.Read()
+	switch {
+	case err == io.EOF:
+		d.err = io.EOF
+	case err != nil:
+		d.err = err
+	default:
+		d.headers = append([]string{}, headers...)
+		if len(d.headers) > 0 {
+			d.headers[0] = strings.TrimPrefix(d.headers[0], "\ufeff")
+		}
+	}
+	return d
+}
+
+func (d *delimitedReader) Columns() []string {
+	return d.headers
+}
+
+func (d *delimitedReader) Read() (map[string]interface{}, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(d.headers))
+	for i, h := range d.headers {
+		if i < len(record) {
+			row[h] = record[i]
+		}
+	}
+	return row, nil
+}
+
+func (d *delimitedReader) Close() error {
+	return nil
+}
+
+func writeDelimited(w io.Writer, comma rune, columns []string, records []map[string]interface{}) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, rec := range records {
+		for i, c := range columns {
+			row[i] = textValue(rec[c])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// textValue formats a value for a text cell; null is empty and nested
+// values are JSON
+func textValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
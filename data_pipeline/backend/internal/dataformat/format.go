@@ -0,0 +1,210 @@
+// Package dataformat reads and writes the tabular file formats of the data
+// endpoints. Rows are JSON-style records (map[string]interface{}); readers
+// return them one at a time so a preview only decodes what it shows.
+package dataformat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format names, as used by workspace.DetectFormat and the fileFormat option
+const (
+	CSV     = "csv"
+	TSV     = "tsv"
+	JSON    = "json"
+	JSONL   = "jsonl"
+	Parquet = "parquet"
+	XLSX    = "xlsx"
+)
+
+// Normalize maps format aliases ("excel", "ndjson", ...) to a format name;
+// unknown names are returned lower-cased
+func Normalize(format string) string {
+	switch f := strings.ToLower(format); f {
+	case "excel":
+		return XLSX
+	case "ndjson", "jsonlines":
+		return JSONL
+	case "pq":
+		return Parquet
+	default:
+		return f
+	}
+}
+
+// ErrNotTabular is returned by readers of JSON documents that are not an
+// array of objects
+var ErrNotTabular = errors.New("data is not an array of objects")
+
+// Field is a column of a schema. Type is one of integer, number, boolean,
+// timestamp, date, string, binary, object, array (or a parquet name when
+// nothing better fits).
+type Field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Reader returns the rows of a file
+type Reader interface {
+	// Columns are the known column names in file order; JSON readers learn
+	// them from the rows read so far
+	Columns() []string
+	// Read returns the next row, or io.EOF
+	Read() (map[string]interface{}, error)
+	Close() error
+}
+
+// Typed is implemented by readers whose files declare a schema
+type Typed interface {
+	Schema() []Field
+}
+
+// ReadOptions select what a reader returns
+type ReadOptions struct {
+	Columns []string // projection; empty = all (parquet skips the other columns)
+	Sheet   string   // xlsx sheet; default the first
+}
+
+// NewReader reads format from r. Parquet needs random access: r is used
+// directly when it is an io.ReaderAt and io.Seeker, otherwise it is spooled
+// to a temporary file first.
+func NewReader(r io.Reader, format string, opts ReadOptions) (Reader, error) {
+	var rd Reader
+	var err error
+	switch format {
+	case CSV:
+		rd, err = newDelimitedReader(r, ','), nil
+	case TSV:
+		rd, err = newDelimitedReader(r, '\t'), nil
+	case JSON:
+		rd, err = newJSONReader(r)
+	case JSONL:
+		rd, err = newJSONLReader(r), nil
+	case Parquet:
+		return newParquetReader(r, opts.Columns)
+	case XLSX:
+		rd, err = newXLSXReader(r, opts.Sheet)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Columns) > 0 {
+		rd = &projection{Reader: rd, columns: opts.Columns}
+	}
+	return rd, nil
+}
+
+// WriteOptions configure a writer
+type WriteOptions struct {
+	Columns []string // column order; default the sorted union of the record keys
+	Sheet   string   // xlsx sheet name; default Sheet1
+}
+
+// Write stores records in format. JSON output is indented; the other
+// formats need the schema of all records up front, so they take the whole
+// slice rather than one row at a time.
+func Write(w io.Writer, format string, records []map[string]interface{}, opts WriteOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = ColumnsOf(records)
+	}
+	switch format {
+	case CSV:
+		return writeDelimited(w, ',', columns, records)
+	case TSV:
+		return writeDelimited(w, '\t', columns, records)
+	case JSON:
+		return writeJSON(w, records)
+	case JSONL:
+		return writeJSONL(w, records)
+	case Parquet:
+		return writeParquet(w, columns, records)
+	case XLSX:
+		return writeXLSX(w, opts.Sheet, columns, records)
+	}
+	return fmt.Errorf("unsupported file format: %s", format)
+}
+
+// ReadAll reads up to limit rows (0 = all) and closes rd. truncated is set
+// when rows were left unread.
+func ReadAll(rd Reader, limit int) (rows []map[string]interface{}, truncated bool, err error) {
+	defer rd.Close()
+	rows = []map[string]interface{}{}
+	for {
+		row, err := rd.Read()
+		if err == io.EOF {
+			return rows, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if limit > 0 && len(rows) == limit {
+			return rows, true, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ColumnsOf is the sorted union of the keys of records
+func ColumnsOf(records []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var columns []string
+	for _, rec := range records {
+		for k := range rec {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// projection keeps only the selected columns of the rows of a reader
+type projection struct {
+	Reader
+	columns []string
+}
+
+func (p *projection) Columns() []string {
+	return p.columns
+}
+
+func (p *projection) Read() (map[string]interface{}, error) {
+	row, err := p.Reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{}, len(p.columns))
+	for _, c := range p.columns {
+		out[c] = row[c]
+	}
+	return out, nil
+}
+
+func (p *projection) Schema() []Field {
+	if t, ok := p.Reader.(Typed); ok {
+		byName := map[string]Field{}
+		for _, f := range t.Schema() {
+			byName[f.Name] = f
+		}
+		fields := make([]Field, 0, len(p.columns))
+		for _, c := range p.columns {
+			f, ok := byName[c]
+			if !ok {
+				f = Field{Name: c, Type: "string", Nullable: true}
+			}
+			fields = append(fields, f)
+		}
+		return fields
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dataformat
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// jsonReader streams the objects of a top-level JSON array; a top-level
+// object is a single row
+type jsonReader struct {
+	dec     *json.Decoder
+	columns columnSet
+	single  map[string]interface{}
+	done    bool
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return &jsonReader{dec: dec, done: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		return &jsonReader{dec: dec}, nil
+	case json.Delim('{'):
+		// 객체 하나는 나머지를 읽어 한 행으로 만든다
+		row := map[string]interface{}{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			row[key.(string)] = v
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		rd := &jsonReader{dec: dec, single: row}
+		rd.columns.add(row)
+		return rd, nil
+	}
+	return nil, ErrNotTabular
+}
+
+func (j *jsonReader) Columns() []string {
+	return j.columns.names
+}
+
+func (j *jsonReader) Read() (map[string]interface{}, error) {
+	if j.single != nil {
+		row := j.single
+		j.single, j.done = nil, true
+		return row, nil
+	}
+	if j.done || !j.dec.More() {
+		j.done = true
+		return nil, io.EOF
+	}
+	var v interface{}
+	if err := j.dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	row, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrNotTabular
+	}
+	j.columns.add(row)
+	return row, nil
+}
+
+func (j *jsonReader) Close() error {
+	return nil
+}
+
+// jsonlReader reads one JSON object per line; blank lines are skipped
+type jsonlReader struct {
+	dec     *json.Decoder
+	columns columnSet
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	return &jsonlReader{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+func (j *jsonlReader) Columns() []string {
+	return j.columns.names
+}
+
+func (j *jsonlReader) Read() (map[string]interface{}, error) {
+	var v interface{}
+	if err := j.dec.Decode(&v); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("record %d: %w", j.line+1, err)
+	}
+	j.line++
+	row, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("record %d: %w", j.line, ErrNotTabular)
+	}
+	j.columns.add(row)
+	return row, nil
+}
+
+func (j *jsonlReader) Close() error {
+	return nil
+}
+
+// columnSet collects column names in the order they are first seen
+type columnSet struct {
+	names []string
+	seen  map[string]bool
+}
+
+func (c *columnSet) add(row map[string]interface{}) {
+	if c.seen == nil {
+		c.seen = map[string]bool{}
+	}
+	var added []string
+	for k := range row {
+		if !c.seen[k] {
+			c.seen[k] = true
+			added = append(added, k)
+		}
+	}
+	// 한 행 안의 순서는 맵이라 알 수 없으므로 정렬한다
+	sort.Strings(added)
+	c.names = append(c.names, added...)
+}
+
+func writeJSON(w io.Writer, records []map[string]interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeJSONL(w io.Writer, records []map[string]interface{}) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,534 @@
+package dataformat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetBatch is how many rows are decoded per column at a time
+const parquetBatch = 1024
+
+// parquetReader assembles rows from the column chunks of the selected leaf
+// columns. Nested groups become dotted column names and repeated columns
+// arrays; other columns are never read.
+type parquetReader struct {
+	pr        *reader.ParquetReader
+	columns   []parquetColumn
+	remaining int64
+	rows      []map[string]interface{}
+	cleanup   func()
+}
+
+type parquetColumn struct {
+	name   string
+	inPath string
+	elem   *parquet.SchemaElement
+	maxRL  int32
+	maxDL  int32
+}
+
+func newParquetReader(r io.Reader, projection []string) (rd Reader, err error) {
+	// parquet-go는 손상된 파일에서 panic을 낸다
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("invalid parquet file: %v", p)
+		}
+	}()
+
+	file, cleanup, err := randomAccess(r)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("invalid parquet file: %w", err)
+	}
+
+	p := &parquetReader{pr: pr, remaining: pr.GetNumRows(), cleanup: cleanup}
+	sh := pr.SchemaHandler
+	all := map[string]parquetColumn{}
+	var order []string
+	for _, inPath := range sh.ValueColumns {
+		exPath := sh.InPathToExPath[inPath]
+		parts := common.StrToPath(exPath)
+		c := parquetColumn{
+			name:   listName(strings.Join(parts[1:], ".")),
+			inPath: inPath,
+			elem:   sh.SchemaElements[sh.MapIndex[inPath]],
+		}
+		path := common.StrToPath(inPath)
+		c.maxRL, _ = sh.MaxRepetitionLevel(path)
+		c.maxDL, _ = sh.MaxDefinitionLevel(path)
+		all[c.name] = c
+		order = append(order, c.name)
+	}
+
+	if len(projection) == 0 {
+		projection = order
+	}
+	for _, name := range projection {
+		c, ok := all[name]
+		if !ok {
+			p.Close()
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+		p.columns = append(p.columns, c)
+	}
+	return p, nil
+}
+
+// listName drops the groups of the standard list layouts, so a list column
+// keeps its own name
+func listName(name string) string {
+	for _, suffix := range []string{".list.element", ".list.item", ".bag.array_element", ".array"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+func (p *parquetReader) Columns() []string {
+	names := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (p *parquetReader) Schema() []Field {
+	fields := make([]Field, len(p.columns))
+	for i, c := range p.columns {
+		f := Field{Name: c.name, Type: parquetType(c.elem), Nullable: c.maxDL > 0}
+		if c.maxRL > 0 {
+			f.Type = "array"
+		}
+		fields[i] = f
+	}
+	return fields
+}
+
+func (p *parquetReader) Read() (row map[string]interface{}, err error) {
+	if len(p.rows) == 0 {
+		if err := p.readBatch(); err != nil {
+			return nil, err
+		}
+	}
+	row, p.rows = p.rows[0], p.rows[1:]
+	return row, nil
+}
+
+func (p *parquetReader) readBatch() (err error) {
+	if p.remaining <= 0 {
+		return io.EOF
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid parquet file: %v", r)
+		}
+	}()
+
+	n := min(p.remaining, parquetBatch)
+	rows := make([]map[string]interface{}, n)
+	for i := range rows {
+		rows[i] = make(map[string]interface{}, len(p.columns))
+	}
+	for _, c := range p.columns {
+		values, rls, dls, err := p.pr.ReadColumnByPath(c.inPath, n)
+		if err != nil {
+			return err
+		}
+		row := -1
+		for i, v := range values {
+			if rls[i] == 0 {
+				row++
+				if row >= len(rows) {
+					break
+				}
+				if c.maxRL > 0 && dls[i] > 0 {
+					rows[row][c.name] = []interface{}{}
+				} else {
+					rows[row][c.name] = nil
+				}
+			}
+			if dls[i] < c.maxDL {
+				continue
+			}
+			value := parquetValue(v, c.elem)
+			if c.maxRL > 0 {
+				list, _ := rows[row][c.name].([]interface{})
+				rows[row][c.name] = append(list, value)
+			} else {
+				rows[row][c.name] = value
+			}
+		}
+	}
+	p.remaining -= n
+	p.rows = rows
+	return nil
+}
+
+func (p *parquetReader) Close() error {
+	if p.pr != nil {
+		p.pr.ReadStop()
+	}
+	p.cleanup()
+	return nil
+}
+
+// parquetType names the type of a leaf column
+func parquetType(e *parquet.SchemaElement) string {
+	lt := e.GetLogicalType()
+	switch {
+	case lt != nil && lt.IsSetSTRING(), lt != nil && lt.IsSetENUM():
+		return "string"
+	case lt != nil && lt.IsSetJSON():
+		return "object"
+	case lt != nil && lt.IsSetDATE():
+		return "date"
+	case lt != nil && lt.IsSetTIMESTAMP():
+		return "timestamp"
+	case lt != nil && lt.IsSetDECIMAL():
+		return "number"
+	}
+	if e.IsSetConvertedType() {
+		switch e.GetConvertedType() {
+		case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM:
+			return "string"
+		case parquet.ConvertedType_JSON:
+			return "object"
+		case parquet.ConvertedType_DATE:
+			return "date"
+		case parquet.ConvertedType_TIMESTAMP_MILLIS, parquet.ConvertedType_TIMESTAMP_MICROS:
+			return "timestamp"
+		case parquet.ConvertedType_DECIMAL:
+			return "number"
+		}
+	}
+	switch e.GetType() {
+	case parquet.Type_BOOLEAN:
+		return "boolean"
+	case parquet.Type_INT32, parquet.Type_INT64:
+		return "integer"
+	case parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		return "number"
+	case parquet.Type_INT96:
+		return "timestamp"
+	}
+	return "binary"
+}
+
+// parquetValue converts a physical value to what the JSON rows carry:
+// dates as 2006-01-02, timestamps as RFC 3339, decimals as exact numbers,
+// JSON as is and other binary data as base64
+func parquetValue(v interface{}, e *parquet.SchemaElement) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch parquetType(e) {
+	case "date":
+		if days, ok := v.(int32); ok {
+			return time.Unix(int64(days)*86400, 0).UTC().Format("2006-01-02")
+		}
+	case "timestamp":
+		return parquetTime(v, e).Format(time.RFC3339Nano)
+	case "number":
+		if scale := decimalScale(e); scale >= 0 {
+			return decimalValue(v, scale)
+		}
+	case "object":
+		if s, ok := v.(string); ok && json.Valid([]byte(s)) {
+			return json.RawMessage(s)
+		}
+	case "binary":
+		if s, ok := v.(string); ok {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		}
+	}
+	switch t := v.(type) {
+	case int32:
+		return int64(t)
+	case float32:
+		return float64(t)
+	}
+	return v
+}
+
+func parquetTime(v interface{}, e *parquet.SchemaElement) time.Time {
+	switch t := v.(type) {
+	case string: // INT96 (Impala, Spark)
+		return types.INT96ToTime(t).UTC()
+	case int64:
+		unit := "MILLIS"
+		if lt := e.GetLogicalType(); lt != nil && lt.IsSetTIMESTAMP() {
+			switch u := lt.TIMESTAMP.GetUnit(); {
+			case u.IsSetMICROS():
+				unit = "MICROS"
+			case u.IsSetNANOS():
+				unit = "NANOS"
+			}
+		} else if e.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS {
+			unit = "MICROS"
+		}
+		switch unit {
+		case "MICROS":
+			return time.UnixMicro(t).UTC()
+		case "NANOS":
+			return time.Unix(0, t).UTC()
+		}
+		return time.UnixMilli(t).UTC()
+	}
+	return time.Time{}
+}
+
+// decimalScale is the scale of a decimal column, -1 for other columns
+func decimalScale(e *parquet.SchemaElement) int {
+	if lt := e.GetLogicalType(); lt != nil && lt.IsSetDECIMAL() {
+		return int(lt.DECIMAL.GetScale())
+	}
+	if e.GetConvertedType() == parquet.ConvertedType_DECIMAL {
+		return int(e.GetScale())
+	}
+	return -1
+}
+
+// decimalValue formats an unscaled decimal (int32, int64 or big-endian two's
+// complement bytes) as an exact json.Number
+func decimalValue(v interface{}, scale int) interface{} {
+	n := new(big.Int)
+	switch t := v.(type) {
+	case int32:
+		n.SetInt64(int64(t))
+	case int64:
+		n.SetInt64(t)
+	case string:
+		b := []byte(t)
+		n.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+	default:
+		return v
+	}
+	s := n.String()
+	if scale == 0 {
+		return json.Number(s)
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= scale {
+		s = strings.Repeat("0", scale-len(s)+1) + s
+	}
+	return json.Number(sign + s[:len(s)-scale] + "." + s[len(s)-scale:])
+}
+
+// randomAccess returns r as a parquet source. Readers that cannot seek are
+// copied to a temporary file, removed by the returned cleanup.
+func randomAccess(r io.Reader) (source.ParquetFile, func(), error) {
+	if n, ok := r.(nopReadCloser); ok {
+		r = n.Reader
+	}
+	if ra, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := ra.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &readerAtFile{r: ra, size: size}, func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "parquet-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return &readerAtFile{r: tmp, size: size}, cleanup, nil
+}
+
+// readerAtFile is a read-only source.ParquetFile over an io.ReaderAt. Open
+// returns an independent cursor, one per column chunk being read.
+type readerAtFile struct {
+	r    io.ReaderAt
+	size int64
+	off  int64
+}
+
+func (f *readerAtFile) Read(p []byte) (int, error) {
+	if f.off >= f.size {
+		return 0, io.EOF
+	}
+	n, err := f.r.ReadAt(p, f.off)
+	f.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *readerAtFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *readerAtFile) Open(string) (source.ParquetFile, error) {
+	return &readerAtFile{r: f.r, size: f.size}, nil
+}
+
+func (f *readerAtFile) Write([]byte) (int, error) {
+	return 0, errors.New("parquet source is read-only")
+}
+
+func (f *readerAtFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet source is read-only")
+}
+
+func (f *readerAtFile) Close() error {
+	return nil
+}
+
+// writeParquet stores records with a flat schema inferred from their
+// values: integers as INT64, other numbers as DOUBLE, booleans, strings as
+// UTF8 and nested values as JSON. Every column is optional.
+func writeParquet(w io.Writer, columns []string, records []map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parquet: %v", r)
+		}
+	}()
+
+	kinds := make([]string, len(columns))
+	md := make([]string, len(columns))
+	inNames := map[string]string{}
+	for i, c := range columns {
+		if c == "" || strings.ContainsAny(c, ",=") {
+			return fmt.Errorf("column name %q cannot be stored in parquet", c)
+		}
+		in := common.StringToVariableName(c)
+		if other, ok := inNames[in]; ok {
+			return fmt.Errorf("columns %q and %q clash in parquet", other, c)
+		}
+		inNames[in] = c
+
+		for _, rec := range records {
+			if v := rec[c]; v != nil {
+				kinds[i] = widenType(kinds[i], recordType(v))
+			}
+		}
+		switch kinds[i] {
+		case "integer":
+			md[i] = "name=" + c + ", type=INT64, repetitiontype=OPTIONAL"
+		case "number":
+			md[i] = "name=" + c + ", type=DOUBLE, repetitiontype=OPTIONAL"
+		case "boolean":
+			md[i] = "name=" + c + ", type=BOOLEAN, repetitiontype=OPTIONAL"
+		case "object", "array":
+			md[i] = "name=" + c + ", type=BYTE_ARRAY, convertedtype=JSON, repetitiontype=OPTIONAL"
+		default:
+			kinds[i] = "string"
+			md[i] = "name=" + c + ", type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"
+		}
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return err
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	for _, rec := range records {
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			row[i] = parquetCell(rec[c], kinds[i])
+		}
+		if err := pw.Write(row); err != nil {
+			return err
+		}
+	}
+	return pw.WriteStop()
+}
+
+// recordType is the type of a record value for writing; unlike text cells,
+// strings stay strings
+func recordType(v interface{}) string {
+	if _, ok := v.(string); ok {
+		return "string"
+	}
+	return valueType(v)
+}
+
+func parquetCell(v interface{}, kind string) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch kind {
+	case "integer":
+		switch t := v.(type) {
+		case float64:
+			return int64(t)
+		case int:
+			return int64(t)
+		case int32:
+			return int64(t)
+		case json.Number:
+			n, _ := t.Int64()
+			return n
+		}
+	case "number":
+		switch t := v.(type) {
+		case int:
+			return float64(t)
+		case int32:
+			return float64(t)
+		case int64:
+			return float64(t)
+		case float32:
+			return float64(t)
+		case json.Number:
+			f, _ := t.Float64()
+			return f
+		}
+	case "boolean":
+		return v
+	case "object", "array":
+		b, _ := json.Marshal(v)
+		return string(b)
+	case "string":
+		return textValue(v)
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package dataformat
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaOf returns the declared schema of rd, or one inferred from rows
+func SchemaOf(rd Reader, rows []map[string]interface{}) []Field {
+	if t, ok := rd.(Typed); ok {
+		if fields := t.Schema(); len(fields) > 0 {
+			return fields
+		}
+	}
+	columns := rd.Columns()
+	if len(columns) == 0 {
+		columns = ColumnsOf(rows)
+	}
+	return InferSchema(columns, rows)
+}
+
+// InferSchema gives each column the narrowest type that holds all of its
+// values in rows. Text values (CSV, TSV, XLSX) are typed by their content.
+func InferSchema(columns []string, rows []map[string]interface{}) []Field {
+	fields := make([]Field, len(columns))
+	for i, c := range columns {
+		f := Field{Name: c}
+		for _, row := range rows {
+			v, ok := row[c]
+			if !ok || v == nil || v == "" {
+				f.Nullable = true
+				continue
+			}
+			f.Type = widenType(f.Type, valueType(v))
+		}
+		if f.Type == "" {
+			f.Type = "string"
+		}
+		fields[i] = f
+	}
+	return fields
+}
+
+func valueType(v interface{}) string {
+	switch t := v.(type) {
+	case bool:
+		return "boolean"
+	case int, int32, int64:
+		return "integer"
+	case float32:
+		return "number"
+	case float64:
+		if t == float64(int64(t)) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return textType(t)
+	}
+	return "string"
+}
+
+// textType recognises numbers, booleans, dates and timestamps in text
+func textType(s string) string {
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return "number"
+	}
+	switch strings.ToLower(s) {
+	case "true", "false":
+		return "boolean"
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return "date"
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return "timestamp"
+		}
+	}
+	return "string"
+}
+
+// widenType combines the types of two values of one column
+func widenType(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case (a == "integer" && b == "number") || (a == "number" && b == "integer"):
+		return "number"
+	case (a == "date" && b == "timestamp") || (a == "timestamp" && b == "date"):
+		return "timestamp"
+	}
+	return "string"
+}
@@ -0,0 +1,114 @@
+package dataformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// Streamable reports whether Copy writes format one row at a time
+func Streamable(format string) bool {
+	return format == CSV || format == TSV || format == JSONL
+}
+
+// Copy writes the rows of rd in format and returns how many were written;
+// rd is not closed. CSV, TSV and JSONL are written as the rows are read, so
+// the data never has to fit in memory. Delimited output needs its columns
+// up front: opts.Columns, else the reader's columns after the first row.
+// The other formats collect all rows and call Write.
+func Copy(w io.Writer, format string, rd Reader, opts WriteOptions) (int, error) {
+	if !Streamable(format) {
+		records, err := readRows(rd)
+		if err != nil {
+			return 0, err
+		}
+		return len(records), Write(w, format, records, opts)
+	}
+
+	first, err := rd.Read()
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	pending := first
+	next := func() (map[string]interface{}, error) {
+		if pending != nil {
+			row := pending
+			pending = nil
+			return row, nil
+		}
+		if first == nil {
+			return nil, io.EOF
+		}
+		return rd.Read()
+	}
+
+	bw := bufio.NewWriterSize(w, 64*1024)
+	n := 0
+	if format == JSONL {
+		enc := json.NewEncoder(bw)
+		for {
+			row, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+			if err := enc.Encode(row); err != nil {
+				return n, err
+			}
+			n++
+		}
+		return n, bw.Flush()
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = append([]string{}, rd.Columns()...)
+	}
+	cw := csv.NewWriter(bw)
+	if format == TSV {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(columns); err != nil {
+		return 0, err
+	}
+	record := make([]string, len(columns))
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		for i, c := range columns {
+			record[i] = textValue(row[c])
+		}
+		if err := cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// readRows reads every row of rd without closing it
+func readRows(rd Reader) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	for {
+		row, err := rd.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,149 @@
+package dataformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxReader reads one sheet whose first row holds the headers. Cells are
+// their formatted text, like CSV values; the workbook itself is held in
+// memory (it is a zip archive), its rows are decoded one at a time.
+type xlsxReader struct {
+	f       *excelize.File
+	rows    *excelize.Rows
+	headers []string
+	done    bool
+}
+
+func newXLSXReader(r io.Reader, sheet string) (*xlsxReader, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+	if sheet == "" {
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			f.Close()
+			return nil, fmt.Errorf("xlsx file has no sheets")
+		}
+		sheet = sheets[0]
+	}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	x := &xlsxReader{f: f, rows: rows}
+	if !rows.Next() {
+		x.done = true
+		return x, rows.Error()
+	}
+	headers, err := rows.Columns()
+	if err != nil {
+		x.Close()
+		return nil, err
+	}
+	for i, h := range headers {
+		if h = strings.TrimSpace(h); h == "" {
+			// 이름 없는 열은 스프레드시트의 열 이름을 쓴다
+			h, _ = excelize.ColumnNumberToName(i + 1)
+		}
+		x.headers = append(x.headers, h)
+	}
+	return x, nil
+}
+
+func (x *xlsxReader) Columns() []string {
+	return x.headers
+}
+
+func (x *xlsxReader) Read() (map[string]interface{}, error) {
+	for !x.done {
+		if !x.rows.Next() {
+			x.done = true
+			if err := x.rows.Error(); err != nil {
+				return nil, err
+			}
+			break
+		}
+		cells, err := x.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if len(cells) == 0 {
+			continue // 빈 행
+		}
+		row := make(map[string]interface{}, len(x.headers))
+		for i, h := range x.headers {
+			if i < len(cells) {
+				row[h] = cells[i]
+			}
+		}
+		return row, nil
+	}
+	return nil, io.EOF
+}
+
+func (x *xlsxReader) Close() error {
+	if x.rows != nil {
+		x.rows.Close()
+	}
+	return x.f.Close()
+}
+
+// writeXLSX writes one sheet with a header row through the streaming
+// writer. Numbers and booleans stay typed cells; nested values are JSON.
+func writeXLSX(w io.Writer, sheet string, columns []string, records []map[string]interface{}) error {
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	if sheet != "Sheet1" {
+		if err := f.SetSheetName("Sheet1", sheet); err != nil {
+			return err
+		}
+	}
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for r, rec := range records {
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			switch v := rec[c].(type) {
+			case map[string]interface{}, []interface{}:
+				b, _ := json.Marshal(v)
+				row[i] = string(b)
+			case json.Number:
+				row[i] = v.String()
+			default:
+				row[i] = v
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
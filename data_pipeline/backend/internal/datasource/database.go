@@ -0,0 +1,199 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	// pure Go, so SQLite also works in the image built without cgo
+	_ "modernc.org/sqlite"
+)
+
+// Database kinds
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// Dialect holds what differs between the supported databases
+type Dialect struct {
+	Kind   string
+	Driver string
+}
+
+// Dialects by kind
+var dialects = map[string]Dialect{
+	Postgres: {Kind: Postgres, Driver: "postgres"},
+	MySQL:    {Kind: MySQL, Driver: "mysql"},
+	SQLite:   {Kind: SQLite, Driver: "sqlite"},
+}
+
+// Database is an open SQL source or sink
+type Database struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open connects to a database of kind ("postgres", "mysql", "sqlite") and
+// checks the connection
+func Open(ctx context.Context, kind, dsn string) (*Database, error) {
+	kind = NormalizeKind(kind)
+	d, ok := dialects[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database %q (expected postgres, mysql or sqlite)", kind)
+	}
+	db, err := sql.Open(d.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(2)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot connect to %s: %w", kind, err)
+	}
+	return &Database{DB: db, Dialect: d}, nil
+}
+
+// NormalizeKind maps aliases such as "postgresql" or "mariadb" to a kind;
+// unknown names are returned lower-cased
+func NormalizeKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case "postgres", "postgresql", "pg":
+		return Postgres
+	case "mysql", "mariadb":
+		return MySQL
+	case "sqlite", "sqlite3":
+		return SQLite
+	}
+	return strings.ToLower(kind)
+}
+
+// Params are the parts of a connection; DSN, when set, is used as is
+// (MySQL DSNs get parseTime=true)
+type Params struct {
+	DSN      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	SSLMode  string // postgres; default disable
+	Path     string // sqlite file on the backend host
+	ReadOnly bool   // sqlite: open with mode=ro
+}
+
+// DSN builds the data source name of kind from p
+func DSN(kind string, p Params) (string, error) {
+	switch NormalizeKind(kind) {
+	case Postgres:
+		if p.DSN != "" {
+			return p.DSN, nil
+		}
+		if p.Host == "" || p.Database == "" {
+			return "", fmt.Errorf("postgres needs host and database")
+		}
+		if p.Port == "" {
+			p.Port = "5432"
+		}
+		if p.SSLMode == "" {
+			p.SSLMode = "disable"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(p.User, p.Password),
+			Host:     net.JoinHostPort(p.Host, p.Port),
+			Path:     "/" + p.Database,
+			RawQuery: url.Values{"sslmode": {p.SSLMode}}.Encode(),
+		}
+		return u.String(), nil
+
+	case MySQL:
+		cfg := mysql.NewConfig()
+		if p.DSN != "" {
+			parsed, err := mysql.ParseDSN(p.DSN)
+			if err != nil {
+				return "", err
+			}
+			cfg = parsed
+		} else {
+			if p.Host == "" || p.Database == "" {
+				return "", fmt.Errorf("mysql needs host and database")
+			}
+			if p.Port == "" {
+				p.Port = "3306"
+			}
+			cfg.User = p.User
+			cfg.Passwd = p.Password
+			cfg.Net = "tcp"
+			cfg.Addr = net.JoinHostPort(p.Host, p.Port)
+			cfg.DBName = p.Database
+		}
+		cfg.ParseTime = true
+		return cfg.FormatDSN(), nil
+
+	case SQLite:
+		if p.Path == "" {
+			return "", fmt.Errorf("sqlite needs a path")
+		}
+		mode := "rwc"
+		if p.ReadOnly {
+			mode = "ro"
+		}
+		q := url.Values{"mode": {mode}, "_pragma": {"busy_timeout(5000)"}}
+		return "file:" + (&url.URL{Path: p.Path}).EscapedPath() + "?" + q.Encode(), nil
+	}
+	return "", fmt.Errorf("unsupported database %q (expected postgres, mysql or sqlite)", kind)
+}
+
+// placeholder is the n-th (1-based) bind parameter
+func (d Dialect) placeholder(n int) string {
+	if d.Kind == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// quote quotes a validated identifier
+func (d Dialect) quote(name string) string {
+	if d.Kind == MySQL {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+// Table quotes a table name, optionally qualified by a schema
+func (d Dialect) Table(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid table name %q", name)
+	}
+	for i, p := range parts {
+		if !identPattern.MatchString(p) {
+			return "", fmt.Errorf("invalid table name %q", name)
+		}
+		parts[i] = d.quote(p)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// Column quotes a column name
+func (d Dialect) Column(name string) (string, error) {
+	if !identPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid column name %q (letters, digits and _ only)", name)
+	}
+	return d.quote(name), nil
+}
@@ -0,0 +1,188 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column describes a result column
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // database type name, e.g. INT8, VARCHAR
+}
+
+// QueryResult summarises a streamed query
+type QueryResult struct {
+	Columns   []Column
+	Rows      int
+	Truncated bool // more rows were available than the limit
+}
+
+// Query runs a parameterised read-only query and calls fn for every row,
+// converted to JSON-friendly values, without buffering the result set.
+// Reading stops after limit rows (0 = no limit).
+func (db *Database) Query(ctx context.Context, query string, args []interface{}, limit int, fn func(row map[string]interface{}) error) (*QueryResult, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: db.Dialect.Kind != SQLite})
+	if err != nil {
+		return nil, err
+	}
+	// 읽기 전용이므로 결과와 무관하게 롤백한다
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{}
+	for _, t := range types {
+		result.Columns = append(result.Columns, Column{Name: t.Name(), Type: strings.ToUpper(t.DatabaseTypeName())})
+	}
+
+	values := make([]interface{}, len(types))
+	ptrs := make([]interface{}, len(types))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if limit > 0 && result.Rows >= limit {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(types))
+		for i, c := range result.Columns {
+			row[c.Name] = convertValue(values[i], c.Type)
+		}
+		if err := fn(row); err != nil {
+			return nil, err
+		}
+		result.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// convertValue maps a scanned value to what the Python step receives:
+// numbers, booleans, strings (RFC 3339 for times, base64 for binary) and
+// decoded JSON. Drivers return many types as text, so the database type
+// decides.
+func convertValue(v interface{}, dbType string) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case []byte:
+		return convertText(t, dbType)
+	case string:
+		return convertText([]byte(t), dbType)
+	case int64:
+		if isBoolType(dbType) {
+			return t != 0
+		}
+	}
+	return v
+}
+
+func convertText(b []byte, dbType string) interface{} {
+	s := string(b)
+	switch {
+	case isBinaryType(dbType):
+		return base64.StdEncoding.EncodeToString(b)
+	case isIntType(dbType):
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case isFloatType(dbType):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case dbType == "DECIMAL" || dbType == "NUMERIC":
+		// 정밀도를 잃지 않도록 숫자 문자열 그대로 싣는다
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(s)
+		}
+	case isBoolType(dbType):
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+		return s == "t"
+	case dbType == "JSON" || dbType == "JSONB":
+		if json.Valid(b) {
+			return json.RawMessage(append([]byte{}, b...))
+		}
+	}
+	return s
+}
+
+func isIntType(t string) bool {
+	switch t {
+	case "INT", "INTEGER", "INT2", "INT4", "INT8", "SMALLINT", "MEDIUMINT", "BIGINT", "TINYINT", "YEAR",
+		"UNSIGNED INT", "UNSIGNED BIGINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED TINYINT":
+		return true
+	}
+	return false
+}
+
+func isFloatType(t string) bool {
+	switch t {
+	case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL", "DOUBLE PRECISION":
+		return true
+	}
+	return false
+}
+
+func isBoolType(t string) bool {
+	return t == "BOOL" || t == "BOOLEAN"
+}
+
+func isBinaryType(t string) bool {
+	switch t {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		return true
+	}
+	return false
+}
+
+// QueryArgs converts JSON parameters to driver arguments: whole numbers
+// become int64, nested values JSON text
+func QueryArgs(params []interface{}) ([]interface{}, error) {
+	args := make([]interface{}, len(params))
+	for i, p := range params {
+		switch v := p.(type) {
+		case nil, string, bool:
+			args[i] = v
+		case float64:
+			if v == float64(int64(v)) {
+				args[i] = int64(v)
+			} else {
+				args[i] = v
+			}
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %v", i+1, err)
+			}
+			args[i] = string(b)
+		default:
+			return nil, fmt.Errorf("parameter %d: unsupported type %T", i+1, p)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,380 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Save modes
+const (
+	ModeAppend  = "append"
+	ModeReplace = "replace" // delete existing rows, then insert
+	ModeUpsert  = "upsert"  // insert, updating rows whose key already exists
+)
+
+// SaveOptions selects how records are written
+type SaveOptions struct {
+	Table       string
+	Mode        string   // append (default), replace or upsert
+	Key         []string // upsert key columns
+	CreateTable bool     // create the table from the inferred schema if missing
+}
+
+// SaveResult summarises a write
+type SaveResult struct {
+	Rows    int      `json:"rows"`
+	Columns []string `json:"columns"`
+	Created bool     `json:"created"` // the table did not exist and was created
+}
+
+// column kinds inferred from the records
+const (
+	kindInteger = iota + 1
+	kindFloat
+	kindBool
+	kindTimestamp
+	kindJSON
+	kindText
+)
+
+// Save writes records in one transaction, with multi-row INSERTs
+func (db *Database) Save(ctx context.Context, records []map[string]interface{}, opts SaveOptions) (*SaveResult, error) {
+	d := db.Dialect
+	table, err := d.Table(opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeAppend
+	}
+	switch opts.Mode {
+	case ModeAppend, ModeReplace:
+	case ModeUpsert:
+		if len(opts.Key) == 0 {
+			return nil, fmt.Errorf("upsert needs a key column")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %q (expected append, replace or upsert)", opts.Mode)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records to save")
+	}
+
+	columns, kinds := inferSchema(records)
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		if quoted[i], err = d.Column(c); err != nil {
+			return nil, err
+		}
+	}
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		index[c] = i
+	}
+	var keys []string
+	for _, k := range opts.Key {
+		i, ok := index[k]
+		if !ok {
+			return nil, fmt.Errorf("key column %q is not in the data", k)
+		}
+		keys = append(keys, quoted[i])
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &SaveResult{Columns: columns}
+	if opts.CreateTable {
+		exists, err := d.tableExists(ctx, tx, opts.Table)
+		if err != nil {
+			return nil, fmt.Errorf("check table: %w", err)
+		}
+		if !exists {
+			defs := make([]string, len(columns))
+			for i := range columns {
+				defs[i] = quoted[i] + " " + d.columnType(kinds[i], opts.Mode == ModeUpsert && contains(opts.Key, columns[i]))
+			}
+			if len(keys) > 0 {
+				defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+			}
+			// 다른 저장이 먼저 만들었을 수도 있어 IF NOT EXISTS는 남긴다
+			stmt := "CREATE TABLE IF NOT EXISTS " + table + " (" + strings.Join(defs, ", ") + ")"
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("create table: %w", err)
+			}
+			result.Created = true
+		}
+	}
+	if opts.Mode == ModeReplace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return nil, err
+		}
+	}
+
+	suffix := ""
+	if opts.Mode == ModeUpsert {
+		suffix = d.upsertClause(quoted, keys)
+	}
+	prefix := "INSERT INTO " + table + " (" + strings.Join(quoted, ", ") + ") VALUES "
+
+	// 드라이버 파라미터 한도(MySQL 65535, SQLite 32766) 아래로 묶는다
+	batch := 30000 / len(columns)
+	if batch > 500 {
+		batch = 500
+	}
+	if batch < 1 {
+		batch = 1
+	}
+	for start := 0; start < len(records); start += batch {
+		end := start + batch
+		if end > len(records) {
+			end = len(records)
+		}
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for r, rec := range records[start:end] {
+			if r > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteByte('(')
+			for i, c := range columns {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				args = append(args, d.bindValue(rec[c], kinds[i]))
+				sb.WriteString(d.placeholder(len(args)))
+			}
+			sb.WriteByte(')')
+		}
+		sb.WriteString(suffix)
+		if _, err := tx.ExecContext(ctx, sb.String(), args...); err != nil {
+			return nil, fmt.Errorf("insert rows %d-%d: %w", start+1, end, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	result.Rows = len(records)
+	return result, nil
+}
+
+// tableExists reports whether a table, named as accepted by Table, exists
+func (d Dialect) tableExists(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	schema, table, qualified := strings.Cut(name, ".")
+	if !qualified {
+		schema, table = "", schema
+	}
+
+	var n int
+	var err error
+	switch d.Kind {
+	case Postgres:
+		quoted, err := d.Table(name)
+		if err != nil {
+			return false, err
+		}
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, quoted).Scan(&exists)
+		return exists, err
+	case MySQL:
+		err = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?
+		`, schema, table).Scan(&n)
+	default:
+		master := "sqlite_master"
+		if schema != "" {
+			master = d.quote(schema) + ".sqlite_master"
+		}
+		// SQLite 식별자는 대소문자를 구분하지 않는다
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+master+` WHERE type = 'table' AND name = ? COLLATE NOCASE`, table).Scan(&n)
+	}
+	return n > 0, err
+}
+
+// inferSchema returns the sorted union of the record keys and the narrowest
+// kind that holds every value of each column
+func inferSchema(records []map[string]interface{}) ([]string, []int) {
+	seen := map[string]int{}
+	for _, rec := range records {
+		for k, v := range rec {
+			seen[k] = widen(seen[k], valueKind(v))
+		}
+	}
+	columns := make([]string, 0, len(seen))
+	for k := range seen {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	kinds := make([]int, len(columns))
+	for i, c := range columns {
+		kinds[i] = seen[c]
+		if kinds[i] == 0 {
+			kinds[i] = kindText // 값이 모두 null인 컬럼
+		}
+	}
+	return columns, kinds
+}
+
+func valueKind(v interface{}) int {
+	switch t := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return kindBool
+	case int, int32, int64:
+		return kindInteger
+	case float64:
+		if t == float64(int64(t)) && t >= -1<<53 && t <= 1<<53 {
+			return kindInteger
+		}
+		return kindFloat
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return kindInteger
+		}
+		return kindFloat
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return kindTimestamp
+		}
+		return kindText
+	case map[string]interface{}, []interface{}:
+		return kindJSON
+	}
+	return kindText
+}
+
+// widen combines two kinds; mixed columns fall back to text
+func widen(a, b int) int {
+	switch {
+	case a == 0:
+		return b
+	case b == 0 || a == b:
+		return a
+	case (a == kindInteger && b == kindFloat) || (a == kindFloat && b == kindInteger):
+		return kindFloat
+	}
+	return kindText
+}
+
+func (d Dialect) columnType(kind int, key bool) string {
+	switch kind {
+	case kindInteger:
+		return "BIGINT"
+	case kindFloat:
+		if d.Kind == Postgres {
+			return "DOUBLE PRECISION"
+		}
+		return "DOUBLE"
+	case kindBool:
+		return "BOOLEAN"
+	case kindTimestamp:
+		switch d.Kind {
+		case Postgres:
+			return "TIMESTAMPTZ"
+		case MySQL:
+			return "DATETIME(6)"
+		}
+		return "TIMESTAMP"
+	case kindJSON:
+		if d.Kind == Postgres {
+			return "JSONB"
+		}
+		return "JSON"
+	}
+	// MySQL은 TEXT 컬럼을 키로 쓸 수 없다
+	if d.Kind == MySQL && key {
+		return "VARCHAR(255)"
+	}
+	return "TEXT"
+}
+
+// bindValue converts a record value to a driver argument of the column kind
+func (d Dialect) bindValue(v interface{}, kind int) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch kind {
+	case kindJSON:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case kindTimestamp:
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				if d.Kind == MySQL {
+					return t.UTC()
+				}
+				return t
+			}
+		}
+	case kindInteger:
+		switch t := v.(type) {
+		case float64:
+			return int64(t)
+		case json.Number:
+			n, _ := t.Int64()
+			return n
+		}
+	case kindFloat:
+		if n, ok := v.(json.Number); ok {
+			f, _ := n.Float64()
+			return f
+		}
+	case kindText:
+		switch t := v.(type) {
+		case string:
+			return t
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(t)
+			return string(b)
+		}
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+func (d Dialect) upsertClause(columns, keys []string) string {
+	var sets []string
+	for _, c := range columns {
+		if contains(keys, c) {
+			continue
+		}
+		if d.Kind == MySQL {
+			sets = append(sets, c+" = VALUES("+c+")")
+		} else {
+			sets = append(sets, c+" = EXCLUDED."+c)
+		}
+	}
+	if d.Kind == MySQL {
+		if len(sets) == 0 {
+			// 키만 있는 경우 아무것도 바꾸지 않는다
+			sets = append(sets, keys[0]+" = "+keys[0])
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+	conflict := " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO "
+	if len(sets) == 0 {
+		return conflict + "NOTHING"
+	}
+	return conflict + "UPDATE SET " + strings.Join(sets, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
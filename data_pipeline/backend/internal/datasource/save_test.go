@@ -0,0 +1,110 @@
+package datasource
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openSQLite(t *testing.T, readOnly bool, path string) *Database {
+	t.Helper()
+	dsn, err := DSN("sqlite3", Params{Path: path, ReadOnly: readOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(context.Background(), "sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func queryAll(t *testing.T, db *Database, query string) []map[string]interface{} {
+	t.Helper()
+	var rows []map[string]interface{}
+	_, err := db.Query(context.Background(), query, nil, 0, func(row map[string]interface{}) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	return rows
+}
+
+func TestSaveSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.db")
+	db := openSQLite(t, false, path)
+	ctx := context.Background()
+
+	records := []map[string]interface{}{
+		{"id": float64(1), "name": "a", "score": 1.5},
+		{"id": float64(2), "name": "b", "score": nil},
+	}
+	res, err := db.Save(ctx, records, SaveOptions{Table: "results", CreateTable: true})
+	if err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	if !res.Created || res.Rows != 2 || !reflect.DeepEqual(res.Columns, []string{"id", "name", "score"}) {
+		t.Fatalf("first save = %+v", res)
+	}
+
+	// 테이블이 이미 있으면 만들었다고 보고하지 않는다 (대소문자가 달라도 같은 테이블)
+	res, err = db.Save(ctx, records[:1], SaveOptions{Table: "Results", CreateTable: true})
+	if err != nil {
+		t.Fatalf("second save: %v", err)
+	}
+	if res.Created {
+		t.Fatal("existing table reported as created")
+	}
+	if rows := queryAll(t, db, `SELECT COUNT(*) AS n FROM results`); rows[0]["n"] != int64(3) {
+		t.Fatalf("rows after append = %v", rows)
+	}
+
+	upsert := []map[string]interface{}{{"id": float64(1), "name": "z"}, {"id": float64(3), "name": "c"}}
+	res, err = db.Save(ctx, upsert, SaveOptions{Table: "keyed", Mode: ModeUpsert, Key: []string{"id"}, CreateTable: true})
+	if err != nil || !res.Created {
+		t.Fatalf("create keyed = %+v, %v", res, err)
+	}
+	if _, err := db.Save(ctx, records[:1], SaveOptions{Table: "keyed", Mode: ModeUpsert, Key: []string{"id"}, CreateTable: true}); err == nil {
+		// score는 keyed 테이블에 없는 컬럼이다
+		t.Fatal("upsert of an unknown column succeeded")
+	}
+	if _, err := db.Save(ctx, []map[string]interface{}{{"id": float64(3), "name": "cc"}}, SaveOptions{Table: "keyed", Mode: ModeUpsert, Key: []string{"id"}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	got := queryAll(t, db, `SELECT id, name FROM keyed ORDER BY id`)
+	want := []map[string]interface{}{{"id": int64(1), "name": "z"}, {"id": int64(3), "name": "cc"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("keyed = %v, want %v", got, want)
+	}
+
+	if _, err := db.Save(ctx, records[:1], SaveOptions{Table: "results", Mode: ModeReplace}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if rows := queryAll(t, db, `SELECT COUNT(*) AS n FROM results`); rows[0]["n"] != int64(1) {
+		t.Fatalf("rows after replace = %v", rows)
+	}
+
+	if _, err := db.Save(ctx, records, SaveOptions{Table: "missing"}); err == nil {
+		t.Fatal("save into a missing table without CreateTable succeeded")
+	}
+}
+
+func TestSQLiteReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro.db")
+	rw := openSQLite(t, false, path)
+	if _, err := rw.Save(context.Background(), []map[string]interface{}{{"a": "x"}}, SaveOptions{Table: "t", CreateTable: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	ro := openSQLite(t, true, path)
+	if rows := queryAll(t, ro, `SELECT a FROM t`); len(rows) != 1 || rows[0]["a"] != "x" {
+		t.Fatalf("rows = %v", rows)
+	}
+	if _, err := ro.Save(context.Background(), []map[string]interface{}{{"a": "y"}}, SaveOptions{Table: "t"}); err == nil {
+		t.Fatal("write through a read-only connection succeeded")
+	}
+}
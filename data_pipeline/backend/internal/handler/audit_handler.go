@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ListAuditEvents returns audit events, newest first. Filters: actor, action
+// (a trailing "." matches a prefix), target_type, target_id, outcome,
+// since/until (RFC3339), limit and offset. Non-admins only see their own.
+func (h *Handler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := 100
+	offset := 0
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	events, err := h.auditService.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		h.auditError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, events)
+}
+
+// ExportAuditEvents streams every matching event as JSON Lines
+func (h *Handler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	written := 0
+	err = h.auditService.Export(r.Context(), filter, func(e *models.AuditEvent) error {
+		if written == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		written++
+		if written%500 == 0 && flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if written == 0 {
+			h.auditError(w, err)
+		} else {
+			// 헤더가 이미 나갔으므로 잘린 응답으로 끝낸다
+			log.Printf("audit export aborted after %d events: %v", written, err)
+		}
+		return
+	}
+	if written == 0 {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (h *Handler) auditError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrForbidden) {
+		h.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAuditFilter) {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.Error(w, http.StatusInternalServerError, "Internal server error")
+}
+
+func auditFilter(r *http.Request) (*models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := &models.AuditFilter{
+		Actor:      q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+		Outcome:    q.Get("outcome"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC3339 timestamp", p.name)
+			}
+			t = t.UTC()
+			*p.dst = &t
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// currentUser is the caller authenticated by AuthMiddleware
+func currentUser(r *http.Request) *models.User {
+	return auth.UserFromContext(r.Context())
+}
+
+// GetCurrentUser returns the authenticated caller
+func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := currentUser(r)
+	if user == nil {
+		h.Error(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, map[string]interface{}{
+		"user":      user,
+		"namespace": user.Namespace(),
+	})
+}
+
+// accessError writes the response for an access check failure
+func (h *Handler) accessError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		h.Error(w, http.StatusForbidden, err.Error())
+	case err == repository.ErrFlowNotFound:
+		h.Error(w, http.StatusNotFound, "Flow not found")
+	case err == repository.ErrObjectNotFound:
+		h.Error(w, http.StatusNotFound, "Object not found")
+	case err == repository.ErrProjectNotFound:
+		h.Error(w, http.StatusNotFound, "Project not found")
+	default:
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// denied writes 403/404 for the errors of an access check (see accessError)
+// and reports whether err was one
+func (h *Handler) denied(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrForbidden), err == repository.ErrFlowNotFound,
+		err == repository.ErrObjectNotFound, err == repository.ErrProjectNotFound:
+		h.accessError(w, err)
+		return true
+	}
+	return false
+}
+
+// forbidden writes 403 for a service.ErrForbidden and reports whether it did
+func (h *Handler) forbidden(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, service.ErrForbidden) {
+		h.Error(w, http.StatusForbidden, err.Error())
+		return true
+	}
+	return false
+}
+
+// authorizeFlow writes 404/403 and returns false unless the caller holds role
+// on the flow (see service.AccessService)
+func (h *Handler) authorizeFlow(w http.ResponseWriter, r *http.Request, flowID int64, role string) bool {
+	if _, err := h.accessService.RequireFlow(r.Context(), flowID, role); err != nil {
+		h.accessError(w, err)
+		return false
+	}
+	return true
+}
+
+// authorizeProject writes 404/403 and returns false unless the caller holds role in the project
+func (h *Handler) authorizeProject(w http.ResponseWriter, r *http.Request, projectID int64, role string) bool {
+	if err := h.accessService.RequireProject(r.Context(), projectID, role); err != nil {
+		h.accessError(w, err)
+		return false
+	}
+	return true
+}
+
+// authorizeNamespace checks that the caller may act in user-<name>: only its
+// own namespace unless admin. An empty name means the caller's namespace.
+func (h *Handler) authorizeNamespace(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	user := currentUser(r)
+	if user == nil {
+		h.Error(w, http.StatusUnauthorized, "Authentication required")
+		return "", false
+	}
+	if name == "" {
+		return user.Username, true
+	}
+	if name != user.Username && !user.Admin {
+		h.Error(w, http.StatusForbidden, "Cannot access another user's namespace")
+		return "", false
+	}
+	return name, true
+}
+
+// bindRequest binds a runtime request to the caller: User defaults to the
+// caller's namespace, the caller must hold role on the flow and every step
+// (and test) object must belong to that flow.
+func (h *Handler) bindRequest(w http.ResponseWriter, r *http.Request, dto *models.K8sRequestDTO, role string) bool {
+	name, ok := h.authorizeNamespace(w, r, dto.User)
+	if !ok {
+		return false
+	}
+	dto.User = name
+
+	flowID, err := strconv.ParseInt(dto.FlowID, 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return false
+	}
+	if !h.authorizeFlow(w, r, flowID, role) {
+		return false
+	}
+
+	ids := append([]int64{}, dto.Steps...)
+	if dto.Test != nil {
+		ids = append(ids, *dto.Test)
+	}
+	for _, id := range ids {
+		object, err := h.objectRepo.FindByID(id)
+		if err == repository.ErrObjectNotFound {
+			continue // 없는 스텝은 런타임이 보고한다
+		}
+		if err != nil {
+			h.Error(w, http.StatusInternalServerError, "Internal server error")
+			return false
+		}
+		if object.FlowID == nil || *object.FlowID != flowID {
+			h.Error(w, http.StatusForbidden, fmt.Sprintf("Object %d does not belong to flow %d", id, flowID))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListConnections lists the caller's connections (admins: any ?user) without their values
+func (h *Handler) ListConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := h.authorizeNamespace(w, r, r.URL.Query().Get("user"))
+	if !ok {
+		return
+	}
+
+	conns, err := h.connectionService.FindByOwner(user)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, conns)
+}
+
+func (h *Handler) GetConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+	if !h.authorizeConnection(w, r, id) {
+		return
+	}
+
+	conn, err := h.connectionService.FindByID(id)
+	if err != nil {
+		if err == repository.ErrConnectionNotFound {
+			h.Error(w, http.StatusNotFound, "Connection not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, conn)
+}
+
+func (h *Handler) CreateConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.ConnectionRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	owner, ok := h.authorizeNamespace(w, r, req.User)
+	if !ok {
+		return
+	}
+	req.User = owner
+
+	conn, err := h.connectionService.Create(&req)
+	if err != nil {
+		h.connectionError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, conn)
+}
+
+func (h *Handler) UpdateConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+	if !h.authorizeConnection(w, r, id) {
+		return
+	}
+
+	var req models.ConnectionRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	conn, err := h.connectionService.Update(id, &req)
+	if err != nil {
+		h.connectionError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, conn)
+}
+
+func (h *Handler) DeleteConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+	if !h.authorizeConnection(w, r, id) {
+		return
+	}
+
+	if err := h.connectionService.Delete(id); err != nil {
+		if err == repository.ErrConnectionNotFound {
+			h.Error(w, http.StatusNotFound, "Connection not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Connection deleted successfully")
+}
+
+// authorizeConnection writes 404/403 and returns false unless the caller owns the connection
+func (h *Handler) authorizeConnection(w http.ResponseWriter, r *http.Request, id int64) bool {
+	conn, err := h.connectionService.FindByID(id)
+	if err != nil {
+		h.connectionError(w, err)
+		return false
+	}
+	_, ok := h.authorizeNamespace(w, r, conn.Owner)
+	return ok
+}
+
+func (h *Handler) connectionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrConnectionNotFound):
+		h.Error(w, http.StatusNotFound, "Connection not found")
+	case errors.Is(err, service.ErrConnectionExists):
+		h.Error(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrSecretsKeyMissing):
+		h.Error(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.Error(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListFlowDeadLetters returns the most recent events the flow's steps failed on
+func (h *Handler) ListFlowDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	ns, rt, status, err := h.deployedRuntime(r, id)
+	if err != nil {
+		h.Error(w, status, err.Error())
+		return
+	}
+
+	entries, err := rt.ListDeadLetters(r.Context(), ns, strconv.FormatInt(id, 10), limit)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.JSON(w, http.StatusOK, entries)
+}
+
+// ReplayFlowDeadLetters sends the selected dead letters back to the steps that
+// failed on them, typically after the step code was fixed and redeployed
+func (h *Handler) ReplayFlowDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleDeployer) {
+		return
+	}
+
+	var req models.DLQReplayRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ns, rt, status, err := h.deployedRuntime(r, id)
+	if err != nil {
+		h.Error(w, status, err.Error())
+		return
+	}
+
+	resp, err := service.ReplayDeadLetters(r.Context(), rt, ns, strconv.FormatInt(id, 10), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReplay) {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.JSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetFlowDeployment returns the last deployment of a flow reconciled against
+// the live runtime state: deployed, drifted, broken, deploying or not_deployed.
+func (h *Handler) GetFlowDeployment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	deployment, err := h.deploymentService.Latest(id)
+	if err != nil {
+		if err == repository.ErrDeploymentNotFound {
+			h.JSON(w, http.StatusOK, h.deploymentService.NotDeployed(id))
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	rt, err := h.runtimeByName(deployment.Runtime, false)
+	if err != nil {
+		h.Error(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	status, err := h.deploymentService.Reconcile(r.Context(), deployment, rt)
+	if err != nil {
+		h.Error(w, http.StatusServiceUnavailable, fmt.Sprintf("Failed to read runtime state: %v", err))
+		return
+	}
+
+	h.JSON(w, http.StatusOK, status)
+}
@@ -2,12 +2,16 @@ package handler
 
 import (
 	"data-pipeline-backend/internal/models"
-	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"sigs.k8s.io/yaml"
 )
 
 func (h *Handler) GetAllFlows(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +20,7 @@ func (h *Handler) GetAllFlows(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flows, err := h.flowService.FindAll()
+	flows, err := h.flowService.List(r.Context())
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -38,13 +42,9 @@ func (h *Handler) GetFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flow, err := h.flowService.FindByID(id)
+	flow, err := h.flowService.FindByID(r.Context(), id)
 	if err != nil {
-		if err == repository.ErrFlowNotFound {
-			h.Error(w, http.StatusNotFound, "Flow not found")
-			return
-		}
-		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		h.accessError(w, err)
 		return
 	}
 
@@ -68,8 +68,11 @@ func (h *Handler) CreateFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flow, err := h.flowService.Create(&req)
+	flow, err := h.flowService.Create(r.Context(), &req)
 	if err != nil {
+		if h.denied(w, err) {
+			return
+		}
 		h.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -97,15 +100,9 @@ func (h *Handler) UpdateFlow(w http.ResponseWriter, r *http.Request) {
 	}
 	req.ID = &id
 
-	if req.Name == "" {
-		h.Error(w, http.StatusBadRequest, "Flow name is required")
-		return
-	}
-
-	flow, err := h.flowService.Update(&req)
+	flow, err := h.flowService.Update(r.Context(), &req)
 	if err != nil {
-		if err == repository.ErrFlowNotFound {
-			h.Error(w, http.StatusNotFound, "Flow not found")
+		if h.denied(w, err) {
 			return
 		}
 		h.Error(w, http.StatusBadRequest, err.Error())
@@ -128,14 +125,117 @@ func (h *Handler) DeleteFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.flowService.Delete(id); err != nil {
-		if err == repository.ErrFlowNotFound {
-			h.Error(w, http.StatusNotFound, "Flow not found")
+	if err := h.flowService.Delete(r.Context(), id); err != nil {
+		h.accessError(w, err)
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Flow deleted successfully")
+}
+
+func (h *Handler) GetFlowGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	graph, err := h.flowService.GetGraph(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrFlowCycle) {
+			h.Error(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.accessError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, graph)
+}
+
+// ExportFlow returns the flow as a portable bundle (?format=json|yaml)
+func (h *Handler) ExportFlow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "yaml" {
+		h.Error(w, http.StatusBadRequest, "format must be json or yaml")
+		return
+	}
+
+	bundle, err := h.flowService.Export(r.Context(), id)
+	if err != nil {
+		h.accessError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="flow-%d.%s"`, id, format))
+	if format == "json" {
+		h.JSON(w, http.StatusOK, bundle)
+		return
+	}
+
+	out, err := yaml.Marshal(bundle)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Failed to encode bundle")
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// ImportFlow creates a new flow from a JSON or YAML bundle (?name= overrides the flow name)
+func (h *Handler) ImportFlow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// YAML은 JSON의 상위 집합이므로 두 형식 모두 처리된다
+	var bundle models.FlowBundle
+	if err := yaml.Unmarshal(body, &bundle); err != nil {
+		h.Error(w, http.StatusBadRequest, fmt.Sprintf("Invalid bundle: %v", err))
+		return
+	}
+
+	result, err := h.flowService.Import(r.Context(), &bundle, r.URL.Query().Get("name"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBundle) {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if h.denied(w, err) {
 			return
 		}
 		h.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	h.Message(w, http.StatusOK, "Flow deleted successfully")
+	h.JSON(w, http.StatusCreated, result)
 }
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) ListFlowVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	limit := 50
+	offset := 0
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	versions, err := h.versionService.List(id, limit, offset)
+	if err != nil {
+		if err == repository.ErrFlowNotFound {
+			h.Error(w, http.StatusNotFound, "Flow not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, versions)
+}
+
+func (h *Handler) GetFlowVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	v, err := h.versionService.Get(id, version)
+	if err != nil {
+		if err == repository.ErrFlowVersionNotFound {
+			h.Error(w, http.StatusNotFound, "Flow version not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, v)
+}
+
+func (h *Handler) DiffFlowVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "from version is required")
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "to version is required")
+		return
+	}
+
+	diff, err := h.versionService.Diff(id, from, to)
+	if err != nil {
+		if err == repository.ErrFlowVersionNotFound {
+			h.Error(w, http.StatusNotFound, "Flow version not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, diff)
+}
+
+// RollbackFlowVersion restores an older version; deploy it with
+// POST /api/k8s/deploy/stream and {"version": N} to redeploy in one step.
+func (h *Handler) RollbackFlowVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleEditor) {
+		return
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	result, err := h.versionService.Rollback(id, version, &currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrFlowNotFound) {
+			h.Error(w, http.StatusNotFound, "Flow not found")
+			return
+		}
+		if errors.Is(err, repository.ErrFlowVersionNotFound) {
+			h.Error(w, http.StatusNotFound, "Flow version not found")
+			return
+		}
+		log.Printf("rollback of flow %d to version %d failed: %v", id, version, err)
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, result)
+}
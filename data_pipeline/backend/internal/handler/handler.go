@@ -1,39 +1,133 @@
 package handler
 
 import (
+	"context"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/leader"
 	"data-pipeline-backend/internal/repository"
 	"data-pipeline-backend/internal/service"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 type Handler struct {
-	flowRepo        *repository.FlowRepository
-	flowService     *service.FlowService
-	objectRepo      *repository.ObjectRepository
-	objectService   *service.ObjectService
-	trainingRepo    *repository.TrainingRepository
-	trainingService *service.TrainingService
+	flowRepo           *repository.FlowRepository
+	flowService        *service.FlowService
+	objectRepo         *repository.ObjectRepository
+	objectService      *service.ObjectService
+	edgeRepo           *repository.EdgeRepository
+	stepImageRepo      *repository.StepImageRepository
+	connectionRepo     *repository.ConnectionRepository
+	connectionService  *service.ConnectionService
+	accessService      *service.AccessService
+	projectService     *service.ProjectService
+	versionService     *service.FlowVersionService
+	localRuntime       *service.LocalRuntime
+	deploymentService  *service.DeploymentService
+	traceService       *service.TraceService
+	trainingRepo       *repository.TrainingRepository
+	trainingService    *service.TrainingService
+	auditService       *service.AuditService
+	workspaceService   *service.WorkspaceService
+	dataService        *service.DataService
+	workflowRunService *service.WorkflowRunService
+	workflowService    *service.WorkflowService
+	scheduleService    *service.ScheduleService
+	triggerService     *service.TriggerService
 }
 
 func NewHandler(db *sql.DB) *Handler {
 	flowRepo := repository.NewFlowRepository(db)
 	objectRepo := repository.NewObjectRepository(db)
+	edgeRepo := repository.NewEdgeRepository(db)
+	versionRepo := repository.NewFlowVersionRepository(db)
+	deploymentRepo := repository.NewDeploymentRepository(db)
+	traceRepo := repository.NewTraceRepository(db)
+	stepImageRepo := repository.NewStepImageRepository(db)
+	connectionRepo := repository.NewConnectionRepository(db)
 	trainingRepo := repository.NewTrainingRepository(db)
-	
-	flowService := service.NewFlowService(flowRepo)
-	objectService := service.NewObjectService(objectRepo, flowRepo)
-	trainingService := service.NewTrainingService(trainingRepo)
-
-	return &Handler{
-		flowRepo:        flowRepo,
-		flowService:     flowService,
-		objectRepo:      objectRepo,
-		objectService:   objectService,
-		trainingRepo:    trainingRepo,
-		trainingService: trainingService,
+	projectRepo := repository.NewProjectRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	workflowRunRepo := repository.NewWorkflowRunRepository(db)
+	scheduleRepo := repository.NewFlowScheduleRepository(db)
+	triggerRepo := repository.NewFlowTriggerRepository(db)
+
+	accessService := service.NewAccessService(projectRepo, flowRepo)
+	projectService := service.NewProjectService(projectRepo, userRepo, accessService)
+	versionService := service.NewFlowVersionService(versionRepo, flowRepo, objectRepo, edgeRepo)
+	flowService := service.NewFlowService(flowRepo, objectRepo, edgeRepo, accessService, versionService)
+	objectService := service.NewObjectService(objectRepo, flowRepo, edgeRepo, accessService, versionService)
+	trainingService := service.NewTrainingService(trainingRepo, accessService)
+	deploymentService := service.NewDeploymentService(deploymentRepo, stepImageRepo, objectRepo, edgeRepo)
+	traceService := service.NewTraceService(traceRepo, deploymentRepo)
+	connectionService := service.NewConnectionService(connectionRepo)
+	auditService := service.NewAuditService(auditRepo)
+	workspaceService := service.NewWorkspaceService(accessService, connectionRepo)
+	workflowRunService := service.NewWorkflowRunService(workflowRunRepo, flowRepo, accessService)
+	if db != nil {
+		go workflowRunService.Heartbeat()
+	}
+
+	// 로컬 러너는 별도 설정이 없으면 이 서버로 span을 보낸다
+	cfg := config.Get()
+	localTraceURL := cfg.Runtime.TraceURL
+	if localTraceURL == "" {
+		localTraceURL = fmt.Sprintf("http://127.0.0.1:%s/api/traces/spans", cfg.Server.Port)
+	}
+	localRuntime := service.NewLocalRuntime(objectRepo, edgeRepo, connectionRepo, accessService, cfg.Runtime.PythonBin, localTraceURL)
+	dataService := service.NewDataService(workspaceService)
+	pythonStepService := service.NewPythonStepService(cfg.Staging, cfg.Jupyter.URL)
+	workflowService := service.NewWorkflowService(flowService, objectService, dataService, pythonStepService, workflowRunService)
+
+	// 인증이 꺼져 있으면 모든 요청이 admin이므로 예약 실행도 그렇게 한다
+	admins := cfg.Auth.Admins
+	if cfg.Auth.EffectiveMode() == config.AuthModeNone {
+		admins = append(admins, cfg.Auth.DevUser)
+	}
+	scheduleService := service.NewScheduleService(scheduleRepo, userRepo, accessService, workflowRunService, workflowService,
+		admins, time.Duration(cfg.Scheduler.IntervalSec)*time.Second)
+	triggerService := service.NewTriggerService(triggerRepo, userRepo, accessService, workspaceService, workflowRunService,
+		workflowService, admins, time.Duration(cfg.Triggers.PollIntervalSec)*time.Second, cfg.K8s.KafkaBootstrap)
+
+	h := &Handler{
+		flowRepo:           flowRepo,
+		flowService:        flowService,
+		objectRepo:         objectRepo,
+		objectService:      objectService,
+		edgeRepo:           edgeRepo,
+		stepImageRepo:      stepImageRepo,
+		connectionRepo:     connectionRepo,
+		connectionService:  connectionService,
+		accessService:      accessService,
+		projectService:     projectService,
+		versionService:     versionService,
+		localRuntime:       localRuntime,
+		deploymentService:  deploymentService,
+		traceService:       traceService,
+		trainingRepo:       trainingRepo,
+		trainingService:    trainingService,
+		auditService:       auditService,
+		workspaceService:   workspaceService,
+		dataService:        dataService,
+		workflowRunService: workflowRunService,
+		workflowService:    workflowService,
+		scheduleService:    scheduleService,
+		triggerService:     triggerService,
+	}
+
+	if db != nil && cfg.Scheduler.Enabled {
+		// 스케줄은 잠금을 가진 레플리카 하나만 실행한다
+		go leader.New(db, "flow-scheduler", 0).Run(context.Background(), scheduleService.Run)
+	}
+	if db != nil && cfg.Triggers.Enabled {
+		// 파일/Kafka 트리거도 한 레플리카만 감시한다; 웹훅은 모든 레플리카가 받는다
+		go leader.New(db, "flow-triggers", 0).Run(context.Background(), triggerService.Run)
 	}
+	return h
 }
 
 func (h *Handler) JSON(w http.ResponseWriter, status int, data interface{}) {
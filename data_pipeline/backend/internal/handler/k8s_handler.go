@@ -21,6 +21,9 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 		h.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if !h.bindRequest(w, r, &dto, models.ProjectRoleDeployer) {
+		return
+	}
 
 	op := r.URL.Query().Get("op")
 	if op == "" {
@@ -40,11 +43,11 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Accel-Buffering", "no")
 
 	ctx := r.Context()
-	k8sService, err := service.NewK8sService(h.objectRepo)
+	rt, err := h.flowRuntime(r, createNamespaceIfMissing)
 	if err != nil {
 		h.sendSSEWithID(w, "error", "", models.ProgressEventDTO{
 			Phase:   "error",
-			Message: fmt.Sprintf("Failed to create K8s service: %v", err),
+			Message: err.Error(),
 			OK:      false,
 		})
 		return
@@ -52,15 +55,17 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 
 	opID := fmt.Sprintf("%d", time.Now().UnixNano())
 	t0 := time.Now()
+	userID := &currentUser(r).ID
 
 	h.sendSSEWithID(w, "hello", opID, models.ProgressEventDTO{
 		Phase:   "hello",
 		Message: "operation started",
 		OK:      true,
 		Data: map[string]interface{}{
-			"op":   op,
-			"ts":   t0.Format(time.RFC3339),
-			"opId": opID,
+			"op":      op,
+			"ts":      t0.Format(time.RFC3339),
+			"opId":    opID,
+			"runtime": rt.Name(),
 		},
 	})
 
@@ -103,7 +108,10 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 			}
 		}()
 
+		var phases []models.DeploymentPhase
 		progress := func(phase, message string, ok bool, extra map[string]interface{}) {
+			phases = append(phases, models.DeploymentPhase{Phase: phase, Message: message, OK: ok, At: time.Now()})
+
 			data := make(map[string]interface{})
 			if extra != nil {
 				for k, v := range extra {
@@ -127,7 +135,7 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 				"timeoutSeconds": verifyTimeoutSeconds,
 			})
 
-			result := k8sService.RunOnceAndVerify(ctx, &dto, verifyTimeoutSeconds)
+			result := rt.RunOnce(ctx, &dto, verifyTimeoutSeconds)
 			ok := len(result) > 0 && len(result) >= 2 && result[:2] == "OK"
 			phase := "verify.ok"
 			if !ok {
@@ -145,8 +153,77 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 				},
 			})
 		} else {
-			steps, err := k8sService.MakeStep(dto.Steps)
+			// 저장된 버전을 복원한 뒤 해당 구성으로 재배포
+			if dto.Version != nil {
+				restored, err := h.versionService.Rollback(flowIDInt, *dto.Version, userID)
+				if err != nil {
+					h.sendSSEWithID(w, "error", opID, models.ProgressEventDTO{
+						Phase:   "error",
+						Message: fmt.Sprintf("Failed to restore version %d: %v", *dto.Version, err),
+						OK:      false,
+						Data: map[string]interface{}{
+							"op":        op,
+							"opId":      opID,
+							"elapsedMs": time.Since(t0).Milliseconds(),
+						},
+					})
+					return
+				}
+				dto.Steps = restored.Steps
+				if dto.User == "" {
+					dto.User = restored.User
+				}
+				progress("rollback", fmt.Sprintf("restored version %d as version %d", *dto.Version, restored.Version.Version), true, map[string]interface{}{
+					"restoredFrom": restored.RestoredFrom,
+					"version":      restored.Version.Version,
+					"steps":        restored.Steps,
+				})
+			}
+
+			// 배포 기록은 verify 결과와 함께 마무리한다
+			var deployment *models.Deployment
+			if flowIDInt > 0 {
+				d, err := h.deploymentService.Start(flowIDInt, &dto, rt.Name(), userID)
+				if err != nil {
+					progress("warning", fmt.Sprintf("Failed to record deployment: %v", err), true, nil)
+				}
+				deployment = d
+			}
+			finishDeployment := func(phase, result string, ok bool, version *int) {
+				if deployment == nil {
+					return
+				}
+				phases = append(phases, models.DeploymentPhase{Phase: phase, Message: result, OK: ok, At: time.Now()})
+				if err := h.deploymentService.Finish(deployment, phases, ok, result, version); err != nil {
+					progress("warning", fmt.Sprintf("Failed to record deployment: %v", err), true, nil)
+				}
+			}
+			// 배포를 시도한 구성을 결과와 함께 불변 버전으로 기록
+			recordDeploy := func(ok bool, result string) *int {
+				if flowIDInt <= 0 {
+					return nil
+				}
+				opts := &models.DeployOptions{
+					User:                     dto.User,
+					Steps:                    dto.Steps,
+					CreateNamespaceIfMissing: createNamespaceIfMissing,
+					VerifyTimeoutSeconds:     verifyTimeoutSeconds,
+				}
+				v, err := h.versionService.RecordDeploy(flowIDInt, opts, ok, result, userID)
+				if err != nil {
+					progress("warning", fmt.Sprintf("Failed to record deploy version: %v", err), true, nil)
+					return nil
+				}
+				progress("version", fmt.Sprintf("recorded version %d", v.Version), true, map[string]interface{}{
+					"version": v.Version,
+				})
+				return &v.Version
+			}
+
+			changed, err := rt.Changed(ctx, &dto)
 			if err != nil {
+				result := fmt.Sprintf("NG: failed to make steps: %v", err)
+				finishDeployment("error", result, false, recordDeploy(false, result))
 				h.sendSSEWithID(w, "error", opID, models.ProgressEventDTO{
 					Phase:   "error",
 					Message: fmt.Sprintf("Failed to make steps: %v", err),
@@ -159,20 +236,19 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 				})
 				return
 			}
-			ns := "user-" + dto.User
-			changed := k8sService.FlowChanged(ctx, ns, dto.FlowID, steps)
 
 			if !changed {
 				progress("apply.skip", "no changes; kick only", true, map[string]interface{}{
 					"op": "deploy",
 				})
 
-				result := k8sService.RunOnceAndVerify(ctx, &dto, verifyTimeoutSeconds)
+				result := rt.RunOnce(ctx, &dto, verifyTimeoutSeconds)
 				ok := len(result) > 0 && len(result) >= 2 && result[:2] == "OK"
 				phase := "verify.ok"
 				if !ok {
 					phase = "verify.ng"
 				}
+				finishDeployment(phase, result, ok, nil)
 
 				h.sendSSEWithID(w, map[bool]string{true: "progress", false: "error"}[ok], opID, models.ProgressEventDTO{
 					Phase:   phase,
@@ -189,13 +265,15 @@ func (h *Handler) DeployStream(w http.ResponseWriter, r *http.Request) {
 					"op": "deploy",
 				})
 
-				result := k8sService.ApplyAndKickVerify(ctx, &dto, createNamespaceIfMissing, verifyTimeoutSeconds, progress)
+				result := service.DeployAndVerify(ctx, rt, &dto, verifyTimeoutSeconds, progress)
 				ok := len(result) > 0 && len(result) >= 2 && result[:2] == "OK"
 				phase := "verify.ok"
 				if !ok {
 					phase = "verify.ng"
 				}
 
+				finishDeployment(phase, result, ok, recordDeploy(ok, result))
+
 				h.sendSSEWithID(w, map[bool]string{true: "progress", false: "error"}[ok], opID, models.ProgressEventDTO{
 					Phase:   phase,
 					Message: result,
@@ -230,35 +308,31 @@ func (h *Handler) DeleteK8sResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flowID := r.URL.Query().Get("flowId")
-	user := r.URL.Query().Get("user")
-	if flowID == "" || user == "" {
-		h.Error(w, http.StatusBadRequest, "flowId and user are required")
+	dto := &models.K8sRequestDTO{FlowID: r.URL.Query().Get("flowId"), User: r.URL.Query().Get("user")}
+	if dto.FlowID == "" {
+		h.Error(w, http.StatusBadRequest, "flowId is required")
+		return
+	}
+	if !h.bindRequest(w, r, dto, models.ProjectRoleDeployer) {
 		return
 	}
 
 	ctx := r.Context()
-	k8sService, err := service.NewK8sService(h.objectRepo)
+	rt, err := h.flowRuntime(r, false)
 	if err != nil {
-		h.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create K8s service: %v", err))
+		h.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	ns := "user-" + user
-	kafkaNs := "kafka"
-
-	msg, err := k8sService.DeleteByFlowId(ctx, ns, flowID)
+	msg, err := rt.Delete(ctx, dto)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete flow resources: %v", err))
 		return
 	}
 
-	if err := k8sService.DeleteKafkaTopic(ctx, kafkaNs, flowID); err != nil {
-		msg += fmt.Sprintf(" (KafkaTopic deletion warning: %v)", err)
-	} else {
-		msg += " + KafkaTopic deleted in ns=" + kafkaNs
-	}
-
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(msg))
@@ -275,6 +349,9 @@ func (h *Handler) UnitTest(w http.ResponseWriter, r *http.Request) {
 		h.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if !h.bindRequest(w, r, &dto, models.ProjectRoleEditor) {
+		return
+	}
 
 	totalTimeoutSeconds := 20
 	if v := r.URL.Query().Get("totalTimeoutSeconds"); v != "" {
@@ -284,7 +361,7 @@ func (h *Handler) UnitTest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	k8sService, err := service.NewK8sService(h.objectRepo)
+	k8sService, err := service.NewK8sService(h.objectRepo, h.edgeRepo, h.stepImageRepo, h.connectionRepo, h.accessService)
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create K8s service: %v", err))
 		return
@@ -299,6 +376,62 @@ func (h *Handler) UnitTest(w http.ResponseWriter, r *http.Request) {
 	h.JSON(w, http.StatusOK, result)
 }
 
+// RenderK8s returns the manifests a deploy would apply as multi-document YAML.
+// With ?dryRun=server every manifest is also submitted as a server-side dry-run.
+func (h *Handler) RenderK8s(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var dto models.K8sRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !h.bindRequest(w, r, &dto, models.ProjectRoleViewer) {
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun")
+	if dryRun != "" && dryRun != "server" {
+		h.Error(w, http.StatusBadRequest, "dryRun must be server")
+		return
+	}
+	includeNamespace := r.URL.Query().Get("createNamespaceIfMissing") == "true"
+
+	ctx := r.Context()
+	k8sService, err := service.NewK8sService(h.objectRepo, h.edgeRepo, h.stepImageRepo, h.connectionRepo, h.accessService)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create K8s service: %v", err))
+		return
+	}
+
+	if dryRun == "server" {
+		result, err := k8sService.RenderDryRun(ctx, &dto, includeNamespace)
+		if err != nil {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		status := http.StatusOK
+		if !result.OK {
+			status = http.StatusUnprocessableEntity
+		}
+		h.JSON(w, status, result)
+		return
+	}
+
+	manifest, err := k8sService.Render(&dto, includeNamespace)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(manifest))
+}
+
 func (h *Handler) sendSSEWithID(w http.ResponseWriter, eventName, eventID string, payload models.ProgressEventDTO) {
 	data, err := json.Marshal(payload)
 	if err != nil {
@@ -315,3 +448,16 @@ func (h *Handler) sendSSEWithID(w http.ResponseWriter, eventName, eventID string
 		f.Flush()
 	}
 }
+
+func (h *Handler) sendSSE(w http.ResponseWriter, eventName string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{"error":"Failed to marshal event: %v"}`, err))
+	}
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName, string(data))
+
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
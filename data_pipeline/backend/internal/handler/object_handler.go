@@ -2,7 +2,6 @@ package handler
 
 import (
 	"data-pipeline-backend/internal/models"
-	"data-pipeline-backend/internal/repository"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -16,7 +15,7 @@ func (h *Handler) GetAllObjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	objects, err := h.objectService.FindAll()
+	objects, err := h.objectService.List(r.Context())
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -38,13 +37,9 @@ func (h *Handler) GetObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	object, err := h.objectService.FindByID(id)
+	object, err := h.objectService.FindByID(r.Context(), id)
 	if err != nil {
-		if err == repository.ErrObjectNotFound {
-			h.Error(w, http.StatusNotFound, "Object not found")
-			return
-		}
-		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		h.accessError(w, err)
 		return
 	}
 
@@ -64,13 +59,9 @@ func (h *Handler) GetObjectsByFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	objects, err := h.objectService.FindByFlow(flowID)
+	objects, err := h.objectService.FindByFlow(r.Context(), flowID)
 	if err != nil {
-		if err.Error() == "플로우를 찾을 수 없습니다" {
-			h.Error(w, http.StatusNotFound, "Flow not found")
-			return
-		}
-		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		h.accessError(w, err)
 		return
 	}
 
@@ -94,8 +85,11 @@ func (h *Handler) CreateObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	object, err := h.objectService.Create(&req)
+	object, err := h.objectService.Create(r.Context(), &req)
 	if err != nil {
+		if h.denied(w, err) {
+			return
+		}
 		h.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -123,10 +117,9 @@ func (h *Handler) UpdateObject(w http.ResponseWriter, r *http.Request) {
 	}
 	req.ID = &id
 
-	object, err := h.objectService.Update(&req)
+	object, err := h.objectService.Update(r.Context(), &req)
 	if err != nil {
-		if err == repository.ErrObjectNotFound {
-			h.Error(w, http.StatusNotFound, "Object not found")
+		if h.denied(w, err) {
 			return
 		}
 		h.Error(w, http.StatusBadRequest, err.Error())
@@ -149,12 +142,8 @@ func (h *Handler) DeleteObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.objectService.Delete(id); err != nil {
-		if err == repository.ErrObjectNotFound {
-			h.Error(w, http.StatusNotFound, "Object not found")
-			return
-		}
-		h.Error(w, http.StatusInternalServerError, "Internal server error")
+	if err := h.objectService.Delete(r.Context(), id); err != nil {
+		h.accessError(w, err)
 		return
 	}
 
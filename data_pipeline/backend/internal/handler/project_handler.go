@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListProjects lists the caller's projects with its role in each
+func (h *Handler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	projects, err := h.projectService.FindAll(r.Context())
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, projects)
+}
+
+func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := h.projectService.FindByID(r.Context(), id)
+	if err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, project)
+}
+
+// CreateProject creates a project with the caller as its admin
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.ProjectRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	project, err := h.projectService.Create(r.Context(), &req)
+	if err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, project)
+}
+
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ProjectRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	project, err := h.projectService.Update(r.Context(), id, &req)
+	if err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, project)
+}
+
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.projectService.Delete(r.Context(), id); err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Project deleted successfully")
+}
+
+func (h *Handler) ListProjectMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.projectService.ListMembers(r.Context(), id)
+	if err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, members)
+}
+
+// SetProjectMember adds a user to the project or changes its role ({"role": "editor"})
+func (h *Handler) SetProjectMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ProjectMemberRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	members, err := h.projectService.SetMember(r.Context(), id, mux.Vars(r)["username"], req.Role)
+	if err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, members)
+}
+
+func (h *Handler) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.projectID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.projectService.RemoveMember(r.Context(), id, mux.Vars(r)["username"]); err != nil {
+		h.projectError(w, err)
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Member removed successfully")
+}
+
+func (h *Handler) projectID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) projectError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		h.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repository.ErrProjectNotFound):
+		h.Error(w, http.StatusNotFound, "Project not found")
+	case errors.Is(err, repository.ErrUserNotFound):
+		h.Error(w, http.StatusNotFound, "User not found (users appear after their first sign-in)")
+	case errors.Is(err, repository.ErrProjectMemberNotFound):
+		h.Error(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, service.ErrProjectExists), errors.Is(err, service.ErrLastProjectAdmin):
+		h.Error(w, http.StatusConflict, err.Error())
+	default:
+		h.Error(w, http.StatusBadRequest, err.Error())
+	}
+}
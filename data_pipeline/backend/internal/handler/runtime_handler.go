@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// flowRuntime selects the execution backend from ?runtime= or FLOW_RUNTIME
+func (h *Handler) flowRuntime(r *http.Request, createNamespaceIfMissing bool) (service.FlowRuntime, error) {
+	name := r.URL.Query().Get("runtime")
+	if name == "" {
+		name = config.Get().Runtime.Default
+	}
+	return h.runtimeByName(name, createNamespaceIfMissing)
+}
+
+func (h *Handler) runtimeByName(name string, createNamespaceIfMissing bool) (service.FlowRuntime, error) {
+	if err := service.ValidateRuntimeName(name); err != nil {
+		return nil, err
+	}
+
+	if name == service.RuntimeLocal {
+		return h.localRuntime, nil
+	}
+
+	k8sService, err := service.NewK8sService(h.objectRepo, h.edgeRepo, h.stepImageRepo, h.connectionRepo, h.accessService)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create K8s service: %v", err)
+	}
+	return service.NewKnativeRuntime(k8sService, createNamespaceIfMissing), nil
+}
+
+// deployedRuntime resolves where a flow runs: ?user= (and ?runtime=) or, if
+// omitted, the flow's last deployment. On error the HTTP status is returned.
+// Only admins may name another user's namespace.
+func (h *Handler) deployedRuntime(r *http.Request, flowID int64) (string, service.FlowRuntime, int, error) {
+	if user := r.URL.Query().Get("user"); user != "" {
+		if caller := currentUser(r); caller == nil || (user != caller.Username && !caller.Admin) {
+			return "", nil, http.StatusForbidden, errors.New("Cannot access another user's namespace")
+		}
+		rt, err := h.flowRuntime(r, false)
+		if err != nil {
+			return "", nil, http.StatusBadRequest, err
+		}
+		return "user-" + user, rt, http.StatusOK, nil
+	}
+
+	deployment, err := h.deploymentService.Latest(flowID)
+	if err != nil {
+		if err == repository.ErrDeploymentNotFound {
+			return "", nil, http.StatusBadRequest, errors.New("user is required for a flow without deployments")
+		}
+		return "", nil, http.StatusInternalServerError, errors.New("Internal server error")
+	}
+	rt, err := h.runtimeByName(deployment.Runtime, false)
+	if err != nil {
+		return "", nil, http.StatusBadRequest, err
+	}
+	return deployment.Namespace, rt, http.StatusOK, nil
+}
+
+// runtimeRequest builds a request from ?flowId[&user][&steps=1,2,3]; without
+// steps every object of the flow is used. user defaults to the caller.
+func (h *Handler) runtimeRequest(r *http.Request) (*models.K8sRequestDTO, error) {
+	q := r.URL.Query()
+	dto := &models.K8sRequestDTO{
+		FlowID: q.Get("flowId"),
+		User:   q.Get("user"),
+	}
+	if dto.FlowID == "" {
+		return nil, errors.New("flowId is required")
+	}
+
+	if steps := q.Get("steps"); steps != "" {
+		for _, s := range strings.Split(steps, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid step id %q", s)
+			}
+			dto.Steps = append(dto.Steps, id)
+		}
+		return dto, nil
+	}
+
+	flowID, err := strconv.ParseInt(dto.FlowID, 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid flow ID")
+	}
+	objects, err := h.objectRepo.FindByFlow(flowID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		dto.Steps = append(dto.Steps, o.ID)
+	}
+	return dto, nil
+}
+
+func (h *Handler) GetFlowRuntimeStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	dto, err := h.runtimeRequest(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.bindRequest(w, r, dto, models.ProjectRoleViewer) {
+		return
+	}
+	rt, err := h.flowRuntime(r, false)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, err := rt.Status(r.Context(), dto)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.JSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) GetFlowRuntimeLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	dto, err := h.runtimeRequest(r)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.bindRequest(w, r, dto, models.ProjectRoleViewer) {
+		return
+	}
+	rt, err := h.flowRuntime(r, false)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tail := 200
+	if t := r.URL.Query().Get("tail"); t != "" {
+		if parsed, err := strconv.Atoi(t); err == nil {
+			tail = parsed
+		}
+	}
+
+	logs, err := rt.Logs(r.Context(), dto, tail)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.JSON(w, http.StatusOK, logs)
+}
+
+// StreamFlowLogs multiplexes the logs of every step of a deployed flow into one
+// SSE stream of "log" events. The namespace comes from ?user= or, if omitted,
+// from the flow's last deployment.
+func (h *Handler) StreamFlowLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	tail := 20
+	if t := r.URL.Query().Get("tail"); t != "" {
+		if parsed, err := strconv.Atoi(t); err == nil {
+			tail = parsed
+		}
+	}
+
+	ns, rt, status, err := h.deployedRuntime(r, id)
+	if err != nil {
+		h.Error(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	flowID := strconv.FormatInt(id, 10)
+	h.sendSSE(w, "hello", map[string]interface{}{
+		"flowId":    flowID,
+		"namespace": ns,
+		"runtime":   rt.Name(),
+	})
+
+	err = rt.StreamLogs(r.Context(), ns, flowID, tail, func(ev models.StepLogEventDTO) {
+		h.sendSSE(w, "log", ev)
+	})
+	if err != nil {
+		h.sendSSE(w, "error", map[string]string{"error": err.Error()})
+	}
+}
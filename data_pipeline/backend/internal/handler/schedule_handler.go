@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListSchedules lists the schedules of ?flowId=, or of every flow the caller may see
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var flowID *int64
+	if v := r.URL.Query().Get("flowId"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+			return
+		}
+		flowID = &id
+	}
+
+	schedules, err := h.scheduleService.List(r.Context(), flowID)
+	if err != nil {
+		h.scheduleError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, schedules)
+}
+
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleService.Get(r.Context(), id)
+	if err != nil {
+		h.scheduleError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, schedule)
+}
+
+// CreateSchedule adds a cron schedule to a flow (deployer on the flow)
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.FlowScheduleRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule, err := h.scheduleService.Create(r.Context(), &req)
+	if err != nil {
+		h.scheduleError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusCreated, schedule)
+}
+
+// UpdateSchedule changes the fields given; the caller becomes the user the
+// scheduled runs execute as
+func (h *Handler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.FlowScheduleRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule, err := h.scheduleService.Update(r.Context(), id, &req)
+	if err != nil {
+		h.scheduleError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, schedule)
+}
+
+func (h *Handler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scheduleService.Delete(r.Context(), id); err != nil {
+		h.scheduleError(w, err)
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Schedule deleted successfully")
+}
+
+func (h *Handler) scheduleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid schedule ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) scheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		h.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repository.ErrFlowScheduleNotFound):
+		h.Error(w, http.StatusNotFound, "Schedule not found")
+	case errors.Is(err, repository.ErrFlowNotFound):
+		h.Error(w, http.StatusNotFound, "Flow not found")
+	default:
+		h.Error(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// IngestTraceSpans accepts a single span or an array of spans from runners.
+// It is outside authentication; X-Trace-Token must be the token the runner
+// was deployed with, which is valid for the spans of its flow only.
+func (h *Handler) IngestTraceSpans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var spans []models.SpanIngestDTO
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &spans)
+	} else {
+		var span models.SpanIngestDTO
+		err = json.Unmarshal(trimmed, &span)
+		spans = append(spans, span)
+	}
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	stored, err := h.traceService.Ingest(r.Header.Get("X-Trace-Token"), spans)
+	if err != nil {
+		if errors.Is(err, service.ErrTraceToken) {
+			h.Error(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidSpan) {
+			h.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusAccepted, map[string]int{"stored": stored})
+}
+
+func (h *Handler) ListFlowTraces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	limit := 50
+	offset := 0
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	traces, err := h.traceService.List(id, limit, offset)
+	if err != nil {
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, traces)
+}
+
+// GetFlowTrace rebuilds one event's path through the flow with per-step timing
+func (h *Handler) GetFlowTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+		return
+	}
+
+	if !h.authorizeFlow(w, r, id, models.ProjectRoleViewer) {
+		return
+	}
+
+	trace, err := h.traceService.Get(id, vars["traceId"])
+	if err != nil {
+		if err == repository.ErrTraceNotFound {
+			h.Error(w, http.StatusNotFound, "Trace not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.JSON(w, http.StatusOK, trace)
+}
@@ -38,8 +38,11 @@ func (h *Handler) StartTraining(w http.ResponseWriter, r *http.Request) {
 		req.ModelType = "qlora"
 	}
 
-	result, err := h.trainingService.StartTraining(&req)
+	result, err := h.trainingService.StartTraining(r.Context(), &req)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -60,8 +63,11 @@ func (h *Handler) GetTrainingStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status, err := h.trainingService.GetTrainingStatus(id)
+	status, err := h.trainingService.GetTrainingStatus(r.Context(), id)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusNotFound, err.Error())
 		return
 	}
@@ -82,7 +88,10 @@ func (h *Handler) CancelTraining(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.trainingService.CancelTraining(id); err != nil {
+	if err := h.trainingService.CancelTraining(r.Context(), id); err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -110,7 +119,7 @@ func (h *Handler) ListTrainingRuns(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	runs, err := h.trainingService.ListTrainingRuns(limit, offset)
+	runs, err := h.trainingService.ListTrainingRuns(r.Context(), limit, offset)
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -132,8 +141,11 @@ func (h *Handler) GetTrainingRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	run, err := h.trainingService.GetTrainingRun(id)
+	run, err := h.trainingService.GetTrainingRun(r.Context(), id)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusNotFound, err.Error())
 		return
 	}
@@ -166,8 +178,11 @@ func (h *Handler) CreateFeedback(w http.ResponseWriter, r *http.Request) {
 		req.FeedbackType = "code_generation"
 	}
 
-	fb, err := h.trainingService.CreateFeedback(&req)
+	fb, err := h.trainingService.CreateFeedback(r.Context(), &req)
 	if err != nil {
+		if h.denied(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -266,8 +281,11 @@ func (h *Handler) BuildDataset(w http.ResponseWriter, r *http.Request) {
 		req.TestSplitRatio = 0.1
 	}
 
-	ds, err := h.trainingService.BuildDataset(&req)
+	ds, err := h.trainingService.BuildDataset(r.Context(), &req)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -295,7 +313,7 @@ func (h *Handler) ListDatasets(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	datasets, err := h.trainingService.ListDatasets(limit, offset)
+	datasets, err := h.trainingService.ListDatasets(r.Context(), limit, offset)
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -317,8 +335,11 @@ func (h *Handler) GetDataset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ds, err := h.trainingService.GetDataset(id)
+	ds, err := h.trainingService.GetDataset(r.Context(), id)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusNotFound, err.Error())
 		return
 	}
@@ -339,7 +360,10 @@ func (h *Handler) SetActiveDataset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.trainingService.SetActiveDataset(id); err != nil {
+	if err := h.trainingService.SetActiveDataset(r.Context(), id); err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -371,7 +395,7 @@ func (h *Handler) ListModels(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	modelsResult, err := h.trainingService.ListModels(limit, offset)
+	modelsResult, err := h.trainingService.ListModels(r.Context(), limit, offset)
 	if err != nil {
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -393,8 +417,11 @@ func (h *Handler) GetModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	model, err := h.trainingService.GetModel(id)
+	model, err := h.trainingService.GetModel(r.Context(), id)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusNotFound, err.Error())
 		return
 	}
@@ -434,8 +461,11 @@ func (h *Handler) ActivateModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.trainingService.ActivateModel(&req)
+	result, err := h.trainingService.ActivateModel(r.Context(), &req)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -468,8 +498,11 @@ func (h *Handler) RunEvaluation(w http.ResponseWriter, r *http.Request) {
 		req.EvalType = "automated"
 	}
 
-	result, err := h.trainingService.RunEvaluation(&req)
+	result, err := h.trainingService.RunEvaluation(r.Context(), &req)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -490,8 +523,11 @@ func (h *Handler) ListEvaluations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	evals, err := h.trainingService.ListEvaluations(modelID)
+	evals, err := h.trainingService.ListEvaluations(r.Context(), modelID)
 	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
 		h.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// webhook bodies larger than this are refused
+const maxWebhookBody = 1 << 20
+
+// ListTriggers lists the triggers of ?flowId=, or of every flow the caller may see
+func (h *Handler) ListTriggers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var flowID *int64
+	if v := r.URL.Query().Get("flowId"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+			return
+		}
+		flowID = &id
+	}
+
+	triggers, err := h.triggerService.List(r.Context(), flowID)
+	if err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	withWebhookPath(triggers...)
+	h.JSON(w, http.StatusOK, triggers)
+}
+
+func (h *Handler) GetTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.triggerID(w, r)
+	if !ok {
+		return
+	}
+
+	trigger, err := h.triggerService.Get(r.Context(), id)
+	if err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	withWebhookPath(trigger)
+	h.JSON(w, http.StatusOK, trigger)
+}
+
+// CreateTrigger adds a file, webhook or kafka trigger to a flow (deployer
+// on the flow). A webhook trigger's key is only returned here and when it
+// is rotated.
+func (h *Handler) CreateTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.FlowTriggerRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	trigger, err := h.triggerService.Create(r.Context(), &req)
+	if err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	withWebhookPath(trigger)
+	h.JSON(w, http.StatusCreated, trigger)
+}
+
+// UpdateTrigger changes the fields given; the caller becomes the user the
+// triggered runs execute as
+func (h *Handler) UpdateTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.triggerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.FlowTriggerRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	trigger, err := h.triggerService.Update(r.Context(), id, &req)
+	if err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	withWebhookPath(trigger)
+	h.JSON(w, http.StatusOK, trigger)
+}
+
+func (h *Handler) DeleteTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.triggerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.triggerService.Delete(r.Context(), id); err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	h.Message(w, http.StatusOK, "Trigger deleted successfully")
+}
+
+// ListTriggerFirings returns the latest firings of a trigger, newest first,
+// with the runs they started (limit, offset)
+func (h *Handler) ListTriggerFirings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.triggerID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	firings, err := h.triggerService.Firings(r.Context(), id, limit, offset)
+	if err != nil {
+		h.triggerError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, firings)
+}
+
+// TriggerWebhook fires a webhook trigger. It is outside authentication: the
+// request must carry X-Signature-Timestamp, the unix seconds it was sent
+// at, and X-Signature-256, sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+// with the trigger's key. X-Delivery-ID, when set, names the delivery so
+// that its retries fire once.
+func (h *Handler) TriggerWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.triggerID(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		h.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Webhook body exceeds %d bytes", maxWebhookBody))
+		return
+	}
+	firing, err := h.triggerService.Webhook(r.Context(), id, service.WebhookDelivery{
+		Body:       body,
+		Signature:  r.Header.Get("X-Signature-256"),
+		Timestamp:  r.Header.Get("X-Signature-Timestamp"),
+		DeliveryID: r.Header.Get("X-Delivery-ID"),
+		Source:     r.RemoteAddr,
+	})
+	switch {
+	case errors.Is(err, service.ErrTriggerSignature):
+		h.Error(w, http.StatusUnauthorized, "Invalid signature")
+	case errors.Is(err, service.ErrTriggerDuplicate):
+		// 재전송은 이미 받은 것으로 응답해 발신자가 다시 보내지 않게 한다
+		h.Message(w, http.StatusOK, err.Error())
+	case errors.Is(err, service.ErrTriggerBusy):
+		h.JSON(w, http.StatusTooManyRequests, firing)
+	case errors.Is(err, service.ErrTriggerDisabled):
+		h.Error(w, http.StatusConflict, err.Error())
+	case firing != nil:
+		// 실행을 시작하지 못한 발화도 기록과 함께 돌려준다
+		status := http.StatusAccepted
+		if err != nil {
+			status = http.StatusUnprocessableEntity
+		}
+		h.JSON(w, status, firing)
+	default:
+		h.triggerError(w, err)
+	}
+}
+
+func (h *Handler) triggerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid trigger ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// withWebhookPath sets where webhook triggers are called
+func withWebhookPath(triggers ...*models.FlowTrigger) {
+	for _, t := range triggers {
+		if t.Kind == models.TriggerKindWebhook {
+			t.WebhookPath = fmt.Sprintf("/api/hooks/%d", t.ID)
+		}
+	}
+}
+
+func (h *Handler) triggerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		h.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repository.ErrFlowTriggerNotFound):
+		h.Error(w, http.StatusNotFound, "Trigger not found")
+	case errors.Is(err, repository.ErrFlowNotFound):
+		h.Error(w, http.StatusNotFound, "Flow not found")
+	case errors.Is(err, service.ErrSecretsKeyMissing):
+		h.Error(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.Error(w, http.StatusBadRequest, err.Error())
+	}
+}
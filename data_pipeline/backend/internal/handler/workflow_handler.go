@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ExecuteWorkflow runs a workflow of sources, Python transforms, joins and
+// sinks. Each step receives the output of its inputs; independent branches
+// run in parallel and a failed step skips the steps that depend on it.
+//
+// The workflow runs in the background as a stored run: the response is the
+// pending run (202), followed with /api/workflow/runs/{id} and its events.
+// With ?wait=true the request waits and returns the step results.
+func (h *Handler) ExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var req models.WorkflowExecuteRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	plan, err := h.workflowService.Plan(r.Context(), req)
+	if err != nil {
+		if h.forbidden(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrFlowNotFound) {
+			h.Error(w, http.StatusNotFound, "Flow not found")
+			return
+		}
+		h.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	record := &models.WorkflowRun{FlowID: req.FlowID, Trigger: models.WorkflowTriggerManual, Request: body}
+	done, err := h.workflowService.Start(r.Context(), plan, record)
+	if err != nil {
+		if errors.Is(err, repository.ErrFlowNotFound) {
+			h.Error(w, http.StatusNotFound, "Flow not found")
+			return
+		}
+		h.Error(w, http.StatusInternalServerError, "Failed to start workflow run: "+err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("wait") != "true" {
+		h.JSON(w, http.StatusAccepted, record)
+		return
+	}
+	select {
+	case response := <-done:
+		if !response.Success {
+			h.JSON(w, http.StatusBadRequest, response)
+			return
+		}
+		h.JSON(w, http.StatusOK, response)
+	case <-r.Context().Done():
+		// 실행은 계속되고 결과는 실행 기록에 남는다
+	}
+}
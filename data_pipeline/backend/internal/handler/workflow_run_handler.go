@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	workflowRunKeepAlive = 15 * time.Second
+	// runs executing on another server are followed by polling
+	workflowRunPollInterval = 2 * time.Second
+)
+
+// ListWorkflowRuns returns workflow runs, newest first. Filters: flowId,
+// status, trigger, limit and offset. Non-admins see the runs of their
+// projects and their own.
+func (h *Handler) ListWorkflowRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := &models.WorkflowRunFilter{
+		Status:  q.Get("status"),
+		Trigger: q.Get("trigger"),
+	}
+	if v := q.Get("flowId"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.Error(w, http.StatusBadRequest, "Invalid flow ID")
+			return
+		}
+		filter.FlowID = &id
+	}
+
+	limit := 50
+	offset := 0
+
+	if l := q.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := q.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	runs, err := h.workflowRunService.List(r.Context(), filter, limit, offset)
+	if err != nil {
+		h.workflowRunError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, runs)
+}
+
+// GetWorkflowRun returns a run with its steps
+func (h *Handler) GetWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.workflowRunID(w, r)
+	if !ok {
+		return
+	}
+	run, err := h.workflowRunService.Get(r.Context(), id)
+	if err != nil {
+		h.workflowRunError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusOK, run)
+}
+
+// CancelWorkflowRun stops a pending or running run (editor on it). Steps
+// not yet started are skipped and the run ends as cancelled.
+func (h *Handler) CancelWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.workflowRunID(w, r)
+	if !ok {
+		return
+	}
+	run, err := h.workflowRunService.Cancel(r.Context(), id)
+	if err != nil {
+		h.workflowRunError(w, err)
+		return
+	}
+
+	h.JSON(w, http.StatusAccepted, run)
+}
+
+// StreamWorkflowRun follows a run as SSE: a "run" event with the run and its
+// steps, a "step" event as each step starts and finishes, and at the end
+// the final "run" and a "done" event.
+func (h *Handler) StreamWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := h.workflowRunID(w, r)
+	if !ok {
+		return
+	}
+
+	// 스냅샷 이전에 구독해야 그 사이의 이벤트를 놓치지 않는다
+	events, cancel, live := h.workflowRunService.Subscribe(id)
+	defer cancel()
+
+	run, err := h.workflowRunService.Get(r.Context(), id)
+	if err != nil {
+		h.workflowRunError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	h.sendSSE(w, "run", run)
+	if run.Finished() {
+		h.sendSSE(w, "done", map[string]string{"status": run.Status})
+		return
+	}
+
+	keepAlive := time.NewTicker(workflowRunKeepAlive)
+	defer keepAlive.Stop()
+	var poll <-chan time.Time
+	if !live {
+		ticker := time.NewTicker(workflowRunPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// 끝난 실행은 저장된 기록으로 마무리한다
+				if final, err := h.workflowRunService.Get(r.Context(), id); err == nil {
+					run = final
+				}
+				h.sendSSE(w, "run", run)
+				h.sendSSE(w, "done", map[string]string{"status": run.Status})
+				return
+			}
+			if event.Step != nil {
+				h.sendSSE(w, "step", event.Step)
+			} else if event.Run != nil {
+				h.sendSSE(w, "run", event.Run)
+			}
+		case <-poll:
+			current, err := h.workflowRunService.Get(r.Context(), id)
+			if err != nil {
+				h.sendSSE(w, "error", map[string]string{"error": err.Error()})
+				return
+			}
+			h.sendSSE(w, "run", current)
+			if current.Finished() {
+				h.sendSSE(w, "done", map[string]string{"status": current.Status})
+				return
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}
+}
+
+func (h *Handler) workflowRunID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.Error(w, http.StatusBadRequest, "Invalid run ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// workflowRunError writes the response for a failed run lookup
+func (h *Handler) workflowRunError(w http.ResponseWriter, err error) {
+	if err == repository.ErrWorkflowRunNotFound {
+		h.Error(w, http.StatusNotFound, "Workflow run not found")
+		return
+	}
+	if err == service.ErrWorkflowRunFinished {
+		h.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if h.forbidden(w, err) {
+		return
+	}
+	h.Error(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,99 @@
+// Package leader picks one backend replica to do work that must not run
+// twice, such as firing schedules. Leadership is a Postgres advisory lock,
+// which belongs to a database session: the leader keeps a dedicated
+// connection open, and if the replica or its connection dies the database
+// releases the lock and another replica takes over.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log"
+	"time"
+)
+
+// Elector campaigns for one named lock
+type Elector struct {
+	db       *sql.DB
+	name     string
+	key      int64
+	interval time.Duration
+}
+
+// New returns an elector for the lock called name. interval is how often a
+// follower retries and the leader checks its connection.
+func New(db *sql.DB, name string, interval time.Duration) *Elector {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Elector{db: db, name: name, key: int64(h.Sum64()), interval: interval}
+}
+
+// Run calls work whenever this replica becomes the leader. work's context
+// is cancelled when leadership is lost, after which Run campaigns again.
+// Run returns when ctx ends.
+func (e *Elector) Run(ctx context.Context, work func(ctx context.Context)) {
+	for {
+		e.lead(ctx, work)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// lead takes the lock if it is free and runs work while holding it
+func (e *Elector) lead(ctx context.Context, work func(ctx context.Context)) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		log.Printf("%s: leader election failed: %v", e.name, err)
+		return
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&acquired); err != nil {
+		log.Printf("%s: leader election failed: %v", e.name, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	log.Printf("%s: this replica is the leader", e.name)
+
+	workCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(workCtx)
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	held := true
+	for held {
+		select {
+		case <-done:
+			held = false
+		case <-ctx.Done():
+			held = false
+		case <-ticker.C:
+			// 세션이 끊기면 잠금도 풀린 것이다
+			if _, err := conn.ExecContext(ctx, `SELECT 1`); err != nil {
+				log.Printf("%s: lost leadership: %v", e.name, err)
+				held = false
+			}
+		}
+	}
+	cancel()
+	<-done
+
+	unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelUnlock()
+	conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock($1)`, e.key)
+	log.Printf("%s: stepped down", e.name)
+}
@@ -0,0 +1,424 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxAuditBody     = 64 * 1024 // request body kept as params
+	maxAuditResponse = 4 * 1024  // response prefix read for ids and errors
+)
+
+// AuditRecorder stores audit events; Start redacts the params
+type AuditRecorder interface {
+	Start(e *models.AuditEvent) error
+	Finish(e *models.AuditEvent) error
+}
+
+// auditRoute names the action of a route and where its target id is read:
+// route vars, then query, request body and response body, in that order.
+// Dotted keys look into nested objects.
+type auditRoute struct {
+	action string
+	ids    []string
+}
+
+// auditRoutes maps "METHOD path template" to an action. The target type is
+// the part of the action before the dot.
+var auditRoutes = map[string]auditRoute{
+	"POST /api/flows":                                         {"flow.create", []string{"f_id"}},
+	"POST /api/flows/import":                                  {"flow.import", []string{"flow.f_id"}},
+	"PUT /api/flows/{id}":                                     {"flow.update", []string{"id"}},
+	"DELETE /api/flows/{id}":                                  {"flow.delete", []string{"id"}},
+	"POST /api/flows/{id}/dlq/replay":                         {"flow.dlq_replay", []string{"id"}},
+	"POST /api/flows/{id}/versions/{version:[0-9]+}/rollback": {"flow.rollback", []string{"id"}},
+	"POST /api/objects":                                       {"object.create", []string{"o_id"}},
+	"PUT /api/objects/{id}":                                   {"object.update", []string{"id"}},
+	"DELETE /api/objects/{id}":                                {"object.delete", []string{"id"}},
+	"POST /api/projects":                                      {"project.create", []string{"p_id"}},
+	"PUT /api/projects/{id}":                                  {"project.update", []string{"id"}},
+	"DELETE /api/projects/{id}":                               {"project.delete", []string{"id"}},
+	"PUT /api/projects/{id}/members/{username}":               {"project.member_set", []string{"id"}},
+	"DELETE /api/projects/{id}/members/{username}":            {"project.member_remove", []string{"id"}},
+	"POST /api/connections":                                   {"connection.create", []string{"c_id"}},
+	"PUT /api/connections/{id}":                               {"connection.update", []string{"id"}},
+	"DELETE /api/connections/{id}":                            {"connection.delete", []string{"id"}},
+	"POST /api/k8s/deploy/stream":                             {"flow.deploy", []string{"flowId"}},
+	"DELETE /api/k8s/delete":                                  {"flow.undeploy", []string{"flowId"}},
+	"POST /api/k8s/test":                                      {"flow.unit_test", []string{"flowId"}},
+	"POST /api/workflow/execute":                              {"workflow.execute", nil},
+	"POST /api/schedules":                                     {"schedule.create", []string{"s_id"}},
+	"PUT /api/schedules/{id}":                                 {"schedule.update", []string{"id"}},
+	"DELETE /api/schedules/{id}":                              {"schedule.delete", []string{"id"}},
+	"POST /api/triggers":                                      {"trigger.create", []string{"t_id"}},
+	"PUT /api/triggers/{id}":                                  {"trigger.update", []string{"id"}},
+	"DELETE /api/triggers/{id}":                               {"trigger.delete", []string{"id"}},
+	"POST /api/data/save":                                     {"data.save", nil},
+	"POST /api/train/start":                                   {"training.start", []string{"run_id"}},
+	"POST /api/train/runs/{id}/cancel":                        {"training.cancel", []string{"id"}},
+	"POST /api/feedbacks":                                     {"feedback.create", []string{"feedback_id"}},
+	"POST /api/datasets/build":                                {"dataset.build", []string{"dataset_id"}},
+	"POST /api/datasets/{id}/activate":                        {"dataset.activate", []string{"id"}},
+	"POST /api/models/activate":                               {"model.activate", []string{"model_id"}},
+	"POST /api/evaluations/run":                               {"evaluation.run", []string{"eval_id"}},
+}
+
+// ParseTrustedProxies parses IPs and CIDRs of the proxies in front of the
+// server
+func ParseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, v := range list {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", v)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// AuditMiddleware records every mutating request (POST/PUT/PATCH/DELETE) as
+// an audit event, except the skipped paths (exact match or prefix ending in
+// "/"). It must run after AuthMiddleware so the actor is known. Failing to
+// store an event is logged and never fails the request. X-Forwarded-For is
+// only believed from the trusted proxies.
+func AuditMiddleware(recorder AuditRecorder, trustedProxies []*net.IPNet, skip ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if recorder == nil || !isMutating(r.Method) || isPublicPath(r.URL.Path, skip) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			template := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					template = t
+				}
+			}
+			spec, ok := auditRoutes[r.Method+" "+template]
+			if !ok {
+				spec = auditRoute{action: r.Method + " " + template}
+			}
+			// 배포 스트림의 op=kick은 재배포가 아니라 검증 실행
+			if spec.action == "flow.deploy" && r.URL.Query().Get("op") == "kick" {
+				spec.action = "flow.kick"
+			}
+
+			body, rawBody := readAuditBody(r)
+			vars := mux.Vars(r)
+			query := r.URL.Query()
+			query.Del("access_token")
+
+			e := &models.AuditEvent{
+				Action:     spec.action,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Params:     auditParams(query, vars, body, rawBody),
+				RemoteAddr: remoteAddr(r, trustedProxies),
+			}
+			if user := auth.UserFromContext(r.Context()); user != nil {
+				e.Actor = user.Username
+				if user.ID != 0 {
+					e.ActorID = &user.ID
+				}
+			}
+			if ok {
+				targetType := spec.action[:strings.Index(spec.action, ".")]
+				e.TargetType = &targetType
+			}
+			e.TargetID = lookupID(spec.ids, vars, query, body)
+
+			if err := recorder.Start(e); err != nil {
+				log.Printf("audit: failed to record %s: %v", e.Action, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := &auditWriter{ResponseWriter: w}
+			defer func() {
+				status, errMsg, sniffed := rec.result()
+				if p := recover(); p != nil {
+					status, errMsg = http.StatusInternalServerError, fmt.Sprintf("panic: %v", p)
+					defer panic(p)
+				}
+				if e.TargetID == nil {
+					e.TargetID = lookupID(spec.ids, nil, nil, sniffed)
+				}
+
+				duration := int(time.Since(start).Milliseconds())
+				e.Status = &status
+				e.DurationMs = &duration
+				switch {
+				case status == http.StatusUnauthorized || status == http.StatusForbidden:
+					e.Outcome = models.AuditOutcomeDenied
+				case status >= 400 || errMsg != "":
+					e.Outcome = models.AuditOutcomeFailure
+				default:
+					e.Outcome = models.AuditOutcomeSuccess
+				}
+				if errMsg != "" {
+					if len(errMsg) > 1000 {
+						errMsg = errMsg[:1000]
+					}
+					e.Error = &errMsg
+				}
+				if err := recorder.Finish(e); err != nil {
+					log.Printf("audit: failed to finish %s (%d): %v", e.Action, e.ID, err)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// readAuditBody reads up to maxAuditBody of a JSON body and puts it back for
+// the handler. Bodies that are not JSON objects or are too long are not kept.
+func readAuditBody(r *http.Request) (map[string]interface{}, string) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, ""
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil {
+		return nil, "[unreadable]"
+	}
+	if len(buf) > maxAuditBody {
+		return nil, fmt.Sprintf("[omitted: larger than %d bytes]", maxAuditBody)
+	}
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return nil, ""
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(buf, &body); err != nil {
+		return nil, fmt.Sprintf("[omitted: %d bytes of %s]", len(buf), r.Header.Get("Content-Type"))
+	}
+	return body, ""
+}
+
+func auditParams(query map[string][]string, vars map[string]string, body map[string]interface{}, rawBody string) json.RawMessage {
+	params := make(map[string]interface{})
+	if len(query) > 0 {
+		q := make(map[string]interface{}, len(query))
+		for k, v := range query {
+			if len(v) == 1 {
+				q[k] = v[0]
+			} else {
+				q[k] = v
+			}
+		}
+		params["query"] = q
+	}
+	if len(vars) > 0 {
+		params["vars"] = vars
+	}
+	if body != nil {
+		params["body"] = body
+	} else if rawBody != "" {
+		params["body"] = rawBody
+	}
+	b, _ := json.Marshal(params)
+	return b
+}
+
+// lookupID returns the first of keys found in vars, query or body
+func lookupID(keys []string, vars map[string]string, query map[string][]string, body map[string]interface{}) *string {
+	for _, key := range keys {
+		if v := vars[key]; v != "" {
+			return &v
+		}
+		if v := query[key]; len(v) > 0 && v[0] != "" {
+			return &v[0]
+		}
+		var cur interface{} = body
+		for _, part := range strings.Split(key, ".") {
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				cur = nil
+				break
+			}
+			cur = m[part]
+		}
+		switch v := cur.(type) {
+		case string:
+			if v != "" {
+				return &v
+			}
+		case float64:
+			s := fmt.Sprintf("%.0f", v)
+			return &s
+		}
+	}
+	return nil
+}
+
+// remoteAddr returns the client address. When the connection comes from a
+// trusted proxy, X-Forwarded-For is read from the right and the first hop
+// that is not a trusted proxy is the client; the header of anyone else is
+// ignored since it is up to the client.
+func remoteAddr(r *http.Request, trustedProxies []*net.IPNet) *string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if isTrustedProxy(addr, trustedProxies) {
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			addr = hop
+			if !isTrustedProxy(hop, trustedProxies) {
+				break
+			}
+		}
+	}
+	if addr == "" {
+		return nil
+	}
+	return &addr
+}
+
+func isTrustedProxy(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditWriter remembers the status and the start of the response. SSE
+// responses fail when they send an "error" event. Streaming handlers write
+// from their own goroutines, hence the lock.
+type auditWriter struct {
+	http.ResponseWriter
+	mu       sync.Mutex
+	status   int
+	head     []byte
+	inError  bool // inside an "error" event whose data has not been seen yet
+	sseError string
+}
+
+func (w *auditWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = status
+	}
+	w.mu.Unlock()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if len(w.head) < maxAuditResponse {
+		n := maxAuditResponse - len(w.head)
+		if n > len(b) {
+			n = len(b)
+		}
+		w.head = append(w.head, b[:n]...)
+	}
+	if w.sseError == "" {
+		if bytes.HasPrefix(b, []byte("event: ")) {
+			w.inError = bytes.HasPrefix(b, []byte("event: error\n"))
+		}
+		if w.inError && bytes.Contains(b, []byte("data: ")) {
+			w.sseError = sseMessage(b)
+		}
+	}
+	w.mu.Unlock()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// result returns the status, the error message if any and the decoded JSON
+// response when it fit in the sniffed prefix
+func (w *auditWriter) result() (int, string, map[string]interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if w.sseError != "" {
+		return status, w.sseError, nil
+	}
+	var body map[string]interface{}
+	if json.Unmarshal(w.head, &body) != nil {
+		return status, "", nil
+	}
+	if msg, ok := body["error"].(string); ok && status >= 400 {
+		return status, msg, body
+	}
+	return status, "", body
+}
+
+// sseMessage extracts the message of a ProgressEventDTO error event
+func sseMessage(event []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(event))
+	scanner.Buffer(nil, len(event)+1)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var payload struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal([]byte(data), &payload) == nil && payload.Message != "" {
+				return payload.Message
+			}
+		}
+	}
+	return "stream reported an error"
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteAddr(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		fwd     []string
+		trusted bool
+		want    string
+	}{
+		{name: "direct", remote: "203.0.113.7:5123", want: "203.0.113.7"},
+		{name: "spoofed header from client", remote: "203.0.113.7:5123", fwd: []string{"1.2.3.4"}, trusted: true, want: "203.0.113.7"},
+		{name: "no proxies configured", remote: "10.1.2.3:80", fwd: []string{"1.2.3.4"}, want: "10.1.2.3"},
+		{name: "trusted proxy", remote: "10.1.2.3:80", fwd: []string{"198.51.100.9"}, trusted: true, want: "198.51.100.9"},
+		{name: "trusted single ip", remote: "192.168.1.5:80", fwd: []string{"198.51.100.9"}, trusted: true, want: "198.51.100.9"},
+		{
+			// 클라이언트가 앞에 붙인 값은 무시하고 마지막 신뢰 프록시가 본 주소를 쓴다
+			name: "client prepended hops", remote: "10.1.2.3:80", fwd: []string{"1.2.3.4, 198.51.100.9, 10.4.4.4"},
+			trusted: true, want: "198.51.100.9",
+		},
+		{name: "several headers", remote: "10.1.2.3:80", fwd: []string{"1.2.3.4", "198.51.100.9"}, trusted: true, want: "198.51.100.9"},
+		{name: "only proxies", remote: "10.1.2.3:80", fwd: []string{"10.9.9.9"}, trusted: true, want: "10.9.9.9"},
+		{name: "trusted proxy without header", remote: "10.1.2.3:80", trusted: true, want: "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/flows", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.fwd {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			proxies := trusted
+			if !tt.trusted {
+				proxies = nil
+			}
+			got := remoteAddr(r, proxies)
+			if got == nil || *got != tt.want {
+				t.Fatalf("remoteAddr = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("invalid CIDR accepted")
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.local"}); err == nil {
+		t.Fatal("host name accepted")
+	}
+	nets, err := ParseTrustedProxies([]string{"::1", "fd00::/8"})
+	if err != nil || len(nets) != 2 {
+		t.Fatalf("nets = %v, err = %v", nets, err)
+	}
+}
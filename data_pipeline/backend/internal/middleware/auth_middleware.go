@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// UserResolver maps an authenticated caller to a stored user
+type UserResolver interface {
+	Resolve(claims auth.Claims) (*models.User, error)
+	ResolveDev(name string) (*models.User, error)
+}
+
+// AuthMiddleware requires a bearer token on every request except the public
+// paths (exact match or prefix ending in "/"). EventSource cannot set headers,
+// so ?access_token= is accepted as well. With a nil verifier (AUTH_MODE=none)
+// every request runs as devUser, an admin; without a database (nil users) that
+// user is not stored.
+func AuthMiddleware(verifier auth.Verifier, users UserResolver, devUser string, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || isPublicPath(r.URL.Path, public) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var user *models.User
+			var err error
+			if verifier == nil && users == nil {
+				user = &models.User{Username: devUser, Admin: true}
+			} else if verifier == nil {
+				user, err = users.ResolveDev(devUser)
+				if err != nil {
+					log.Printf("auth: failed to resolve dev user: %v", err)
+					authError(w, http.StatusInternalServerError, "Internal server error")
+					return
+				}
+			} else {
+				token := bearerToken(r)
+				if token == "" {
+					w.Header().Set("WWW-Authenticate", `Bearer`)
+					authError(w, http.StatusUnauthorized, "Authentication required")
+					return
+				}
+				if users == nil {
+					authError(w, http.StatusServiceUnavailable, "Authentication requires a database")
+					return
+				}
+				claims, err := verifier.Verify(r.Context(), token)
+				if err != nil {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					authError(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
+					return
+				}
+				user, err = users.Resolve(claims)
+				if err != nil {
+					authError(w, http.StatusForbidden, err.Error())
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+func isPublicPath(path string, public []string) bool {
+	for _, p := range public {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func authError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomePending = "pending" // request still running (or the server stopped during it)
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeDenied  = "denied" // 401/403
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent records one mutating API request
+type AuditEvent struct {
+	ID         int64           `json:"a_id" db:"a_id"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+	ActorID    *int64          `json:"actor_id,omitempty" db:"actor_id"`
+	Actor      string          `json:"actor" db:"actor"`
+	Action     string          `json:"action" db:"action"`
+	TargetType *string         `json:"target_type,omitempty" db:"target_type"`
+	TargetID   *string         `json:"target_id,omitempty" db:"target_id"`
+	Method     string          `json:"method" db:"method"`
+	Path       string          `json:"path" db:"path"`
+	Params     json.RawMessage `json:"params" db:"params"`
+	Outcome    string          `json:"outcome" db:"outcome"`
+	Status     *int            `json:"status,omitempty" db:"status"`
+	Error      *string         `json:"error,omitempty" db:"error"`
+	DurationMs *int            `json:"duration_ms,omitempty" db:"duration_ms"`
+	RemoteAddr *string         `json:"remote_addr,omitempty" db:"remote_addr"`
+}
+
+// AuditFilter selects audit events; empty fields match everything. Action
+// ending in "." matches a prefix (e.g. "flow.").
+type AuditFilter struct {
+	ActorID    *int64
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	Since      *time.Time
+	Until      *time.Time
+}
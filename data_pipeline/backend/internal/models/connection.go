@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Connection is a named set of secret values owned by a user
+type Connection struct {
+	ID          int64           `json:"c_id" db:"c_id"`
+	Owner       string          `json:"owner" db:"owner"`
+	Name        string          `json:"name" db:"name"`
+	Type        string          `json:"type" db:"type"`
+	Description *string         `json:"description,omitempty" db:"description"`
+	Keys        json.RawMessage `json:"keys" db:"keys"`
+	Secret      []byte          `json:"-" db:"secret"` // encrypted values
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ConnectionRequestDTO creates or updates a connection. On update Values
+// replaces every stored value; omit it to keep them.
+type ConnectionRequestDTO struct {
+	User        string            `json:"user"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Description *string           `json:"description,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+}
+
+// ConnectionResponseDTO never carries the secret values
+type ConnectionResponseDTO struct {
+	ID          int64     `json:"c_id"`
+	Owner       string    `json:"owner"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Description *string   `json:"description,omitempty"`
+	Keys        []string  `json:"keys"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponseDTO converts Connection entity to ConnectionResponseDTO
+func (c *Connection) ToResponseDTO() *ConnectionResponseDTO {
+	dto := &ConnectionResponseDTO{
+		ID:          c.ID,
+		Owner:       c.Owner,
+		Name:        c.Name,
+		Type:        c.Type,
+		Description: c.Description,
+		Keys:        []string{},
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+	if len(c.Keys) > 0 {
+		json.Unmarshal(c.Keys, &dto.Keys)
+	}
+	return dto
+}
+
+// Step connection mount modes
+const (
+	ConnectionMountEnv  = "env"
+	ConnectionMountFile = "file"
+)
+
+// StepConnectionRef is an entry of params.connections. A plain string is
+// shorthand for {"name": ...} with env mount.
+type StepConnectionRef struct {
+	Name   string `json:"name"`
+	Mount  string `json:"mount,omitempty"`  // env (default) | file
+	Prefix string `json:"prefix,omitempty"` // env var prefix; default NAME_
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetterCE holds the CloudEvent attributes of the event a step failed on
+type DeadLetterCE struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Source   string `json:"source,omitempty"`
+	TraceID  string `json:"traceid,omitempty"`
+	Hops     int    `json:"hops"`
+	Producer string `json:"producer,omitempty"`
+}
+
+// DeadLetterDTO is a failed event read from a flow's dead-letter queue.
+// Step is the runner APP_ID of the step that raised.
+type DeadLetterDTO struct {
+	ID        string          `json:"id"`
+	Step      string          `json:"step"`
+	Error     string          `json:"error"`
+	Traceback string          `json:"traceback,omitempty"`
+	Event     json.RawMessage `json:"event"`
+	CE        DeadLetterCE    `json:"ce"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// DeadLetterPayload is the body a runner publishes to the dead-letter topic
+type DeadLetterPayload struct {
+	Step      string          `json:"step"`
+	Error     string          `json:"error"`
+	Traceback string          `json:"traceback"`
+	Event     json.RawMessage `json:"event"`
+	CE        DeadLetterCE    `json:"ce"`
+	FailedAt  float64         `json:"failed_at"` // unix seconds
+}
+
+// DLQReplayRequestDTO selects dead letters to replay by id, or all of them
+type DLQReplayRequestDTO struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+// DLQReplayResponseDTO lists the dead letters sent back into the flow
+type DLQReplayResponseDTO struct {
+	Replayed int      `json:"replayed"`
+	IDs      []string `json:"ids"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Deployment status values
+const (
+	DeploymentStatusRunning = "running"
+	DeploymentStatusOK      = "ok"
+	DeploymentStatusNG      = "ng"
+)
+
+// Reconciled deployment states
+const (
+	DeploymentStateNotDeployed = "not_deployed"
+	DeploymentStateDeploying   = "deploying"
+	DeploymentStateDeployed    = "deployed"
+	DeploymentStateDrifted     = "drifted"
+	DeploymentStateBroken      = "broken"
+)
+
+// Deployment represents a recorded deploy of a flow
+type Deployment struct {
+	ID           int64           `json:"d_id" db:"d_id"`
+	FlowID       int64           `json:"f_id" db:"flow"`
+	Version      *int            `json:"version,omitempty" db:"version"`
+	Runtime      string          `json:"runtime" db:"runtime"`
+	Namespace    string          `json:"namespace" db:"namespace"`
+	Topic        string          `json:"topic" db:"topic"`
+	DeployedBy   string          `json:"deployed_by" db:"deployed_by"`
+	Steps        json.RawMessage `json:"steps" db:"steps"`
+	Phases       json.RawMessage `json:"phases" db:"phases"`
+	Status       string          `json:"status" db:"status"`
+	VerifyResult *string         `json:"verify_result,omitempty" db:"verify_result"`
+	CreatedBy    *int64          `json:"created_by,omitempty" db:"created_by"`
+	StartedAt    time.Time       `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// DeploymentStep records what was applied for a single step
+type DeploymentStep struct {
+	Name          string `json:"name"`
+	ObjectID      int64  `json:"object_id"`
+	KSVC          string `json:"ksvc"`
+	KafkaSource   string `json:"kafka_source"`
+	ConsumerGroup string `json:"consumer_group"`
+	CodeHash      string `json:"code_hash"`
+	Route         string `json:"route"`
+	Image         string `json:"image,omitempty"`
+	ImageKey      string `json:"image_key,omitempty"` // dependency set of a built image
+	ImageDigest   string `json:"image_digest,omitempty"`
+}
+
+// DeploymentPhase records the outcome of a deploy phase
+type DeploymentPhase struct {
+	Phase   string    `json:"phase"`
+	Message string    `json:"message"`
+	OK      bool      `json:"ok"`
+	At      time.Time `json:"at"`
+}
+
+// DeploymentResponseDTO represents a deployment with decoded steps and phases
+type DeploymentResponseDTO struct {
+	ID           int64             `json:"d_id"`
+	FlowID       int64             `json:"f_id"`
+	Version      *int              `json:"version,omitempty"`
+	Runtime      string            `json:"runtime"`
+	Namespace    string            `json:"namespace"`
+	Topic        string            `json:"topic"`
+	DeployedBy   string            `json:"deployed_by"`
+	Steps        []DeploymentStep  `json:"steps"`
+	Phases       []DeploymentPhase `json:"phases"`
+	Status       string            `json:"status"`
+	VerifyResult *string           `json:"verify_result,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+}
+
+// ToResponseDTO decodes the stored steps and phases
+func (d *Deployment) ToResponseDTO() *DeploymentResponseDTO {
+	dto := &DeploymentResponseDTO{
+		ID:           d.ID,
+		FlowID:       d.FlowID,
+		Version:      d.Version,
+		Runtime:      d.Runtime,
+		Namespace:    d.Namespace,
+		Topic:        d.Topic,
+		DeployedBy:   d.DeployedBy,
+		Steps:        []DeploymentStep{},
+		Phases:       []DeploymentPhase{},
+		Status:       d.Status,
+		VerifyResult: d.VerifyResult,
+		StartedAt:    d.StartedAt,
+		FinishedAt:   d.FinishedAt,
+	}
+	if len(d.Steps) > 0 {
+		json.Unmarshal(d.Steps, &dto.Steps)
+	}
+	if len(d.Phases) > 0 {
+		json.Unmarshal(d.Phases, &dto.Phases)
+	}
+	return dto
+}
+
+// ObservedStepDTO is the live state of a step as reported by the runtime
+type ObservedStepDTO struct {
+	Name        string `json:"name"` // ksvc name
+	Ready       bool   `json:"ready"`
+	SourceReady bool   `json:"sourceReady"`
+	Revision    string `json:"revision,omitempty"`
+	CodeHash    string `json:"codeHash,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// DeploymentStepStateDTO compares a recorded step with its live state
+type DeploymentStepStateDTO struct {
+	Name        string `json:"name"`
+	KSVC        string `json:"ksvc"`
+	Found       bool   `json:"found"`
+	Ready       bool   `json:"ready"`
+	SourceReady bool   `json:"sourceReady"`
+	Revision    string `json:"revision,omitempty"`
+	Drifted     bool   `json:"drifted"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// DeploymentStatusDTO is the last deployment reconciled against live state
+type DeploymentStatusDTO struct {
+	FlowID     int64                    `json:"f_id"`
+	State      string                   `json:"state"` // not_deployed, deploying, deployed, drifted, broken
+	Reasons    []string                 `json:"reasons,omitempty"`
+	Deployment *DeploymentResponseDTO   `json:"deployment,omitempty"`
+	Steps      []DeploymentStepStateDTO `json:"steps"`
+	CheckedAt  time.Time                `json:"checked_at"`
+}
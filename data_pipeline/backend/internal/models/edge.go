@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Edge represents a directed connection between two objects (nodes) of a flow
+type Edge struct {
+	ID        int64     `json:"e_id" db:"e_id"`
+	FlowID    int64     `json:"f_id" db:"flow"`
+	Source    int64     `json:"source" db:"source"`
+	Target    int64     `json:"target" db:"target"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// EdgeDTO represents an edge in API payloads
+type EdgeDTO struct {
+	Source int64 `json:"source"`
+	Target int64 `json:"target"`
+}
+
+// FlowGraphResponseDTO represents the DAG view of a flow
+type FlowGraphResponseDTO struct {
+	FlowID int64                `json:"f_id"`
+	Nodes  []*ObjectResponseDTO `json:"nodes"`
+	Edges  []EdgeDTO            `json:"edges"`
+	Order  []int64              `json:"order"`
+	Roots  []int64              `json:"roots"`
+	Leaves []int64              `json:"leaves"`
+}
+
+// ToDTO converts Edge entity to EdgeDTO
+func (e *Edge) ToDTO() EdgeDTO {
+	return EdgeDTO{Source: e.Source, Target: e.Target}
+}
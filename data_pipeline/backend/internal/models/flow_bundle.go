@@ -0,0 +1,36 @@
+package models
+
+// FlowBundleAPIVersion identifies the bundle format
+const FlowBundleAPIVersion = "data-pipeline/v1"
+
+// FlowBundle is a portable export of a flow with its objects and edges
+type FlowBundle struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Flow       BundleFlow     `json:"flow"`
+	Objects    []BundleObject `json:"objects"`
+	Edges      []EdgeDTO      `json:"edges,omitempty"`
+}
+
+// BundleFlow holds the flow-level fields of a bundle
+type BundleFlow struct {
+	Name    string `json:"name"`
+	RunType string `json:"run_type"`
+}
+
+// BundleObject is an object inside a bundle; o_id/target are bundle-local references
+type BundleObject struct {
+	ID     int64                  `json:"o_id"`
+	Type   string                 `json:"type"`
+	Label  string                 `json:"label"`
+	X      *int64                 `json:"x,omitempty"`
+	Y      *int64                 `json:"y,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Target *int64                 `json:"target,omitempty"`
+}
+
+// FlowImportResponseDTO is returned after a bundle is imported
+type FlowImportResponseDTO struct {
+	Flow  *FlowResponseDTO `json:"flow"`
+	IDMap map[int64]int64  `json:"id_map"`
+}
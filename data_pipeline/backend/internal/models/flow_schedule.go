@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Schedule overlap policies: what a firing does while the previous run of
+// the schedule is still pending or running
+const (
+	ScheduleOverlapSkip   = "skip"   // drop the firing
+	ScheduleOverlapQueue  = "queue"  // run it once the previous run ends
+	ScheduleOverlapCancel = "cancel" // cancel the previous run and start
+)
+
+// Schedule catch-up modes: what happens to the firings missed while the
+// scheduler was down or the schedule was waiting
+const (
+	ScheduleCatchUpNone   = "none"   // drop them
+	ScheduleCatchUpLatest = "latest" // run once for all of them
+	ScheduleCatchUpAll    = "all"    // run each of them, oldest first
+)
+
+// FlowSchedule runs a flow on a cron schedule
+type FlowSchedule struct {
+	ID            int64      `json:"s_id" db:"s_id"`
+	FlowID        int64      `json:"f_id" db:"flow_id"`
+	Name          string     `json:"name" db:"name"`
+	Cron          string     `json:"cron" db:"cron"`
+	Timezone      string     `json:"timezone" db:"timezone"`
+	Enabled       bool       `json:"enabled" db:"enabled"`
+	OverlapPolicy string     `json:"overlap_policy" db:"overlap_policy"`
+	CatchUp       string     `json:"catch_up" db:"catch_up"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty" db:"next_run_at"`
+	QueuedAt      *time.Time `json:"queued_at,omitempty" db:"queued_at"`
+	LastRunID     *int64     `json:"last_run_id,omitempty" db:"last_run_id"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
+	RunAs         *int64     `json:"-" db:"run_as"` // the runs execute as this user
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// FlowScheduleRequestDTO creates or updates a schedule. On update omitted
+// fields keep their value.
+type FlowScheduleRequestDTO struct {
+	FlowID        *int64  `json:"f_id,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	Cron          *string `json:"cron,omitempty"`
+	Timezone      *string `json:"timezone,omitempty"`
+	Enabled       *bool   `json:"enabled,omitempty"`
+	OverlapPolicy *string `json:"overlap_policy,omitempty"`
+	CatchUp       *string `json:"catch_up,omitempty"`
+}
+
+// WorkflowLaunch asks for a run of a stored flow by something other than a
+// user request. Event, when set, is the evt of the flow's first Python step.
+type WorkflowLaunch struct {
+	FlowID     int64
+	Trigger    string
+	ScheduleID *int64
+	TriggerID  *int64
+	Event      map[string]interface{}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Flow trigger kinds; a run started by a trigger has its kind as trigger
+const (
+	TriggerKindFile    = "file"    // a new file under a watched directory or S3 prefix
+	TriggerKindWebhook = "webhook" // an HMAC-signed POST to /api/hooks/{id}
+	TriggerKindKafka   = "kafka"   // a message on a Kafka topic
+)
+
+// Trigger firing statuses
+const (
+	TriggerFiringStarted = "started" // a run was started
+	TriggerFiringSkipped = "skipped" // max_active runs were still active
+	TriggerFiringFailed  = "failed"  // the run could not be started
+)
+
+// FlowTrigger runs a flow when an event arrives. The event becomes the evt
+// of the flow's first Python step.
+type FlowTrigger struct {
+	ID          int64           `json:"t_id" db:"t_id"`
+	FlowID      int64           `json:"f_id" db:"flow_id"`
+	Name        string          `json:"name" db:"name"`
+	Kind        string          `json:"kind" db:"kind"`
+	Enabled     bool            `json:"enabled" db:"enabled"`
+	Config      json.RawMessage `json:"config" db:"config"`
+	MaxActive   int             `json:"max_active" db:"max_active"`
+	Secret      []byte          `json:"-" db:"secret"` // encrypted webhook key
+	State       json.RawMessage `json:"-" db:"state"`
+	LastFiredAt *time.Time      `json:"last_fired_at,omitempty" db:"last_fired_at"`
+	LastError   *string         `json:"last_error,omitempty" db:"last_error"`
+	RunAs       *int64          `json:"-" db:"run_as"` // the runs execute as this user
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+
+	WebhookPath   string `json:"webhook_path,omitempty" db:"-"`
+	WebhookSecret string `json:"webhook_secret,omitempty" db:"-"` // only right after it is generated
+}
+
+// FlowTriggerRequestDTO creates or updates a trigger. On update omitted
+// fields keep their value and the kind cannot change.
+type FlowTriggerRequestDTO struct {
+	FlowID       *int64          `json:"f_id,omitempty"`
+	Name         *string         `json:"name,omitempty"`
+	Kind         *string         `json:"kind,omitempty"`
+	Enabled      *bool           `json:"enabled,omitempty"`
+	Config       json.RawMessage `json:"config,omitempty"`
+	MaxActive    *int            `json:"max_active,omitempty"`
+	RotateSecret bool            `json:"rotate_secret,omitempty"` // webhook: generate a new key
+}
+
+// TriggerFiring records one event a trigger received and the run it started
+type TriggerFiring struct {
+	ID        int64           `json:"fi_id" db:"fi_id"`
+	TriggerID int64           `json:"t_id" db:"trigger_id"`
+	RunID     *int64          `json:"run_id,omitempty" db:"run_id"`
+	Status    string          `json:"status" db:"status"`
+	Source    string          `json:"source" db:"source"`
+	Payload   json.RawMessage `json:"payload,omitempty" db:"payload"`
+	Error     *string         `json:"error,omitempty" db:"error"`
+	FiredAt   time.Time       `json:"fired_at" db:"fired_at"`
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Flow version reasons
+const (
+	FlowVersionReasonSave     = "save"
+	FlowVersionReasonDeploy   = "deploy"
+	FlowVersionReasonRollback = "rollback"
+)
+
+// FlowVersion represents an immutable snapshot of a flow
+type FlowVersion struct {
+	ID            int64           `json:"v_id" db:"v_id"`
+	FlowID        int64           `json:"f_id" db:"flow"`
+	Version       int             `json:"version" db:"version"`
+	Reason        string          `json:"reason" db:"reason"`
+	Snapshot      json.RawMessage `json:"snapshot" db:"snapshot"`
+	SnapshotHash  string          `json:"snapshot_hash" db:"snapshot_hash"`
+	DeployOptions json.RawMessage `json:"deploy_options,omitempty" db:"deploy_options"`
+	DeployStatus  *string         `json:"deploy_status,omitempty" db:"deploy_status"`
+	DeployMessage *string         `json:"deploy_message,omitempty" db:"deploy_message"`
+	ParentVersion *int            `json:"parent_version,omitempty" db:"parent_version"`
+	CreatedBy     *int64          `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// FlowSnapshot is the content stored in flow_versions.snapshot
+type FlowSnapshot struct {
+	Name    string         `json:"name"`
+	RunType string         `json:"run_type"`
+	Nodes   []SnapshotNode `json:"nodes"`
+	Edges   []EdgeDTO      `json:"edges"`
+}
+
+// SnapshotNode is a single object captured in a flow snapshot
+type SnapshotNode struct {
+	ObjectID int64                  `json:"o_id"`
+	Type     string                 `json:"type"`
+	Label    string                 `json:"label"`
+	X        *int64                 `json:"x,omitempty"`
+	Y        *int64                 `json:"y,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	CodeHash string                 `json:"code_hash,omitempty"`
+}
+
+// DeployOptions records the request options used for a deploy
+type DeployOptions struct {
+	User                     string  `json:"user"`
+	Steps                    []int64 `json:"steps"`
+	CreateNamespaceIfMissing bool    `json:"createNamespaceIfMissing"`
+	VerifyTimeoutSeconds     int     `json:"verifyTimeoutSeconds"`
+}
+
+// FlowVersionResponseDTO represents a version without its snapshot body
+type FlowVersionResponseDTO struct {
+	ID            int64     `json:"v_id"`
+	FlowID        int64     `json:"f_id"`
+	Version       int       `json:"version"`
+	Reason        string    `json:"reason"`
+	SnapshotHash  string    `json:"snapshot_hash"`
+	NodeCount     int       `json:"node_count"`
+	DeployStatus  *string   `json:"deploy_status,omitempty"`
+	ParentVersion *int      `json:"parent_version,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FlowVersionDetailDTO represents a version together with its decoded snapshot
+type FlowVersionDetailDTO struct {
+	FlowVersionResponseDTO
+	Snapshot      *FlowSnapshot  `json:"snapshot"`
+	DeployOptions *DeployOptions `json:"deploy_options,omitempty"`
+	DeployMessage *string        `json:"deploy_message,omitempty"`
+}
+
+// NodeDiffDTO describes how a single node differs between two versions
+type NodeDiffDTO struct {
+	ObjectID int64    `json:"o_id"`
+	Label    string   `json:"label"`
+	Change   string   `json:"change"` // added, removed, modified
+	Fields   []string `json:"fields,omitempty"`
+}
+
+// FlowVersionDiffDTO is the node-by-node diff of two versions
+type FlowVersionDiffDTO struct {
+	FlowID       int64         `json:"f_id"`
+	From         int           `json:"from"`
+	To           int           `json:"to"`
+	FlowFields   []string      `json:"flow_fields,omitempty"`
+	Nodes        []NodeDiffDTO `json:"nodes"`
+	EdgesAdded   []EdgeDTO     `json:"edges_added"`
+	EdgesRemoved []EdgeDTO     `json:"edges_removed"`
+}
+
+// FlowRollbackResponseDTO is returned after restoring an older version
+type FlowRollbackResponseDTO struct {
+	RestoredFrom int                     `json:"restored_from"`
+	Version      *FlowVersionResponseDTO `json:"version"`
+	IDMap        map[int64]int64         `json:"id_map,omitempty"`
+	Steps        []int64                 `json:"steps"`
+	User         string                  `json:"user,omitempty"`
+}
+
+// ToResponseDTO converts FlowVersion entity to FlowVersionResponseDTO
+func (v *FlowVersion) ToResponseDTO() *FlowVersionResponseDTO {
+	dto := &FlowVersionResponseDTO{
+		ID:            v.ID,
+		FlowID:        v.FlowID,
+		Version:       v.Version,
+		Reason:        v.Reason,
+		SnapshotHash:  v.SnapshotHash,
+		DeployStatus:  v.DeployStatus,
+		ParentVersion: v.ParentVersion,
+		CreatedAt:     v.CreatedAt,
+	}
+
+	var snap struct {
+		Nodes []json.RawMessage `json:"nodes"`
+	}
+	if err := json.Unmarshal(v.Snapshot, &snap); err == nil {
+		dto.NodeCount = len(snap.Nodes)
+	}
+
+	return dto
+}
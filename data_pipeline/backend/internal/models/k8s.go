@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // K8sRequestDTO represents the request payload for K8s operations
 type K8sRequestDTO struct {
 	User      string   `json:"user"`
@@ -7,6 +9,8 @@ type K8sRequestDTO struct {
 	Steps     []int64  `json:"steps"`
 	Test      *int64   `json:"test,omitempty"`
 	TestInput *string  `json:"testInput,omitempty"`
+	// Version restores and redeploys a stored flow version
+	Version   *int     `json:"version,omitempty"`
 }
 
 // ProgressEventDTO represents SSE progress event payload
@@ -16,3 +20,62 @@ type ProgressEventDTO struct {
 	OK      bool                   `json:"ok"`
 	Data    map[string]interface{} `json:"data,omitempty"`
 }
+
+// K8sRenderResponseDTO represents the result of rendering a flow with ?dryRun=server
+type K8sRenderResponseDTO struct {
+	Manifest string               `json:"manifest"`
+	DryRun   string               `json:"dryRun"`
+	OK       bool                 `json:"ok"`
+	Results  []K8sDryRunResultDTO `json:"results"`
+}
+
+// K8sDryRunResultDTO is the server-side dry-run outcome of a single manifest
+type K8sDryRunResultDTO struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Operation  string `json:"operation"` // create, update
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+}
+
+// FlowRuntimeStatusDTO represents the runtime state of a deployed flow
+type FlowRuntimeStatusDTO struct {
+	Runtime   string          `json:"runtime"`
+	FlowID    string          `json:"flowId"`
+	Namespace string          `json:"namespace"`
+	Ready     bool            `json:"ready"`
+	Steps     []StepStatusDTO `json:"steps"`
+}
+
+// StepStatusDTO represents the runtime state of a single step
+type StepStatusDTO struct {
+	Name     string `json:"name"`
+	Ready    bool   `json:"ready"`
+	Revision string `json:"revision,omitempty"`
+	CodeHash string `json:"codeHash,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// StepLogEventDTO is a single step log line streamed over SSE
+type StepLogEventDTO struct {
+	Step   string           `json:"step"`
+	Pod    string           `json:"pod,omitempty"`
+	Line   string           `json:"line"`
+	Time   time.Time        `json:"ts"`
+	Runner *RunnerLogFields `json:"runner,omitempty"`
+}
+
+// RunnerLogFields holds the fields parsed from a RUNNER_PY log line
+type RunnerLogFields struct {
+	App     string   `json:"app"`
+	Kind    string   `json:"kind"` // io, emit, emit_error, error, retry
+	In      string   `json:"in,omitempty"`
+	Out     []string `json:"out,omitempty"`
+	Items   *int     `json:"items,omitempty"`
+	CeType  string   `json:"ceType,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Retry   string   `json:"retry,omitempty"` // handle, emit or dlq
+	Attempt int      `json:"attempt,omitempty"`
+}
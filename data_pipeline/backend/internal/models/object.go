@@ -16,26 +16,29 @@ type Object struct {
 
 // ObjectRequestDTO represents the request payload for object operations
 type ObjectRequestDTO struct {
-	ID     *int64                 `json:"o_id,omitempty"`
-	FlowID *int64                 `json:"f_id,omitempty"`
-	MenuID *int64                 `json:"m_id,omitempty"`
-	Target *int64                 `json:"target,omitempty"`
-	X      *int64                 `json:"x,omitempty"`
-	Y      *int64                 `json:"y,omitempty"`
-	Type   string                 `json:"type"`
-	Label  string                 `json:"label"`
-	Params map[string]interface{} `json:"params,omitempty"`
+	ID     *int64 `json:"o_id,omitempty"`
+	FlowID *int64 `json:"f_id,omitempty"`
+	MenuID *int64 `json:"m_id,omitempty"`
+	Target *int64 `json:"target,omitempty"`
+	// Targets lists every successor of the node; takes precedence over Target
+	Targets []int64                `json:"targets,omitempty"`
+	X       *int64                 `json:"x,omitempty"`
+	Y       *int64                 `json:"y,omitempty"`
+	Type    string                 `json:"type"`
+	Label   string                 `json:"label"`
+	Params  map[string]interface{} `json:"params,omitempty"`
 }
 
 // ObjectResponseDTO represents the response payload for object operations
 type ObjectResponseDTO struct {
-	ID     int64                  `json:"o_id"`
-	Type   string                 `json:"type"`
-	Label  string                 `json:"label"`
-	Params map[string]interface{} `json:"params,omitempty"`
-	Target *int64                 `json:"target,omitempty"`
-	X      *int64                 `json:"x,omitempty"`
-	Y      *int64                 `json:"y,omitempty"`
+	ID      int64                  `json:"o_id"`
+	Type    string                 `json:"type"`
+	Label   string                 `json:"label"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Target  *int64                 `json:"target,omitempty"`
+	Targets []int64                `json:"targets,omitempty"`
+	X       *int64                 `json:"x,omitempty"`
+	Y       *int64                 `json:"y,omitempty"`
 }
 
 // ToResponseDTO converts Object entity to ObjectResponseDTO
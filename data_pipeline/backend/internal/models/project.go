@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Project roles, from least to most privileged
+const (
+	ProjectRoleViewer   = "viewer"   // read flows, datasets and models
+	ProjectRoleEditor   = "editor"   // + change flows and objects, build datasets
+	ProjectRoleDeployer = "deployer" // + deploy, delete and kick flows, activate models
+	ProjectRoleAdmin    = "admin"    // + manage members, production models
+)
+
+var projectRoleRank = map[string]int{
+	ProjectRoleViewer:   1,
+	ProjectRoleEditor:   2,
+	ProjectRoleDeployer: 3,
+	ProjectRoleAdmin:    4,
+}
+
+// ValidProjectRole reports whether role is one of the project roles
+func ValidProjectRole(role string) bool {
+	return projectRoleRank[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything want does
+func RoleAtLeast(role, want string) bool {
+	return projectRoleRank[role] > 0 && projectRoleRank[role] >= projectRoleRank[want]
+}
+
+// Project is a team that owns flows, datasets and models
+type Project struct {
+	ID          int64     `json:"p_id" db:"p_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedBy   *int64    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	// Role is the caller's role, set when listed by member; not stored
+	Role string `json:"-" db:"-"`
+}
+
+// ProjectMember is a user's role in a project
+type ProjectMember struct {
+	ProjectID int64     `json:"p_id" db:"project_id"`
+	UserID    int64     `json:"u_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	Role      string    `json:"role" db:"role"`
+	AddedAt   time.Time `json:"added_at" db:"added_at"`
+}
+
+// ProjectRequestDTO represents the request payload for project operations
+type ProjectRequestDTO struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ProjectMemberRequestDTO sets the role of a member
+type ProjectMemberRequestDTO struct {
+	Role string `json:"role"`
+}
+
+// ProjectResponseDTO is a project with the caller's role in it
+type ProjectResponseDTO struct {
+	ID          int64            `json:"p_id"`
+	Name        string           `json:"name"`
+	Description *string          `json:"description,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Role        string           `json:"role,omitempty"`
+	Members     []*ProjectMember `json:"members,omitempty"`
+}
+
+// ToResponseDTO converts Project entity to ProjectResponseDTO
+func (p *Project) ToResponseDTO() *ProjectResponseDTO {
+	return &ProjectResponseDTO{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		CreatedAt:   p.CreatedAt,
+		Role:        p.Role,
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Step image build status values
+const (
+	StepImageStatusBuilding = "building"
+	StepImageStatusReady    = "ready"
+	StepImageStatusFailed   = "failed"
+)
+
+// StepImage is an image built for one (base image, requirements) set
+type StepImage struct {
+	ID           int64           `json:"i_id" db:"i_id"`
+	DepKey       string          `json:"dep_key" db:"dep_key"`
+	BaseImage    string          `json:"base_image" db:"base_image"`
+	Requirements json.RawMessage `json:"requirements" db:"requirements"`
+	Image        string          `json:"image" db:"image"`
+	Digest       *string         `json:"digest,omitempty" db:"digest"`
+	Status       string          `json:"status" db:"status"`
+	Log          *string         `json:"log,omitempty" db:"log"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// StepRuntimeConfig is params.runtimeConfig of a step: resources, scaling
+// and environment of the container it runs in. Unset fields keep the defaults.
+type StepRuntimeConfig struct {
+	Resources            *StepResources    `json:"resources,omitempty"`
+	MinScale             *int              `json:"minScale,omitempty"`
+	MaxScale             *int              `json:"maxScale,omitempty"`
+	ContainerConcurrency *int              `json:"containerConcurrency,omitempty"` // 0 = unlimited
+	TimeoutSeconds       *int              `json:"timeoutSeconds,omitempty"`       // per request
+	Env                  map[string]string `json:"env,omitempty"`
+}
+
+// StepResources holds Kubernetes quantities such as "250m" or "512Mi"
+type StepResources struct {
+	Requests ResourceAmounts `json:"requests,omitempty"`
+	Limits   ResourceAmounts `json:"limits,omitempty"`
+}
+
+type ResourceAmounts struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Span status values
+const (
+	SpanStatusOK      = "ok"
+	SpanStatusError   = "error"
+	SpanStatusDropped = "dropped"
+)
+
+// TraceSpan represents one event handled by one step
+type TraceSpan struct {
+	ID         int64           `json:"s_id" db:"s_id"`
+	FlowID     int64           `json:"f_id" db:"flow"`
+	TraceID    string          `json:"trace_id" db:"trace_id"`
+	EventID    string          `json:"event_id" db:"event_id"`
+	Step       string          `json:"step" db:"step"`
+	Producer   *string         `json:"producer,omitempty" db:"producer"`
+	InType     *string         `json:"in_type,omitempty" db:"in_type"`
+	Hops       int             `json:"hops" db:"hops"`
+	Items      int             `json:"items" db:"items"`
+	Emitted    json.RawMessage `json:"emitted" db:"emitted"`
+	Status     string          `json:"status" db:"status"`
+	Error      *string         `json:"error,omitempty" db:"error"`
+	StartedAt  time.Time       `json:"started_at" db:"started_at"`
+	DurationMs float64         `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// SpanEmit is an event emitted while handling a span
+type SpanEmit struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// SpanIngestDTO is a span as reported by the runner
+type SpanIngestDTO struct {
+	FlowID     string     `json:"flow_id"`
+	Step       string     `json:"step"`
+	TraceID    string     `json:"trace_id"`
+	EventID    string     `json:"event_id"`
+	Producer   string     `json:"producer"`
+	InType     string     `json:"in_type"`
+	Hops       int        `json:"hops"`
+	Items      int        `json:"items"`
+	Emitted    []SpanEmit `json:"emitted"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error"`
+	Start      float64    `json:"start"` // unix seconds
+	DurationMs float64    `json:"duration_ms"`
+}
+
+// TraceSpanDTO is a span placed in its trace; ParentEventID is the event
+// handled by the span that emitted this span's event
+type TraceSpanDTO struct {
+	Step          string     `json:"step"`
+	EventID       string     `json:"event_id"`
+	ParentEventID string     `json:"parent_event_id,omitempty"`
+	Producer      string     `json:"producer,omitempty"`
+	InType        string     `json:"in_type,omitempty"`
+	Hops          int        `json:"hops"`
+	Items         int        `json:"items"`
+	Emitted       []SpanEmit `json:"emitted"`
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	OffsetMs      float64    `json:"offset_ms"` // trace 시작 기준
+	DurationMs    float64    `json:"duration_ms"`
+}
+
+// TraceStepDTO aggregates the spans of one step within a trace
+type TraceStepDTO struct {
+	Step          string  `json:"step"`
+	Spans         int     `json:"spans"`
+	TotalMs       float64 `json:"total_ms"`
+	MaxMs         float64 `json:"max_ms"`
+	Errors        int     `json:"errors"`
+	Dropped       int     `json:"dropped"`
+	FirstOffsetMs float64 `json:"first_offset_ms"`
+}
+
+// TraceDTO is the full path of one event through a flow
+type TraceDTO struct {
+	FlowID       int64          `json:"f_id"`
+	TraceID      string         `json:"trace_id"`
+	StartedAt    time.Time      `json:"started_at"`
+	FinishedAt   time.Time      `json:"finished_at"`
+	DurationMs   float64        `json:"duration_ms"`
+	Spans        []TraceSpanDTO `json:"spans"`
+	Steps        []TraceStepDTO `json:"steps"`
+	SlowestStep  string         `json:"slowest_step,omitempty"`
+	MissingSteps []string       `json:"missing_steps,omitempty"` // 마지막 배포에 있지만 이벤트를 받지 못한 스텝
+}
+
+// TraceSummaryDTO represents a trace in a list
+type TraceSummaryDTO struct {
+	TraceID    string    `json:"trace_id"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+	Spans      int       `json:"spans"`
+	Errors     int       `json:"errors"`
+}
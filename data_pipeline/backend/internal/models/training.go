@@ -28,6 +28,7 @@ type TrainingRun struct {
 	GPUInfo         json.RawMessage  `json:"gpu_info,omitempty"`
 	CreatedAt       time.Time        `json:"created_at"`
 	CreatedBy       *int64           `json:"created_by,omitempty"`
+	ProjectID       *int64           `json:"project_id,omitempty"`
 }
 
 type TrainingRunRequestDTO struct {
@@ -37,6 +38,7 @@ type TrainingRunRequestDTO struct {
 	ModelType       string          `json:"model_type"`
 	Hyperparameters json.RawMessage `json:"hyperparameters,omitempty"`
 	DatasetID       *int64          `json:"dataset_id,omitempty"`
+	ProjectID       *int64          `json:"project_id,omitempty"`
 }
 
 type TrainingRunResponseDTO struct {
@@ -141,6 +143,7 @@ type Dataset struct {
 	IsActive     bool            `json:"is_active"`
 	CreatedAt    time.Time       `json:"created_at"`
 	CreatedBy    *int64          `json:"created_by,omitempty"`
+	ProjectID    *int64          `json:"project_id,omitempty"`
 }
 
 type DatasetRequestDTO struct {
@@ -148,6 +151,7 @@ type DatasetRequestDTO struct {
 	Version     string          `json:"version"`
 	Description *string         `json:"description,omitempty"`
 	Config      json.RawMessage `json:"config,omitempty"`
+	ProjectID   *int64          `json:"project_id,omitempty"`
 }
 
 type DatasetBuildRequestDTO struct {
@@ -161,6 +165,7 @@ type DatasetBuildRequestDTO struct {
 	TestSplitRatio   float64  `json:"test_split_ratio"`   // 0.1
 	DedupEnabled     bool     `json:"dedup_enabled"`
 	MinSampleLength  int      `json:"min_sample_length"`
+	ProjectID        *int64   `json:"project_id,omitempty"`
 }
 
 type FeedbackFilters struct {
@@ -196,6 +201,7 @@ type Model struct {
 	OllamaImported  bool            `json:"ollama_imported"`
 	CreatedAt       time.Time       `json:"created_at"`
 	CreatedBy       *int64          `json:"created_by,omitempty"`
+	ProjectID       *int64          `json:"project_id,omitempty"`
 }
 
 type ModelRequestDTO struct {
@@ -206,6 +212,7 @@ type ModelRequestDTO struct {
 	ModelType   string  `json:"model_type"`
 	ModelPath   string  `json:"model_path"`
 	AdapterPath *string `json:"adapter_path,omitempty"`
+	ProjectID   *int64  `json:"project_id,omitempty"`
 }
 
 type ModelActivateRequestDTO struct {
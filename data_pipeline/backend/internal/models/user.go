@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// User is an authenticated identity
+type User struct {
+	ID          int64     `json:"u_id" db:"u_id"`
+	Subject     string    `json:"subject" db:"subject"`
+	Username    string    `json:"username" db:"username"`
+	Email       *string   `json:"email,omitempty" db:"email"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at" db:"last_login_at"`
+	// Admin is granted by AUTH_ADMINS (or disabled auth), not stored
+	Admin bool `json:"admin" db:"-"`
+}
+
+// Namespace is the Kubernetes namespace the user's flows deploy into
+func (u *User) Namespace() string {
+	return "user-" + u.Username
+}
@@ -0,0 +1,73 @@
+package models
+
+// WorkflowStep is one node of a workflow. It is given inline or references a
+// stored Object, whose type, label and params fill what the step leaves
+// empty. Inputs name upstream steps: omitted, a step reads the one before
+// it in the list; an empty list means no inputs.
+type WorkflowStep struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type,omitempty"` // data, python, join, save
+	Name      string                 `json:"name,omitempty"`
+	ObjectID  *int64                 `json:"objectId,omitempty"`
+	Inputs    []string               `json:"inputs,omitempty"`
+	Config    map[string]interface{} `json:"config,omitempty"` // data / save config, join options
+	Code      string                 `json:"code,omitempty"`
+	ChunkRows int                    `json:"chunkRows,omitempty"`
+}
+
+// WorkflowExecuteRequest represents a workflow execution request: Steps, the
+// objects and edges of a stored flow, or the single Data → Python → Save
+// shape of DataConfig, PythonCode and SaveConfig. Event is the input of the
+// Python steps without inputs, as with a flow trigger.
+type WorkflowExecuteRequest struct {
+	Steps       []WorkflowStep         `json:"steps,omitempty"`
+	FlowID      *int64                 `json:"flowId,omitempty"`
+	Event       map[string]interface{} `json:"event,omitempty"`
+	Parallelism int                    `json:"parallelism,omitempty"` // steps run at once; default 4
+	DataConfig  map[string]interface{} `json:"dataConfig"`
+	PythonCode  string                 `json:"pythonCode"`
+	SaveConfig  map[string]interface{} `json:"saveConfig"`
+	ChunkRows   int                    `json:"chunkRows,omitempty"` // run the code per chunk of rows
+}
+
+// WorkflowExecuteResponse represents a workflow execution response
+type WorkflowExecuteResponse struct {
+	RunID      int64                `json:"runId,omitempty"`
+	Success    bool                 `json:"success"`
+	Steps      []WorkflowStepResult `json:"steps"`
+	Error      string               `json:"error,omitempty"`
+	OutputPath string               `json:"outputPath,omitempty"` // of the last save step
+	DurationMs int64                `json:"durationMs"`
+}
+
+// WorkflowStepResult represents a single step result
+type WorkflowStepResult struct {
+	ID         string `json:"id,omitempty"`
+	Step       string `json:"step"`
+	Type       string `json:"type,omitempty"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Rows       *int   `json:"rows,omitempty"`
+	Logs       string `json:"logs,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// DataLoadResponse is the result of reading a data source
+type DataLoadResponse struct {
+	Success bool                   `json:"success"`
+	Data    interface{}            `json:"data,omitempty"`
+	Preview string                 `json:"preview,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// DataSaveResponse is the result of writing to a sink
+type DataSaveResponse struct {
+	Success bool   `json:"success"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
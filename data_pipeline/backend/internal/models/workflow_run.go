@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Workflow run statuses
+const (
+	WorkflowRunPending   = "pending"
+	WorkflowRunRunning   = "running"
+	WorkflowRunSuccess   = "success"
+	WorkflowRunFailed    = "failed"
+	WorkflowRunCancelled = "cancelled"
+)
+
+// Workflow run step statuses; pending, running, success and failed as above
+const (
+	WorkflowStepSkipped = "skipped" // an input failed or the run was stopped
+)
+
+// Workflow run triggers; runs of a flow trigger have its kind
+const (
+	WorkflowTriggerManual   = "manual"
+	WorkflowTriggerSchedule = "schedule"
+)
+
+// WorkflowRun is one execution of /api/workflow/execute
+type WorkflowRun struct {
+	ID         int64              `json:"run_id" db:"r_id"`
+	FlowID     *int64             `json:"f_id,omitempty" db:"flow_id"`
+	ProjectID  *int64             `json:"p_id,omitempty" db:"project_id"`
+	ScheduleID *int64             `json:"s_id,omitempty" db:"schedule_id"`
+	TriggerID  *int64             `json:"t_id,omitempty" db:"trigger_id"`
+	CreatedBy  *int64             `json:"-" db:"created_by"`
+	Actor      string             `json:"actor" db:"actor"`
+	Trigger    string             `json:"trigger" db:"trigger"`
+	Status     string             `json:"status" db:"status"`
+	Request    json.RawMessage    `json:"request,omitempty" db:"request"`
+	OutputPath *string            `json:"output_path,omitempty" db:"output_path"`
+	Error      *string            `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+	StartedAt  *time.Time         `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty" db:"finished_at"`
+	DurationMs *int64             `json:"duration_ms,omitempty" db:"duration_ms"`
+	Steps      []*WorkflowRunStep `json:"steps,omitempty"`
+}
+
+// Finished reports whether the run has ended
+func (r *WorkflowRun) Finished() bool {
+	return r.Status == WorkflowRunSuccess || r.Status == WorkflowRunFailed || r.Status == WorkflowRunCancelled
+}
+
+// WorkflowRunStep records one step of a run
+type WorkflowRunStep struct {
+	RunID      int64      `json:"run_id" db:"run_id"`
+	Position   int        `json:"position" db:"position"`
+	StepID     string     `json:"step_id" db:"step_id"`
+	Name       string     `json:"name" db:"name"`
+	Type       string     `json:"type" db:"type"`
+	Status     string     `json:"status" db:"status"`
+	Rows       *int64     `json:"rows,omitempty" db:"rows"`
+	OutputPath *string    `json:"output_path,omitempty" db:"output_path"`
+	Message    *string    `json:"message,omitempty" db:"message"`
+	Error      *string    `json:"error,omitempty" db:"error"`
+	Logs       *string    `json:"logs,omitempty" db:"logs"`
+	StartedAt  *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	DurationMs *int64     `json:"duration_ms,omitempty" db:"duration_ms"`
+}
+
+// WorkflowRunFilter selects runs; empty fields match everything. UserID
+// limits the runs to those the user may see (its own personal runs and the
+// runs of its projects).
+type WorkflowRunFilter struct {
+	FlowID  *int64
+	Status  string
+	Trigger string
+	UserID  *int64
+}
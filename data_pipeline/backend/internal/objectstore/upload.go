@@ -0,0 +1,191 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinPartSize is the smallest part S3 accepts (except the last one)
+	MinPartSize = 5 * 1024 * 1024
+	// DefaultPartSize is the buffer of an Uploader
+	DefaultPartSize = 16 * 1024 * 1024
+	maxParts        = 10000
+)
+
+// Uploader writes one object. Output up to the part size is stored with a
+// single PUT on Close; anything larger becomes a multipart upload whose parts
+// are sent as the buffer fills. A failed upload is aborted.
+type Uploader struct {
+	ctx      context.Context
+	client   *Client
+	bucket   string
+	key      string
+	partSize int
+	buf      bytes.Buffer
+	uploadID string
+	parts    []completedPart
+	err      error
+	closed   bool
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// NewUploader starts writing bucket/key; partSize 0 uses DefaultPartSize
+func (c *Client) NewUploader(ctx context.Context, bucket, key string, partSize int) *Uploader {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+	return &Uploader{ctx: ctx, client: c, bucket: bucket, key: key, partSize: partSize}
+}
+
+func (u *Uploader) Write(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	if u.closed {
+		return 0, errors.New("upload is closed")
+	}
+	n := len(p)
+	for len(p) > 0 {
+		room := u.partSize - u.buf.Len()
+		if room > len(p) {
+			room = len(p)
+		}
+		u.buf.Write(p[:room])
+		p = p[room:]
+		// 마지막 파트는 Close에서 보낸다
+		if u.buf.Len() == u.partSize && len(p) > 0 {
+			if err := u.flushPart(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close stores what is left and completes the upload
+func (u *Uploader) Close() error {
+	if u.closed {
+		return u.err
+	}
+	u.closed = true
+	if u.err != nil {
+		return u.err
+	}
+
+	if u.uploadID == "" {
+		u.err = u.client.PutObject(u.ctx, u.bucket, u.key, u.buf.Bytes())
+		return u.err
+	}
+	if u.buf.Len() > 0 {
+		if err := u.flushPart(); err != nil {
+			return err
+		}
+	}
+	if err := u.complete(); err != nil {
+		u.fail(err)
+	}
+	return u.err
+}
+
+// Abort discards a multipart upload in progress
+func (u *Uploader) Abort() {
+	u.closed = true
+	if u.err == nil {
+		u.fail(errors.New("upload aborted"))
+	}
+}
+
+func (u *Uploader) flushPart() error {
+	if u.uploadID == "" {
+		id, err := u.initiate()
+		if err != nil {
+			u.err = err
+			return err
+		}
+		u.uploadID = id
+	}
+	number := len(u.parts) + 1
+	if number > maxParts {
+		return u.fail(fmt.Errorf("object is larger than %d parts", maxParts))
+	}
+
+	q := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {u.uploadID}}
+	resp, err := u.client.do(u.ctx, http.MethodPut, u.bucket, u.key, q, nil, u.buf.Bytes())
+	if err != nil {
+		return u.fail(err)
+	}
+	resp.Body.Close()
+	u.parts = append(u.parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	u.buf.Reset()
+	return nil
+}
+
+func (u *Uploader) initiate() (string, error) {
+	resp, err := u.client.do(u.ctx, http.MethodPost, u.bucket, u.key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		UploadId string
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadId == "" {
+		return "", fmt.Errorf("initiate multipart upload of %s: %v", u.key, err)
+	}
+	return result.UploadId, nil
+}
+
+func (u *Uploader) complete() error {
+	var body strings.Builder
+	body.WriteString("<CompleteMultipartUpload>")
+	for _, p := range u.parts {
+		fmt.Fprintf(&body, "<Part><PartNumber>%d</PartNumber><ETag>", p.PartNumber)
+		xml.EscapeText(&body, []byte(p.ETag))
+		body.WriteString("</ETag></Part>")
+	}
+	body.WriteString("</CompleteMultipartUpload>")
+
+	header := http.Header{"Content-Type": {"application/xml"}}
+	resp, err := u.client.do(u.ctx, http.MethodPost, u.bucket, u.key, url.Values{"uploadId": {u.uploadID}}, header, []byte(body.String()))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// 완료 요청은 200으로 응답한 뒤 본문에 오류를 담을 수 있다
+	var result struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(resp.Body).Decode(&result) == nil && result.XMLName.Local == "Error" {
+		return &S3Error{Status: resp.StatusCode, Code: result.Code, Message: result.Message}
+	}
+	return nil
+}
+
+// fail records err and aborts the multipart upload, if one was started
+func (u *Uploader) fail(err error) error {
+	u.err = err
+	if u.uploadID != "" {
+		if resp, abortErr := u.client.do(context.WithoutCancel(u.ctx), http.MethodDelete, u.bucket, u.key, url.Values{"uploadId": {u.uploadID}}, nil, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		u.uploadID = ""
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestUploader uploads with tiny parts; S3's 5 MiB minimum is not
+// enforced by the fake
+func newTestUploader(c *Client, key string, partSize int) *Uploader {
+	u := c.NewUploader(context.Background(), "data", key, 0)
+	u.partSize = partSize
+	return u
+}
+
+func methods(requests []string) []string {
+	var out []string
+	for _, r := range requests {
+		method, uri, _ := strings.Cut(r, " ")
+		switch {
+		case strings.Contains(uri, "uploads="):
+			out = append(out, "initiate")
+		case strings.Contains(uri, "partNumber="):
+			out = append(out, "part")
+		case strings.Contains(uri, "uploadId=") && method == "POST":
+			out = append(out, "complete")
+		case strings.Contains(uri, "uploadId=") && method == "DELETE":
+			out = append(out, "abort")
+		default:
+			out = append(out, method)
+		}
+	}
+	return out
+}
+
+func TestUploaderSmallObjectIsOnePut(t *testing.T) {
+	fake, c := newFakeS3(t, "data")
+	u := newTestUploader(c, "small.csv", 8)
+	if _, err := u.Write([]byte("12345678")); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := methods(fake.requestLog()); strings.Join(got, ",") != "PUT" {
+		t.Fatalf("requests = %v", got)
+	}
+	if data, _ := fake.object("data", "small.csv"); string(data) != "12345678" {
+		t.Fatalf("stored %q", data)
+	}
+}
+
+func TestUploaderMultipart(t *testing.T) {
+	fake, c := newFakeS3(t, "data")
+	u := newTestUploader(c, "big.jsonl", 4)
+	want := []byte("abcdefghij")
+	// 파트 경계와 어긋나게 나눠 쓴다
+	for _, chunk := range [][]byte{want[:3], want[3:9], want[9:]} {
+		if n, err := u.Write(chunk); err != nil || n != len(chunk) {
+			t.Fatalf("write = %d, %v", n, err)
+		}
+	}
+	if err := u.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if got := strings.Join(methods(fake.requestLog()), ","); got != "initiate,part,part,part,complete" {
+		t.Fatalf("requests = %s", got)
+	}
+	if data, _ := fake.object("data", "big.jsonl"); !bytes.Equal(data, want) {
+		t.Fatalf("stored %q", data)
+	}
+	if fake.openUploads() != 0 {
+		t.Fatal("upload left open")
+	}
+	if _, err := u.Write([]byte("x")); err == nil {
+		t.Fatal("write after close accepted")
+	}
+}
+
+func TestUploaderAbortsWhenAPartFails(t *testing.T) {
+	fake, c := newFakeS3(t, "data")
+	fake.failPart = 2
+	u := newTestUploader(c, "big.jsonl", 4)
+
+	_, err := u.Write([]byte("abcdefghijkl"))
+	var s3err *S3Error
+	if !errors.As(err, &s3err) || s3err.Code != "InternalError" {
+		t.Fatalf("write: %v", err)
+	}
+	if err := u.Close(); !errors.Is(err, s3err) {
+		t.Fatalf("close = %v, want the part error", err)
+	}
+
+	if got := strings.Join(methods(fake.requestLog()), ","); got != "initiate,part,part,abort" {
+		t.Fatalf("requests = %s", got)
+	}
+	if _, ok := fake.object("data", "big.jsonl"); ok || fake.openUploads() != 0 {
+		t.Fatal("failed upload left an object or an open upload")
+	}
+}
+
+func TestUploaderCompleteErrorInOKResponse(t *testing.T) {
+	fake, c := newFakeS3(t, "data")
+	fake.completeError = true
+	u := newTestUploader(c, "big.jsonl", 4)
+	if _, err := u.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := u.Close()
+	var s3err *S3Error
+	if !errors.As(err, &s3err) || s3err.Code != "InternalError" || s3err.Status != 200 {
+		t.Fatalf("close: %v", err)
+	}
+	if got := strings.Join(methods(fake.requestLog()), ","); got != "initiate,part,part,complete,abort" {
+		t.Fatalf("requests = %s", got)
+	}
+	if fake.openUploads() != 0 {
+		t.Fatal("upload left open")
+	}
+}
+
+func TestUploaderAbort(t *testing.T) {
+	fake, c := newFakeS3(t, "data")
+	u := newTestUploader(c, "big.jsonl", 4)
+	if _, err := u.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+	u.Abort()
+	if err := u.Close(); err == nil {
+		t.Fatal("close after abort succeeded")
+	}
+	if got := strings.Join(methods(fake.requestLog()), ","); got != "initiate,part,abort" {
+		t.Fatalf("requests = %s", got)
+	}
+	if _, ok := fake.object("data", "big.jsonl"); ok || fake.openUploads() != 0 {
+		t.Fatal("aborted upload left an object or an open upload")
+	}
+}
+
+func TestNewUploaderPartSize(t *testing.T) {
+	_, c := newFakeS3(t, "data")
+	if u := c.NewUploader(context.Background(), "data", "k", 1); u.partSize != MinPartSize {
+		t.Fatalf("partSize = %d, want %d", u.partSize, MinPartSize)
+	}
+	if u := c.NewUploader(context.Background(), "data", "k", 0); u.partSize != DefaultPartSize {
+		t.Fatalf("partSize = %d, want %d", u.partSize, DefaultPartSize)
+	}
+}
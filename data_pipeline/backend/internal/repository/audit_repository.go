@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create inserts an event, normally still pending
+func (r *AuditRepository) Create(e *models.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (actor_id, actor, action, target_type, target_id, method, path,
+		                          params, outcome, status, error, duration_ms, remote_addr)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING a_id, occurred_at
+	`
+
+	return r.db.QueryRow(query,
+		e.ActorID,
+		e.Actor,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Method,
+		e.Path,
+		auditParams(e.Params),
+		e.Outcome,
+		e.Status,
+		e.Error,
+		e.DurationMs,
+		e.RemoteAddr,
+	).Scan(&e.ID, &e.OccurredAt)
+}
+
+// Finish records how the request ended
+func (r *AuditRepository) Finish(e *models.AuditEvent) error {
+	query := `
+		UPDATE audit_events
+		SET target_id = $1, outcome = $2, status = $3, error = $4, duration_ms = $5
+		WHERE a_id = $6
+	`
+	_, err := r.db.Exec(query, e.TargetID, e.Outcome, e.Status, e.Error, e.DurationMs, e.ID)
+	return err
+}
+
+const auditColumns = `
+		SELECT a_id, occurred_at, actor_id, actor, action, target_type, target_id, method, path,
+		       params, outcome, status, error, duration_ms, remote_addr
+		FROM audit_events
+		WHERE ($1::bigint IS NULL OR actor_id = $1)
+		  AND ($2 = '' OR actor = $2)
+		  AND ($3 = '' OR action = $3 OR (RIGHT($3, 1) = '.' AND action LIKE $4))
+		  AND ($5 = '' OR target_type = $5)
+		  AND ($6 = '' OR target_id = $6)
+		  AND ($7 = '' OR outcome = $7)
+		  AND ($8::timestamptz IS NULL OR occurred_at >= $8)
+		  AND ($9::timestamptz IS NULL OR occurred_at < $9)
+		ORDER BY occurred_at DESC, a_id DESC`
+
+func auditArgs(f *models.AuditFilter) []interface{} {
+	var since, until sql.NullTime
+	if f.Since != nil {
+		since = sql.NullTime{Time: *f.Since, Valid: true}
+	}
+	if f.Until != nil {
+		until = sql.NullTime{Time: *f.Until, Valid: true}
+	}
+	prefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Action) + "%"
+	return []interface{}{f.ActorID, f.Actor, f.Action, prefix, f.TargetType, f.TargetID, f.Outcome, since, until}
+}
+
+// List returns the newest events matching the filter
+func (r *AuditRepository) List(f *models.AuditFilter, limit, offset int) ([]*models.AuditEvent, error) {
+	events := []*models.AuditEvent{}
+	err := r.each(auditColumns+"\n\t\tLIMIT $10 OFFSET $11", append(auditArgs(f), limit, offset), func(e *models.AuditEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	return events, err
+}
+
+// Each calls fn for every matching event, newest first, without loading
+// them all into memory
+func (r *AuditRepository) Each(f *models.AuditFilter, fn func(*models.AuditEvent) error) error {
+	return r.each(auditColumns, auditArgs(f), fn)
+}
+
+func (r *AuditRepository) each(query string, args []interface{}, fn func(*models.AuditEvent) error) error {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &models.AuditEvent{}
+		var params string
+		err := rows.Scan(
+			&e.ID, &e.OccurredAt, &e.ActorID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID,
+			&e.Method, &e.Path, &params, &e.Outcome, &e.Status, &e.Error, &e.DurationMs, &e.RemoteAddr,
+		)
+		if err != nil {
+			return err
+		}
+		e.Params = json.RawMessage(params)
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func auditParams(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return []byte("{}")
+	}
+	return []byte(raw)
+}
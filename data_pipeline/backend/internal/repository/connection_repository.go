@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrConnectionNotFound = errors.New("connection not found")
+)
+
+type ConnectionRepository struct {
+	db *sql.DB
+}
+
+func NewConnectionRepository(db *sql.DB) *ConnectionRepository {
+	return &ConnectionRepository{db: db}
+}
+
+const connectionColumns = `c_id, owner, name, type, description, keys, secret, created_at, updated_at`
+
+func (r *ConnectionRepository) Create(c *models.Connection) error {
+	query := `
+		INSERT INTO connections (owner, name, type, description, keys, secret)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING c_id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(query,
+		c.Owner,
+		c.Name,
+		c.Type,
+		c.Description,
+		jsonOrDefault(c.Keys),
+		c.Secret,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *ConnectionRepository) FindByID(id int64) (*models.Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM connections WHERE c_id = $1`
+	return r.scanOne(r.db.QueryRow(query, id))
+}
+
+func (r *ConnectionRepository) FindByOwnerAndName(owner, name string) (*models.Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM connections WHERE owner = $1 AND name = $2`
+	return r.scanOne(r.db.QueryRow(query, owner, name))
+}
+
+func (r *ConnectionRepository) FindByOwner(owner string) ([]*models.Connection, error) {
+	query := `SELECT ` + connectionColumns + ` FROM connections WHERE owner = $1 ORDER BY name`
+
+	rows, err := r.db.Query(query, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conns []*models.Connection
+	for rows.Next() {
+		c, err := r.scanOne(rows)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, c)
+	}
+	return conns, rows.Err()
+}
+
+func (r *ConnectionRepository) Update(c *models.Connection) error {
+	query := `
+		UPDATE connections
+		SET type = $1, description = $2, keys = $3, secret = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE c_id = $5
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		c.Type,
+		c.Description,
+		jsonOrDefault(c.Keys),
+		c.Secret,
+		c.ID,
+	).Scan(&c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrConnectionNotFound
+	}
+	return err
+}
+
+func (r *ConnectionRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM connections WHERE c_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrConnectionNotFound
+	}
+	return nil
+}
+
+func (r *ConnectionRepository) scanOne(row interface{ Scan(...interface{}) error }) (*models.Connection, error) {
+	c := &models.Connection{}
+	var description sql.NullString
+	var keys []byte
+	err := row.Scan(
+		&c.ID,
+		&c.Owner,
+		&c.Name,
+		&c.Type,
+		&description,
+		&keys,
+		&c.Secret,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrConnectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		c.Description = &description.String
+	}
+	c.Keys = keys
+	return c, nil
+}
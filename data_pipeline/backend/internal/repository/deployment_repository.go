@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrDeploymentNotFound = errors.New("deployment not found")
+)
+
+type DeploymentRepository struct {
+	db *sql.DB
+}
+
+func NewDeploymentRepository(db *sql.DB) *DeploymentRepository {
+	return &DeploymentRepository{db: db}
+}
+
+func (r *DeploymentRepository) Create(d *models.Deployment) error {
+	query := `
+		INSERT INTO deployments (flow, version, runtime, namespace, topic, deployed_by, steps, phases, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING d_id, started_at
+	`
+
+	return r.db.QueryRow(query,
+		d.FlowID,
+		d.Version,
+		d.Runtime,
+		d.Namespace,
+		d.Topic,
+		d.DeployedBy,
+		jsonOrDefault(d.Steps),
+		jsonOrDefault(d.Phases),
+		d.Status,
+		d.CreatedBy,
+	).Scan(&d.ID, &d.StartedAt)
+}
+
+// Finish stores the final steps, phases, status and verify result of a deployment
+func (r *DeploymentRepository) Finish(d *models.Deployment) error {
+	query := `
+		UPDATE deployments
+		SET version = $1, steps = $2, phases = $3, status = $4, verify_result = $5, finished_at = CURRENT_TIMESTAMP
+		WHERE d_id = $6
+		RETURNING finished_at
+	`
+
+	var finishedAt sql.NullTime
+	err := r.db.QueryRow(query,
+		d.Version,
+		jsonOrDefault(d.Steps),
+		jsonOrDefault(d.Phases),
+		d.Status,
+		d.VerifyResult,
+		d.ID,
+	).Scan(&finishedAt)
+	if err == sql.ErrNoRows {
+		return ErrDeploymentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if finishedAt.Valid {
+		d.FinishedAt = &finishedAt.Time
+	}
+	return nil
+}
+
+// FindLatest returns the most recent deployment of a flow
+func (r *DeploymentRepository) FindLatest(flowID int64) (*models.Deployment, error) {
+	query := `
+		SELECT d_id, flow, version, runtime, namespace, topic, deployed_by, steps, phases,
+		       status, verify_result, created_by, started_at, finished_at
+		FROM deployments
+		WHERE flow = $1
+		ORDER BY d_id DESC
+		LIMIT 1
+	`
+
+	d := &models.Deployment{}
+	var steps, phases string
+	var version, createdBy sql.NullInt64
+	var verifyResult sql.NullString
+	var finishedAt sql.NullTime
+
+	err := r.db.QueryRow(query, flowID).Scan(
+		&d.ID, &d.FlowID, &version, &d.Runtime, &d.Namespace, &d.Topic, &d.DeployedBy, &steps, &phases,
+		&d.Status, &verifyResult, &createdBy, &d.StartedAt, &finishedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrDeploymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.Steps = json.RawMessage(steps)
+	d.Phases = json.RawMessage(phases)
+	if version.Valid {
+		v := int(version.Int64)
+		d.Version = &v
+	}
+	if verifyResult.Valid {
+		d.VerifyResult = &verifyResult.String
+	}
+	if createdBy.Valid {
+		id := createdBy.Int64
+		d.CreatedBy = &id
+	}
+	if finishedAt.Valid {
+		d.FinishedAt = &finishedAt.Time
+	}
+
+	return d, nil
+}
+
+func jsonOrDefault(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return []byte("[]")
+	}
+	return []byte(raw)
+}
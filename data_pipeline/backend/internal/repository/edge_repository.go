@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+)
+
+type EdgeRepository struct {
+	db DBTX
+}
+
+func NewEdgeRepository(db *sql.DB) *EdgeRepository {
+	return &EdgeRepository{db: db}
+}
+
+// WithTx returns the repository running its statements in tx
+func (r *EdgeRepository) WithTx(tx DBTX) *EdgeRepository {
+	return &EdgeRepository{db: tx}
+}
+
+func (r *EdgeRepository) FindByFlow(flowID int64) ([]*models.Edge, error) {
+	query := `
+		SELECT e_id, flow, source, target, created_at
+		FROM edges
+		WHERE flow = $1
+		ORDER BY source, target
+	`
+
+	rows, err := r.db.Query(query, flowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []*models.Edge
+	for rows.Next() {
+		edge := &models.Edge{}
+		if err := rows.Scan(&edge.ID, &edge.FlowID, &edge.Source, &edge.Target, &edge.CreatedAt); err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, rows.Err()
+}
+
+func (r *EdgeRepository) FindTargets(sourceID int64) ([]int64, error) {
+	query := `SELECT target FROM edges WHERE source = $1 ORDER BY target`
+
+	rows, err := r.db.Query(query, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []int64
+	for rows.Next() {
+		var target int64
+		if err := rows.Scan(&target); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// ReplaceTargets replaces all outgoing edges of a source object in one transaction
+func (r *EdgeRepository) ReplaceTargets(flowID, sourceID int64, targets []int64) error {
+	return InTx(r.db, func(tx DBTX) error {
+		if _, err := tx.Exec(`DELETE FROM edges WHERE source = $1`, sourceID); err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			_, err := tx.Exec(`
+				INSERT INTO edges (flow, source, target)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (source, target) DO NOTHING
+			`, flowID, sourceID, target)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteIncoming removes the edges pointing at an object
+func (r *EdgeRepository) DeleteIncoming(targetID int64) error {
+	_, err := r.db.Exec(`DELETE FROM edges WHERE target = $1`, targetID)
+	return err
+}
+
+func (r *EdgeRepository) FindAll() ([]*models.Edge, error) {
+	query := `
+		SELECT e_id, flow, source, target, created_at
+		FROM edges
+		ORDER BY source, target
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []*models.Edge
+	for rows.Next() {
+		edge := &models.Edge{}
+		if err := rows.Scan(&edge.ID, &edge.FlowID, &edge.Source, &edge.Target, &edge.CreatedAt); err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, rows.Err()
+}
@@ -12,17 +12,27 @@ var (
 )
 
 type FlowRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewFlowRepository(db *sql.DB) *FlowRepository {
 	return &FlowRepository{db: db}
 }
 
+// WithTx returns the repository running its statements in tx
+func (r *FlowRepository) WithTx(tx DBTX) *FlowRepository {
+	return &FlowRepository{db: tx}
+}
+
+// InTx runs fn in a transaction of the repository's database
+func (r *FlowRepository) InTx(fn func(tx DBTX) error) error {
+	return InTx(r.db, fn)
+}
+
 func (r *FlowRepository) Create(flow *models.Flow) error {
 	query := `
-		INSERT INTO flows (name, lastest_run, run_type, created_by)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO flows (name, lastest_run, run_type, created_by, project_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING f_id, saved_at
 	`
 
@@ -46,6 +56,7 @@ func (r *FlowRepository) Create(flow *models.Flow) error {
 		latestRun,
 		flow.RunType,
 		createdBy,
+		flow.ProjectID,
 	).Scan(&flow.ID, &flow.SavedAt)
 
 	return err
@@ -53,21 +64,23 @@ func (r *FlowRepository) Create(flow *models.Flow) error {
 
 func (r *FlowRepository) FindByID(id int64) (*models.Flow, error) {
 	query := `
-		SELECT f_id, name, lastest_run, run_type, created_by, saved_at
+		SELECT f_id, name, lastest_run, lastest_run_id, run_type, created_by, project_id, saved_at
 		FROM flows
 		WHERE f_id = $1
 	`
 
 	flow := &models.Flow{}
 	var latestRun sql.NullTime
-	var createdBy sql.NullInt64
+	var createdBy, projectID sql.NullInt64
 
 	err := r.db.QueryRow(query, id).Scan(
 		&flow.ID,
 		&flow.Name,
 		&latestRun,
+		&flow.LatestRunID,
 		&flow.RunType,
 		&createdBy,
+		&projectID,
 		&flow.SavedAt,
 	)
 
@@ -87,12 +100,17 @@ func (r *FlowRepository) FindByID(id int64) (*models.Flow, error) {
 		flow.CreatedBy = &id
 	}
 
+	if projectID.Valid {
+		id := projectID.Int64
+		flow.ProjectID = &id
+	}
+
 	return flow, nil
 }
 
 func (r *FlowRepository) FindAll() ([]*models.Flow, error) {
 	query := `
-		SELECT f_id, name, lastest_run, run_type, created_by, saved_at
+		SELECT f_id, name, lastest_run, lastest_run_id, run_type, created_by, project_id, saved_at
 		FROM flows
 		ORDER BY f_id
 	`
@@ -101,20 +119,44 @@ func (r *FlowRepository) FindAll() ([]*models.Flow, error) {
 	if err != nil {
 		return nil, err
 	}
+	return scanFlows(rows)
+}
+
+// FindAccessible returns the personal flows of a user and the flows of the
+// projects it is a member of
+func (r *FlowRepository) FindAccessible(userID int64) ([]*models.Flow, error) {
+	query := `
+		SELECT f_id, name, lastest_run, lastest_run_id, run_type, created_by, project_id, saved_at
+		FROM flows
+		WHERE (project_id IS NULL AND created_by = $1)
+		   OR project_id IN (SELECT project_id FROM project_members WHERE user_id = $1)
+		ORDER BY f_id
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanFlows(rows)
+}
+
+func scanFlows(rows *sql.Rows) ([]*models.Flow, error) {
 	defer rows.Close()
 
 	var flows []*models.Flow
 	for rows.Next() {
 		flow := &models.Flow{}
 		var latestRun sql.NullTime
-		var createdBy sql.NullInt64
+		var createdBy, projectID sql.NullInt64
 
 		err := rows.Scan(
 			&flow.ID,
 			&flow.Name,
 			&latestRun,
+			&flow.LatestRunID,
 			&flow.RunType,
 			&createdBy,
+			&projectID,
 			&flow.SavedAt,
 		)
 		if err != nil {
@@ -130,6 +172,11 @@ func (r *FlowRepository) FindAll() ([]*models.Flow, error) {
 			flow.CreatedBy = &id
 		}
 
+		if projectID.Valid {
+			id := projectID.Int64
+			flow.ProjectID = &id
+		}
+
 		flows = append(flows, flow)
 	}
 
@@ -144,8 +191,8 @@ func (r *FlowRepository) Update(flow *models.Flow) error {
 
 	query := `
 		UPDATE flows
-		SET name = $1, lastest_run = $2, run_type = $3, created_by = $4
-		WHERE f_id = $5
+		SET name = $1, lastest_run = $2, run_type = $3, created_by = $4, project_id = $5
+		WHERE f_id = $6
 	`
 
 	var latestRun interface{}
@@ -168,6 +215,7 @@ func (r *FlowRepository) Update(flow *models.Flow) error {
 		latestRun,
 		flow.RunType,
 		createdBy,
+		flow.ProjectID,
 		flow.ID,
 	)
 
@@ -190,3 +238,10 @@ func (r *FlowRepository) UpdateLatestRun(id int64, latestRun time.Time) error {
 	_, err := r.db.Exec(query, latestRun, id)
 	return err
 }
+
+// UpdateLatestRunID points the flow at a workflow run started at startedAt
+func (r *FlowRepository) UpdateLatestRunID(id, runID int64, startedAt time.Time) error {
+	query := `UPDATE flows SET lastest_run = $1, lastest_run_id = $2 WHERE f_id = $3`
+	_, err := r.db.Exec(query, startedAt, runID, id)
+	return err
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrFlowScheduleNotFound = errors.New("flow schedule not found")
+)
+
+type FlowScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewFlowScheduleRepository(db *sql.DB) *FlowScheduleRepository {
+	return &FlowScheduleRepository{db: db}
+}
+
+func (r *FlowScheduleRepository) Create(s *models.FlowSchedule) error {
+	query := `
+		INSERT INTO flow_schedules (flow_id, name, cron, timezone, enabled, overlap_policy, catch_up, next_run_at, run_as)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING s_id, created_at, updated_at
+	`
+	return r.db.QueryRow(query, s.FlowID, s.Name, s.Cron, s.Timezone, s.Enabled, s.OverlapPolicy, s.CatchUp,
+		s.NextRunAt, s.RunAs,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+}
+
+const flowScheduleColumns = `
+		SELECT s_id, flow_id, name, cron, timezone, enabled, overlap_policy, catch_up, next_run_at, queued_at,
+		       last_run_id, last_run_at, last_error, run_as, created_at, updated_at
+		FROM flow_schedules`
+
+func (r *FlowScheduleRepository) FindByID(id int64) (*models.FlowSchedule, error) {
+	schedules, err := r.query(flowScheduleColumns+"\n\t\tWHERE s_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrFlowScheduleNotFound
+	}
+	return schedules[0], nil
+}
+
+// FindByFlow returns the schedules of a flow
+func (r *FlowScheduleRepository) FindByFlow(flowID int64) ([]*models.FlowSchedule, error) {
+	return r.query(flowScheduleColumns+"\n\t\tWHERE flow_id = $1\n\t\tORDER BY s_id", flowID)
+}
+
+// FindAll returns every schedule
+func (r *FlowScheduleRepository) FindAll() ([]*models.FlowSchedule, error) {
+	return r.query(flowScheduleColumns + "\n\t\tORDER BY s_id")
+}
+
+// FindAccessible returns the schedules of the flows userID may see
+func (r *FlowScheduleRepository) FindAccessible(userID int64) ([]*models.FlowSchedule, error) {
+	return r.query(flowScheduleColumns+`
+		WHERE flow_id IN (
+			SELECT f_id FROM flows
+			WHERE (project_id IS NULL AND created_by = $1)
+			   OR project_id IN (SELECT project_id FROM project_members WHERE user_id = $1)
+		)
+		ORDER BY s_id`, userID)
+}
+
+// FindDue returns the enabled schedules that fire at or before now or have
+// a queued firing
+func (r *FlowScheduleRepository) FindDue(now time.Time) ([]*models.FlowSchedule, error) {
+	return r.query(flowScheduleColumns+`
+		WHERE enabled AND (next_run_at <= $1 OR queued_at IS NOT NULL)
+		ORDER BY COALESCE(queued_at, next_run_at), s_id`, now)
+}
+
+// Update stores the settings of a schedule and its next firing
+func (r *FlowScheduleRepository) Update(s *models.FlowSchedule) error {
+	query := `
+		UPDATE flow_schedules
+		SET name = $1, cron = $2, timezone = $3, enabled = $4, overlap_policy = $5, catch_up = $6,
+		    next_run_at = $7, queued_at = $8, run_as = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE s_id = $10
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(query, s.Name, s.Cron, s.Timezone, s.Enabled, s.OverlapPolicy, s.CatchUp,
+		s.NextRunAt, s.QueuedAt, s.RunAs, s.ID,
+	).Scan(&s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrFlowScheduleNotFound
+	}
+	return err
+}
+
+// UpdateState stores the scheduler's bookkeeping of a schedule without
+// touching its settings
+func (r *FlowScheduleRepository) UpdateState(s *models.FlowSchedule) error {
+	query := `
+		UPDATE flow_schedules
+		SET next_run_at = $1, queued_at = $2, last_run_id = $3, last_run_at = $4, last_error = $5
+		WHERE s_id = $6
+	`
+	_, err := r.db.Exec(query, s.NextRunAt, s.QueuedAt, s.LastRunID, s.LastRunAt, s.LastError, s.ID)
+	return err
+}
+
+// ClaimFiring stores the next_run_at and queued_at the scheduler moved a
+// schedule to, provided they are still next and queued as it read them. It
+// reports false when another scheduler or an update got there first.
+func (r *FlowScheduleRepository) ClaimFiring(s *models.FlowSchedule, next, queued *time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE flow_schedules SET next_run_at = $1, queued_at = $2
+		WHERE s_id = $3 AND next_run_at IS NOT DISTINCT FROM $4 AND queued_at IS NOT DISTINCT FROM $5
+	`, s.NextRunAt, s.QueuedAt, s.ID, next, queued)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *FlowScheduleRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM flow_schedules WHERE s_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrFlowScheduleNotFound
+	}
+	return nil
+}
+
+func (r *FlowScheduleRepository) query(query string, args ...interface{}) ([]*models.FlowSchedule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.FlowSchedule{}
+	for rows.Next() {
+		s := &models.FlowSchedule{}
+		err := rows.Scan(
+			&s.ID, &s.FlowID, &s.Name, &s.Cron, &s.Timezone, &s.Enabled, &s.OverlapPolicy, &s.CatchUp,
+			&s.NextRunAt, &s.QueuedAt, &s.LastRunID, &s.LastRunAt, &s.LastError, &s.RunAs,
+			&s.CreatedAt, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
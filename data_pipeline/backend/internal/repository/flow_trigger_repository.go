@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrFlowTriggerNotFound = errors.New("flow trigger not found")
+)
+
+type FlowTriggerRepository struct {
+	db *sql.DB
+}
+
+func NewFlowTriggerRepository(db *sql.DB) *FlowTriggerRepository {
+	return &FlowTriggerRepository{db: db}
+}
+
+func (r *FlowTriggerRepository) Create(t *models.FlowTrigger) error {
+	query := `
+		INSERT INTO flow_triggers (flow_id, name, kind, enabled, config, max_active, secret, state, run_as)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING t_id, created_at, updated_at
+	`
+	return r.db.QueryRow(query, t.FlowID, t.Name, t.Kind, t.Enabled, jsonColumn(t.Config), t.MaxActive, t.Secret,
+		jsonColumn(t.State), t.RunAs,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+const flowTriggerColumns = `
+		SELECT t_id, flow_id, name, kind, enabled, config, max_active, secret, state, last_fired_at, last_error,
+		       run_as, created_at, updated_at
+		FROM flow_triggers`
+
+func (r *FlowTriggerRepository) FindByID(id int64) (*models.FlowTrigger, error) {
+	triggers, err := r.query(flowTriggerColumns+"\n\t\tWHERE t_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(triggers) == 0 {
+		return nil, ErrFlowTriggerNotFound
+	}
+	return triggers[0], nil
+}
+
+// FindByFlow returns the triggers of a flow
+func (r *FlowTriggerRepository) FindByFlow(flowID int64) ([]*models.FlowTrigger, error) {
+	return r.query(flowTriggerColumns+"\n\t\tWHERE flow_id = $1\n\t\tORDER BY t_id", flowID)
+}
+
+// FindAll returns every trigger
+func (r *FlowTriggerRepository) FindAll() ([]*models.FlowTrigger, error) {
+	return r.query(flowTriggerColumns + "\n\t\tORDER BY t_id")
+}
+
+// FindAccessible returns the triggers of the flows userID may see
+func (r *FlowTriggerRepository) FindAccessible(userID int64) ([]*models.FlowTrigger, error) {
+	return r.query(flowTriggerColumns+`
+		WHERE flow_id IN (
+			SELECT f_id FROM flows
+			WHERE (project_id IS NULL AND created_by = $1)
+			   OR project_id IN (SELECT project_id FROM project_members WHERE user_id = $1)
+		)
+		ORDER BY t_id`, userID)
+}
+
+// FindEnabled returns the enabled triggers of a kind
+func (r *FlowTriggerRepository) FindEnabled(kind string) ([]*models.FlowTrigger, error) {
+	return r.query(flowTriggerColumns+"\n\t\tWHERE enabled AND kind = $1\n\t\tORDER BY t_id", kind)
+}
+
+// Update stores the settings of a trigger
+func (r *FlowTriggerRepository) Update(t *models.FlowTrigger) error {
+	query := `
+		UPDATE flow_triggers
+		SET name = $1, enabled = $2, config = $3, max_active = $4, secret = $5, state = $6, run_as = $7,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE t_id = $8
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(query, t.Name, t.Enabled, jsonColumn(t.Config), t.MaxActive, t.Secret, jsonColumn(t.State),
+		t.RunAs, t.ID,
+	).Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrFlowTriggerNotFound
+	}
+	return err
+}
+
+// UpdateState stores the watcher's bookkeeping of a trigger
+func (r *FlowTriggerRepository) UpdateState(id int64, state json.RawMessage) error {
+	_, err := r.db.Exec(`UPDATE flow_triggers SET state = $1 WHERE t_id = $2`, jsonColumn(state), id)
+	return err
+}
+
+// UpdateStatus stores when a trigger last fired and why it last failed
+func (r *FlowTriggerRepository) UpdateStatus(id int64, lastFiredAt *time.Time, lastError *string) error {
+	_, err := r.db.Exec(`
+		UPDATE flow_triggers SET last_fired_at = COALESCE($1, last_fired_at), last_error = $2
+		WHERE t_id = $3
+	`, lastFiredAt, lastError, id)
+	return err
+}
+
+func (r *FlowTriggerRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM flow_triggers WHERE t_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrFlowTriggerNotFound
+	}
+	return nil
+}
+
+// CreateFiring records an event a trigger received
+func (r *FlowTriggerRepository) CreateFiring(f *models.TriggerFiring) error {
+	var payload interface{}
+	if len(f.Payload) > 0 {
+		payload = []byte(f.Payload)
+	}
+	query := `
+		INSERT INTO trigger_firings (trigger_id, run_id, status, source, payload, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING fi_id, fired_at
+	`
+	return r.db.QueryRow(query, f.TriggerID, f.RunID, f.Status, f.Source, payload, f.Error).Scan(&f.ID, &f.FiredAt)
+}
+
+// ClaimDelivery records a webhook delivery of a trigger and reports whether
+// it is new. Deliveries older than keep are forgotten first.
+func (r *FlowTriggerRepository) ClaimDelivery(triggerID int64, deliveryID string, keep time.Duration) (bool, error) {
+	_, err := r.db.Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE trigger_id = $1 AND received_at < CURRENT_TIMESTAMP - make_interval(secs => $2)
+	`, triggerID, keep.Seconds())
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (trigger_id, delivery_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, triggerID, deliveryID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// FindFirings returns the latest firings of a trigger, newest first
+func (r *FlowTriggerRepository) FindFirings(triggerID int64, limit, offset int) ([]*models.TriggerFiring, error) {
+	rows, err := r.db.Query(`
+		SELECT fi_id, trigger_id, run_id, status, source, payload, error, fired_at
+		FROM trigger_firings
+		WHERE trigger_id = $1
+		ORDER BY fi_id DESC
+		LIMIT $2 OFFSET $3
+	`, triggerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	firings := []*models.TriggerFiring{}
+	for rows.Next() {
+		f := &models.TriggerFiring{}
+		var payload []byte
+		if err := rows.Scan(&f.ID, &f.TriggerID, &f.RunID, &f.Status, &f.Source, &payload, &f.Error, &f.FiredAt); err != nil {
+			return nil, err
+		}
+		if len(payload) > 0 {
+			f.Payload = json.RawMessage(payload)
+		}
+		firings = append(firings, f)
+	}
+	return firings, rows.Err()
+}
+
+func (r *FlowTriggerRepository) query(query string, args ...interface{}) ([]*models.FlowTrigger, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	triggers := []*models.FlowTrigger{}
+	for rows.Next() {
+		t := &models.FlowTrigger{}
+		var config, state []byte
+		err := rows.Scan(
+			&t.ID, &t.FlowID, &t.Name, &t.Kind, &t.Enabled, &config, &t.MaxActive, &t.Secret, &state,
+			&t.LastFiredAt, &t.LastError, &t.RunAs, &t.CreatedAt, &t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		t.Config, t.State = json.RawMessage(config), json.RawMessage(state)
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+// jsonColumn is the value of a NOT NULL JSONB column; empty is {}
+func jsonColumn(v json.RawMessage) []byte {
+	if len(v) == 0 {
+		return []byte("{}")
+	}
+	return []byte(v)
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrFlowVersionNotFound = errors.New("flow version not found")
+)
+
+type FlowVersionRepository struct {
+	db DBTX
+}
+
+func NewFlowVersionRepository(db *sql.DB) *FlowVersionRepository {
+	return &FlowVersionRepository{db: db}
+}
+
+// WithTx returns the repository running its statements in tx
+func (r *FlowVersionRepository) WithTx(tx DBTX) *FlowVersionRepository {
+	return &FlowVersionRepository{db: tx}
+}
+
+// InTx runs fn in a transaction of the repository's database
+func (r *FlowVersionRepository) InTx(fn func(tx DBTX) error) error {
+	return InTx(r.db, fn)
+}
+
+// Create inserts a new version and assigns the next version number of the
+// flow. The flow row stays locked until the transaction ends so concurrent
+// saves number their versions one after the other.
+func (r *FlowVersionRepository) Create(v *models.FlowVersion) error {
+	query := `
+		INSERT INTO flow_versions (flow, version, reason, snapshot, snapshot_hash, deploy_options,
+		                           deploy_status, deploy_message, parent_version, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9
+		FROM flow_versions WHERE flow = $1
+		RETURNING v_id, version, created_at
+	`
+
+	var deployOptions interface{}
+	if len(v.DeployOptions) > 0 {
+		deployOptions = []byte(v.DeployOptions)
+	}
+
+	return InTx(r.db, func(tx DBTX) error {
+		// MAX(version)+1이 겹치지 않도록 flow 행을 잠근다
+		if _, err := tx.Exec(`SELECT 1 FROM flows WHERE f_id = $1 FOR UPDATE`, v.FlowID); err != nil {
+			return err
+		}
+		return tx.QueryRow(query,
+			v.FlowID,
+			v.Reason,
+			[]byte(v.Snapshot),
+			v.SnapshotHash,
+			deployOptions,
+			v.DeployStatus,
+			v.DeployMessage,
+			v.ParentVersion,
+			v.CreatedBy,
+		).Scan(&v.ID, &v.Version, &v.CreatedAt)
+	})
+}
+
+func (r *FlowVersionRepository) FindByVersion(flowID int64, version int) (*models.FlowVersion, error) {
+	query := `
+		SELECT v_id, flow, version, reason, snapshot, snapshot_hash, deploy_options,
+		       deploy_status, deploy_message, parent_version, created_by, created_at
+		FROM flow_versions
+		WHERE flow = $1 AND version = $2
+	`
+	return r.scanOne(r.db.QueryRow(query, flowID, version))
+}
+
+// FindLatest returns the most recent version of a flow
+func (r *FlowVersionRepository) FindLatest(flowID int64) (*models.FlowVersion, error) {
+	query := `
+		SELECT v_id, flow, version, reason, snapshot, snapshot_hash, deploy_options,
+		       deploy_status, deploy_message, parent_version, created_by, created_at
+		FROM flow_versions
+		WHERE flow = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	return r.scanOne(r.db.QueryRow(query, flowID))
+}
+
+func (r *FlowVersionRepository) FindByFlow(flowID int64, limit, offset int) ([]*models.FlowVersion, error) {
+	query := `
+		SELECT v_id, flow, version, reason, snapshot, snapshot_hash, deploy_options,
+		       deploy_status, deploy_message, parent_version, created_by, created_at
+		FROM flow_versions
+		WHERE flow = $1
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, flowID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*models.FlowVersion
+	for rows.Next() {
+		v, err := r.scanOne(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *FlowVersionRepository) scanOne(row rowScanner) (*models.FlowVersion, error) {
+	v := &models.FlowVersion{}
+	var snapshot string
+	var deployOptions, deployStatus, deployMessage sql.NullString
+	var parentVersion sql.NullInt64
+	var createdBy sql.NullInt64
+
+	err := row.Scan(
+		&v.ID, &v.FlowID, &v.Version, &v.Reason, &snapshot, &v.SnapshotHash, &deployOptions,
+		&deployStatus, &deployMessage, &parentVersion, &createdBy, &v.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrFlowVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v.Snapshot = json.RawMessage(snapshot)
+	if deployOptions.Valid {
+		v.DeployOptions = json.RawMessage(deployOptions.String)
+	}
+	if deployStatus.Valid {
+		v.DeployStatus = &deployStatus.String
+	}
+	if deployMessage.Valid {
+		v.DeployMessage = &deployMessage.String
+	}
+	if parentVersion.Valid {
+		pv := int(parentVersion.Int64)
+		v.ParentVersion = &pv
+	}
+	if createdBy.Valid {
+		id := createdBy.Int64
+		v.CreatedBy = &id
+	}
+
+	return v, nil
+}
@@ -12,13 +12,23 @@ var (
 )
 
 type ObjectRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewObjectRepository(db *sql.DB) *ObjectRepository {
 	return &ObjectRepository{db: db}
 }
 
+// WithTx returns the repository running its statements in tx
+func (r *ObjectRepository) WithTx(tx DBTX) *ObjectRepository {
+	return &ObjectRepository{db: tx}
+}
+
+// InTx runs fn in a transaction of the repository's database
+func (r *ObjectRepository) InTx(fn func(tx DBTX) error) error {
+	return InTx(r.db, fn)
+}
+
 func (r *ObjectRepository) Create(object *models.Object) error {
 	query := `
 		INSERT INTO objects (type, x, y, label, params, target, flow)
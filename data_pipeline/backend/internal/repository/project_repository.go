@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrProjectNotFound       = errors.New("project not found")
+	ErrProjectMemberNotFound = errors.New("project member not found")
+)
+
+type ProjectRepository struct {
+	db *sql.DB
+}
+
+func NewProjectRepository(db *sql.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+// Create inserts the project and makes its creator the first admin
+func (r *ProjectRepository) Create(project *models.Project) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO projects (name, description, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING p_id, created_at
+	`, project.Name, project.Description, project.CreatedBy).Scan(&project.ID, &project.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if project.CreatedBy != nil {
+		_, err = tx.Exec(`
+			INSERT INTO project_members (project_id, user_id, role)
+			VALUES ($1, $2, $3)
+		`, project.ID, *project.CreatedBy, models.ProjectRoleAdmin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ProjectRepository) FindByID(id int64) (*models.Project, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT p_id, name, description, created_by, created_at
+		FROM projects
+		WHERE p_id = $1
+	`, id))
+}
+
+func (r *ProjectRepository) FindByName(name string) (*models.Project, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT p_id, name, description, created_by, created_at
+		FROM projects
+		WHERE name = $1
+	`, name))
+}
+
+func (r *ProjectRepository) scanOne(row *sql.Row) (*models.Project, error) {
+	project := &models.Project{}
+	var description sql.NullString
+	var createdBy sql.NullInt64
+
+	err := row.Scan(&project.ID, &project.Name, &description, &createdBy, &project.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		project.Description = &description.String
+	}
+	if createdBy.Valid {
+		id := createdBy.Int64
+		project.CreatedBy = &id
+	}
+	return project, nil
+}
+
+func (r *ProjectRepository) FindAll() ([]*models.Project, error) {
+	rows, err := r.db.Query(`
+		SELECT p_id, name, description, created_by, created_at, ''
+		FROM projects
+		ORDER BY p_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanProjects(rows)
+}
+
+// FindByMember returns the projects of a user with Role set to its role in each
+func (r *ProjectRepository) FindByMember(userID int64) ([]*models.Project, error) {
+	rows, err := r.db.Query(`
+		SELECT p.p_id, p.name, p.description, p.created_by, p.created_at, m.role
+		FROM projects p
+		JOIN project_members m ON m.project_id = p.p_id
+		WHERE m.user_id = $1
+		ORDER BY p.p_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanProjects(rows)
+}
+
+func scanProjects(rows *sql.Rows) ([]*models.Project, error) {
+	defer rows.Close()
+
+	var projects []*models.Project
+	for rows.Next() {
+		project := &models.Project{}
+		var description sql.NullString
+		var createdBy sql.NullInt64
+
+		if err := rows.Scan(&project.ID, &project.Name, &description, &createdBy, &project.CreatedAt, &project.Role); err != nil {
+			return nil, err
+		}
+		if description.Valid {
+			project.Description = &description.String
+		}
+		if createdBy.Valid {
+			id := createdBy.Int64
+			project.CreatedBy = &id
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *ProjectRepository) Update(project *models.Project) error {
+	result, err := r.db.Exec(`
+		UPDATE projects SET name = $1, description = $2
+		WHERE p_id = $3
+	`, project.Name, project.Description, project.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *ProjectRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM projects WHERE p_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+// FindMemberRole returns the role of a user in a project
+func (r *ProjectRepository) FindMemberRole(projectID, userID int64) (string, error) {
+	var role string
+	err := r.db.QueryRow(`
+		SELECT role FROM project_members
+		WHERE project_id = $1 AND user_id = $2
+	`, projectID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrProjectMemberNotFound
+	}
+	return role, err
+}
+
+func (r *ProjectRepository) ListMembers(projectID int64) ([]*models.ProjectMember, error) {
+	rows, err := r.db.Query(`
+		SELECT m.project_id, m.user_id, u.username, m.role, m.added_at
+		FROM project_members m
+		JOIN users u ON u.u_id = m.user_id
+		WHERE m.project_id = $1
+		ORDER BY u.username
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.ProjectMember
+	for rows.Next() {
+		member := &models.ProjectMember{}
+		if err := rows.Scan(&member.ProjectID, &member.UserID, &member.Username, &member.Role, &member.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetMember adds a member or changes its role
+func (r *ProjectRepository) SetMember(projectID, userID int64, role string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO project_members (project_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, projectID, userID, role)
+	return err
+}
+
+func (r *ProjectRepository) DeleteMember(projectID, userID int64) error {
+	result, err := r.db.Exec(`
+		DELETE FROM project_members
+		WHERE project_id = $1 AND user_id = $2
+	`, projectID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrProjectMemberNotFound
+	}
+	return nil
+}
+
+// CountAdmins returns the number of admins of a project
+func (r *ProjectRepository) CountAdmins(projectID int64) (int, error) {
+	var n int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM project_members
+		WHERE project_id = $1 AND role = $2
+	`, projectID, models.ProjectRoleAdmin).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrStepImageNotFound = errors.New("step image not found")
+)
+
+type StepImageRepository struct {
+	db *sql.DB
+}
+
+func NewStepImageRepository(db *sql.DB) *StepImageRepository {
+	return &StepImageRepository{db: db}
+}
+
+func (r *StepImageRepository) FindByKey(depKey string) (*models.StepImage, error) {
+	query := `
+		SELECT i_id, dep_key, base_image, requirements, image, digest, status, log, created_at, updated_at
+		FROM step_images
+		WHERE dep_key = $1
+	`
+
+	img := &models.StepImage{}
+	var requirements []byte
+	var digest, log sql.NullString
+	err := r.db.QueryRow(query, depKey).Scan(
+		&img.ID,
+		&img.DepKey,
+		&img.BaseImage,
+		&requirements,
+		&img.Image,
+		&digest,
+		&img.Status,
+		&log,
+		&img.CreatedAt,
+		&img.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrStepImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img.Requirements = requirements
+	if digest.Valid {
+		img.Digest = &digest.String
+	}
+	if log.Valid {
+		img.Log = &log.String
+	}
+	return img, nil
+}
+
+// Upsert records a build of the image keyed by dep_key
+func (r *StepImageRepository) Upsert(img *models.StepImage) error {
+	query := `
+		INSERT INTO step_images (dep_key, base_image, requirements, image, digest, status, log)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (dep_key) DO UPDATE
+		SET image = EXCLUDED.image, digest = EXCLUDED.digest, status = EXCLUDED.status,
+		    log = EXCLUDED.log, updated_at = CURRENT_TIMESTAMP
+		RETURNING i_id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(query,
+		img.DepKey,
+		img.BaseImage,
+		jsonOrDefault(img.Requirements),
+		img.Image,
+		img.Digest,
+		img.Status,
+		img.Log,
+	).Scan(&img.ID, &img.CreatedAt, &img.UpdatedAt)
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrTraceNotFound = errors.New("trace not found")
+)
+
+type TraceRepository struct {
+	db *sql.DB
+}
+
+func NewTraceRepository(db *sql.DB) *TraceRepository {
+	return &TraceRepository{db: db}
+}
+
+func (r *TraceRepository) Create(s *models.TraceSpan) error {
+	query := `
+		INSERT INTO trace_spans (flow, trace_id, event_id, step, producer, in_type, hops, items,
+		                         emitted, status, error, started_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING s_id, created_at
+	`
+
+	return r.db.QueryRow(query,
+		s.FlowID,
+		s.TraceID,
+		s.EventID,
+		s.Step,
+		s.Producer,
+		s.InType,
+		s.Hops,
+		s.Items,
+		jsonOrDefault(s.Emitted),
+		s.Status,
+		s.Error,
+		s.StartedAt,
+		s.DurationMs,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+// FindByTrace returns the spans of one trace ordered by start time
+func (r *TraceRepository) FindByTrace(flowID int64, traceID string) ([]*models.TraceSpan, error) {
+	query := `
+		SELECT s_id, flow, trace_id, event_id, step, producer, in_type, hops, items,
+		       emitted, status, error, started_at, duration_ms, created_at
+		FROM trace_spans
+		WHERE flow = $1 AND trace_id = $2
+		ORDER BY started_at, s_id
+	`
+
+	rows, err := r.db.Query(query, flowID, traceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spans []*models.TraceSpan
+	for rows.Next() {
+		s := &models.TraceSpan{}
+		var emitted string
+		var producer, inType, spanErr sql.NullString
+
+		err := rows.Scan(
+			&s.ID, &s.FlowID, &s.TraceID, &s.EventID, &s.Step, &producer, &inType, &s.Hops, &s.Items,
+			&emitted, &s.Status, &spanErr, &s.StartedAt, &s.DurationMs, &s.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Emitted = json.RawMessage(emitted)
+		if producer.Valid {
+			s.Producer = &producer.String
+		}
+		if inType.Valid {
+			s.InType = &inType.String
+		}
+		if spanErr.Valid {
+			s.Error = &spanErr.String
+		}
+		spans = append(spans, s)
+	}
+
+	return spans, rows.Err()
+}
+
+// ListTraces returns the most recent traces of a flow
+func (r *TraceRepository) ListTraces(flowID int64, limit, offset int) ([]*models.TraceSummaryDTO, error) {
+	query := `
+		SELECT trace_id,
+		       MIN(started_at),
+		       EXTRACT(EPOCH FROM (MAX(started_at + duration_ms * INTERVAL '1 millisecond') - MIN(started_at))) * 1000,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE status <> 'ok')
+		FROM trace_spans
+		WHERE flow = $1
+		GROUP BY trace_id
+		ORDER BY MIN(started_at) DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, flowID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	traces := []*models.TraceSummaryDTO{}
+	for rows.Next() {
+		t := &models.TraceSummaryDTO{}
+		if err := rows.Scan(&t.TraceID, &t.StartedAt, &t.DurationMs, &t.Spans, &t.Errors); err != nil {
+			return nil, err
+		}
+		traces = append(traces, t)
+	}
+
+	return traces, rows.Err()
+}
@@ -30,8 +30,9 @@ func NewTrainingRepository(db *sql.DB) *TrainingRepository {
 
 func (r *TrainingRepository) CreateTrainingRun(run *models.TrainingRun) error {
 	query := `
-		INSERT INTO training_runs (name, description, status, base_model, model_type, hyperparameters, dataset_id, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO training_runs (name, description, status, base_model, model_type, hyperparameters, dataset_id, created_by,
+		                           project_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING run_id, created_at
 	`
 	return r.db.QueryRow(query,
@@ -43,6 +44,7 @@ func (r *TrainingRepository) CreateTrainingRun(run *models.TrainingRun) error {
 		run.Hyperparameters,
 		run.DatasetID,
 		run.CreatedBy,
+		run.ProjectID,
 	).Scan(&run.RunID, &run.CreatedAt)
 }
 
@@ -50,7 +52,7 @@ func (r *TrainingRepository) GetTrainingRun(id int64) (*models.TrainingRun, erro
 	query := `
 		SELECT run_id, name, description, status, base_model, model_type, hyperparameters,
 		       dataset_id, dataset_version, started_at, completed_at, duration_seconds,
-		       output_model_path, metrics, error_message, gpu_info, created_at, created_by
+		       output_model_path, metrics, error_message, gpu_info, created_at, created_by, project_id
 		FROM training_runs WHERE run_id = $1
 	`
 	run := &models.TrainingRun{}
@@ -60,7 +62,7 @@ func (r *TrainingRepository) GetTrainingRun(id int64) (*models.TrainingRun, erro
 		&run.RunID, &run.Name, &run.Description, &run.Status, &run.BaseModel, &run.ModelType,
 		&hyperparameters, &run.DatasetID, &run.DatasetVersion, &run.StartedAt, &run.CompletedAt,
 		&run.DurationSeconds, &run.OutputModelPath, &metrics, &run.ErrorMessage, &gpuInfo,
-		&run.CreatedAt, &run.CreatedBy,
+		&run.CreatedAt, &run.CreatedBy, &run.ProjectID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrTrainingRunNotFound
@@ -82,15 +84,18 @@ func (r *TrainingRepository) GetTrainingRun(id int64) (*models.TrainingRun, erro
 	return run, nil
 }
 
-func (r *TrainingRepository) ListTrainingRuns(limit, offset int) ([]*models.TrainingRun, error) {
+// ListTrainingRuns lists training runs; with visibleTo only those the user
+// can see (see visibleToClause)
+func (r *TrainingRepository) ListTrainingRuns(limit, offset int, visibleTo *int64) ([]*models.TrainingRun, error) {
 	query := `
 		SELECT run_id, name, description, status, base_model, model_type, hyperparameters,
-		       dataset_id, started_at, completed_at, duration_seconds, metrics, created_at
+		       dataset_id, started_at, completed_at, duration_seconds, metrics, created_at, created_by, project_id
 		FROM training_runs
+		WHERE ` + visibleToClause + `
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.Query(query, limit, offset, visibleTo)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +109,7 @@ func (r *TrainingRepository) ListTrainingRuns(limit, offset int) ([]*models.Trai
 		err := rows.Scan(
 			&run.RunID, &run.Name, &run.Description, &run.Status, &run.BaseModel, &run.ModelType,
 			&hyperparameters, &run.DatasetID, &run.StartedAt, &run.CompletedAt,
-			&run.DurationSeconds, &metrics, &run.CreatedAt,
+			&run.DurationSeconds, &metrics, &run.CreatedAt, &run.CreatedBy, &run.ProjectID,
 		)
 		if err != nil {
 			return nil, err
@@ -264,24 +269,32 @@ func (r *TrainingRepository) MarkFeedbacksAsUsed(datasetID int64, feedbackIDs []
 // Datasets
 // ============================================================
 
+// visibleToClause filters training runs, datasets and models by $3, a user
+// ID or NULL for all rows: shared rows (no project, no creator), the user's
+// own rows without a project and rows of the user's projects.
+const visibleToClause = `($3::BIGINT IS NULL
+		OR (project_id IS NULL AND (created_by IS NULL OR created_by = $3))
+		OR project_id IN (SELECT project_id FROM project_members WHERE user_id = $3))`
+
 func (r *TrainingRepository) CreateDataset(ds *models.Dataset) error {
 	query := `
 		INSERT INTO datasets (name, version, description, total_samples, train_samples, eval_samples, test_samples,
-		                      data_path, train_file, eval_file, test_file, config, status, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		                      data_path, train_file, eval_file, test_file, config, status, created_by, project_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING dataset_id, created_at
 	`
 	return r.db.QueryRow(query,
 		ds.Name, ds.Version, ds.Description, ds.TotalSamples, ds.TrainSamples, ds.EvalSamples,
 		ds.TestSamples, ds.DataPath, ds.TrainFile, ds.EvalFile, ds.TestFile, ds.Config,
-		ds.Status, ds.CreatedBy,
+		ds.Status, ds.CreatedBy, ds.ProjectID,
 	).Scan(&ds.DatasetID, &ds.CreatedAt)
 }
 
 func (r *TrainingRepository) GetDataset(id int64) (*models.Dataset, error) {
 	query := `
 		SELECT dataset_id, name, version, description, total_samples, train_samples, eval_samples,
-		       test_samples, data_path, train_file, eval_file, test_file, config, status, is_active, created_at
+		       test_samples, data_path, train_file, eval_file, test_file, config, status, is_active, created_at,
+		       created_by, project_id
 		FROM datasets WHERE dataset_id = $1
 	`
 	ds := &models.Dataset{}
@@ -291,6 +304,7 @@ func (r *TrainingRepository) GetDataset(id int64) (*models.Dataset, error) {
 		&ds.DatasetID, &ds.Name, &ds.Version, &ds.Description, &ds.TotalSamples,
 		&ds.TrainSamples, &ds.EvalSamples, &ds.TestSamples, &ds.DataPath,
 		&ds.TrainFile, &ds.EvalFile, &ds.TestFile, &config, &ds.Status, &ds.IsActive, &ds.CreatedAt,
+		&ds.CreatedBy, &ds.ProjectID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrDatasetNotFound
@@ -306,15 +320,18 @@ func (r *TrainingRepository) GetDataset(id int64) (*models.Dataset, error) {
 	return ds, nil
 }
 
-func (r *TrainingRepository) ListDatasets(limit, offset int) ([]*models.Dataset, error) {
+// ListDatasets lists datasets; with visibleTo only those the user can see
+// (see visibleToClause)
+func (r *TrainingRepository) ListDatasets(limit, offset int, visibleTo *int64) ([]*models.Dataset, error) {
 	query := `
 		SELECT dataset_id, name, version, description, total_samples, train_samples, eval_samples,
-		       test_samples, status, is_active, created_at
+		       test_samples, status, is_active, created_at, created_by, project_id
 		FROM datasets
+		WHERE ` + visibleToClause + `
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.Query(query, limit, offset, visibleTo)
 	if err != nil {
 		return nil, err
 	}
@@ -326,6 +343,7 @@ func (r *TrainingRepository) ListDatasets(limit, offset int) ([]*models.Dataset,
 		err := rows.Scan(
 			&ds.DatasetID, &ds.Name, &ds.Version, &ds.Description, &ds.TotalSamples,
 			&ds.TrainSamples, &ds.EvalSamples, &ds.TestSamples, &ds.Status, &ds.IsActive, &ds.CreatedAt,
+			&ds.CreatedBy, &ds.ProjectID,
 		)
 		if err != nil {
 			return nil, err
@@ -371,13 +389,13 @@ func (r *TrainingRepository) SetActiveDataset(id int64) error {
 func (r *TrainingRepository) CreateModel(m *models.Model) error {
 	query := `
 		INSERT INTO models (name, version, description, base_model, model_type, model_path,
-		                    adapter_path, training_run_id, dataset_id, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		                    adapter_path, training_run_id, dataset_id, created_by, project_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING model_id, created_at
 	`
 	return r.db.QueryRow(query,
 		m.Name, m.Version, m.Description, m.BaseModel, m.ModelType, m.ModelPath,
-		m.AdapterPath, m.TrainingRunID, m.DatasetID, m.CreatedBy,
+		m.AdapterPath, m.TrainingRunID, m.DatasetID, m.CreatedBy, m.ProjectID,
 	).Scan(&m.ModelID, &m.CreatedAt)
 }
 
@@ -386,7 +404,7 @@ func (r *TrainingRepository) GetModel(id int64) (*models.Model, error) {
 		SELECT model_id, name, version, description, base_model, model_type, model_path,
 		       adapter_path, merged_model_path, training_run_id, dataset_id, eval_metrics,
 		       benchmark_scores, is_active, is_production, deployed_at, ollama_model_name,
-		       ollama_imported, created_at
+		       ollama_imported, created_at, created_by, project_id
 		FROM models WHERE model_id = $1
 	`
 	m := &models.Model{}
@@ -396,7 +414,7 @@ func (r *TrainingRepository) GetModel(id int64) (*models.Model, error) {
 		&m.ModelID, &m.Name, &m.Version, &m.Description, &m.BaseModel, &m.ModelType,
 		&m.ModelPath, &m.AdapterPath, &m.MergedModelPath, &m.TrainingRunID, &m.DatasetID,
 		&evalMetrics, &benchmarkScores, &m.IsActive, &m.IsProduction, &m.DeployedAt,
-		&m.OllamaModelName, &m.OllamaImported, &m.CreatedAt,
+		&m.OllamaModelName, &m.OllamaImported, &m.CreatedAt, &m.CreatedBy, &m.ProjectID,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrModelNotFound
@@ -415,15 +433,17 @@ func (r *TrainingRepository) GetModel(id int64) (*models.Model, error) {
 	return m, nil
 }
 
-func (r *TrainingRepository) ListModels(limit, offset int) ([]*models.Model, error) {
+// ListModels lists models; with visibleTo only those the user can see
+func (r *TrainingRepository) ListModels(limit, offset int, visibleTo *int64) ([]*models.Model, error) {
 	query := `
 		SELECT model_id, name, version, description, base_model, model_type, model_path,
-		       is_active, is_production, deployed_at, ollama_model_name, created_at
+		       is_active, is_production, deployed_at, ollama_model_name, created_at, created_by, project_id
 		FROM models
+		WHERE ` + visibleToClause + `
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.Query(query, limit, offset, visibleTo)
 	if err != nil {
 		return nil, err
 	}
@@ -435,7 +455,7 @@ func (r *TrainingRepository) ListModels(limit, offset int) ([]*models.Model, err
 		err := rows.Scan(
 			&m.ModelID, &m.Name, &m.Version, &m.Description, &m.BaseModel, &m.ModelType,
 			&m.ModelPath, &m.IsActive, &m.IsProduction, &m.DeployedAt, &m.OllamaModelName,
-			&m.CreatedAt,
+			&m.CreatedAt, &m.CreatedBy, &m.ProjectID,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,30 @@
+package repository
+
+import "database/sql"
+
+// DBTX is what a repository runs its statements on: the database, or a
+// transaction the caller spans over several repositories
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// InTx runs fn in a new transaction of db, or directly in db when it already
+// is a transaction. The transaction commits when fn returns nil.
+func InTx(db DBTX, fn func(tx DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
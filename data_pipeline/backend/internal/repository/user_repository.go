@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) FindBySubject(subject string) (*models.User, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT u_id, subject, username, email, created_at, last_login_at
+		FROM users
+		WHERE subject = $1
+	`, subject))
+}
+
+func (r *UserRepository) FindByID(id int64) (*models.User, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT u_id, subject, username, email, created_at, last_login_at
+		FROM users
+		WHERE u_id = $1
+	`, id))
+}
+
+func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT u_id, subject, username, email, created_at, last_login_at
+		FROM users
+		WHERE username = $1
+	`, username))
+}
+
+func (r *UserRepository) Create(user *models.User) error {
+	query := `
+		INSERT INTO users (subject, username, email)
+		VALUES ($1, $2, $3)
+		RETURNING u_id, created_at, last_login_at
+	`
+
+	return r.db.QueryRow(query,
+		user.Subject,
+		user.Username,
+		user.Email,
+	).Scan(&user.ID, &user.CreatedAt, &user.LastLoginAt)
+}
+
+// TouchLogin records a request of the user and refreshes the email
+func (r *UserRepository) TouchLogin(user *models.User) error {
+	query := `
+		UPDATE users SET email = $1, last_login_at = CURRENT_TIMESTAMP
+		WHERE u_id = $2
+		RETURNING last_login_at
+	`
+	return r.db.QueryRow(query, user.Email, user.ID).Scan(&user.LastLoginAt)
+}
+
+func (r *UserRepository) scanOne(row *sql.Row) (*models.User, error) {
+	user := &models.User{}
+	var email sql.NullString
+	err := row.Scan(
+		&user.ID,
+		&user.Subject,
+		&user.Username,
+		&email,
+		&user.CreatedAt,
+		&user.LastLoginAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if email.Valid {
+		user.Email = &email.String
+	}
+	return user, nil
+}
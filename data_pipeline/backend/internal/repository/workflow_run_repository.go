@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"data-pipeline-backend/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWorkflowRunNotFound = errors.New("workflow run not found")
+)
+
+type WorkflowRunRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowRunRepository(db *sql.DB) *WorkflowRunRepository {
+	return &WorkflowRunRepository{db: db}
+}
+
+// Create inserts a pending run and its steps
+func (r *WorkflowRunRepository) Create(run *models.WorkflowRun) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	request := []byte(run.Request)
+	if len(request) == 0 {
+		request = []byte("{}")
+	}
+	err = tx.QueryRow(`
+		INSERT INTO workflow_runs (flow_id, project_id, schedule_id, trigger_id, created_by, actor, trigger, status, request)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING r_id, created_at
+	`, run.FlowID, run.ProjectID, run.ScheduleID, run.TriggerID, run.CreatedBy, run.Actor, run.Trigger, run.Status, request,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range run.Steps {
+		s.RunID = run.ID
+		_, err := tx.Exec(`
+			INSERT INTO workflow_run_steps (run_id, position, step_id, name, type, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, s.RunID, s.Position, s.StepID, s.Name, s.Type, s.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Start marks a run as running
+func (r *WorkflowRunRepository) Start(id int64, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE workflow_runs SET status = $1, started_at = $2, heartbeat_at = CURRENT_TIMESTAMP WHERE r_id = $3`,
+		models.WorkflowRunRunning, at, id)
+	return err
+}
+
+// UpdateStep stores the state of a step
+func (r *WorkflowRunRepository) UpdateStep(s *models.WorkflowRunStep) error {
+	query := `
+		UPDATE workflow_run_steps
+		SET status = $1, rows = $2, output_path = $3, message = $4, error = $5, logs = $6,
+		    started_at = $7, finished_at = $8, duration_ms = $9
+		WHERE run_id = $10 AND position = $11
+	`
+	_, err := r.db.Exec(query, s.Status, s.Rows, s.OutputPath, s.Message, s.Error, s.Logs,
+		s.StartedAt, s.FinishedAt, s.DurationMs, s.RunID, s.Position)
+	return err
+}
+
+// Finish records how a run ended
+func (r *WorkflowRunRepository) Finish(run *models.WorkflowRun) error {
+	query := `
+		UPDATE workflow_runs
+		SET status = $1, output_path = $2, error = $3, finished_at = $4, duration_ms = $5
+		WHERE r_id = $6
+	`
+	_, err := r.db.Exec(query, run.Status, run.OutputPath, run.Error, run.FinishedAt, run.DurationMs, run.ID)
+	return err
+}
+
+// Heartbeat marks runs as still executing and returns those of them that
+// were asked to stop
+func (r *WorkflowRunRepository) Heartbeat(ids []int64) ([]int64, error) {
+	rows, err := r.db.Query(`
+		UPDATE workflow_runs SET heartbeat_at = CURRENT_TIMESTAMP
+		WHERE r_id = ANY($1)
+		RETURNING r_id, cancel_requested
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cancelled []int64
+	for rows.Next() {
+		var id int64
+		var cancel bool
+		if err := rows.Scan(&id, &cancel); err != nil {
+			return nil, err
+		}
+		if cancel {
+			cancelled = append(cancelled, id)
+		}
+	}
+	return cancelled, rows.Err()
+}
+
+// RequestCancel asks the server executing a run to stop it
+func (r *WorkflowRunRepository) RequestCancel(id int64) error {
+	_, err := r.db.Exec(`UPDATE workflow_runs SET cancel_requested = TRUE WHERE r_id = $1`, id)
+	return err
+}
+
+// FindActiveBySchedule returns the IDs of the pending or running runs of a schedule
+func (r *WorkflowRunRepository) FindActiveBySchedule(scheduleID int64) ([]int64, error) {
+	return r.findActive("schedule_id", scheduleID)
+}
+
+// FindActiveByTrigger returns the IDs of the pending or running runs of a flow trigger
+func (r *WorkflowRunRepository) FindActiveByTrigger(triggerID int64) ([]int64, error) {
+	return r.findActive("trigger_id", triggerID)
+}
+
+// findActive returns the pending or running runs whose column is id
+func (r *WorkflowRunRepository) findActive(column string, id int64) ([]int64, error) {
+	rows, err := r.db.Query(`
+		SELECT r_id FROM workflow_runs
+		WHERE `+column+` = $1 AND status IN ($2, $3)
+		ORDER BY r_id
+	`, id, models.WorkflowRunPending, models.WorkflowRunRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FailStale fails the pending or running runs whose server stopped sending
+// heartbeats for longer than staleAfter, and their unfinished steps
+func (r *WorkflowRunRepository) FailStale(staleAfter time.Duration, reason string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		UPDATE workflow_runs SET status = $1, error = $2, finished_at = CURRENT_TIMESTAMP
+		WHERE status IN ($3, $4) AND heartbeat_at < CURRENT_TIMESTAMP - make_interval(secs => $5)
+		RETURNING r_id
+	`, models.WorkflowRunFailed, reason, models.WorkflowRunPending, models.WorkflowRunRunning, staleAfter.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE workflow_run_steps SET status = $1
+		WHERE run_id = ANY($2) AND status IN ($3, $4)
+	`, models.WorkflowStepSkipped, pq.Array(ids), models.WorkflowRunPending, models.WorkflowRunRunning)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), tx.Commit()
+}
+
+const workflowRunColumns = `
+		SELECT r_id, flow_id, project_id, schedule_id, trigger_id, created_by, actor, trigger, status, request,
+		       output_path, error, created_at, started_at, finished_at, duration_ms
+		FROM workflow_runs`
+
+// FindByID returns a run with its steps
+func (r *WorkflowRunRepository) FindByID(id int64) (*models.WorkflowRun, error) {
+	rows, err := r.db.Query(workflowRunColumns+"\n\t\tWHERE r_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := scanWorkflowRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrWorkflowRunNotFound
+	}
+	run := runs[0]
+
+	stepRows, err := r.db.Query(`
+		SELECT run_id, position, step_id, name, type, status, rows, output_path, message, error, logs,
+		       started_at, finished_at, duration_ms
+		FROM workflow_run_steps
+		WHERE run_id = $1
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer stepRows.Close()
+
+	run.Steps = []*models.WorkflowRunStep{}
+	for stepRows.Next() {
+		s := &models.WorkflowRunStep{}
+		err := stepRows.Scan(
+			&s.RunID, &s.Position, &s.StepID, &s.Name, &s.Type, &s.Status, &s.Rows, &s.OutputPath,
+			&s.Message, &s.Error, &s.Logs, &s.StartedAt, &s.FinishedAt, &s.DurationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		run.Steps = append(run.Steps, s)
+	}
+	return run, stepRows.Err()
+}
+
+// List returns the newest runs matching the filter, without their steps
+func (r *WorkflowRunRepository) List(f *models.WorkflowRunFilter, limit, offset int) ([]*models.WorkflowRun, error) {
+	query := workflowRunColumns + `
+		WHERE ($1::bigint IS NULL OR flow_id = $1)
+		  AND ($2 = '' OR status = $2)
+		  AND ($3 = '' OR trigger = $3)
+		  AND ($4::bigint IS NULL
+		       OR (project_id IS NULL AND created_by = $4)
+		       OR project_id IN (SELECT project_id FROM project_members WHERE user_id = $4))
+		ORDER BY created_at DESC, r_id DESC
+		LIMIT $5 OFFSET $6`
+
+	rows, err := r.db.Query(query, f.FlowID, f.Status, f.Trigger, f.UserID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanWorkflowRuns(rows)
+}
+
+func scanWorkflowRuns(rows *sql.Rows) ([]*models.WorkflowRun, error) {
+	defer rows.Close()
+
+	runs := []*models.WorkflowRun{}
+	for rows.Next() {
+		run := &models.WorkflowRun{}
+		var request []byte
+		err := rows.Scan(
+			&run.ID, &run.FlowID, &run.ProjectID, &run.ScheduleID, &run.TriggerID, &run.CreatedBy, &run.Actor, &run.Trigger, &run.Status,
+			&request, &run.OutputPath, &run.Error, &run.CreatedAt, &run.StartedAt, &run.FinishedAt,
+			&run.DurationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		run.Request = request
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -1,18 +1,49 @@
 package router
 
 import (
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/config"
 	"data-pipeline-backend/internal/handler"
 	"data-pipeline-backend/internal/middleware"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/service"
 	"database/sql"
+	"log"
 
 	"github.com/gorilla/mux"
 )
 
-func SetupRouter(db *sql.DB) *mux.Router {
+func SetupRouter(db *sql.DB) (*mux.Router, error) {
 	r := mux.NewRouter()
 	h := handler.NewHandler(db)
 
+	authCfg := config.Get().Auth
+	verifier, err := auth.NewVerifier(authCfg)
+	if err != nil {
+		return nil, err
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(config.Get().Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	var users middleware.UserResolver
+	var audit middleware.AuditRecorder
+	if db != nil {
+		users = service.NewUserService(repository.NewUserRepository(db), authCfg.UsernameClaim, authCfg.Admins)
+		audit = service.NewAuditService(repository.NewAuditRepository(db))
+	}
+	if verifier == nil {
+		log.Printf("WARNING: authentication is disabled (AUTH_MODE=none); every request runs as admin %q", authCfg.DevUser)
+	} else {
+		log.Printf("Authentication enabled (mode: %s)", authCfg.EffectiveMode())
+	}
+
 	r.Use(middleware.CORSMiddleware())
+	// 러너가 보내는 span 수집과 헬스 체크는 토큰 없이 허용; 웹훅은 HMAC 서명으로 확인
+	r.Use(middleware.AuthMiddleware(verifier, users, authCfg.DevUser, "/healthz", "/readyz", "/api/traces/spans", "/api/hooks/"))
+	// 변경 요청만 기록; 코드 실행/미리보기처럼 상태를 바꾸지 않는 POST는 제외 (웹훅은 trigger_firings에 남는다)
+	r.Use(middleware.AuditMiddleware(audit, trustedProxies, "/api/traces/spans", "/api/hooks/", "/api/python/", "/api/ai/generate",
+		"/api/data/load", "/api/data/preview", "/api/data/files", "/api/k8s/render"))
 
 	// Health check endpoints (before /api prefix)
 	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
@@ -20,12 +51,32 @@ func SetupRouter(db *sql.DB) *mux.Router {
 
 	api := r.PathPrefix("/api").Subrouter()
 
+	// Auth
+	api.HandleFunc("/auth/me", h.GetCurrentUser).Methods("GET")
+
+	// Audit log (non-admins see their own events)
+	api.HandleFunc("/audit", h.ListAuditEvents).Methods("GET")
+	api.HandleFunc("/audit/export", h.ExportAuditEvents).Methods("GET")
+
 	// Flows
 	api.HandleFunc("/flows", h.GetAllFlows).Methods("GET")
 	api.HandleFunc("/flows", h.CreateFlow).Methods("POST")
+	api.HandleFunc("/flows/import", h.ImportFlow).Methods("POST")
 	api.HandleFunc("/flows/{id}", h.GetFlow).Methods("GET")
 	api.HandleFunc("/flows/{id}", h.UpdateFlow).Methods("PUT")
 	api.HandleFunc("/flows/{id}", h.DeleteFlow).Methods("DELETE")
+	api.HandleFunc("/flows/{id}/graph", h.GetFlowGraph).Methods("GET")
+	api.HandleFunc("/flows/{id}/export", h.ExportFlow).Methods("GET")
+	api.HandleFunc("/flows/{id}/deployment", h.GetFlowDeployment).Methods("GET")
+	api.HandleFunc("/flows/{id}/logs/stream", h.StreamFlowLogs).Methods("GET")
+	api.HandleFunc("/flows/{id}/traces", h.ListFlowTraces).Methods("GET")
+	api.HandleFunc("/flows/{id}/traces/{traceId}", h.GetFlowTrace).Methods("GET")
+	api.HandleFunc("/flows/{id}/dlq", h.ListFlowDeadLetters).Methods("GET")
+	api.HandleFunc("/flows/{id}/dlq/replay", h.ReplayFlowDeadLetters).Methods("POST")
+	api.HandleFunc("/flows/{id}/versions", h.ListFlowVersions).Methods("GET")
+	api.HandleFunc("/flows/{id}/versions/diff", h.DiffFlowVersions).Methods("GET")
+	api.HandleFunc("/flows/{id}/versions/{version:[0-9]+}", h.GetFlowVersion).Methods("GET")
+	api.HandleFunc("/flows/{id}/versions/{version:[0-9]+}/rollback", h.RollbackFlowVersion).Methods("POST")
 
 	// Objects
 	api.HandleFunc("/objects", h.GetAllObjects).Methods("GET")
@@ -35,10 +86,33 @@ func SetupRouter(db *sql.DB) *mux.Router {
 	api.HandleFunc("/objects/{id}", h.DeleteObject).Methods("DELETE")
 	api.HandleFunc("/objects/flow/{flowId}", h.GetObjectsByFlow).Methods("GET")
 
+	// Projects (members: viewer < editor < deployer < admin)
+	api.HandleFunc("/projects", h.ListProjects).Methods("GET")
+	api.HandleFunc("/projects", h.CreateProject).Methods("POST")
+	api.HandleFunc("/projects/{id}", h.GetProject).Methods("GET")
+	api.HandleFunc("/projects/{id}", h.UpdateProject).Methods("PUT")
+	api.HandleFunc("/projects/{id}", h.DeleteProject).Methods("DELETE")
+	api.HandleFunc("/projects/{id}/members", h.ListProjectMembers).Methods("GET")
+	api.HandleFunc("/projects/{id}/members/{username}", h.SetProjectMember).Methods("PUT")
+	api.HandleFunc("/projects/{id}/members/{username}", h.RemoveProjectMember).Methods("DELETE")
+
+	// Connections (secret values are write-only)
+	api.HandleFunc("/connections", h.ListConnections).Methods("GET")
+	api.HandleFunc("/connections", h.CreateConnection).Methods("POST")
+	api.HandleFunc("/connections/{id}", h.GetConnection).Methods("GET")
+	api.HandleFunc("/connections/{id}", h.UpdateConnection).Methods("PUT")
+	api.HandleFunc("/connections/{id}", h.DeleteConnection).Methods("DELETE")
+
 	// K8s
 	api.HandleFunc("/k8s/deploy/stream", h.DeployStream).Methods("POST")
 	api.HandleFunc("/k8s/delete", h.DeleteK8sResources).Methods("DELETE")
 	api.HandleFunc("/k8s/test", h.UnitTest).Methods("POST")
+	api.HandleFunc("/k8s/render", h.RenderK8s).Methods("POST")
+	api.HandleFunc("/k8s/status", h.GetFlowRuntimeStatus).Methods("GET")
+	api.HandleFunc("/k8s/logs", h.GetFlowRuntimeLogs).Methods("GET")
+
+	// Trace span ingestion (runner)
+	api.HandleFunc("/traces/spans", h.IngestTraceSpans).Methods("POST")
 
 	// Jupyter (Python execution)
 	api.HandleFunc("/python/execute", h.ExecutePythonCode).Methods("POST")
@@ -59,6 +133,26 @@ func SetupRouter(db *sql.DB) *mux.Router {
 
 	// Workflow execution
 	api.HandleFunc("/workflow/execute", h.ExecuteWorkflow).Methods("POST")
+	api.HandleFunc("/workflow/runs", h.ListWorkflowRuns).Methods("GET")
+	api.HandleFunc("/workflow/runs/{id}", h.GetWorkflowRun).Methods("GET")
+	api.HandleFunc("/workflow/runs/{id}/events", h.StreamWorkflowRun).Methods("GET")
+	api.HandleFunc("/workflow/runs/{id}/cancel", h.CancelWorkflowRun).Methods("POST")
+
+	// Flow schedules (cron)
+	api.HandleFunc("/schedules", h.ListSchedules).Methods("GET")
+	api.HandleFunc("/schedules", h.CreateSchedule).Methods("POST")
+	api.HandleFunc("/schedules/{id}", h.GetSchedule).Methods("GET")
+	api.HandleFunc("/schedules/{id}", h.UpdateSchedule).Methods("PUT")
+	api.HandleFunc("/schedules/{id}", h.DeleteSchedule).Methods("DELETE")
+
+	// Flow triggers (file, webhook, kafka)
+	api.HandleFunc("/triggers", h.ListTriggers).Methods("GET")
+	api.HandleFunc("/triggers", h.CreateTrigger).Methods("POST")
+	api.HandleFunc("/triggers/{id}", h.GetTrigger).Methods("GET")
+	api.HandleFunc("/triggers/{id}", h.UpdateTrigger).Methods("PUT")
+	api.HandleFunc("/triggers/{id}", h.DeleteTrigger).Methods("DELETE")
+	api.HandleFunc("/triggers/{id}/firings", h.ListTriggerFirings).Methods("GET")
+	api.HandleFunc("/hooks/{id}", h.TriggerWebhook).Methods("POST")
 
 	// ============================================================
 	// ML Pipeline APIs
@@ -92,5 +186,5 @@ func SetupRouter(db *sql.DB) *mux.Router {
 	api.HandleFunc("/evaluations/run", h.RunEvaluation).Methods("POST")
 	api.HandleFunc("/evaluations/model/{modelId}", h.ListEvaluations).Methods("GET")
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrForbidden is returned when the caller's role does not allow an action
+var ErrForbidden = errors.New("forbidden")
+
+// AccessService resolves the caller's role on projects and the resources in
+// them. Global admins (AUTH_ADMINS) hold every role. Without a project a
+// flow, dataset or model belongs to its creator, who is its admin; legacy
+// datasets and models without creator are shared up to deployer.
+type AccessService struct {
+	projectRepo *repository.ProjectRepository
+	flowRepo    *repository.FlowRepository
+}
+
+func NewAccessService(projectRepo *repository.ProjectRepository, flowRepo *repository.FlowRepository) *AccessService {
+	return &AccessService{projectRepo: projectRepo, flowRepo: flowRepo}
+}
+
+// ProjectRole returns the user's role in a project, "" when not a member
+func (a *AccessService) ProjectRole(user *models.User, projectID int64) (string, error) {
+	if user == nil {
+		return "", nil
+	}
+	if user.Admin {
+		return models.ProjectRoleAdmin, nil
+	}
+	role, err := a.projectRepo.FindMemberRole(projectID, user.ID)
+	if err == repository.ErrProjectMemberNotFound {
+		return "", nil
+	}
+	return role, err
+}
+
+// ResourceRole returns the user's role on a resource of projectID created by createdBy
+func (a *AccessService) ResourceRole(user *models.User, projectID, createdBy *int64, shared bool) (string, error) {
+	if user == nil {
+		return "", nil
+	}
+	if projectID != nil {
+		return a.ProjectRole(user, *projectID)
+	}
+	if user.Admin || (createdBy != nil && *createdBy == user.ID) {
+		return models.ProjectRoleAdmin, nil
+	}
+	if shared && createdBy == nil {
+		return models.ProjectRoleDeployer, nil
+	}
+	return "", nil
+}
+
+// RequireProject fails with ErrForbidden unless the caller holds role in the project
+func (a *AccessService) RequireProject(ctx context.Context, projectID int64, role string) error {
+	if _, err := a.projectRepo.FindByID(projectID); err != nil {
+		return err
+	}
+	have, err := a.ProjectRole(auth.UserFromContext(ctx), projectID)
+	if err != nil {
+		return err
+	}
+	return requireRole(have, role)
+}
+
+// RequireFlow loads a flow and checks the caller's role on it
+func (a *AccessService) RequireFlow(ctx context.Context, flowID int64, role string) (*models.Flow, error) {
+	flow, err := a.flowRepo.FindByID(flowID)
+	if err != nil {
+		return nil, err
+	}
+	have, err := a.ResourceRole(auth.UserFromContext(ctx), flow.ProjectID, flow.CreatedBy, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(have, role); err != nil {
+		return nil, err
+	}
+	return flow, nil
+}
+
+// RequireFlowID is RequireFlow for the string flow IDs of runtime requests
+func (a *AccessService) RequireFlowID(ctx context.Context, flowID string, role string) error {
+	id, err := strconv.ParseInt(flowID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid flow id %q", flowID)
+	}
+	_, err = a.RequireFlow(ctx, id, role)
+	return err
+}
+
+// RequireResource checks the caller's role on a dataset or model
+func (a *AccessService) RequireResource(ctx context.Context, projectID, createdBy *int64, role string) error {
+	have, err := a.ResourceRole(auth.UserFromContext(ctx), projectID, createdBy, true)
+	if err != nil {
+		return err
+	}
+	return requireRole(have, role)
+}
+
+func requireRole(have, want string) error {
+	if models.RoleAtLeast(have, want) {
+		return nil
+	}
+	if have == "" {
+		return fmt.Errorf("%w: 접근 권한이 없습니다", ErrForbidden)
+	}
+	return fmt.Errorf("%w: %s 권한이 필요합니다 (현재 %s)", ErrForbidden, want, have)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidAuditFilter is returned for a malformed audit query
+var ErrInvalidAuditFilter = errors.New("잘못된 감사 로그 조회 조건입니다")
+
+const (
+	maxAuditPage  = 1000
+	auditRedacted = "[REDACTED]"
+)
+
+// auditSecretKeys are redacted wherever they appear in request parameters,
+// compared case-insensitively with '-' and '_' removed
+var auditSecretKeys = []string{"password", "passwd", "secret", "token", "apikey", "authorization", "credential", "privatekey", "dsn"}
+
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// Start stores a pending event with its parameters redacted
+func (s *AuditService) Start(e *models.AuditEvent) error {
+	e.Params = RedactParams(e.Params)
+	e.Outcome = models.AuditOutcomePending
+	return s.auditRepo.Create(e)
+}
+
+// Finish records the outcome of a started event
+func (s *AuditService) Finish(e *models.AuditEvent) error {
+	return s.auditRepo.Finish(e)
+}
+
+// List returns events matching the filter; non-admins only see their own
+func (s *AuditService) List(ctx context.Context, filter *models.AuditFilter, limit, offset int) ([]*models.AuditEvent, error) {
+	if err := s.scope(ctx, filter); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxAuditPage {
+		limit = maxAuditPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.auditRepo.List(filter, limit, offset)
+}
+
+// Export calls fn for every matching event, newest first
+func (s *AuditService) Export(ctx context.Context, filter *models.AuditFilter, fn func(*models.AuditEvent) error) error {
+	if err := s.scope(ctx, filter); err != nil {
+		return err
+	}
+	return s.auditRepo.Each(filter, fn)
+}
+
+func (s *AuditService) scope(ctx context.Context, filter *models.AuditFilter) error {
+	switch filter.Outcome {
+	case "", models.AuditOutcomePending, models.AuditOutcomeSuccess, models.AuditOutcomeDenied, models.AuditOutcomeFailure:
+	default:
+		return fmt.Errorf("%w: outcome은 pending, success, denied, failure 중 하나여야 합니다", ErrInvalidAuditFilter)
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return fmt.Errorf("%w: since는 until보다 앞서야 합니다", ErrInvalidAuditFilter)
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return ErrForbidden
+	}
+	if !user.Admin {
+		filter.ActorID = &user.ID
+	}
+	return nil
+}
+
+// RedactParams replaces the values of secret-looking keys, and connection
+// values, anywhere in a JSON document. Invalid JSON is dropped.
+func RedactParams(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return json.RawMessage(`{}`)
+	}
+	b, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return json.RawMessage(`{}`)
+	}
+	return b
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range t {
+			if isSecretKey(k) && inner != nil {
+				t[k] = auditRedacted
+			} else {
+				t[k] = redactValue(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range t {
+			t[i] = redactValue(inner)
+		}
+	}
+	return v
+}
+
+func isSecretKey(key string) bool {
+	k := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+	// 커넥션 값은 키 이름과 무관하게 모두 비밀
+	if k == "values" {
+		return true
+	}
+	for _, s := range auditSecretKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxConnectionBytes keeps the values within a single Kubernetes Secret
+const maxConnectionBytes = 512 * 1024
+
+var (
+	ErrConnectionExists = errors.New("같은 이름의 커넥션이 이미 있습니다")
+
+	connectionNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	connectionKeyPattern  = regexp.MustCompile(`^[-._a-zA-Z0-9]{1,253}$`)
+)
+
+type ConnectionService struct {
+	connRepo *repository.ConnectionRepository
+}
+
+func NewConnectionService(connRepo *repository.ConnectionRepository) *ConnectionService {
+	return &ConnectionService{connRepo: connRepo}
+}
+
+func (s *ConnectionService) Create(req *models.ConnectionRequestDTO) (*models.ConnectionResponseDTO, error) {
+	if req.User == "" {
+		return nil, errors.New("사용자(user)는 필수입니다")
+	}
+	if !connectionNamePattern.MatchString(req.Name) {
+		return nil, errors.New("커넥션 이름은 소문자, 숫자, '-'로 된 63자 이하여야 합니다")
+	}
+	if len(req.Values) == 0 {
+		return nil, errors.New("커넥션 값(values)이 하나 이상 필요합니다")
+	}
+
+	if _, err := s.connRepo.FindByOwnerAndName(req.User, req.Name); err == nil {
+		return nil, ErrConnectionExists
+	} else if err != repository.ErrConnectionNotFound {
+		return nil, err
+	}
+
+	conn := &models.Connection{
+		Owner:       req.User,
+		Name:        req.Name,
+		Type:        req.Type,
+		Description: req.Description,
+	}
+	if conn.Type == "" {
+		conn.Type = "generic"
+	}
+	if err := setConnectionValues(conn, req.Values); err != nil {
+		return nil, err
+	}
+
+	if err := s.connRepo.Create(conn); err != nil {
+		return nil, err
+	}
+	return conn.ToResponseDTO(), nil
+}
+
+func (s *ConnectionService) FindByOwner(owner string) ([]*models.ConnectionResponseDTO, error) {
+	conns, err := s.connRepo.FindByOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.ConnectionResponseDTO, 0, len(conns))
+	for _, c := range conns {
+		result = append(result, c.ToResponseDTO())
+	}
+	return result, nil
+}
+
+func (s *ConnectionService) FindByID(id int64) (*models.ConnectionResponseDTO, error) {
+	conn, err := s.connRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return conn.ToResponseDTO(), nil
+}
+
+// Update changes type, description and, when given, replaces all values.
+// Owner and name are fixed because steps reference the connection by name.
+func (s *ConnectionService) Update(id int64, req *models.ConnectionRequestDTO) (*models.ConnectionResponseDTO, error) {
+	conn, err := s.connRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" && req.Name != conn.Name {
+		return nil, errors.New("커넥션 이름은 변경할 수 없습니다")
+	}
+
+	if req.Type != "" {
+		conn.Type = req.Type
+	}
+	if req.Description != nil {
+		conn.Description = req.Description
+	}
+	if req.Values != nil {
+		if len(req.Values) == 0 {
+			return nil, errors.New("커넥션 값(values)이 하나 이상 필요합니다")
+		}
+		if err := setConnectionValues(conn, req.Values); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.connRepo.Update(conn); err != nil {
+		return nil, err
+	}
+	return conn.ToResponseDTO(), nil
+}
+
+func (s *ConnectionService) Delete(id int64) error {
+	return s.connRepo.Delete(id)
+}
+
+// setConnectionValues validates the values and stores them encrypted
+func setConnectionValues(conn *models.Connection, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	size := 0
+	for k, v := range values {
+		if !connectionKeyPattern.MatchString(k) || strings.HasPrefix(k, "..") || k == "." {
+			return fmt.Errorf("커넥션 키 이름이 올바르지 않습니다: %q", k)
+		}
+		keys = append(keys, k)
+		size += len(k) + len(v)
+	}
+	if size > maxConnectionBytes {
+		return fmt.Errorf("커넥션 값은 %dKB를 넘을 수 없습니다", maxConnectionBytes/1024)
+	}
+	sort.Strings(keys)
+
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealSecret(plain)
+	if err != nil {
+		return err
+	}
+	keysJSON, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	conn.Secret = sealed
+	conn.Keys = keysJSON
+	return nil
+}
+
+// connectionValues decrypts the stored values of a connection
+func connectionValues(conn *models.Connection) (map[string]string, error) {
+	plain, err := openSecret(conn.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("connection %q: %w", conn.Name, err)
+	}
+	values := make(map[string]string)
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("connection %q: %w", conn.Name, err)
+	}
+	return values, nil
+}
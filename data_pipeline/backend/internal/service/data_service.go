@@ -0,0 +1,863 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/dataformat"
+	"data-pipeline-backend/internal/datasource"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/staging"
+	"data-pipeline-backend/internal/workspace"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DataService reads and writes the data of the /api/data endpoints and of
+// workflow steps: workspace and S3 files, databases, HTTP APIs and inline
+// JSON. Results are reported in the response rather than as errors, except
+// for failures to open the storage itself.
+type DataService struct {
+	workspaces *WorkspaceService
+}
+
+func NewDataService(workspaces *WorkspaceService) *DataService {
+	return &DataService{workspaces: workspaces}
+}
+
+// Load reads the data of a source step; config.dataSource defaults to file
+func (s *DataService) Load(ctx context.Context, config map[string]interface{}) models.DataLoadResponse {
+	dataSource, _ := config["dataSource"].(string)
+	switch dataSource {
+	case "", "file", "s3":
+		if dataSource == "" {
+			dataSource = "file"
+		}
+		ws, err := s.Storage(ctx, dataSource, config, models.ProjectRoleViewer)
+		if err != nil {
+			return models.DataLoadResponse{Success: false, Error: err.Error()}
+		}
+		defer ws.Close()
+		return s.LoadFile(ws, config)
+	case "database":
+		db, err := s.Database(ctx, config, models.ProjectRoleViewer)
+		if err != nil {
+			return models.DataLoadResponse{Success: false, Error: err.Error()}
+		}
+		defer db.Close()
+		return s.LoadDatabase(ctx, db, config)
+	case "api":
+		return s.LoadAPI(config)
+	case "manual":
+		return s.LoadManual(config)
+	}
+	return models.DataLoadResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Unsupported data source: %s", dataSource),
+	}
+}
+
+// Save writes data to a sink; config.saveFormat defaults to file. data may
+// be a staged dataset: files stream it, the other sinks load it first.
+func (s *DataService) Save(ctx context.Context, data interface{}, config map[string]interface{}) models.DataSaveResponse {
+	saveFormat, _ := config["saveFormat"].(string)
+	switch saveFormat {
+	case "", "file", "s3":
+		if saveFormat == "" {
+			saveFormat = "file"
+		}
+		ws, err := s.Storage(ctx, saveFormat, config, models.ProjectRoleEditor)
+		if err != nil {
+			return models.DataSaveResponse{Success: false, Error: err.Error()}
+		}
+		defer ws.Close()
+		return s.SaveFile(ws, data, config)
+	case "database", "api":
+		if staged, ok := data.(*staging.Dataset); ok {
+			var err error
+			if data, err = staged.Load(); err != nil {
+				return models.DataSaveResponse{Success: false, Error: err.Error()}
+			}
+		}
+		if saveFormat == "api" {
+			return s.SaveAPI(data, config)
+		}
+		db, err := s.Database(ctx, config, models.ProjectRoleEditor)
+		if err != nil {
+			return models.DataSaveResponse{Success: false, Error: err.Error()}
+		}
+		defer db.Close()
+		return s.SaveDatabase(ctx, db, data, config)
+	}
+	return models.DataSaveResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Unsupported save format: %s", saveFormat),
+	}
+}
+
+// Storage opens the workspace of a "file" or "s3" data request. S3
+// requests name one of the caller's connections in config.connection and may
+// override its bucket and prefix.
+func (s *DataService) Storage(ctx context.Context, kind string, config map[string]interface{}, role string) (workspace.Workspace, error) {
+	if kind != "s3" {
+		return s.workspaces.Open(ctx, projectFromConfig(config), role)
+	}
+
+	connection, _ := config["connection"].(string)
+	bucket, _ := config["bucket"].(string)
+	prefix, _ := config["prefix"].(string)
+	return s.workspaces.OpenS3(ctx, S3Location{Connection: connection, Bucket: bucket, Prefix: prefix})
+}
+
+// Database connects to the database of config.databaseConnection. role
+// is the workspace role SQLite files need.
+func (s *DataService) Database(ctx context.Context, config map[string]interface{}, role string) (*datasource.Database, error) {
+	connection, _ := config["databaseConnection"].(string)
+	return s.workspaces.OpenDatabase(ctx, DatabaseLocation{
+		Connection: connection,
+		ProjectID:  projectFromConfig(config),
+		Write:      role != models.ProjectRoleViewer,
+	})
+}
+
+// filePathFromConfig is config.filePath, or config.key for s3
+func filePathFromConfig(config map[string]interface{}) string {
+	if p, _ := config["filePath"].(string); p != "" {
+		return p
+	}
+	p, _ := config["key"].(string)
+	return p
+}
+
+// projectFromConfig reads the optional config.projectId of a data request
+func projectFromConfig(config map[string]interface{}) *int64 {
+	if v, ok := config["projectId"].(float64); ok && v > 0 {
+		id := int64(v)
+		return &id
+	}
+	return nil
+}
+
+// WorkspaceError describes a failed workspace access without host paths
+func WorkspaceError(filePath string, err error) string {
+	switch {
+	case errors.Is(err, workspace.ErrNotFound):
+		return fmt.Sprintf("File not found: %s", filePath)
+	case errors.Is(err, workspace.ErrOutsideWorkspace):
+		return fmt.Sprintf("Path is outside the workspace: %s", filePath)
+	case errors.Is(err, workspace.ErrQuotaExceeded):
+		return "Workspace quota exceeded"
+	}
+	return err.Error()
+}
+
+// Helper functions for data loading
+
+// LoadFile reads config.filePath of ws
+func (s *DataService) LoadFile(ws workspace.Workspace, config map[string]interface{}) models.DataLoadResponse {
+	filePath := filePathFromConfig(config)
+	if filePath == "" {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   "filePath is required",
+		}
+	}
+
+	fileFormat, _ := config["fileFormat"].(string)
+	compression, _ := config["compression"].(string)
+
+	// Check if file exists
+	if _, err := ws.Stat(filePath); err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   WorkspaceError(filePath, err),
+		}
+	}
+
+	file, err := openDataFile(ws, filePath, fileFormat, compression)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read file: %s", WorkspaceError(filePath, err)),
+		}
+	}
+	defer file.Close()
+
+	// JSON is passed on as the whole document, whatever its shape
+	if file.format == dataformat.JSON {
+		return loadJSONFile(file, filePath)
+	}
+
+	maxRows := 0
+	if v, ok := config["maxRows"].(float64); ok && v > 0 {
+		maxRows = int(v)
+	}
+	table, err := readDataFile(file, readOptions(config), maxRows)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read %s file: %v", file.format, err),
+		}
+	}
+	return tableResponse(file, filePath, table)
+}
+
+// dataFile is an opened workspace file, decompressed, with its format
+type dataFile struct {
+	io.Reader
+	format      string
+	compression string
+	closers     []io.Closer
+}
+
+func (f *dataFile) Close() error {
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		f.closers[i].Close()
+	}
+	return nil
+}
+
+// openDataFile opens filePath of ws. An empty compression is detected from
+// the magic bytes, an empty (or "auto") format from the file name and then
+// from the decompressed content.
+func openDataFile(ws workspace.Workspace, filePath, format, compression string) (*dataFile, error) {
+	rc, err := ws.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file := &dataFile{closers: []io.Closer{rc}}
+
+	dr, compression, err := dataformat.Decompress(rc, compression)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.closers = append(file.closers, dr)
+	file.Reader, file.compression = dr, compression
+
+	format = dataformat.Normalize(format)
+	if format == "" || format == "auto" {
+		head, r, err := dataformat.Peek(dr, 512)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		file.Reader = r
+		if format = workspace.DetectFormat(filePath, head); format == "" || format == "text" {
+			format = dataformat.CSV
+		}
+	}
+	file.format = format
+	return file, nil
+}
+
+// readOptions reads the column projection and xlsx sheet of a request
+func readOptions(config map[string]interface{}) dataformat.ReadOptions {
+	var opts dataformat.ReadOptions
+	opts.Sheet, _ = config["sheet"].(string)
+	switch v := config["columns"].(type) {
+	case string:
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				opts.Columns = append(opts.Columns, c)
+			}
+		}
+	case []interface{}:
+		for _, c := range v {
+			if s, ok := c.(string); ok && s != "" {
+				opts.Columns = append(opts.Columns, s)
+			}
+		}
+	}
+	return opts
+}
+
+// dataTable is the rows read from a tabular file
+type dataTable struct {
+	rows      []map[string]interface{}
+	schema    []dataformat.Field
+	truncated bool
+}
+
+// readDataFile reads up to maxRows rows (0 = all) of a tabular file
+func readDataFile(file *dataFile, opts dataformat.ReadOptions, maxRows int) (*dataTable, error) {
+	rd, err := dataformat.NewReader(file, file.format, opts)
+	if err != nil {
+		return nil, err
+	}
+	rows, truncated, err := dataformat.ReadAll(rd, maxRows)
+	if err != nil {
+		return nil, err
+	}
+	return &dataTable{rows: rows, schema: dataformat.SchemaOf(rd, rows), truncated: truncated}, nil
+}
+
+func tableResponse(file *dataFile, filePath string, table *dataTable) models.DataLoadResponse {
+	headers := make([]string, len(table.schema))
+	for i, f := range table.schema {
+		headers[i] = f.Name
+	}
+
+	return models.DataLoadResponse{
+		Success: true,
+		Data:    table.rows,
+		Preview: rowsPreview(headers, table.rows),
+		Meta: map[string]interface{}{
+			"filePath":    filePath,
+			"format":      file.format,
+			"compression": file.compression,
+			"rows":        len(table.rows),
+			"columns":     len(headers),
+			"headers":     headers,
+			"schema":      table.schema,
+			"truncated":   table.truncated,
+		},
+	}
+}
+
+// rowsPreview renders the headers and the first 10 rows as CSV-like lines
+func rowsPreview(headers []string, data []map[string]interface{}) string {
+	previewRows := min(10, len(data))
+	var previewLines []string
+	previewLines = append(previewLines, strings.Join(headers, ","))
+	for i := 0; i < previewRows; i++ {
+		var values []string
+		for _, h := range headers {
+			val := fmt.Sprintf("%v", data[i][h])
+			values = append(values, val)
+		}
+		previewLines = append(previewLines, strings.Join(values, ","))
+	}
+	if len(data) > 10 {
+		previewLines = append(previewLines, fmt.Sprintf("... and %d more rows", len(data)-10))
+	}
+	return strings.Join(previewLines, "\n")
+}
+
+func loadJSONFile(file *dataFile, filePath string) models.DataLoadResponse {
+	var data interface{}
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse JSON: %v", err),
+		}
+	}
+
+	content, _ := json.MarshalIndent(data, "", "  ")
+	preview := string(content)
+	if len(preview) > 1000 {
+		preview = preview[:1000] + "..."
+	}
+
+	meta := map[string]interface{}{
+		"filePath":    filePath,
+		"format":      "json",
+		"compression": file.compression,
+	}
+	// Arrays of objects also get the table metadata of the other formats
+	if records, ok := toRecords(data); ok {
+		columns := dataformat.ColumnsOf(records)
+		meta["rows"] = len(records)
+		meta["columns"] = len(columns)
+		meta["headers"] = columns
+		meta["schema"] = dataformat.InferSchema(columns, records)
+	}
+
+	return models.DataLoadResponse{
+		Success: true,
+		Data:    data,
+		Preview: preview,
+		Meta:    meta,
+	}
+}
+
+// toRecords returns data as records when it is an array of objects
+func toRecords(data interface{}) ([]map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		records := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			records = append(records, m)
+		}
+		return records, true
+	}
+	return nil, false
+}
+
+const (
+	defaultDatabaseLimit = 10000
+	maxDatabaseLimit     = 1000000
+)
+
+// LoadDatabase runs config.databaseQuery with the positional
+// config.databaseParams and returns at most config.databaseLimit rows
+func (s *DataService) LoadDatabase(ctx context.Context, db *datasource.Database, config map[string]interface{}) models.DataLoadResponse {
+	query, _ := config["databaseQuery"].(string)
+	if strings.TrimSpace(query) == "" {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   "databaseQuery is required",
+		}
+	}
+
+	params, _ := config["databaseParams"].([]interface{})
+	args, err := datasource.QueryArgs(params)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid databaseParams: %v", err),
+		}
+	}
+
+	limit := defaultDatabaseLimit
+	if v, ok := config["databaseLimit"].(float64); ok && v > 0 {
+		limit = min(int(v), maxDatabaseLimit)
+	}
+
+	// Rows are read one at a time; only the returned ones are kept
+	data := []map[string]interface{}{}
+	result, err := db.Query(ctx, query, args, limit, func(row map[string]interface{}) error {
+		data = append(data, row)
+		return nil
+	})
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Query failed: %v", err),
+		}
+	}
+
+	headers := make([]string, len(result.Columns))
+	for i, c := range result.Columns {
+		headers[i] = c.Name
+	}
+
+	return models.DataLoadResponse{
+		Success: true,
+		Data:    data,
+		Preview: rowsPreview(headers, data),
+		Meta: map[string]interface{}{
+			"database":    db.Dialect.Kind,
+			"rows":        len(data),
+			"columns":     len(headers),
+			"headers":     headers,
+			"columnTypes": result.Columns,
+			"truncated":   result.Truncated,
+			"limit":       limit,
+		},
+	}
+}
+
+func (s *DataService) LoadAPI(config map[string]interface{}) models.DataLoadResponse {
+	apiUrl, _ := config["apiUrl"].(string)
+	apiMethod, _ := config["apiMethod"].(string)
+	apiBody, _ := config["apiBody"].(string)
+
+	if apiUrl == "" {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   "apiUrl is required",
+		}
+	}
+
+	if apiMethod == "" {
+		apiMethod = "GET"
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	var req *http.Request
+	var err error
+
+	if apiMethod == "POST" && apiBody != "" {
+		req, err = http.NewRequest(apiMethod, apiUrl, strings.NewReader(apiBody))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequest(apiMethod, apiUrl, nil)
+	}
+
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create request: %v", err),
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("API request failed: %v", err),
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read response: %v", err),
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(body)),
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		// If not JSON, return as string
+		data = string(body)
+	}
+
+	return models.DataLoadResponse{
+		Success: true,
+		Data:    data,
+		Preview: fmt.Sprintf("API response: %d bytes", len(body)),
+		Meta: map[string]interface{}{
+			"url":    apiUrl,
+			"method": apiMethod,
+			"status": resp.StatusCode,
+		},
+	}
+}
+
+func (s *DataService) LoadManual(config map[string]interface{}) models.DataLoadResponse {
+	manualData, _ := config["manualData"].(string)
+
+	if manualData == "" {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   "manualData is required",
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(manualData), &data); err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid JSON: %v", err),
+		}
+	}
+
+	return models.DataLoadResponse{
+		Success: true,
+		Data:    data,
+		Preview: fmt.Sprintf("Manual data: %d bytes", len(manualData)),
+	}
+}
+
+// Helper functions for data saving
+
+// SaveFile writes data to config.filePath. The format defaults to the one
+// of the file extension (csv otherwise) and the compression to a .gz or
+// .zst suffix. JSON takes any data; the other formats need an array of
+// objects, written with config.columns or the sorted union of their keys.
+func (s *DataService) SaveFile(ws workspace.Workspace, data interface{}, config map[string]interface{}) models.DataSaveResponse {
+	filePath := filePathFromConfig(config)
+	fileFormat, _ := config["fileFormat"].(string)
+	compression, _ := config["compression"].(string)
+
+	if filePath == "" {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   "filePath is required",
+		}
+	}
+
+	fileFormat = dataformat.Normalize(fileFormat)
+	if fileFormat == "" || fileFormat == "auto" {
+		if fileFormat = workspace.DetectFormat(filePath, nil); fileFormat == "" || fileFormat == "text" {
+			fileFormat = dataformat.CSV
+		}
+	}
+	if compression == "" {
+		compression = dataformat.CompressionFromName(filePath)
+	}
+
+	// 스트리밍할 수 없으면 메모리로 읽는다
+	staged, _ := data.(*staging.Dataset)
+	if staged != nil && (staged.Kind != staging.KindRecords || !dataformat.Streamable(fileFormat)) {
+		var err error
+		if data, err = staged.Load(); err != nil {
+			return models.DataSaveResponse{
+				Success: false,
+				Error:   err.Error(),
+			}
+		}
+		staged = nil
+	}
+
+	var records []map[string]interface{}
+	if staged != nil {
+		if staged.Rows == 0 {
+			return models.DataSaveResponse{
+				Success: false,
+				Error:   "No data to save",
+			}
+		}
+	} else if fileFormat != dataformat.JSON {
+		var ok bool
+		if records, ok = toRecords(data); !ok {
+			return models.DataSaveResponse{
+				Success: false,
+				Error:   "Data must be an array of objects",
+			}
+		}
+		if len(records) == 0 {
+			return models.DataSaveResponse{
+				Success: false,
+				Error:   "No data to save",
+			}
+		}
+	}
+	opts := dataformat.WriteOptions{Columns: readOptions(config).Columns}
+	opts.Sheet, _ = config["sheet"].(string)
+
+	// Parent directories are created by the workspace
+	file, err := ws.Create(filePath)
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create file: %s", WorkspaceError(filePath, err)),
+		}
+	}
+	defer file.Close()
+
+	out, err := dataformat.Compress(file, compression)
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+	rows := len(records)
+	switch {
+	case staged != nil:
+		if len(opts.Columns) == 0 {
+			opts.Columns = staged.Columns
+		}
+		var rd dataformat.Reader
+		if rd, err = staged.Open(); err == nil {
+			rows, err = dataformat.Copy(out, fileFormat, rd, opts)
+			rd.Close()
+		}
+	case fileFormat == dataformat.JSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(data)
+	default:
+		err = dataformat.Write(out, fileFormat, records, opts)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if a, ok := file.(interface{ Abort() }); ok && err != nil {
+		// 실패한 업로드는 올리지 않는다
+		a.Abort()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to write file: %s", WorkspaceError(filePath, err)),
+		}
+	}
+
+	message := fmt.Sprintf("Saved %d rows to %s", rows, filePath)
+	if fileFormat == dataformat.JSON {
+		message = fmt.Sprintf("Saved JSON to %s", filePath)
+	}
+	return models.DataSaveResponse{
+		Success: true,
+		Path:    filePath,
+		Message: message,
+	}
+}
+
+// SaveDatabase writes records to config.databaseTable. databaseMode is
+// append, replace or upsert (on the databaseKey columns); the table is
+// created from the data unless createTable is false.
+func (s *DataService) SaveDatabase(ctx context.Context, db *datasource.Database, data interface{}, config map[string]interface{}) models.DataSaveResponse {
+	table, _ := config["databaseTable"].(string)
+	mode, _ := config["databaseMode"].(string)
+
+	if table == "" {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   "databaseTable is required",
+		}
+	}
+
+	if mode == "" {
+		mode = datasource.ModeAppend
+	}
+
+	var key []string
+	switch v := config["databaseKey"].(type) {
+	case string:
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				key = append(key, k)
+			}
+		}
+	case []interface{}:
+		for _, k := range v {
+			if s, ok := k.(string); ok && s != "" {
+				key = append(key, s)
+			}
+		}
+	}
+
+	createTable := true
+	if v, ok := config["createTable"].(bool); ok {
+		createTable = v
+	}
+
+	var records []map[string]interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				records = append(records, m)
+			}
+		}
+	case []map[string]interface{}:
+		records = v
+	default:
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   "Data must be an array of objects",
+		}
+	}
+
+	result, err := db.Save(ctx, records, datasource.SaveOptions{
+		Table:       table,
+		Mode:        mode,
+		Key:         key,
+		CreateTable: createTable,
+	})
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to save to table %s: %v", table, err),
+		}
+	}
+
+	return models.DataSaveResponse{
+		Success: true,
+		Path:    table,
+		Message: fmt.Sprintf("Saved %d rows to table %s (%s mode)", result.Rows, table, mode),
+	}
+}
+
+func (s *DataService) SaveAPI(data interface{}, config map[string]interface{}) models.DataSaveResponse {
+	apiUrl, _ := config["apiUrl"].(string)
+	apiMethod, _ := config["apiMethod"].(string)
+
+	if apiUrl == "" {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   "apiUrl is required",
+		}
+	}
+
+	if apiMethod == "" {
+		apiMethod = "POST"
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to marshal data: %v", err),
+		}
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	req, err := http.NewRequest(apiMethod, apiUrl, strings.NewReader(string(jsonData)))
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to create request: %v", err),
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.DataSaveResponse{
+			Success: false,
+			Error:   fmt.Sprintf("API request failed: %v", err),
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return models.DataSaveResponse{
+			Success: true,
+			Message: fmt.Sprintf("Data sent to API: %s (status %d)", apiUrl, resp.StatusCode),
+		}
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return models.DataSaveResponse{
+		Success: false,
+		Error:   fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(body)),
+	}
+}
+
+// PreviewFile reads the schema and up to rows rows of filePath. JSON that
+// is not an array of objects is returned as a document.
+func (s *DataService) PreviewFile(ws workspace.Workspace, filePath, format, compression string, opts dataformat.ReadOptions, rows int) models.DataLoadResponse {
+	file, err := openDataFile(ws, filePath, format, compression)
+	if err != nil {
+		return models.DataLoadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read file: %s", WorkspaceError(filePath, err)),
+		}
+	}
+	defer file.Close()
+
+	table, err := readDataFile(file, opts, rows)
+	switch {
+	case err == nil:
+		return tableResponse(file, filePath, table)
+	case errors.Is(err, dataformat.ErrNotTabular):
+		file.Close()
+		if file, err = openDataFile(ws, filePath, file.format, file.compression); err != nil {
+			return models.DataLoadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to read file: %s", WorkspaceError(filePath, err)),
+			}
+		}
+		defer file.Close()
+		return loadJSONFile(file, filePath)
+	}
+	return models.DataLoadResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Failed to read %s file: %v", file.format, err),
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrInvalidReplay = errors.New("재처리할 DLQ 항목(ids 또는 all)을 지정해야 합니다")
+
+// dlqReplayScanLimit bounds how many dead letters a replay looks through
+const dlqReplayScanLimit = 1000
+
+// dlqTopicName is the dead-letter topic created next to the flow topic
+func dlqTopicName(flowID string) string {
+	return flowID + "-dlq"
+}
+
+// dlqSinkName is the KafkaSink runners post failed events to
+func dlqSinkName(flowID string) string {
+	return "sink-" + flowID + "-dlq"
+}
+
+// parseDeadLetter decodes a runner's dead-letter payload
+func parseDeadLetter(id string, payload []byte) (models.DeadLetterDTO, error) {
+	var p models.DeadLetterPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return models.DeadLetterDTO{}, err
+	}
+	dl := models.DeadLetterDTO{
+		ID:        id,
+		Step:      p.Step,
+		Error:     p.Error,
+		Traceback: p.Traceback,
+		Event:     p.Event,
+		CE:        p.CE,
+	}
+	if len(dl.Event) == 0 {
+		dl.Event = json.RawMessage("{}")
+	}
+	if p.FailedAt > 0 {
+		sec, frac := math.Modf(p.FailedAt)
+		dl.FailedAt = time.Unix(int64(sec), int64(frac*1e9))
+	}
+	return dl, nil
+}
+
+// ReplayDeadLetters sends the selected dead letters back into the flow. Each
+// event is re-published with its original type and trace id and targeted at
+// the step that failed, so the other steps ignore it.
+func ReplayDeadLetters(ctx context.Context, rt FlowRuntime, ns, flowID string, req *models.DLQReplayRequestDTO) (*models.DLQReplayResponseDTO, error) {
+	if !req.All && len(req.IDs) == 0 {
+		return nil, ErrInvalidReplay
+	}
+
+	entries, err := rt.ListDeadLetters(ctx, ns, flowID, dlqReplayScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := entries
+	if !req.All {
+		wanted := make(map[string]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			wanted[id] = true
+		}
+		selected = nil
+		for _, dl := range entries {
+			if wanted[dl.ID] {
+				selected = append(selected, dl)
+			}
+		}
+	}
+
+	resp := &models.DLQReplayResponseDTO{IDs: []string{}}
+	if len(selected) == 0 {
+		return resp, nil
+	}
+	if err := rt.Replay(ctx, ns, flowID, selected); err != nil {
+		return nil, err
+	}
+	for _, dl := range selected {
+		resp.IDs = append(resp.IDs, dl.ID)
+	}
+	resp.Replayed = len(resp.IDs)
+	return resp, nil
+}
+
+// shellQuote single-quotes s for /bin/sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type DeploymentService struct {
+	deploymentRepo *repository.DeploymentRepository
+	imageRepo      *repository.StepImageRepository
+	planner        *K8sService // step resolution and naming only; has no cluster clients
+}
+
+func NewDeploymentService(deploymentRepo *repository.DeploymentRepository, imageRepo *repository.StepImageRepository, objectRepo *repository.ObjectRepository, edgeRepo *repository.EdgeRepository) *DeploymentService {
+	return &DeploymentService{
+		deploymentRepo: deploymentRepo,
+		imageRepo:      imageRepo,
+		planner:        &K8sService{objectRepo: objectRepo, edgeRepo: edgeRepo},
+	}
+}
+
+// Start records a running deployment with the resources the deploy will apply
+func (s *DeploymentService) Start(flowID int64, dto *models.K8sRequestDTO, runtime string, createdBy *int64) (*models.Deployment, error) {
+	var records []models.DeploymentStep
+	// 스텝을 만들 수 없는 경우에도 실패한 배포로 남긴다
+	if steps, err := s.planner.makeStep(dto.Steps); err == nil {
+		for _, step := range steps {
+			safeStepName := s.planner.safeName(step.Name)
+			ksvcName := fmt.Sprintf("flow%s-%s", dto.FlowID, safeStepName)
+			spec := s.planner.stepImageSpec(step.Params)
+			record := models.DeploymentStep{
+				Name:          step.Name,
+				ObjectID:      step.ObjectID,
+				KSVC:          ksvcName,
+				KafkaSource:   fmt.Sprintf("source-%s-to-%s", dto.FlowID, ksvcName),
+				ConsumerGroup: fmt.Sprintf("cg-flow%s-%s-v1", dto.FlowID, safeStepName),
+				CodeHash:      s.planner.sha256(step.Code),
+				Route:         s.planner.getInType(dto.FlowID, step) + ">" + s.planner.getOutType(dto.FlowID, step),
+				Image:         s.planner.plannedImage(spec),
+				ImageDigest:   imageDigest(spec.Base),
+			}
+			if len(spec.Requirements) > 0 {
+				record.ImageKey = spec.Key()
+				record.ImageDigest = ""
+			}
+			records = append(records, record)
+		}
+	}
+
+	stepsJSON, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &models.Deployment{
+		FlowID:     flowID,
+		Runtime:    runtime,
+		Namespace:  "user-" + dto.User,
+		Topic:      dto.FlowID,
+		DeployedBy: dto.User,
+		Steps:      stepsJSON,
+		Status:     models.DeploymentStatusRunning,
+		CreatedBy:  createdBy,
+	}
+	if err := s.deploymentRepo.Create(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Finish stores the phase outcomes and the final verify result
+func (s *DeploymentService) Finish(d *models.Deployment, phases []models.DeploymentPhase, ok bool, verifyResult string, version *int) error {
+	if phases == nil {
+		phases = []models.DeploymentPhase{}
+	}
+	phasesJSON, err := json.Marshal(phases)
+	if err != nil {
+		return err
+	}
+
+	d.Phases = phasesJSON
+	d.Steps = s.withImageDigests(d.Steps)
+	d.Version = version
+	d.VerifyResult = &verifyResult
+	d.Status = models.DeploymentStatusNG
+	if ok {
+		d.Status = models.DeploymentStatusOK
+	}
+	return s.deploymentRepo.Finish(d)
+}
+
+// withImageDigests fills in the digests of images built during the deploy
+func (s *DeploymentService) withImageDigests(stepsJSON json.RawMessage) json.RawMessage {
+	var steps []models.DeploymentStep
+	if err := json.Unmarshal(stepsJSON, &steps); err != nil {
+		return stepsJSON
+	}
+	for i := range steps {
+		if steps[i].ImageKey == "" || steps[i].ImageDigest != "" {
+			continue
+		}
+		img, err := s.imageRepo.FindByKey(steps[i].ImageKey)
+		if err != nil || img.Status != models.StepImageStatusReady || img.Digest == nil {
+			continue
+		}
+		steps[i].ImageDigest = *img.Digest
+	}
+	out, err := json.Marshal(steps)
+	if err != nil {
+		return stepsJSON
+	}
+	return out
+}
+
+func (s *DeploymentService) Latest(flowID int64) (*models.Deployment, error) {
+	return s.deploymentRepo.FindLatest(flowID)
+}
+
+// Reconcile compares the deployment record with what the runtime reports:
+// missing or unready steps mean broken, a different code hash/route or an
+// unrecorded ksvc means drifted.
+func (s *DeploymentService) Reconcile(ctx context.Context, d *models.Deployment, rt FlowRuntime) (*models.DeploymentStatusDTO, error) {
+	observed, err := rt.Observe(ctx, d.Namespace, d.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	record := d.ToResponseDTO()
+	status := &models.DeploymentStatusDTO{
+		FlowID:     d.FlowID,
+		Deployment: record,
+		Steps:      make([]models.DeploymentStepStateDTO, 0, len(record.Steps)),
+		CheckedAt:  time.Now(),
+	}
+
+	live := make(map[string]models.ObservedStepDTO, len(observed))
+	for _, obs := range observed {
+		live[obs.Name] = obs
+	}
+
+	broken, drifted := false, false
+	recorded := make(map[string]bool, len(record.Steps))
+	for _, step := range record.Steps {
+		recorded[step.KSVC] = true
+		st := models.DeploymentStepStateDTO{
+			Name: step.Name,
+			KSVC: step.KSVC,
+		}
+
+		obs, found := live[step.KSVC]
+		if !found {
+			st.Detail = "not found"
+			broken = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: ksvc %s not found", step.Name, step.KSVC))
+			status.Steps = append(status.Steps, st)
+			continue
+		}
+
+		st.Found = true
+		st.Ready = obs.Ready
+		st.SourceReady = obs.SourceReady
+		st.Revision = obs.Revision
+		st.Detail = obs.Detail
+		if !obs.Ready {
+			broken = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: ksvc %s not ready", step.Name, step.KSVC))
+		}
+		if !obs.SourceReady {
+			broken = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: KafkaSource %s not ready", step.Name, step.KafkaSource))
+		}
+		if obs.CodeHash != step.CodeHash {
+			st.Drifted = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: code hash differs from deployment", step.Name))
+		}
+		if obs.Route != step.Route {
+			st.Drifted = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("%s: route %s differs from deployment %s", step.Name, obs.Route, step.Route))
+		}
+		if st.Drifted {
+			drifted = true
+		}
+		status.Steps = append(status.Steps, st)
+	}
+
+	for _, obs := range observed {
+		if !recorded[obs.Name] {
+			drifted = true
+			status.Reasons = append(status.Reasons, fmt.Sprintf("ksvc %s is not part of the deployment", obs.Name))
+		}
+	}
+
+	if d.Status == models.DeploymentStatusNG {
+		broken = true
+		result := ""
+		if d.VerifyResult != nil {
+			result = *d.VerifyResult
+		}
+		status.Reasons = append(status.Reasons, "last deploy failed: "+result)
+	}
+
+	switch {
+	case d.Status == models.DeploymentStatusRunning:
+		status.State = models.DeploymentStateDeploying
+	case broken:
+		status.State = models.DeploymentStateBroken
+	case drifted:
+		status.State = models.DeploymentStateDrifted
+	default:
+		status.State = models.DeploymentStateDeployed
+	}
+
+	return status, nil
+}
+
+// NotDeployed is the reconciled status of a flow without a deployment record
+func (s *DeploymentService) NotDeployed(flowID int64) *models.DeploymentStatusDTO {
+	return &models.DeploymentStatusDTO{
+		FlowID:    flowID,
+		State:     models.DeploymentStateNotDeployed,
+		Steps:     []models.DeploymentStepStateDTO{},
+		CheckedAt: time.Now(),
+	}
+}
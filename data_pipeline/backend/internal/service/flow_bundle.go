@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidBundle = errors.New("유효하지 않은 플로우 번들입니다")
+)
+
+// Export builds a portable bundle of the flow, its objects and edges
+func (s *FlowService) Export(ctx context.Context, id int64) (*models.FlowBundle, error) {
+	flow, err := s.access.RequireFlow(ctx, id, models.ProjectRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := s.objectRepo.FindByFlow(id)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.edgeRepo.FindByFlow(id)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &models.FlowBundle{
+		APIVersion: models.FlowBundleAPIVersion,
+		Kind:       "Flow",
+		Flow: models.BundleFlow{
+			Name:    flow.Name,
+			RunType: flow.RunType,
+		},
+		Objects: make([]models.BundleObject, 0, len(objects)),
+		Edges:   make([]models.EdgeDTO, 0, len(edges)),
+	}
+
+	for _, o := range objects {
+		obj := models.BundleObject{
+			ID:     o.ID,
+			Type:   o.Type,
+			Label:  o.Label,
+			X:      o.X,
+			Y:      o.Y,
+			Target: o.Target,
+		}
+		if len(o.Params) > 0 {
+			if err := json.Unmarshal(o.Params, &obj.Params); err != nil {
+				return nil, fmt.Errorf("invalid params JSON for object id=%d: %w", o.ID, err)
+			}
+		}
+		bundle.Objects = append(bundle.Objects, obj)
+	}
+	for _, e := range edges {
+		bundle.Edges = append(bundle.Edges, e.ToDTO())
+	}
+
+	return bundle, nil
+}
+
+// Import validates a bundle and creates a new flow from it, owned by the
+// caller. Bundle o_id/target references are remapped to the newly created
+// object IDs.
+func (s *FlowService) Import(ctx context.Context, bundle *models.FlowBundle, name string) (*models.FlowImportResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, fmt.Errorf("%w: 로그인이 필요합니다", ErrForbidden)
+	}
+	userID := &user.ID
+
+	edges, err := s.ValidateBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = bundle.Flow.Name
+	}
+	flow := &models.Flow{
+		Name:      name,
+		RunType:   bundle.Flow.RunType,
+		CreatedBy: userID,
+	}
+	if err := s.flowRepo.Create(flow); err != nil {
+		return nil, err
+	}
+
+	idMap, err := s.importObjects(flow.ID, bundle.Objects, edges)
+	if err != nil {
+		// 부분적으로 생성된 플로우 정리 (objects/edges는 cascade)
+		s.flowRepo.Delete(flow.ID)
+		return nil, err
+	}
+
+	if _, err := s.versions.RecordSave(flow.ID, userID); err != nil {
+		return nil, err
+	}
+
+	return &models.FlowImportResponseDTO{
+		Flow:  flow.ToResponseDTO(),
+		IDMap: idMap,
+	}, nil
+}
+
+func (s *FlowService) importObjects(flowID int64, objects []models.BundleObject, edges []models.EdgeDTO) (map[int64]int64, error) {
+	idMap := make(map[int64]int64, len(objects))
+	created := make(map[int64]*models.Object, len(objects))
+
+	for _, b := range objects {
+		var paramsJSON json.RawMessage
+		if len(b.Params) > 0 {
+			paramsBytes, err := json.Marshal(b.Params)
+			if err != nil {
+				return nil, errors.New("파라미터 JSON 변환 실패: " + err.Error())
+			}
+			paramsJSON = paramsBytes
+		}
+
+		fid := flowID
+		object := &models.Object{
+			Type:   b.Type,
+			X:      b.X,
+			Y:      b.Y,
+			Label:  b.Label,
+			Params: paramsJSON,
+			FlowID: &fid,
+		}
+		if object.Type == "" {
+			object.Type = "python"
+		}
+		if object.Label == "" {
+			object.Label = object.Type
+		}
+
+		if err := s.objectRepo.Create(object); err != nil {
+			return nil, err
+		}
+		idMap[b.ID] = object.ID
+		created[object.ID] = object
+	}
+
+	targets := make(map[int64][]int64)
+	for _, e := range edges {
+		src := idMap[e.Source]
+		targets[src] = append(targets[src], idMap[e.Target])
+	}
+	for _, b := range objects {
+		id := idMap[b.ID]
+		if len(targets[id]) == 0 {
+			continue
+		}
+		object := created[id]
+		object.Target = firstTarget(targets[id])
+		if err := s.objectRepo.Update(object); err != nil {
+			return nil, err
+		}
+		if err := s.edgeRepo.ReplaceTargets(flowID, id, targets[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	return idMap, nil
+}
+
+// ValidateBundle checks a bundle before anything is written and returns its
+// edge list merged from `edges` and the legacy per-object `target`.
+// Code steps are checked with the same rule the K8s deploy uses.
+func (s *FlowService) ValidateBundle(bundle *models.FlowBundle) ([]models.EdgeDTO, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("%w: 번들이 비어 있습니다", ErrInvalidBundle)
+	}
+	if bundle.APIVersion != "" && bundle.APIVersion != models.FlowBundleAPIVersion {
+		return nil, fmt.Errorf("%w: 지원하지 않는 apiVersion %q", ErrInvalidBundle, bundle.APIVersion)
+	}
+	if bundle.Flow.Name == "" {
+		return nil, fmt.Errorf("%w: 플로우명은 필수입니다", ErrInvalidBundle)
+	}
+	if len(bundle.Objects) == 0 {
+		return nil, fmt.Errorf("%w: 오브젝트가 하나 이상 필요합니다", ErrInvalidBundle)
+	}
+
+	ids := make([]int64, 0, len(bundle.Objects))
+	known := make(map[int64]bool, len(bundle.Objects))
+	for _, o := range bundle.Objects {
+		if known[o.ID] {
+			return nil, fmt.Errorf("%w: 중복된 o_id %d", ErrInvalidBundle, o.ID)
+		}
+		known[o.ID] = true
+		ids = append(ids, o.ID)
+
+		if isCodeStep(o) {
+			name := o.Label
+			if name == "" {
+				name = fmt.Sprintf("o_id=%d", o.ID)
+			}
+			code := ""
+			if v, ok := o.Params["code"]; ok && v != nil {
+				code = fmt.Sprintf("%v", v)
+			}
+			if err := validateStepCode(name, code); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+			}
+		}
+		if err := ValidateStepParams(o.Params); err != nil {
+			return nil, fmt.Errorf("%w: 오브젝트 %d: %v", ErrInvalidBundle, o.ID, err)
+		}
+	}
+
+	seen := make(map[models.EdgeDTO]bool)
+	var edges []models.EdgeDTO
+	addEdge := func(e models.EdgeDTO) error {
+		if !known[e.Source] || !known[e.Target] {
+			return fmt.Errorf("%w: 엣지 %d -> %d 가 번들에 없는 오브젝트를 참조합니다", ErrInvalidBundle, e.Source, e.Target)
+		}
+		if e.Source == e.Target {
+			return fmt.Errorf("%w: 오브젝트 %d 가 자기 자신을 타깃으로 지정했습니다", ErrInvalidBundle, e.Source)
+		}
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+		return nil
+	}
+	for _, e := range bundle.Edges {
+		if err := addEdge(e); err != nil {
+			return nil, err
+		}
+	}
+	for _, o := range bundle.Objects {
+		if o.Target != nil {
+			if err := addEdge(models.EdgeDTO{Source: o.ID, Target: *o.Target}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := NewFlowGraph(ids, edges).TopologicalOrder(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	return edges, nil
+}
+
+// isCodeStep reports whether an object is deployed as a Python step
+func isCodeStep(o models.BundleObject) bool {
+	if o.Type == "" || o.Type == "python" {
+		return true
+	}
+	_, hasCode := o.Params["code"]
+	return hasCode
+}
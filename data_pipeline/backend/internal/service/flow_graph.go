@@ -0,0 +1,187 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrFlowCycle = errors.New("flow graph contains a cycle")
+)
+
+// CycleError reports the nodes that could not be ordered because they form a cycle
+type CycleError struct {
+	Nodes []int64
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, 0, len(e.Nodes))
+	for _, id := range e.Nodes {
+		ids = append(ids, fmt.Sprintf("%d", id))
+	}
+	return fmt.Sprintf("%s: [%s]", ErrFlowCycle.Error(), strings.Join(ids, ", "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrFlowCycle
+}
+
+// FlowGraph is an adjacency view of a flow's objects and edges
+type FlowGraph struct {
+	nodes []int64
+	succ  map[int64][]int64
+	pred  map[int64][]int64
+}
+
+// NewFlowGraph builds a graph over the given node IDs.
+// Edges that reference nodes outside the set are ignored.
+func NewFlowGraph(nodes []int64, edges []models.EdgeDTO) *FlowGraph {
+	g := &FlowGraph{
+		succ: make(map[int64][]int64),
+		pred: make(map[int64][]int64),
+	}
+
+	known := make(map[int64]bool, len(nodes))
+	for _, id := range nodes {
+		if known[id] {
+			continue
+		}
+		known[id] = true
+		g.nodes = append(g.nodes, id)
+	}
+
+	seen := make(map[[2]int64]bool)
+	for _, e := range edges {
+		if !known[e.Source] || !known[e.Target] {
+			continue
+		}
+		key := [2]int64{e.Source, e.Target}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		g.succ[e.Source] = append(g.succ[e.Source], e.Target)
+		g.pred[e.Target] = append(g.pred[e.Target], e.Source)
+	}
+
+	for id := range g.succ {
+		sortInt64s(g.succ[id])
+	}
+	for id := range g.pred {
+		sortInt64s(g.pred[id])
+	}
+
+	return g
+}
+
+// Nodes returns node IDs in insertion order
+func (g *FlowGraph) Nodes() []int64 {
+	return g.nodes
+}
+
+// Successors returns the direct successors of a node
+func (g *FlowGraph) Successors(id int64) []int64 {
+	return g.succ[id]
+}
+
+// Predecessors returns the direct predecessors of a node
+func (g *FlowGraph) Predecessors(id int64) []int64 {
+	return g.pred[id]
+}
+
+// HasEdges reports whether the graph has at least one edge
+func (g *FlowGraph) HasEdges() bool {
+	return len(g.succ) > 0
+}
+
+// Edges returns all edges sorted by (source, target)
+func (g *FlowGraph) Edges() []models.EdgeDTO {
+	var edges []models.EdgeDTO
+	for _, src := range g.nodes {
+		for _, dst := range g.succ[src] {
+			edges = append(edges, models.EdgeDTO{Source: src, Target: dst})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+	return edges
+}
+
+// Roots returns nodes without predecessors
+func (g *FlowGraph) Roots() []int64 {
+	var roots []int64
+	for _, id := range g.nodes {
+		if len(g.pred[id]) == 0 {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// Leaves returns nodes without successors
+func (g *FlowGraph) Leaves() []int64 {
+	var leaves []int64
+	for _, id := range g.nodes {
+		if len(g.succ[id]) == 0 {
+			leaves = append(leaves, id)
+		}
+	}
+	return leaves
+}
+
+// TopologicalOrder returns nodes so that every edge points forward (Kahn's algorithm).
+// Ties are broken by the original node order so the result is deterministic.
+// A *CycleError is returned when the graph is not a DAG.
+func (g *FlowGraph) TopologicalOrder() ([]int64, error) {
+	position := make(map[int64]int, len(g.nodes))
+	inDegree := make(map[int64]int, len(g.nodes))
+	for i, id := range g.nodes {
+		position[id] = i
+		inDegree[id] = len(g.pred[id])
+	}
+
+	var ready []int64
+	for _, id := range g.nodes {
+		if inDegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]int64, 0, len(g.nodes))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return position[ready[i]] < position[ready[j]] })
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+
+		for _, next := range g.succ[id] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if len(order) != len(g.nodes) {
+		var cyclic []int64
+		for _, id := range g.nodes {
+			if inDegree[id] > 0 {
+				cyclic = append(cyclic, id)
+			}
+		}
+		return nil, &CycleError{Nodes: cyclic}
+	}
+
+	return order, nil
+}
+
+func sortInt64s(s []int64) {
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+}
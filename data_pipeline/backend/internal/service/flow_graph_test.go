@@ -0,0 +1,119 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func edgeList(pairs ...[2]int64) []models.EdgeDTO {
+	out := make([]models.EdgeDTO, 0, len(pairs))
+	for _, p := range pairs {
+		out = append(out, models.EdgeDTO{Source: p[0], Target: p[1]})
+	}
+	return out
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []int64
+		edges []models.EdgeDTO
+		want  []int64
+	}{
+		{name: "empty", want: []int64{}},
+		{name: "chain", nodes: []int64{3, 2, 1}, edges: edgeList([2]int64{1, 2}, [2]int64{2, 3}), want: []int64{1, 2, 3}},
+		{
+			name:  "diamond",
+			nodes: []int64{1, 2, 3, 4},
+			edges: edgeList([2]int64{1, 2}, [2]int64{1, 3}, [2]int64{2, 4}, [2]int64{3, 4}),
+			want:  []int64{1, 2, 3, 4},
+		},
+		{
+			name:  "fan-in",
+			nodes: []int64{3, 1, 2},
+			edges: edgeList([2]int64{1, 3}, [2]int64{2, 3}),
+			want:  []int64{1, 2, 3},
+		},
+		{
+			// 진입 차수가 같으면 원래 노드 순서를 따른다
+			name:  "disconnected",
+			nodes: []int64{4, 1, 2, 3},
+			edges: edgeList([2]int64{1, 2}),
+			want:  []int64{4, 1, 2, 3},
+		},
+		{
+			name:  "duplicate and foreign edges",
+			nodes: []int64{1, 2},
+			edges: edgeList([2]int64{1, 2}, [2]int64{1, 2}, [2]int64{2, 9}, [2]int64{9, 1}),
+			want:  []int64{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFlowGraph(tt.nodes, tt.edges).TopologicalOrder()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopologicalOrderCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []int64
+		edges []models.EdgeDTO
+		want  []int64 // CycleError.Nodes
+	}{
+		{name: "self-loop", nodes: []int64{1, 2}, edges: edgeList([2]int64{2, 2}), want: []int64{2}},
+		{
+			name:  "two nodes",
+			nodes: []int64{1, 2, 3},
+			edges: edgeList([2]int64{3, 1}, [2]int64{1, 2}, [2]int64{2, 1}),
+			want:  []int64{1, 2},
+		},
+		{
+			// 사이클 뒤에 매달린 노드도 정렬할 수 없으므로 함께 보고된다
+			name:  "three nodes with tail",
+			nodes: []int64{1, 2, 3, 4, 5},
+			edges: edgeList([2]int64{1, 2}, [2]int64{2, 3}, [2]int64{3, 4}, [2]int64{4, 2}, [2]int64{4, 5}),
+			want:  []int64{2, 3, 4, 5},
+		},
+		{
+			name:  "two cycles",
+			nodes: []int64{1, 2, 3, 4, 5},
+			edges: edgeList([2]int64{1, 2}, [2]int64{2, 1}, [2]int64{4, 5}, [2]int64{5, 4}),
+			want:  []int64{1, 2, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := NewFlowGraph(tt.nodes, tt.edges).TopologicalOrder()
+			if order != nil {
+				t.Fatalf("order = %v, want nil", order)
+			}
+			if !errors.Is(err, ErrFlowCycle) {
+				t.Fatalf("err = %v, want ErrFlowCycle", err)
+			}
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Fatalf("err = %T, want *CycleError", err)
+			}
+			if !reflect.DeepEqual(cycle.Nodes, tt.want) {
+				t.Fatalf("cycle nodes = %v, want %v", cycle.Nodes, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycleErrorMessage(t *testing.T) {
+	err := &CycleError{Nodes: []int64{4, 7}}
+	if got, want := err.Error(), "flow graph contains a cycle: [4, 7]"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
@@ -1,31 +1,58 @@
 package service
 
 import (
+	"context"
+	"data-pipeline-backend/internal/auth"
 	"data-pipeline-backend/internal/models"
 	"data-pipeline-backend/internal/repository"
 	"errors"
+	"fmt"
 	"time"
 )
 
+// FlowService manages flows. The caller in ctx needs viewer on a flow to
+// read it, editor to change it and admin to delete it or move it to another
+// project (see AccessService).
 type FlowService struct {
-	flowRepo *repository.FlowRepository
+	flowRepo   *repository.FlowRepository
+	objectRepo *repository.ObjectRepository
+	edgeRepo   *repository.EdgeRepository
+	access     *AccessService
+	versions   *FlowVersionService
 }
 
-func NewFlowService(flowRepo *repository.FlowRepository) *FlowService {
+func NewFlowService(flowRepo *repository.FlowRepository, objectRepo *repository.ObjectRepository, edgeRepo *repository.EdgeRepository,
+	access *AccessService, versions *FlowVersionService) *FlowService {
 	return &FlowService{
-		flowRepo: flowRepo,
+		flowRepo:   flowRepo,
+		objectRepo: objectRepo,
+		edgeRepo:   edgeRepo,
+		access:     access,
+		versions:   versions,
 	}
 }
 
-func (s *FlowService) Create(req *models.FlowRequestDTO) (*models.FlowResponseDTO, error) {
+// Create adds a flow owned by the caller; sharing it with a project needs
+// editor in the project
+func (s *FlowService) Create(ctx context.Context, req *models.FlowRequestDTO) (*models.FlowResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, fmt.Errorf("%w: 로그인이 필요합니다", ErrForbidden)
+	}
 	if req.Name == "" {
 		return nil, errors.New("플로우명은 필수입니다")
 	}
+	if req.ProjectID != nil {
+		if err := s.access.RequireProject(ctx, *req.ProjectID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
 
 	flow := &models.Flow{
 		Name:      req.Name,
 		RunType:   req.RunType,
-		CreatedBy: req.UserID,
+		CreatedBy: &user.ID,
+		ProjectID: req.ProjectID,
 	}
 
 	if err := s.flowRepo.Create(flow); err != nil {
@@ -35,8 +62,8 @@ func (s *FlowService) Create(req *models.FlowRequestDTO) (*models.FlowResponseDT
 	return flow.ToResponseDTO(), nil
 }
 
-func (s *FlowService) FindByID(id int64) (*models.FlowResponseDTO, error) {
-	flow, err := s.flowRepo.FindByID(id)
+func (s *FlowService) FindByID(ctx context.Context, id int64) (*models.FlowResponseDTO, error) {
+	flow, err := s.access.RequireFlow(ctx, id, models.ProjectRoleViewer)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +71,18 @@ func (s *FlowService) FindByID(id int64) (*models.FlowResponseDTO, error) {
 	return flow.ToResponseDTO(), nil
 }
 
+// List returns the flows the caller can see; admins see all
+func (s *FlowService) List(ctx context.Context) ([]*models.FlowResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+	if user.Admin {
+		return s.FindAll()
+	}
+	return s.FindAccessible(user.ID)
+}
+
 func (s *FlowService) FindAll() ([]*models.FlowResponseDTO, error) {
 	flows, err := s.flowRepo.FindAll()
 	if err != nil {
@@ -58,35 +97,77 @@ func (s *FlowService) FindAll() ([]*models.FlowResponseDTO, error) {
 	return dtos, nil
 }
 
-func (s *FlowService) Update(req *models.FlowRequestDTO) (*models.FlowResponseDTO, error) {
+// FindAccessible returns the flows a user can see: its personal flows and
+// those of its projects
+func (s *FlowService) FindAccessible(userID int64) ([]*models.FlowResponseDTO, error) {
+	flows, err := s.flowRepo.FindAccessible(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var dtos []*models.FlowResponseDTO
+	for _, flow := range flows {
+		dtos = append(dtos, flow.ToResponseDTO())
+	}
+
+	return dtos, nil
+}
+
+func (s *FlowService) Update(ctx context.Context, req *models.FlowRequestDTO) (*models.FlowResponseDTO, error) {
 	if req.ID == nil {
 		return nil, errors.New("플로우 ID는 필수입니다")
 	}
 
+	flow, err := s.access.RequireFlow(ctx, *req.ID, models.ProjectRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	// 프로젝트 이동은 플로우 admin이면서 대상 프로젝트의 editor여야 한다
+	if req.ProjectID != nil && (flow.ProjectID == nil || *flow.ProjectID != *req.ProjectID) {
+		if _, err := s.access.RequireFlow(ctx, flow.ID, models.ProjectRoleAdmin); err != nil {
+			return nil, err
+		}
+		if err := s.access.RequireProject(ctx, *req.ProjectID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Name == "" {
 		return nil, errors.New("플로우명은 필수입니다")
 	}
 
-	flow, err := s.flowRepo.FindByID(*req.ID)
-	if err != nil {
-		if err == repository.ErrFlowNotFound {
-			return nil, repository.ErrFlowNotFound
-		}
-		return nil, err
+	var userID *int64
+	if user := auth.UserFromContext(ctx); user != nil {
+		userID = &user.ID
 	}
-
 	flow.Name = req.Name
 	flow.RunType = req.RunType
-	flow.CreatedBy = req.UserID
+	// 소유자는 바뀌지 않는다; 소유자 없는 기존 플로우만 처음 저장한 사용자가 갖는다
+	if flow.CreatedBy == nil {
+		flow.CreatedBy = userID
+	}
+	if req.ProjectID != nil {
+		flow.ProjectID = req.ProjectID
+	}
 
-	if err := s.flowRepo.Update(flow); err != nil {
+	err = s.flowRepo.InTx(func(tx repository.DBTX) error {
+		if err := s.flowRepo.WithTx(tx).Update(flow); err != nil {
+			return err
+		}
+		_, err := s.versions.withTx(tx).RecordSave(flow.ID, userID)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return flow.ToResponseDTO(), nil
 }
 
-func (s *FlowService) Delete(id int64) error {
+func (s *FlowService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.access.RequireFlow(ctx, id, models.ProjectRoleAdmin); err != nil {
+		return err
+	}
 	return s.flowRepo.Delete(id)
 }
 
@@ -102,3 +183,49 @@ func (s *FlowService) UpdateLatestRun(id int64) error {
 	now := time.Now()
 	return s.flowRepo.UpdateLatestRun(id, now)
 }
+
+// GetGraph returns the flow's nodes and edges together with a topological order.
+// A *CycleError is returned when the stored edges do not form a DAG.
+func (s *FlowService) GetGraph(ctx context.Context, id int64) (*models.FlowGraphResponseDTO, error) {
+	if _, err := s.access.RequireFlow(ctx, id, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	objects, err := s.objectRepo.FindByFlow(id)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.edgeRepo.FindByFlow(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := toObjectDTOsWithTargets(objects, edges)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs := make([]int64, 0, len(objects))
+	for _, o := range objects {
+		nodeIDs = append(nodeIDs, o.ID)
+	}
+	edgeDTOs := make([]models.EdgeDTO, 0, len(edges))
+	for _, e := range edges {
+		edgeDTOs = append(edgeDTOs, e.ToDTO())
+	}
+
+	graph := NewFlowGraph(nodeIDs, edgeDTOs)
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FlowGraphResponseDTO{
+		FlowID: id,
+		Nodes:  nodes,
+		Edges:  graph.Edges(),
+		Order:  order,
+		Roots:  graph.Roots(),
+		Leaves: graph.Leaves(),
+	}, nil
+}
@@ -0,0 +1,503 @@
+package service
+
+import (
+	"crypto/sha256"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type FlowVersionService struct {
+	versionRepo *repository.FlowVersionRepository
+	flowRepo    *repository.FlowRepository
+	objectRepo  *repository.ObjectRepository
+	edgeRepo    *repository.EdgeRepository
+}
+
+func NewFlowVersionService(versionRepo *repository.FlowVersionRepository, flowRepo *repository.FlowRepository, objectRepo *repository.ObjectRepository, edgeRepo *repository.EdgeRepository) *FlowVersionService {
+	return &FlowVersionService{
+		versionRepo: versionRepo,
+		flowRepo:    flowRepo,
+		objectRepo:  objectRepo,
+		edgeRepo:    edgeRepo,
+	}
+}
+
+// RecordSave snapshots the flow after a save. Nothing is recorded when the
+// snapshot is identical to the latest version.
+func (s *FlowVersionService) RecordSave(flowID int64, createdBy *int64) (*models.FlowVersionResponseDTO, error) {
+	snapshot, hash, err := s.capture(flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.versionRepo.FindLatest(flowID)
+	if err != nil && err != repository.ErrFlowVersionNotFound {
+		return nil, err
+	}
+	if latest != nil && latest.SnapshotHash == hash {
+		return latest.ToResponseDTO(), nil
+	}
+
+	return s.create(flowID, models.FlowVersionReasonSave, snapshot, hash, nil, createdBy)
+}
+
+// RecordDeploy snapshots the flow together with the options and outcome of a deploy
+func (s *FlowVersionService) RecordDeploy(flowID int64, opts *models.DeployOptions, ok bool, message string, createdBy *int64) (*models.FlowVersionResponseDTO, error) {
+	snapshot, hash, err := s.capture(flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	optsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	status := "ng"
+	if ok {
+		status = "ok"
+	}
+
+	v := &models.FlowVersion{
+		FlowID:        flowID,
+		Reason:        models.FlowVersionReasonDeploy,
+		Snapshot:      snapshot,
+		SnapshotHash:  hash,
+		DeployOptions: optsJSON,
+		DeployStatus:  &status,
+		DeployMessage: &message,
+		CreatedBy:     createdBy,
+	}
+	if err := s.versionRepo.Create(v); err != nil {
+		return nil, err
+	}
+	return v.ToResponseDTO(), nil
+}
+
+func (s *FlowVersionService) List(flowID int64, limit, offset int) ([]*models.FlowVersionResponseDTO, error) {
+	if _, err := s.flowRepo.FindByID(flowID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepo.FindByFlow(flowID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]*models.FlowVersionResponseDTO, 0, len(versions))
+	for _, v := range versions {
+		dtos = append(dtos, v.ToResponseDTO())
+	}
+	return dtos, nil
+}
+
+func (s *FlowVersionService) Get(flowID int64, version int) (*models.FlowVersionDetailDTO, error) {
+	v, err := s.versionRepo.FindByVersion(flowID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := decodeSnapshot(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dto := &models.FlowVersionDetailDTO{
+		FlowVersionResponseDTO: *v.ToResponseDTO(),
+		Snapshot:               snapshot,
+		DeployMessage:          v.DeployMessage,
+	}
+	if len(v.DeployOptions) > 0 {
+		var opts models.DeployOptions
+		if err := json.Unmarshal(v.DeployOptions, &opts); err != nil {
+			return nil, err
+		}
+		dto.DeployOptions = &opts
+	}
+	return dto, nil
+}
+
+// Diff compares two versions node by node, matching nodes by object ID
+func (s *FlowVersionService) Diff(flowID int64, from, to int) (*models.FlowVersionDiffDTO, error) {
+	fromV, err := s.versionRepo.FindByVersion(flowID, from)
+	if err != nil {
+		return nil, err
+	}
+	toV, err := s.versionRepo.FindByVersion(flowID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := decodeSnapshot(fromV)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeSnapshot(toV)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.FlowVersionDiffDTO{
+		FlowID:       flowID,
+		From:         from,
+		To:           to,
+		Nodes:        []models.NodeDiffDTO{},
+		EdgesAdded:   []models.EdgeDTO{},
+		EdgesRemoved: []models.EdgeDTO{},
+	}
+	if a.Name != b.Name {
+		diff.FlowFields = append(diff.FlowFields, "name")
+	}
+	if a.RunType != b.RunType {
+		diff.FlowFields = append(diff.FlowFields, "run_type")
+	}
+
+	oldNodes := make(map[int64]models.SnapshotNode, len(a.Nodes))
+	for _, n := range a.Nodes {
+		oldNodes[n.ObjectID] = n
+	}
+	newNodes := make(map[int64]models.SnapshotNode, len(b.Nodes))
+	for _, n := range b.Nodes {
+		newNodes[n.ObjectID] = n
+	}
+
+	for _, n := range b.Nodes {
+		old, ok := oldNodes[n.ObjectID]
+		if !ok {
+			diff.Nodes = append(diff.Nodes, models.NodeDiffDTO{ObjectID: n.ObjectID, Label: n.Label, Change: "added"})
+			continue
+		}
+		if fields := diffNode(old, n); len(fields) > 0 {
+			diff.Nodes = append(diff.Nodes, models.NodeDiffDTO{ObjectID: n.ObjectID, Label: n.Label, Change: "modified", Fields: fields})
+		}
+	}
+	for _, n := range a.Nodes {
+		if _, ok := newNodes[n.ObjectID]; !ok {
+			diff.Nodes = append(diff.Nodes, models.NodeDiffDTO{ObjectID: n.ObjectID, Label: n.Label, Change: "removed"})
+		}
+	}
+	sort.Slice(diff.Nodes, func(i, j int) bool { return diff.Nodes[i].ObjectID < diff.Nodes[j].ObjectID })
+
+	oldEdges := make(map[models.EdgeDTO]bool, len(a.Edges))
+	for _, e := range a.Edges {
+		oldEdges[e] = true
+	}
+	newEdges := make(map[models.EdgeDTO]bool, len(b.Edges))
+	for _, e := range b.Edges {
+		newEdges[e] = true
+		if !oldEdges[e] {
+			diff.EdgesAdded = append(diff.EdgesAdded, e)
+		}
+	}
+	for _, e := range a.Edges {
+		if !newEdges[e] {
+			diff.EdgesRemoved = append(diff.EdgesRemoved, e)
+		}
+	}
+
+	return diff, nil
+}
+
+// Rollback restores the flow's objects and edges to an older version and
+// records the result as a new "rollback" version, all in one transaction.
+// Objects deleted since then are recreated with new IDs; IDMap maps
+// snapshot IDs to the live IDs.
+func (s *FlowVersionService) Rollback(flowID int64, version int, createdBy *int64) (*models.FlowRollbackResponseDTO, error) {
+	var result *models.FlowRollbackResponseDTO
+	err := s.versionRepo.InTx(func(tx repository.DBTX) error {
+		var err error
+		result, err = s.withTx(tx).rollback(flowID, version, createdBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withTx returns the service with its repositories running in tx
+func (s *FlowVersionService) withTx(tx repository.DBTX) *FlowVersionService {
+	return &FlowVersionService{
+		versionRepo: s.versionRepo.WithTx(tx),
+		flowRepo:    s.flowRepo.WithTx(tx),
+		objectRepo:  s.objectRepo.WithTx(tx),
+		edgeRepo:    s.edgeRepo.WithTx(tx),
+	}
+}
+
+func (s *FlowVersionService) rollback(flowID int64, version int, createdBy *int64) (*models.FlowRollbackResponseDTO, error) {
+	flow, err := s.flowRepo.FindByID(flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.versionRepo.FindByVersion(flowID, version)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := decodeSnapshot(target)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.objectRepo.FindByFlow(flowID)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[int64]*models.Object, len(current))
+	for _, o := range current {
+		live[o.ID] = o
+	}
+
+	// 1) 스냅샷 노드 복원 (타깃은 ID 매핑 이후에 연결)
+	idMap := make(map[int64]int64, len(snapshot.Nodes))
+	restored := make(map[int64]*models.Object, len(snapshot.Nodes))
+	for _, n := range snapshot.Nodes {
+		var params json.RawMessage
+		if len(n.Params) > 0 {
+			b, err := json.Marshal(n.Params)
+			if err != nil {
+				return nil, err
+			}
+			params = b
+		}
+
+		fid := flowID
+		obj, exists := live[n.ObjectID]
+		if !exists {
+			obj = &models.Object{}
+		}
+		obj.Type = n.Type
+		obj.Label = n.Label
+		obj.X = n.X
+		obj.Y = n.Y
+		obj.Params = params
+		obj.Target = nil
+		obj.FlowID = &fid
+
+		if exists {
+			err = s.objectRepo.Update(obj)
+		} else {
+			err = s.objectRepo.Create(obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		idMap[n.ObjectID] = obj.ID
+		restored[obj.ID] = obj
+	}
+
+	// 2) 스냅샷에 없는 오브젝트 삭제 (엣지는 cascade)
+	for _, o := range current {
+		if _, keep := restored[o.ID]; !keep {
+			if err := s.objectRepo.Delete(o.ID); err != nil && err != repository.ErrObjectNotFound {
+				return nil, err
+			}
+		}
+	}
+
+	// 3) 엣지 복원
+	targets := make(map[int64][]int64)
+	for _, e := range snapshot.Edges {
+		src, ok1 := idMap[e.Source]
+		dst, ok2 := idMap[e.Target]
+		if ok1 && ok2 {
+			targets[src] = append(targets[src], dst)
+		}
+	}
+	for _, n := range snapshot.Nodes {
+		id := idMap[n.ObjectID]
+		obj := restored[id]
+		obj.Target = firstTarget(targets[id])
+		if obj.Target != nil {
+			if err := s.objectRepo.Update(obj); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.edgeRepo.ReplaceTargets(flowID, id, targets[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4) 플로우 메타데이터 복원
+	if flow.Name != snapshot.Name || flow.RunType != snapshot.RunType {
+		flow.Name = snapshot.Name
+		flow.RunType = snapshot.RunType
+		if err := s.flowRepo.Update(flow); err != nil {
+			return nil, err
+		}
+	}
+
+	newSnapshot, hash, err := s.capture(flowID)
+	if err != nil {
+		return nil, err
+	}
+	parent := version
+	dto, err := s.create(flowID, models.FlowVersionReasonRollback, newSnapshot, hash, &parent, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FlowRollbackResponseDTO{
+		RestoredFrom: version,
+		Version:      dto,
+		Steps:        []int64{},
+	}
+	for oldID, newID := range idMap {
+		if oldID != newID {
+			if result.IDMap == nil {
+				result.IDMap = make(map[int64]int64)
+			}
+			result.IDMap[oldID] = newID
+		}
+	}
+
+	// 배포 버전이면 당시의 스텝 구성을, 아니면 전체 노드를 배포 대상으로 사용
+	if len(target.DeployOptions) > 0 {
+		var opts models.DeployOptions
+		if err := json.Unmarshal(target.DeployOptions, &opts); err != nil {
+			return nil, err
+		}
+		result.User = opts.User
+		for _, step := range opts.Steps {
+			if id, ok := idMap[step]; ok {
+				result.Steps = append(result.Steps, id)
+			}
+		}
+	} else {
+		for _, n := range snapshot.Nodes {
+			result.Steps = append(result.Steps, idMap[n.ObjectID])
+		}
+	}
+
+	return result, nil
+}
+
+func (s *FlowVersionService) create(flowID int64, reason string, snapshot json.RawMessage, hash string, parentVersion *int, createdBy *int64) (*models.FlowVersionResponseDTO, error) {
+	v := &models.FlowVersion{
+		FlowID:        flowID,
+		Reason:        reason,
+		Snapshot:      snapshot,
+		SnapshotHash:  hash,
+		ParentVersion: parentVersion,
+		CreatedBy:     createdBy,
+	}
+	if err := s.versionRepo.Create(v); err != nil {
+		return nil, err
+	}
+	return v.ToResponseDTO(), nil
+}
+
+// capture serializes the current state of the flow and returns it with its sha256
+func (s *FlowVersionService) capture(flowID int64) (json.RawMessage, string, error) {
+	flow, err := s.flowRepo.FindByID(flowID)
+	if err != nil {
+		return nil, "", err
+	}
+	objects, err := s.objectRepo.FindByFlow(flowID)
+	if err != nil {
+		return nil, "", err
+	}
+	edges, err := s.edgeRepo.FindByFlow(flowID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	snapshot := models.FlowSnapshot{
+		Name:    flow.Name,
+		RunType: flow.RunType,
+		Nodes:   make([]models.SnapshotNode, 0, len(objects)),
+		Edges:   make([]models.EdgeDTO, 0, len(edges)),
+	}
+	for _, o := range objects {
+		node := models.SnapshotNode{
+			ObjectID: o.ID,
+			Type:     o.Type,
+			Label:    o.Label,
+			X:        o.X,
+			Y:        o.Y,
+		}
+		if len(o.Params) > 0 {
+			if err := json.Unmarshal(o.Params, &node.Params); err != nil {
+				return nil, "", fmt.Errorf("invalid params JSON for object id=%d: %w", o.ID, err)
+			}
+			if code, ok := node.Params["code"]; ok && code != nil {
+				node.CodeHash = sha256Hex(fmt.Sprintf("%v", code))
+			}
+		}
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool { return snapshot.Nodes[i].ObjectID < snapshot.Nodes[j].ObjectID })
+	for _, e := range edges {
+		snapshot.Edges = append(snapshot.Edges, e.ToDTO())
+	}
+
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, sha256Hex(string(b)), nil
+}
+
+func decodeSnapshot(v *models.FlowVersion) (*models.FlowSnapshot, error) {
+	var snapshot models.FlowSnapshot
+	if err := json.Unmarshal(v.Snapshot, &snapshot); err != nil {
+		return nil, errors.New("스냅샷 JSON 변환 실패: " + err.Error())
+	}
+	return &snapshot, nil
+}
+
+// diffNode lists the fields that differ between two snapshots of the same node
+func diffNode(a, b models.SnapshotNode) []string {
+	var fields []string
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.Label != b.Label {
+		fields = append(fields, "label")
+	}
+	if !reflect.DeepEqual(a.X, b.X) || !reflect.DeepEqual(a.Y, b.Y) {
+		fields = append(fields, "position")
+	}
+	if a.CodeHash != b.CodeHash {
+		fields = append(fields, "code")
+	}
+
+	pa := withoutCode(a.Params)
+	pb := withoutCode(b.Params)
+	keys := make(map[string]bool)
+	for k := range pa {
+		keys[k] = true
+	}
+	for k := range pb {
+		keys[k] = true
+	}
+	var changed []string
+	for k := range keys {
+		if !reflect.DeepEqual(pa[k], pb[k]) {
+			changed = append(changed, "params."+k)
+		}
+	}
+	sort.Strings(changed)
+	return append(fields, changed...)
+}
+
+func withoutCode(params map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if k != "code" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func sha256Hex(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/models"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// renderedObject is a manifest together with the resource it is applied to
+type renderedObject struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+	obj        *unstructured.Unstructured
+}
+
+// Render returns the multi-document YAML that Apply would send to the API server.
+// Names are deterministic; the kick Job is suffixed with a hash of the flow content.
+func (s *K8sService) Render(dto *models.K8sRequestDTO, includeNamespace bool) (string, error) {
+	objs, err := s.renderObjects(dto, includeNamespace)
+	if err != nil {
+		return "", err
+	}
+	return toMultiDocYAML(objs)
+}
+
+// RenderDryRun renders the flow and submits every manifest with dryRun=All so
+// admission errors are reported without persisting anything.
+func (s *K8sService) RenderDryRun(ctx context.Context, dto *models.K8sRequestDTO, includeNamespace bool) (*models.K8sRenderResponseDTO, error) {
+	objs, err := s.renderObjects(dto, includeNamespace)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := toMultiDocYAML(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.K8sRenderResponseDTO{
+		Manifest: manifest,
+		DryRun:   "server",
+		OK:       true,
+		Results:  make([]models.K8sDryRunResultDTO, 0, len(objs)),
+	}
+	for _, ro := range objs {
+		result := s.dryRunObject(ctx, ro)
+		if !result.OK {
+			resp.OK = false
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+func (s *K8sService) renderObjects(dto *models.K8sRequestDTO, includeNamespace bool) ([]renderedObject, error) {
+	steps, err := s.makeStep(dto.Steps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make steps: %w", err)
+	}
+	if err := s.validateDTO(dto, steps); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 커넥션 값이 매니페스트에 남지 않도록 Secret은 렌더링하지 않고 참조만 둔다
+	conns, err := s.loadConnections(dto.User, steps, false)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	ns := "user-" + dto.User
+	flowID := dto.FlowID
+	var objs []renderedObject
+
+	if includeNamespace {
+		nsObj, err := toUnstructured(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}, "v1", "Namespace")
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, renderedObject{
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			obj: nsObj,
+		})
+	}
+
+	objs = append(objs,
+		renderedObject{
+			gvr:        schema.GroupVersionResource{Group: "kafka.strimzi.io", Version: "v1beta2", Resource: "kafkatopics"},
+			namespaced: true,
+			obj:        s.buildKafkaTopic(flowID, flowID),
+		},
+		renderedObject{
+			gvr:        schema.GroupVersionResource{Group: "kafka.strimzi.io", Version: "v1beta2", Resource: "kafkatopics"},
+			namespaced: true,
+			obj:        s.buildKafkaTopic(dlqTopicName(flowID), flowID),
+		},
+		renderedObject{
+			gvr:        schema.GroupVersionResource{Group: "eventing.knative.dev", Version: "v1alpha1", Resource: "kafkasinks"},
+			namespaced: true,
+			obj:        s.buildKafkaSink(ns, flowID, "sink-"+flowID, flowID),
+		},
+		renderedObject{
+			gvr:        schema.GroupVersionResource{Group: "eventing.knative.dev", Version: "v1alpha1", Resource: "kafkasinks"},
+			namespaced: true,
+			obj:        s.buildKafkaSink(ns, flowID, dlqSinkName(flowID), dlqTopicName(flowID)),
+		},
+	)
+
+	var contentHashes []string
+	for _, step := range steps {
+		safeStepName := s.safeName(step.Name)
+		inType := s.getInType(flowID, step)
+		outType := s.getOutType(flowID, step)
+		cmName := fmt.Sprintf("code-flow%s-%s", flowID, safeStepName)
+		ksvcName := fmt.Sprintf("flow%s-%s", flowID, safeStepName)
+		cg := fmt.Sprintf("cg-flow%s-%s-v1", flowID, safeStepName)
+		codeHash := s.sha256(step.Code)
+		retry := s.stepRetryPolicy(step.Params)
+		contentHashes = append(contentHashes, safeStepName+"="+codeHash+"@"+inType+">"+outType)
+
+		cm, err := toUnstructured(s.buildConfigMap(ns, cmName, flowID, step.Code), "v1", "ConfigMap")
+		if err != nil {
+			return nil, err
+		}
+
+		objs = append(objs,
+			renderedObject{
+				gvr:        schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+				namespaced: true,
+				obj:        cm,
+			},
+			renderedObject{
+				gvr:        schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"},
+				namespaced: true,
+				obj:        s.buildKsvc(ns, flowID, cmName, ksvcName, safeStepName, inType, outType, codeHash, s.plannedImage(s.stepImageSpec(step.Params)), s.stepRuntime(step.Params), s.stepConnections(step.Params, conns), retry),
+			},
+			renderedObject{
+				gvr:        schema.GroupVersionResource{Group: "sources.knative.dev", Version: "v1", Resource: "kafkasources"},
+				namespaced: true,
+				obj:        s.buildKafkaSource(ns, flowID, ksvcName, cg, retry),
+			},
+		)
+	}
+
+	jobName := fmt.Sprintf("kick-flow-%s-%s", flowID, s.sha256(strings.Join(contentHashes, ","))[:8])
+	job, err := toUnstructured(s.buildKickJob(ns, flowID, jobName), "batch/v1", "Job")
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, renderedObject{
+		gvr:        schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"},
+		namespaced: true,
+		obj:        job,
+	})
+
+	return objs, nil
+}
+
+func (s *K8sService) dryRunObject(ctx context.Context, ro renderedObject) models.K8sDryRunResultDTO {
+	result := models.K8sDryRunResultDTO{
+		APIVersion: ro.obj.GetAPIVersion(),
+		Kind:       ro.obj.GetKind(),
+		Namespace:  ro.obj.GetNamespace(),
+		Name:       ro.obj.GetName(),
+		Operation:  "create",
+	}
+
+	var client dynamic.ResourceInterface = s.dynamicClient.Resource(ro.gvr)
+	if ro.namespaced {
+		client = s.dynamicClient.Resource(ro.gvr).Namespace(ro.obj.GetNamespace())
+	}
+
+	dryRun := []string{metav1.DryRunAll}
+	_, err := client.Create(ctx, ro.obj, metav1.CreateOptions{DryRun: dryRun})
+	if apierrors.IsAlreadyExists(err) {
+		switch ro.obj.GetKind() {
+		case "Namespace", "Job":
+			// 네임스페이스는 재사용되고, Job 템플릿은 변경 불가이므로 기존 리소스를 그대로 둔다
+			err = nil
+		default:
+			result.Operation = "update"
+			existing, getErr := client.Get(ctx, ro.obj.GetName(), metav1.GetOptions{})
+			if getErr != nil {
+				err = getErr
+				break
+			}
+			ro.obj.SetResourceVersion(existing.GetResourceVersion())
+			_, err = client.Update(ctx, ro.obj, metav1.UpdateOptions{DryRun: dryRun})
+			ro.obj.SetResourceVersion("")
+		}
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// toUnstructured converts a typed object to a manifest with apiVersion/kind set
+// and server-populated empty fields removed.
+func toUnstructured(obj runtime.Object, apiVersion, kind string) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "status")
+	return u, nil
+}
+
+func toMultiDocYAML(objs []renderedObject) (string, error) {
+	var sb strings.Builder
+	for i, ro := range objs {
+		out, err := yaml.Marshal(ro.obj.Object)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s/%s: %w", ro.obj.GetKind(), ro.obj.GetName(), err)
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(out)
+	}
+	return sb.String(), nil
+}
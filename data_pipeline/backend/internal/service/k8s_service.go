@@ -33,7 +33,7 @@ import (
 )
 
 // RUNNER_PY is the Python runner script embedded in KService containers
-const RUNNER_PY = `import os, time, json, uuid, hashlib, importlib.util
+const RUNNER_PY = `import os, time, json, copy, uuid, hashlib, threading, traceback, importlib.util
 from http.server import BaseHTTPRequestHandler, HTTPServer
 from urllib.request import Request, urlopen
 from collections import OrderedDict
@@ -45,6 +45,14 @@ OUT_TYPE = os.environ.get("OUT_TYPE","").strip()
 MAX_HOPS = int(os.environ.get("MAX_HOPS","5"))
 DEDUPE_WINDOW = int(os.environ.get("DEDUPE_WINDOW_SEC","60"))
 SINK = os.environ.get("K_SINK","")
+DLQ_SINK = os.environ.get("K_DLQ_SINK","")
+TRACE_URL = os.environ.get("TRACE_URL","")
+TRACE_TOKEN = os.environ.get("TRACE_TOKEN","")
+RETRY_MAX_ATTEMPTS = max(1, int(os.environ.get("RETRY_MAX_ATTEMPTS","1")))
+RETRY_BACKOFF = os.environ.get("RETRY_BACKOFF","exponential")
+RETRY_BACKOFF_MS = int(os.environ.get("RETRY_BACKOFF_MS","500"))
+RETRY_MAX_BACKOFF_MS = int(os.environ.get("RETRY_MAX_BACKOFF_MS","10000"))
+RETRY_ON = [t.strip() for t in os.environ.get("RETRY_ON","").split(",") if t.strip()]
 
 def load_user():
     p="/code/user_code.py"
@@ -65,20 +73,58 @@ def dedupe(key):
     if len(seen) > 2048: seen.popitem(last=False)
     return False
 
-def post_ce(url, obj, typ, trace_id=None, hops=0):
+# 재시도 가능한 예외: RETRY_ON이 비어 있으면 모든 예외, 아니면 클래스(상위 클래스 포함) 이름으로 판단
+def retryable(exc):
+    if not RETRY_ON: return True
+    return any(c.__name__ in RETRY_ON for c in type(exc).__mro__)
+
+def with_retry(fn, what, check=retryable):
+    attempt = 1
+    while True:
+        try: return fn()
+        except Exception as e:
+            if attempt >= RETRY_MAX_ATTEMPTS or not check(e): raise
+            delay = RETRY_BACKOFF_MS * (2 ** (attempt-1) if RETRY_BACKOFF == "exponential" else attempt)
+            print(f"[{APP_ID.upper()}] retry {what} attempt={attempt} error: {e}", flush=True)
+            time.sleep(min(delay, RETRY_MAX_BACKOFF_MS) / 1000.0)
+            attempt += 1
+
+def post_ce(url, obj, typ, trace_id=None, hops=0, ceid=None):
     if not url or not typ: return
     data = json.dumps(obj).encode("utf-8")
+    ceid = ceid or str(uuid.uuid4())
     hdr = {
       "Content-Type":"application/json",
       "Ce-Specversion":"1.0",
       "Ce-Type": typ,
       "Ce-Source": f"/flow/{FLOW_ID}/{APP_ID}",
-      "Ce-Id": str(uuid.uuid4()),
+      "Ce-Id": ceid,
       "Ce-Traceid": trace_id or str(uuid.uuid4()),
       "Ce-Hops": str(hops),
       "Ce-Producer": APP_ID,
     }
     urlopen(Request(url, data=data, headers=hdr), timeout=5).read()
+    return ceid
+
+# 이벤트 처리 단위 span을 백엔드로 비동기 보고 (실패해도 처리에는 영향 없음)
+def report_span(span):
+    if not TRACE_URL: return
+    span.update({"flow_id": FLOW_ID, "step": APP_ID})
+    def send():
+        try: urlopen(Request(TRACE_URL, data=json.dumps(span).encode("utf-8"), headers={"Content-Type":"application/json", "X-Trace-Token":TRACE_TOKEN}), timeout=3).read()
+        except Exception as e: print(f"[{APP_ID.upper()}] trace error: {e}", flush=True)
+    threading.Thread(target=send, daemon=True).start()
+
+# 실패한 이벤트를 예외와 원본 CloudEvent 속성과 함께 dead-letter 토픽으로 보냄
+def dead_letter(evt, exc, ce):
+    if not DLQ_SINK: return True
+    entry = {"step": APP_ID, "error": str(exc), "traceback": traceback.format_exc(), "event": evt, "ce": ce, "failed_at": time.time()}
+    try:
+        with_retry(lambda: post_ce(DLQ_SINK, entry, f"flow{FLOW_ID}.dlq", trace_id=ce.get("traceid"), hops=ce.get("hops", 0)), "dlq", check=lambda e: True)
+        return True
+    except Exception as e:
+        print(f"[{APP_ID.upper()}] dlq error: {e}", flush=True)
+        return False
 
 class H(BaseHTTPRequestHandler):
     def do_GET(self): self.send_response(200); self.end_headers(); self.wfile.write(b"ok")
@@ -94,41 +140,67 @@ class H(BaseHTTPRequestHandler):
         hops     = int(self.headers.get("Ce-Hops") or self.headers.get("ce-hops") or "0")
         producer = self.headers.get("Ce-Producer") or self.headers.get("ce-producer") or ""
         ceid     = self.headers.get("Ce-Id") or self.headers.get("ce-id")
+        source   = self.headers.get("Ce-Source") or self.headers.get("ce-source") or ""
+        target   = self.headers.get("Ce-Target") or self.headers.get("ce-target") or ""
         if not ceid: ceid = hashlib.sha1(raw).hexdigest()
+        if not trace_id: trace_id = ceid  # 킥 이벤트가 trace의 시작
         if dedupe(ceid): self.send_response(204); self.end_headers(); return
-
-        if hops >= MAX_HOPS: self.send_response(204); self.end_headers(); return
+        if target and target != APP_ID: self.send_response(204); self.end_headers(); return  # DLQ 재처리 대상 스텝만 처리
+
+        t0 = time.time()
+        span = {"trace_id": trace_id, "event_id": ceid, "producer": producer, "in_type": ctype, "hops": hops, "start": t0}
+        if hops >= MAX_HOPS:
+            if not IN_TYPES or ctype in IN_TYPES:
+                span.update({"status": "dropped", "error": f"max hops {MAX_HOPS} reached", "duration_ms": 0})
+                report_span(span)
+            self.send_response(204); self.end_headers(); return
         if OUT_TYPE and ctype == OUT_TYPE: self.send_response(204); self.end_headers(); return
         if producer == APP_ID: self.send_response(204); self.end_headers(); return
         if IN_TYPES and ctype not in IN_TYPES: self.send_response(204); self.end_headers(); return
 
+        status = 204
         try:
-            out = USER_HANDLE(evt if isinstance(evt, dict) else {})
+            out = with_retry(lambda: USER_HANDLE(copy.deepcopy(evt) if isinstance(evt, dict) else {}), "handle")
             outs = out if isinstance(out, list) else ([out] if out is not None else [])
             emitted = []  # 실제 발행된 ce_type들을 기록
+            emitted_ids = []
+            errors = []
 
-            for item in outs:
+            for i, item in enumerate(outs):
                 if not isinstance(item, dict): continue
                 # 마지막 스텝이면 발행 금지 (OUT_TYPE이 비어 있음)
                 ALLOW_EMIT = bool(OUT_TYPE)
                 # 사용자 코드가 지정한 타입이 있으면 우선
                 t = item.pop("__type", OUT_TYPE if ALLOW_EMIT else "")
                 if ALLOW_EMIT and t and SINK:
+                    # 재전송돼도 같은 id가 되도록 입력 이벤트 기준으로 결정 → 다음 스텝의 dedupe로 중복 제거
+                    out_id = str(uuid.uuid5(uuid.NAMESPACE_URL, f"{ceid}/{APP_ID}/{i}"))
                     try:
-                        post_ce(SINK, item, t, trace_id=trace_id, hops=hops+1)
+                        with_retry(lambda: post_ce(SINK, item, t, trace_id=trace_id, hops=hops+1, ceid=out_id), "emit", check=lambda e: True)
                         emitted.append(t)
+                        emitted_ids.append({"id": out_id, "type": t})
                         print(f"[{APP_ID.upper()}] emit ce_type={t}", flush=True)  # 👈 검증용 로그
                     except Exception as e:
+                        errors.append(f"emit error: {e}")
                         print(f"[{APP_ID.upper()}] emit error: {e}", flush=True)
 
             # 요약 로그 (in/out 가시화)
             out_types = ",".join(emitted) if emitted else (OUT_TYPE or "-")
             size = len(outs) if isinstance(outs, list) else (1 if out is not None else 0)
             print(f"[{APP_ID.upper()}] in={ctype} out={out_types} items={size}", flush=True)
+            span.update({"status": "error" if errors else "ok", "error": "; ".join(errors), "items": size,
+                         "emitted": emitted_ids, "duration_ms": (time.time()-t0)*1000})
+            report_span(span)
+            if errors: status = 500  # KafkaSource delivery 정책으로 재전송
         except Exception as e:
             print(f"[{APP_ID.upper()}] error: {e}", flush=True)
+            span.update({"status": "error", "error": str(e), "duration_ms": (time.time()-t0)*1000})
+            report_span(span)
+            if not dead_letter(evt, e, {"type": ctype, "id": ceid, "source": source, "traceid": trace_id, "hops": hops, "producer": producer}):
+                status = 500
 
-        self.send_response(204); self.end_headers()
+        if status != 204: seen.pop(ceid, None)  # 재전송된 이벤트가 dedupe에 걸리지 않게
+        self.send_response(status); self.end_headers()
     def log_message(self,*a): pass
 HTTPServer(("0.0.0.0",8080), H).serve_forever()
 `
@@ -138,14 +210,19 @@ type K8sService struct {
 	clientset      *kubernetes.Clientset
 	dynamicClient  dynamic.Interface
 	objectRepo     *repository.ObjectRepository
+	edgeRepo       *repository.EdgeRepository
+	imageRepo      *repository.StepImageRepository
+	connRepo       *repository.ConnectionRepository
+	access         *AccessService
 	kafkaNamespace string
 	kafkaCluster   string
 	kafkaBootstrap string
 	pyImage        string
+	traceURL       string
 }
 
 // NewK8sService creates a new K8sService instance
-func NewK8sService(objectRepo *repository.ObjectRepository) (*K8sService, error) {
+func NewK8sService(objectRepo *repository.ObjectRepository, edgeRepo *repository.EdgeRepository, imageRepo *repository.StepImageRepository, connRepo *repository.ConnectionRepository, access *AccessService) (*K8sService, error) {
 	cfg := config.Get()
 
 	var k8sConfig *rest.Config
@@ -199,10 +276,15 @@ func NewK8sService(objectRepo *repository.ObjectRepository) (*K8sService, error)
 		clientset:      clientset,
 		dynamicClient:  dynamicClient,
 		objectRepo:     objectRepo,
+		edgeRepo:       edgeRepo,
+		imageRepo:      imageRepo,
+		connRepo:       connRepo,
+		access:         access,
 		kafkaNamespace: cfg.K8s.KafkaNamespace,
 		kafkaCluster:   cfg.K8s.KafkaCluster,
 		kafkaBootstrap: kafkaBootstrap,
-		pyImage:        "ghcr.io/miribitsm3/python_image_build/python-pandas:1.0",
+		pyImage:        cfg.Image.Default,
+		traceURL:       cfg.Runtime.TraceURL,
 	}, nil
 }
 
@@ -215,9 +297,24 @@ type PreflightResult struct {
 // ProgressCallback is called during deployment to report progress
 type ProgressCallback func(phase, message string, ok bool, extra map[string]interface{})
 
-// Apply deploys a flow to Kubernetes
+// FlowStep is a single deployable step resolved from an object.
+// Predecessors/Successors hold step names and define CloudEvent routing.
+type FlowStep struct {
+	ObjectID     int64
+	Name         string
+	Code         string
+	Params       map[string]interface{}
+	Predecessors []string
+	Successors   []string
+}
+
+// Apply deploys a flow to Kubernetes; the caller in ctx must be a deployer of the flow
 // Steps: Preflight → Topic → Sink → Source → KService → Kick Job → Log Verification
 func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, createNamespaceIfMissing, waitReady bool) (string, error) {
+	if err := s.access.RequireFlowID(ctx, dto.FlowID, models.ProjectRoleDeployer); err != nil {
+		return "", err
+	}
+
 	steps, err := s.makeStep(dto.Steps)
 	if err != nil {
 		return "", fmt.Errorf("failed to make steps: %w", err)
@@ -226,6 +323,10 @@ func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, creat
 	if err := s.validateDTO(dto, steps); err != nil {
 		return "", fmt.Errorf("validation failed: %w", err)
 	}
+	conns, err := s.loadConnections(dto.User, steps, true)
+	if err != nil {
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
 
 	ns := "user-" + dto.User
 	flowID := dto.FlowID
@@ -237,10 +338,31 @@ func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, creat
 		}
 	}
 
-	// 0) Preflight
-	pre := s.preflightPipelineCheck(ctx, ns, flowID, steps, 20, 90)
-	if !pre.OK {
-		return "", fmt.Errorf("preflight failed: %s", pre.Detail)
+	// 0) Connections → Secrets (값은 Secret에만 두고 KSVC는 참조만 한다)
+	if err := s.applyConnectionSecrets(ctx, ns, flowID, conns); err != nil {
+		return "", fmt.Errorf("applyConnectionSecrets failed: %w", err)
+	}
+
+	// 0-a) Step images (requirements가 있는 스텝은 필요 시 빌드)
+	images := make(map[string]string, len(steps))
+	for _, step := range steps {
+		image, _, err := s.resolveStepImage(ctx, ns, flowID, s.stepImageSpec(step.Params))
+		if err != nil {
+			return "", fmt.Errorf("image for step %s: %w", step.Name, err)
+		}
+		images[step.Name] = image
+	}
+
+	// 0-b) Preflight
+	preImage, ok, err := s.preflightImage(ctx, ns, flowID, steps)
+	if err != nil {
+		return "", fmt.Errorf("preflight image: %w", err)
+	}
+	if ok {
+		pre := s.preflightPipelineCheck(ctx, ns, flowID, preImage, steps, 20, 90)
+		if !pre.OK {
+			return "", fmt.Errorf("preflight failed: %s", pre.Detail)
+		}
 	}
 
 	// 1) KafkaTopic
@@ -255,12 +377,12 @@ func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, creat
 
 	aliveKsvcNames := make(map[string]bool)
 
-	// 3) Steps — KSVC 생성
-	i := 0
-	for stepName, code := range steps {
+	// 3) Steps — KSVC 생성 (위상 정렬 순서)
+	for _, step := range steps {
+		stepName, code := step.Name, step.Code
 		safeStepName := s.safeName(stepName)
-		inType := s.getInType(flowID, i)
-		outType := s.getOutType(flowID, i, len(steps))
+		inType := s.getInType(flowID, step)
+		outType := s.getOutType(flowID, step)
 
 		cmName := fmt.Sprintf("code-flow%s-%s", flowID, safeStepName)
 		ksvcName := fmt.Sprintf("flow%s-%s", flowID, safeStepName)
@@ -273,64 +395,17 @@ func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, creat
 			return "", fmt.Errorf("createOrReplace ConfigMap failed for step %s: %w", stepName, err)
 		}
 
-		// 3-b) Get maxScale from object params
-		maxScale := 5
-		if i < len(dto.Steps) {
-			objectID := dto.Steps[i]
-			obj, err := s.objectRepo.FindByID(objectID)
-			if err == nil && len(obj.Params) > 0 {
-				var params map[string]interface{}
-				if err := json.Unmarshal(obj.Params, &params); err == nil {
-					if as, ok := params["autoScale"]; ok && as != nil {
-						var userMax *int
-						switch v := as.(type) {
-						case float64:
-							iv := int(v)
-							userMax = &iv
-						case int:
-							userMax = &v
-						case map[string]interface{}:
-							if mv, ok := v["maxScale"]; ok && mv != nil {
-								switch mv2 := mv.(type) {
-								case float64:
-									iv := int(mv2)
-									userMax = &iv
-								case int:
-									userMax = &mv2
-								case string:
-									if parsed, err := strconv.Atoi(strings.TrimSpace(mv2)); err == nil {
-										userMax = &parsed
-									}
-								}
-							}
-						case string:
-							if parsed, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
-								userMax = &parsed
-							}
-						}
-						if userMax != nil {
-							// Clamp to [0..5] like Java
-							effectiveMax := *userMax
-							if effectiveMax < 0 {
-								effectiveMax = 0
-							}
-							if effectiveMax > 5 {
-								effectiveMax = 5
-							}
-							maxScale = effectiveMax
-						}
-					}
-				}
-			}
-		}
+		// 3-b) Get runtime config and retry policy from object params
+		stepRT := s.stepRuntime(step.Params)
+		retry := s.stepRetryPolicy(step.Params)
 
 		// 3-c) KSVC 생성/교체
-		if err := s.createOrRecreateKsvc(ctx, ns, flowID, cmName, ksvcName, safeStepName, inType, outType, codeHash, maxScale); err != nil {
+		if err := s.createOrRecreateKsvc(ctx, ns, flowID, cmName, ksvcName, safeStepName, inType, outType, codeHash, images[stepName], stepRT, s.stepConnections(step.Params, conns), retry); err != nil {
 			return "", fmt.Errorf("createOrReplace KSVC failed for step %s: %w", stepName, err)
 		}
 
 		// 3-d) KafkaSource (sink = ref: KSVC)
-		if err := s.createKafkaSourceToKSVC(ctx, ns, flowID, ksvcName, cg); err != nil {
+		if err := s.createKafkaSourceToKSVC(ctx, ns, flowID, ksvcName, cg, retry); err != nil {
 			return "", fmt.Errorf("createKafkaSource (to KSVC) failed for step %s: %w", stepName, err)
 		}
 
@@ -339,7 +414,6 @@ func (s *K8sService) Apply(ctx context.Context, dto *models.K8sRequestDTO, creat
 				return "", fmt.Errorf("KSVC not Ready -> %s", ksvcName)
 			}
 		}
-		i++
 	}
 
 	s.pruneStaleFlowSteps(ctx, ns, flowID, aliveKsvcNames)
@@ -363,8 +437,8 @@ func (s *K8sService) PrepareDeployedFlow(ctx context.Context, dto *models.K8sReq
 	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
 
 	// Check all KSVCs are ready
-	for stepNameRaw := range steps {
-		ksvc := fmt.Sprintf("flow%s-%s", flowID, s.safeName(stepNameRaw))
+	for _, step := range steps {
+		ksvc := fmt.Sprintf("flow%s-%s", flowID, s.safeName(step.Name))
 		timeout := time.Duration(timeoutSeconds/2) * time.Second
 		if timeout < 5*time.Second {
 			timeout = 5 * time.Second
@@ -380,8 +454,8 @@ func (s *K8sService) PrepareDeployedFlow(ctx context.Context, dto *models.K8sReq
 	}
 
 	// Check all KafkaSources are ready
-	for stepNameRaw := range steps {
-		ksvcName := fmt.Sprintf("flow%s-%s", flowID, s.safeName(stepNameRaw))
+	for _, step := range steps {
+		ksvcName := fmt.Sprintf("flow%s-%s", flowID, s.safeName(step.Name))
 		srcName := fmt.Sprintf("source-%s-to-%s", flowID, ksvcName)
 		if !s.waitKafkaSourceReady(ctx, ns, srcName, deadline) {
 			return fmt.Sprintf("NG: KafkaSource not Ready -> %s", srcName)
@@ -401,22 +475,10 @@ func (s *K8sService) RunOnceAndVerify(ctx context.Context, dto *models.K8sReques
 		return fmt.Sprintf("NG: failed to make steps: %v", err)
 	}
 
-	lastIdx := len(steps) - 1
-	stepNames := make([]string, 0, len(steps))
-	for name := range steps {
-		stepNames = append(stepNames, name)
-	}
-	if lastIdx < 0 {
+	if len(steps) == 0 {
 		return "NG: no steps"
 	}
 
-	lastStepName := s.safeName(stepNames[lastIdx])
-	lastKsvc := fmt.Sprintf("flow%s-%s", flowID, lastStepName)
-	var prevKsvc string
-	if lastIdx > 0 {
-		prevKsvc = fmt.Sprintf("flow%s-%s", flowID, s.safeName(stepNames[lastIdx-1]))
-	}
-
 	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
 
 	// Create Kick Job
@@ -430,20 +492,35 @@ func (s *K8sService) RunOnceAndVerify(ctx context.Context, dto *models.K8sReques
 		return fmt.Sprintf("NG: Kick Job not succeeded -> %s", jobName)
 	}
 
-	// Check previous step emitted expected type
-	if prevKsvc != "" {
-		expectedOutType := fmt.Sprintf("flow%s.s%d", flowID, lastIdx)
-		if !s.waitPrevStepEmittedTypeKSVC(ctx, ns, prevKsvc, expectedOutType, deadline) {
-			return fmt.Sprintf("NG: previous step did not emit expected out=%s -> %s", expectedOutType, prevKsvc)
-		}
+	byName := make(map[string]FlowStep, len(steps))
+	for _, step := range steps {
+		byName[step.Name] = step
 	}
 
-	// Check last step received expected type
-	expectedInType := fmt.Sprintf("flow%s.%s", flowID, s.getInTypeSuffix(lastIdx))
-	if !s.waitLastStepReceivedKSVC(ctx, ns, lastKsvc, expectedInType, deadline) {
-		topicProbe := s.probeTopicForCeTypes(ctx, ns, flowID, 20, timeoutSeconds/3)
-		return fmt.Sprintf("NG: last step did not receive event (no log matched '%s') -> %s\n== topic probe ==\n%s",
-			expectedInType, lastKsvc, topicProbe)
+	// Every leaf step must receive an event routed along its incoming edges
+	for _, leaf := range steps {
+		if len(leaf.Successors) > 0 {
+			continue
+		}
+		leafKsvc := fmt.Sprintf("flow%s-%s", flowID, s.safeName(leaf.Name))
+
+		// Check each predecessor emitted the type the leaf listens for
+		for _, predName := range leaf.Predecessors {
+			pred := byName[predName]
+			prevKsvc := fmt.Sprintf("flow%s-%s", flowID, s.safeName(pred.Name))
+			expectedOutType := s.getOutType(flowID, pred)
+			if !s.waitPrevStepEmittedTypeKSVC(ctx, ns, prevKsvc, expectedOutType, deadline) {
+				return fmt.Sprintf("NG: previous step did not emit expected out=%s -> %s", expectedOutType, prevKsvc)
+			}
+		}
+
+		// Check leaf step received one of its expected types
+		expectedInTypes := strings.Split(s.getInType(flowID, leaf), ",")
+		if !s.waitLastStepReceivedKSVC(ctx, ns, leafKsvc, expectedInTypes, deadline) {
+			topicProbe := s.probeTopicForCeTypes(ctx, ns, flowID, 20, timeoutSeconds/3)
+			return fmt.Sprintf("NG: last step did not receive event (no log matched 'in=%s') -> %s\n== topic probe ==\n%s",
+				strings.Join(expectedInTypes, "|"), leafKsvc, topicProbe)
+		}
 	}
 
 	return "OK: run"
@@ -451,46 +528,33 @@ func (s *K8sService) RunOnceAndVerify(ctx context.Context, dto *models.K8sReques
 
 // ApplyAndKickVerify applies, prepares, and verifies in one go
 func (s *K8sService) ApplyAndKickVerify(ctx context.Context, dto *models.K8sRequestDTO, createNamespaceIfMissing bool, timeoutSeconds int, progress ProgressCallback) string {
-	applyMsg, err := s.Apply(ctx, dto, createNamespaceIfMissing, false)
-	if err != nil {
-		return fmt.Sprintf("NG: apply failed: %v", err)
-	}
-
-	prep := s.PrepareDeployedFlow(ctx, dto, timeoutSeconds)
-	if !strings.HasPrefix(prep, "OK:") {
-		return fmt.Sprintf("NG: %s", prep)
-	}
-
-	if progress != nil {
-		progress("apply.ok", applyMsg, true, map[string]interface{}{
-			"flowId": dto.FlowID,
-		})
-	}
-
-	v := s.RunOnceAndVerify(ctx, dto, timeoutSeconds)
-	if !strings.HasPrefix(v, "OK:") {
-		return v
-	}
-
-	return "OK: deployed & verified"
+	return DeployAndVerify(ctx, NewKnativeRuntime(s, createNamespaceIfMissing), dto, timeoutSeconds, progress)
 }
 
 // Helper methods
-// MakeStep extracts code from objects and creates step map
-func (s *K8sService) MakeStep(steps []int64) (map[string]string, error) {
+// MakeStep extracts code from objects and orders them along the flow graph
+func (s *K8sService) MakeStep(steps []int64) ([]FlowStep, error) {
 	return s.makeStep(steps)
 }
 
-func (s *K8sService) makeStep(steps []int64) (map[string]string, error) {
-	result := make(map[string]string)
+func (s *K8sService) makeStep(steps []int64) ([]FlowStep, error) {
+	byID := make(map[int64]*FlowStep, len(steps))
 	used := make(map[string]bool)
+	flowIDs := make(map[int64]bool)
+	var ids []int64
 	idx := 1
 
 	for _, objectID := range steps {
+		if _, dup := byID[objectID]; dup {
+			continue
+		}
 		obj, err := s.objectRepo.FindByID(objectID)
 		if err != nil {
 			return nil, fmt.Errorf("object not found: id=%d: %w", objectID, err)
 		}
+		if obj.FlowID != nil {
+			flowIDs[*obj.FlowID] = true
+		}
 
 		base := s.slug(obj.Label)
 		if base == "" {
@@ -503,8 +567,8 @@ func (s *K8sService) makeStep(steps []int64) (map[string]string, error) {
 		used[stepName] = true
 
 		var code string
+		var params map[string]interface{}
 		if len(obj.Params) > 0 {
-			var params map[string]interface{}
 			if err := json.Unmarshal(obj.Params, &params); err != nil {
 				return nil, fmt.Errorf("invalid params JSON for object id=%d: %w", objectID, err)
 			}
@@ -513,14 +577,106 @@ func (s *K8sService) makeStep(steps []int64) (map[string]string, error) {
 			}
 		}
 
-		result[stepName] = code
+		byID[objectID] = &FlowStep{
+			ObjectID: objectID,
+			Name:     stepName,
+			Code:     code,
+			Params:   params,
+		}
+		ids = append(ids, objectID)
 		idx++
 	}
 
+	var edges []models.EdgeDTO
+	for flowID := range flowIDs {
+		flowEdges, err := s.edgeRepo.FindByFlow(flowID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load edges for flow %d: %w", flowID, err)
+		}
+		for _, e := range flowEdges {
+			edges = append(edges, e.ToDTO())
+		}
+	}
+
+	graph := NewFlowGraph(ids, edges)
+	if !graph.HasEdges() && len(ids) > 1 {
+		// 엣지가 없는 기존 플로우는 요청 순서대로 선형 체인으로 취급
+		edges = edges[:0]
+		for i := 0; i+1 < len(ids); i++ {
+			edges = append(edges, models.EdgeDTO{Source: ids[i], Target: ids[i+1]})
+		}
+		graph = NewFlowGraph(ids, edges)
+	}
+
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FlowStep, 0, len(order))
+	for _, id := range order {
+		step := byID[id]
+		for _, p := range graph.Predecessors(id) {
+			step.Predecessors = append(step.Predecessors, byID[p].Name)
+		}
+		for _, n := range graph.Successors(id) {
+			step.Successors = append(step.Successors, byID[n].Name)
+		}
+		result = append(result, *step)
+	}
+
 	return result, nil
 }
 
-func (s *K8sService) validateDTO(dto *models.K8sRequestDTO, steps map[string]string) error {
+// stepMaxScale reads params.autoScale (number, string or {maxScale}) and clamps it to [0..5]
+func (s *K8sService) stepMaxScale(params map[string]interface{}) int {
+	maxScale := 5
+	as, ok := params["autoScale"]
+	if !ok || as == nil {
+		return maxScale
+	}
+
+	var userMax *int
+	switch v := as.(type) {
+	case float64:
+		iv := int(v)
+		userMax = &iv
+	case int:
+		userMax = &v
+	case map[string]interface{}:
+		if mv, ok := v["maxScale"]; ok && mv != nil {
+			switch mv2 := mv.(type) {
+			case float64:
+				iv := int(mv2)
+				userMax = &iv
+			case int:
+				userMax = &mv2
+			case string:
+				if parsed, err := strconv.Atoi(strings.TrimSpace(mv2)); err == nil {
+					userMax = &parsed
+				}
+			}
+		}
+	case string:
+		if parsed, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			userMax = &parsed
+		}
+	}
+	if userMax != nil {
+		// Clamp to [0..5] like Java
+		effectiveMax := *userMax
+		if effectiveMax < 0 {
+			effectiveMax = 0
+		}
+		if effectiveMax > 5 {
+			effectiveMax = 5
+		}
+		maxScale = effectiveMax
+	}
+	return maxScale
+}
+
+func (s *K8sService) validateDTO(dto *models.K8sRequestDTO, steps []FlowStep) error {
 	if dto == nil {
 		return fmt.Errorf("request body is null")
 	}
@@ -530,10 +686,56 @@ func (s *K8sService) validateDTO(dto *models.K8sRequestDTO, steps map[string]str
 	if len(steps) == 0 {
 		return fmt.Errorf("steps is required and must be non-empty")
 	}
-	for name, code := range steps {
-		if code == "" || !strings.Contains(code, "def handle(") {
-			return fmt.Errorf("step '%s' code must define def handle(evt: dict)", name)
+	for _, step := range steps {
+		if err := validateStepCode(step.Name, step.Code); err != nil {
+			return err
 		}
+		if err := ValidateStepParams(step.Params); err != nil {
+			return fmt.Errorf("step '%s': %w", step.Name, err)
+		}
+	}
+	return s.validateRoutes(dto.FlowID, steps)
+}
+
+// validateRoutes checks that every step listens for the out type of each of
+// its predecessors, not just the first: a fan-in step's IN_TYPES lists all of
+// them. Steps whose names map to the same service would share an out type.
+func (s *K8sService) validateRoutes(flowID string, steps []FlowStep) error {
+	byName := make(map[string]FlowStep, len(steps))
+	bySafeName := make(map[string]string, len(steps))
+	for _, step := range steps {
+		byName[step.Name] = step
+		safe := s.safeName(step.Name)
+		if other, ok := bySafeName[safe]; ok {
+			return fmt.Errorf("steps '%s' and '%s' share the service name %s", other, step.Name, safe)
+		}
+		bySafeName[safe] = step.Name
+	}
+
+	for _, step := range steps {
+		inTypes := make(map[string]bool)
+		for _, t := range strings.Split(s.getInType(flowID, step), ",") {
+			inTypes[t] = true
+		}
+		for _, predName := range step.Predecessors {
+			pred, ok := byName[predName]
+			if !ok {
+				return fmt.Errorf("step '%s': unknown predecessor '%s'", step.Name, predName)
+			}
+			outType := s.getOutType(flowID, pred)
+			if outType == "" || !inTypes[outType] {
+				return fmt.Errorf("step '%s' does not receive out=%q of '%s' (in=%s)",
+					step.Name, outType, predName, s.getInType(flowID, step))
+			}
+		}
+	}
+	return nil
+}
+
+// validateStepCode checks that a step's code defines the runner entrypoint
+func validateStepCode(name, code string) error {
+	if code == "" || !strings.Contains(code, "def handle(") {
+		return fmt.Errorf("step '%s' code must define def handle(evt: dict)", name)
 	}
 	return nil
 }
@@ -553,11 +755,49 @@ func (s *K8sService) ensureNamespace(ctx context.Context, ns string) error {
 	return err
 }
 
-func (s *K8sService) preflightPipelineCheck(ctx context.Context, ns, flowID string, steps map[string]string, perStepTimeoutSec, totalTimeoutSec int) PreflightResult {
+// preflightImage picks the image the whole pipeline is checked in: the base
+// image with the requirements of every step. Steps on different base images
+// cannot share one preflight pod, so the check is skipped (ok=false).
+func (s *K8sService) preflightImage(ctx context.Context, ns, flowID string, steps []FlowStep) (string, bool, error) {
+	var spec StepImageSpec
+	for i, step := range steps {
+		stepSpec := s.stepImageSpec(step.Params)
+		if i == 0 {
+			spec = stepSpec
+			continue
+		}
+		if stepSpec.Base != spec.Base {
+			return "", false, nil
+		}
+		spec = spec.merge(stepSpec)
+	}
+	image, _, err := s.resolveStepImage(ctx, ns, flowID, spec)
+	if err != nil {
+		return "", false, err
+	}
+	return image, true, nil
+}
+
+func (s *K8sService) preflightPipelineCheck(ctx context.Context, ns, flowID, image string, steps []FlowStep, perStepTimeoutSec, totalTimeoutSec int) PreflightResult {
 	jobName := fmt.Sprintf("preflight-flow%s-pipeline", flowID)
 
-	// Serialize steps to JSON and base64 encode
-	stepsJSON, err := json.Marshal(steps)
+	// Serialize steps (topological order + graph) to JSON and base64 encode
+	type preflightStep struct {
+		Name  string   `json:"name"`
+		Code  string   `json:"code"`
+		Preds []string `json:"preds"`
+		Succs []string `json:"succs"`
+	}
+	payload := make([]preflightStep, 0, len(steps))
+	for _, step := range steps {
+		payload = append(payload, preflightStep{
+			Name:  step.Name,
+			Code:  step.Code,
+			Preds: step.Predecessors,
+			Succs: step.Successors,
+		})
+	}
+	stepsJSON, err := json.Marshal(payload)
 	if err != nil {
 		return PreflightResult{OK: false, Detail: fmt.Sprintf("Failed to serialize steps: %v", err)}
 	}
@@ -623,37 +863,42 @@ def load_step(name, code, path):
     return mod
 steps = json.loads(base64.b64decode(os.environ['STEPS_B64']).decode('utf-8','replace'))
 per_to = int(os.environ.get('PER_STEP_TIMEOUT', '20'))
-event = {"kick": True}
-names = list(steps.keys())
-for idx, name in enumerate(names):
-    code = steps[name]; path = f"/tmp/{name}.py"; open(path, "w").write(code)
-    mod = load_step(name, code, path); last = (idx == len(names)-1)
-    signal.signal(signal.SIGALRM, alarm_handler); signal.alarm(per_to)
-    try: out = mod.handle(event if isinstance(event, dict) else {})
-    except Exception:
-        print(f"STEP {name} RUNTIME_EXCEPTION:"); traceback.print_exc(); sys.exit(10)
-    finally: signal.alarm(0)
+outputs = {}
+for step in steps:
+    name = step["name"]; code = step["code"]; path = f"/tmp/{name}.py"; open(path, "w").write(code)
+    preds = step.get("preds") or []; last = not (step.get("succs") or [])
+    mod = load_step(name, code, path)
     import json as _json
-    if out is None: out_list = []
-    elif isinstance(out, dict): out_list = [out]
-    elif isinstance(out, list): out_list = out
-    else:
-        if last:
-            try: _json.dumps(out)
+    # 팬인 스텝은 선행 스텝마다 이벤트를 따로 받으므로 모두 넣어 본다
+    for src in (preds or ["kick"]):
+        event = outputs.get(src, {}) if preds else {"kick": True}
+        signal.signal(signal.SIGALRM, alarm_handler); signal.alarm(per_to)
+        try: out = mod.handle(event if isinstance(event, dict) else {})
+        except Exception:
+            print(f"STEP {name} RUNTIME_EXCEPTION (event from {src}):"); traceback.print_exc(); sys.exit(10)
+        finally: signal.alarm(0)
+        if out is None: out_list = []
+        elif isinstance(out, dict): out_list = [out]
+        elif isinstance(out, list): out_list = out
+        else:
+            if last:
+                try: _json.dumps(out)
+                except Exception as e:
+                    print(f"STEP {name} NOT_JSON_SERIALIZABLE: {e}"); sys.exit(12)
+                outputs.setdefault(name, {})
+                continue
+            print(f"STEP {name} BAD_RETURN: expected dict or list of dicts, got {type(out).__name__}"); sys.exit(11)
+        if not last and not out_list:
+            print(f"STEP {name} EMPTY_OUTPUT: downstream step would receive nothing (event from {src})"); sys.exit(13)
+        if out_list:
+            if not isinstance(out_list[0], dict):
+                print(f"STEP {name} BAD_RETURN_ITEM: first item is {type(out_list[0]).__name__}, not dict"); sys.exit(14)
+            try: _json.dumps(out_list[0])
             except Exception as e:
-                print(f"STEP {name} NOT_JSON_SERIALIZABLE: {e}"); sys.exit(12)
-            break
-        print(f"STEP {name} BAD_RETURN: expected dict or list of dicts, got {type(out).__name__}"); sys.exit(11)
-    if not last and not out_list:
-        print(f"STEP {name} EMPTY_OUTPUT: downstream step would receive nothing"); sys.exit(13)
-    if out_list:
-        if not isinstance(out_list[0], dict):
-            print(f"STEP {name} BAD_RETURN_ITEM: first item is {type(out_list[0]).__name__}, not dict"); sys.exit(14)
-        try: _json.dumps(out_list[0])
-        except Exception as e:
-            print(f"STEP {name} JSON_SERIALIZE_FAIL: {e}"); sys.exit(15)
-        event = out_list[0]
-    else: event = {}
+                print(f"STEP {name} JSON_SERIALIZE_FAIL: {e}"); sys.exit(15)
+            if name not in outputs:
+                outputs[name] = dict(out_list[0]); outputs[name].pop("__type", None)
+        else: outputs.setdefault(name, {})
 print("OK")
 `
 
@@ -675,8 +920,8 @@ print("OK")
 					Containers: []corev1.Container{
 						{
 							Name:            "check",
-							Image:           s.pyImage,
-							ImagePullPolicy: corev1.PullNever,
+							Image:           image,
+							ImagePullPolicy: s.pullPolicy(image),
 							Env: []corev1.EnvVar{
 								{Name: "STEPS_B64", Value: stepsB64},
 								{Name: "PER_STEP_TIMEOUT", Value: fmt.Sprintf("%d", perStepTimeoutSec)},
@@ -778,12 +1023,30 @@ func (s *K8sService) createKafkaTopic(ctx context.Context, flowID string) error
 		Resource: "kafkatopics",
 	}
 
-	kt := &unstructured.Unstructured{
+	// 메인 토픽과 dead-letter 토픽을 함께 생성
+	for _, topic := range []string{flowID, dlqTopicName(flowID)} {
+		kt := s.buildKafkaTopic(topic, flowID)
+
+		_, err := s.dynamicClient.Resource(gvr).Namespace(s.kafkaNamespace).Create(ctx, kt, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			// Try update if exists
+			_, err = s.dynamicClient.Resource(gvr).Namespace(s.kafkaNamespace).Update(ctx, kt, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildKafkaTopic renders a Strimzi topic of a flow (main or dead-letter)
+func (s *K8sService) buildKafkaTopic(topic, flowID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "kafka.strimzi.io/v1beta2",
 			"kind":       "KafkaTopic",
 			"metadata": map[string]interface{}{
-				"name":      flowID,
+				"name":      topic,
 				"namespace": s.kafkaNamespace,
 				"labels": map[string]interface{}{
 					"strimzi.io/cluster": s.kafkaCluster,
@@ -796,13 +1059,6 @@ func (s *K8sService) createKafkaTopic(ctx context.Context, flowID string) error
 			},
 		},
 	}
-
-	_, err := s.dynamicClient.Resource(gvr).Namespace(s.kafkaNamespace).Create(ctx, kt, metav1.CreateOptions{})
-	if err != nil && !errors.IsAlreadyExists(err) {
-		// Try update if exists
-		_, err = s.dynamicClient.Resource(gvr).Namespace(s.kafkaNamespace).Update(ctx, kt, metav1.UpdateOptions{})
-	}
-	return err
 }
 
 func (s *K8sService) createKafkaSink(ctx context.Context, ns, flowID string) error {
@@ -812,12 +1068,30 @@ func (s *K8sService) createKafkaSink(ctx context.Context, ns, flowID string) err
 		Resource: "kafkasinks",
 	}
 
-	ks := &unstructured.Unstructured{
+	for _, ks := range []*unstructured.Unstructured{
+		s.buildKafkaSink(ns, flowID, "sink-"+flowID, flowID),
+		s.buildKafkaSink(ns, flowID, dlqSinkName(flowID), dlqTopicName(flowID)),
+	} {
+		_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ks, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			// Try update if exists
+			_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ks, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildKafkaSink renders a KafkaSink steps emit to (flow topic or dead-letter topic)
+func (s *K8sService) buildKafkaSink(ns, flowID, name, topic string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "eventing.knative.dev/v1alpha1",
 			"kind":       "KafkaSink",
 			"metadata": map[string]interface{}{
-				"name":      "sink-" + flowID,
+				"name":      name,
 				"namespace": ns,
 				"labels": map[string]interface{}{
 					"flow_id": flowID,
@@ -825,23 +1099,29 @@ func (s *K8sService) createKafkaSink(ctx context.Context, ns, flowID string) err
 			},
 			"spec": map[string]interface{}{
 				"bootstrapServers": []string{s.kafkaBootstrap},
-				"topic":            flowID,
+				"topic":            topic,
 			},
 		},
 	}
+}
 
-	_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ks, metav1.CreateOptions{})
-	if err != nil && !errors.IsAlreadyExists(err) {
+func (s *K8sService) createConfigMap(ctx context.Context, ns, cmName, flowID, code string) error {
+	cm := s.buildConfigMap(ns, cmName, flowID, code)
+
+	_, err := s.clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
 		// Try update if exists
-		_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ks, metav1.UpdateOptions{})
+		_, err = s.clientset.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{})
 	}
 	return err
 }
 
-func (s *K8sService) createConfigMap(ctx context.Context, ns, cmName, flowID, code string) error {
-	cm := &corev1.ConfigMap{
+// buildConfigMap renders the ConfigMap holding a step's user code
+func (s *K8sService) buildConfigMap(ns, cmName, flowID, code string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: cmName,
+			Name:      cmName,
+			Namespace: ns,
 			Labels: map[string]string{
 				"flow_id": flowID,
 			},
@@ -850,26 +1130,49 @@ func (s *K8sService) createConfigMap(ctx context.Context, ns, cmName, flowID, co
 			"user_code.py": code,
 		},
 	}
-	_, err := s.clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{})
-	if err != nil {
-		// Try update if exists
-		_, err = s.clientset.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{})
-	}
-	return err
 }
 
-func (s *K8sService) createOrRecreateKsvc(ctx context.Context, ns, flowID, cmName, ksvcName, stepName, inType, outType, codeHash string, maxScale int) error {
+func (s *K8sService) createOrRecreateKsvc(ctx context.Context, ns, flowID, cmName, ksvcName, stepName, inType, outType, codeHash, image string, rt StepRuntime, conns stepConnections, retry RetryPolicy) error {
 	gvr := schema.GroupVersionResource{
 		Group:    "serving.knative.dev",
 		Version:  "v1",
 		Resource: "services",
 	}
 
+	ksvc := s.buildKsvc(ns, flowID, cmName, ksvcName, stepName, inType, outType, codeHash, image, rt, conns, retry)
+
+	// Try create or replace
+	_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ksvc, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			// Try update
+			_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ksvc, metav1.UpdateOptions{})
+			if err != nil {
+				// Check if error is about immutable annotation
+				if strings.Contains(err.Error(), "annotation value is immutable") {
+					// Delete and recreate
+					if delErr := s.dynamicClient.Resource(gvr).Namespace(ns).Delete(ctx, ksvcName, metav1.DeleteOptions{}); delErr != nil {
+						return fmt.Errorf("failed to delete existing KSVC: %w", delErr)
+					}
+					// Wait a bit for deletion
+					time.Sleep(500 * time.Millisecond)
+					// Create new
+					_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ksvc, metav1.CreateOptions{})
+				}
+			}
+		}
+	}
+	return err
+}
+
+// buildKsvc renders the Knative Service running a single step
+func (s *K8sService) buildKsvc(ns, flowID, cmName, ksvcName, stepName, inType, outType, codeHash, image string, rt StepRuntime, conns stepConnections, retry RetryPolicy) *unstructured.Unstructured {
 	// Build K_SINK URL (last step has no output)
 	ksinkURL := fmt.Sprintf("http://kafka-sink-ingress.knative-eventing.svc.cluster.local/%s/sink-%s", ns, flowID)
 	if outType == "" {
 		ksinkURL = ""
 	}
+	dlqSinkURL := fmt.Sprintf("http://kafka-sink-ingress.knative-eventing.svc.cluster.local/%s/%s", ns, dlqSinkName(flowID))
 
 	// Build environment variables
 	envList := []map[string]interface{}{
@@ -880,6 +1183,13 @@ func (s *K8sService) createOrRecreateKsvc(ctx context.Context, ns, flowID, cmNam
 		{"name": "MAX_HOPS", "value": "5"},
 		{"name": "DEDUPE_WINDOW_SEC", "value": "60"},
 		{"name": "K_SINK", "value": ksinkURL},
+		{"name": "K_DLQ_SINK", "value": dlqSinkURL},
+		{"name": "TRACE_URL", "value": s.traceURL},
+		{"name": "TRACE_TOKEN", "value": TraceToken(flowID)},
+		{"name": "CONNECTIONS_DIR", "value": connectionsMountDir},
+	}
+	for _, kv := range append(retry.env(), rt.Env...) {
+		envList = append(envList, map[string]interface{}{"name": kv[0], "value": kv[1]})
 	}
 
 	// Build container command with RUNNER_PY
@@ -888,8 +1198,78 @@ func (s *K8sService) createOrRecreateKsvc(ctx context.Context, ns, flowID, cmNam
 PY
 exec python -u /tmp/runner.py`, RUNNER_PY)
 
+	annotations := map[string]interface{}{
+		"flow/code-hash":   codeHash,
+		"flow/route":       inType + ">" + outType,
+		"flow/retry":       retry.String(),
+		"flow/image":       image,
+		"flow/runtime":     rt.String(),
+		"flow/connections": conns.Tag,
+	}
+	for k, v := range rt.annotations() {
+		annotations[k] = v
+	}
+
+	container := map[string]interface{}{
+		"name":            "app",
+		"image":           image,
+		"imagePullPolicy": string(s.pullPolicy(image)),
+		"ports": []map[string]interface{}{
+			{"containerPort": 8080},
+		},
+		"env": envList,
+		"volumeMounts": []map[string]interface{}{
+			{
+				"name":      "usercode",
+				"mountPath": "/code",
+				"readOnly":  true,
+			},
+			{
+				"name":      "tmp",
+				"mountPath": "/tmp",
+			},
+		},
+		"command": []string{"/bin/sh", "-c"},
+		"args":    []string{runnerScript},
+	}
+	if res := rt.resources(); res != nil {
+		container["resources"] = res
+	}
+	if envFrom := conns.envFrom(flowID); envFrom != nil {
+		container["envFrom"] = envFrom
+	}
+	connVolumes, connMounts := conns.volumes(flowID)
+	container["volumeMounts"] = append(container["volumeMounts"].([]map[string]interface{}), connMounts...)
+
+	podSpec := map[string]interface{}{
+		"containerConcurrency": int64(rt.ContainerConcurrency),
+		"containers":           []map[string]interface{}{container},
+		"volumes": []map[string]interface{}{
+			{
+				"name": "usercode",
+				"configMap": map[string]interface{}{
+					"name": cmName,
+					"items": []map[string]interface{}{
+						{
+							"key":  "user_code.py",
+							"path": "user_code.py",
+						},
+					},
+				},
+			},
+			{
+				"name":     "tmp",
+				"emptyDir": map[string]interface{}{},
+			},
+		},
+	}
+	podSpec["volumes"] = append(podSpec["volumes"].([]map[string]interface{}), connVolumes...)
+	if rt.TimeoutSeconds > 0 {
+		podSpec["timeoutSeconds"] = int64(rt.TimeoutSeconds)
+	}
+
 	// Build KService spec
-	ksvc := &unstructured.Unstructured{
+	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "serving.knative.dev/v1",
 			"kind":       "Service",
@@ -904,98 +1284,45 @@ exec python -u /tmp/runner.py`, RUNNER_PY)
 			"spec": map[string]interface{}{
 				"template": map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"annotations": map[string]interface{}{
-							"flow/code-hash":                        codeHash,
-							"autoscaling.knative.dev/minScale":       "1",
-							"autoscaling.knative.dev/maxScale":       fmt.Sprintf("%d", maxScale),
-						},
+						"annotations": annotations,
 						"labels": map[string]interface{}{
 							"app":     ksvcName,
 							"flow_id": flowID,
 						},
 					},
-					"spec": map[string]interface{}{
-						"containers": []map[string]interface{}{
-							{
-								"name":            "app",
-								"image":           s.pyImage,
-								"imagePullPolicy": "Never",
-								"ports": []map[string]interface{}{
-									{"containerPort": 8080},
-								},
-								"env": envList,
-								"volumeMounts": []map[string]interface{}{
-									{
-										"name":      "usercode",
-										"mountPath": "/code",
-										"readOnly":  true,
-									},
-									{
-										"name":      "tmp",
-										"mountPath": "/tmp",
-									},
-								},
-								"command": []string{"/bin/sh", "-c"},
-								"args":    []string{runnerScript},
-							},
-						},
-						"volumes": []map[string]interface{}{
-							{
-								"name": "usercode",
-								"configMap": map[string]interface{}{
-									"name": cmName,
-									"items": []map[string]interface{}{
-										{
-											"key":  "user_code.py",
-											"path": "user_code.py",
-										},
-									},
-								},
-							},
-							{
-								"name":     "tmp",
-								"emptyDir": map[string]interface{}{},
-							},
-						},
-					},
+					"spec": podSpec,
 				},
 			},
 		},
 	}
-
-	// Try create or replace
-	_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ksvc, metav1.CreateOptions{})
-	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			// Try update
-			_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ksvc, metav1.UpdateOptions{})
-			if err != nil {
-				// Check if error is about immutable annotation
-				if strings.Contains(err.Error(), "annotation value is immutable") {
-					// Delete and recreate
-					if delErr := s.dynamicClient.Resource(gvr).Namespace(ns).Delete(ctx, ksvcName, metav1.DeleteOptions{}); delErr != nil {
-						return fmt.Errorf("failed to delete existing KSVC: %w", delErr)
-					}
-					// Wait a bit for deletion
-					time.Sleep(500 * time.Millisecond)
-					// Create new
-					_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ksvc, metav1.CreateOptions{})
-				}
-			}
-		}
-	}
-	return err
 }
 
-func (s *K8sService) createKafkaSourceToKSVC(ctx context.Context, ns, flowID, ksvcName, cg string) error {
+func (s *K8sService) createKafkaSourceToKSVC(ctx context.Context, ns, flowID, ksvcName, cg string, retry RetryPolicy) error {
 	gvr := schema.GroupVersionResource{
 		Group:    "sources.knative.dev",
 		Version:  "v1",
 		Resource: "kafkasources",
 	}
 
+	ks := s.buildKafkaSource(ns, flowID, ksvcName, cg, retry)
+
+	_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ks, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// 재시도 정책(delivery)이 바뀌었을 수 있으므로 기존 리소스를 갱신
+		existing, getErr := s.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, ks.GetName(), metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		ks.SetResourceVersion(existing.GetResourceVersion())
+		_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ks, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// buildKafkaSource renders the KafkaSource delivering the flow topic to a KSVC
+func (s *K8sService) buildKafkaSource(ns, flowID, ksvcName, cg string, retry RetryPolicy) *unstructured.Unstructured {
 	srcName := fmt.Sprintf("source-%s-to-%s", flowID, ksvcName)
-	ks := &unstructured.Unstructured{
+	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "sources.knative.dev/v1",
 			"kind":       "KafkaSource",
@@ -1011,6 +1338,7 @@ func (s *K8sService) createKafkaSourceToKSVC(ctx context.Context, ns, flowID, ks
 				"initialOffset":    "latest",
 				"bootstrapServers": []string{s.kafkaBootstrap},
 				"topics":           []string{flowID},
+				"delivery":         retry.delivery(),
 				"sink": map[string]interface{}{
 					"ref": map[string]interface{}{
 						"apiVersion": "serving.knative.dev/v1",
@@ -1021,21 +1349,26 @@ func (s *K8sService) createKafkaSourceToKSVC(ctx context.Context, ns, flowID, ks
 			},
 		},
 	}
-
-	_, err := s.dynamicClient.Resource(gvr).Namespace(ns).Create(ctx, ks, metav1.CreateOptions{})
-	if err != nil && !errors.IsAlreadyExists(err) {
-		// Try update if exists
-		_, err = s.dynamicClient.Resource(gvr).Namespace(ns).Update(ctx, ks, metav1.UpdateOptions{})
-	}
-	return err
 }
 
 func (s *K8sService) createKickJob(ctx context.Context, ns, flowID string) (string, error) {
 	jobName := fmt.Sprintf("kick-flow-%s-%s", flowID, s.randomString(8))
 
-	job := &batchv1.Job{
+	job := s.buildKickJob(ns, flowID, jobName)
+
+	_, err := s.clientset.BatchV1().Jobs(ns).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		_, err = s.clientset.BatchV1().Jobs(ns).Update(ctx, job, metav1.UpdateOptions{})
+	}
+	return jobName, err
+}
+
+// buildKickJob renders the Job that publishes the kick event to the flow topic
+func (s *K8sService) buildKickJob(ns, flowID, jobName string) *batchv1.Job {
+	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: jobName,
+			Name:      jobName,
+			Namespace: ns,
 			Labels: map[string]string{
 				"flow_id": flowID,
 			},
@@ -1058,7 +1391,8 @@ echo "$PAY" | kcat -P -b %s -t %s \
   -H "ce_specversion=1.0" \
   -H "ce_type=flow%s.kick" \
   -H "ce_source=/flow/%s/kick" \
-  -H "ce_id=$CID"
+  -H "ce_id=$CID" \
+  -H "ce_traceid=$CID"
 echo "[KICK] sent to %s (ce_type=flow%s.kick)" >&2`, s.kafkaBootstrap, flowID, flowID, flowID, flowID, flowID),
 							},
 						},
@@ -1067,12 +1401,6 @@ echo "[KICK] sent to %s (ce_type=flow%s.kick)" >&2`, s.kafkaBootstrap, flowID, f
 			},
 		},
 	}
-
-	_, err := s.clientset.BatchV1().Jobs(ns).Create(ctx, job, metav1.CreateOptions{})
-	if err != nil {
-		_, err = s.clientset.BatchV1().Jobs(ns).Update(ctx, job, metav1.UpdateOptions{})
-	}
-	return jobName, err
 }
 
 // isCRReady checks if a CustomResource is ready by checking conditions
@@ -1208,8 +1536,7 @@ func (s *K8sService) waitPrevStepEmittedTypeKSVC(ctx context.Context, ns, ksvcNa
 	return false
 }
 
-func (s *K8sService) waitLastStepReceivedKSVC(ctx context.Context, ns, ksvcName, expectedInType string, deadline time.Time) bool {
-	needle := fmt.Sprintf("in=%s", expectedInType)
+func (s *K8sService) waitLastStepReceivedKSVC(ctx context.Context, ns, ksvcName string, expectedInTypes []string, deadline time.Time) bool {
 	for time.Now().Before(deadline) {
 		pods, err := s.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
 			LabelSelector: fmt.Sprintf("app=%s", ksvcName),
@@ -1224,8 +1551,10 @@ func (s *K8sService) waitLastStepReceivedKSVC(ctx context.Context, ns, ksvcName,
 					logBytes, _ := io.ReadAll(logStream)
 					logStream.Close()
 					log := string(logBytes)
-					if strings.Contains(log, needle) {
-						return true
+					for _, inType := range expectedInTypes {
+						if strings.Contains(log, fmt.Sprintf("in=%s ", inType)) {
+							return true
+						}
 					}
 				}
 			}
@@ -1241,6 +1570,21 @@ func (s *K8sService) probeTopicForCeTypes(ctx context.Context, ns, flowID string
 		maxCount = 5
 	}
 
+	script := fmt.Sprintf("kcat -C -b %s -t %s -o end -e -q -u -c %d -f 'ts=%%T key=%%k headers=%%h payload=%%s\\n' || true", s.kafkaBootstrap, flowID, maxCount)
+	logs, err := s.runKcatJob(ctx, ns, flowID, jobName, script, timeoutSec)
+	if err != nil {
+		return fmt.Sprintf("probe error: %v", err)
+	}
+
+	if len(logs) > 4000 {
+		logs = logs[:4000] + "\n...(truncated)"
+	}
+	return logs
+}
+
+// runKcatJob runs a one-shot kcat Job in ns and returns the pod logs once it
+// finished (or timeoutSec elapsed).
+func (s *K8sService) runKcatJob(ctx context.Context, ns, flowID, jobName, script string, timeoutSec int) (string, error) {
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: jobName,
@@ -1259,9 +1603,7 @@ func (s *K8sService) probeTopicForCeTypes(ctx context.Context, ns, flowID string
 							Name:    "kcat",
 							Image:   "edenhill/kcat:1.7.1",
 							Command: []string{"/bin/sh", "-c"},
-							Args: []string{
-								fmt.Sprintf("kcat -C -b %s -t %s -o end -e -q -u -c %d -f 'ts=%%T key=%%k headers=%%h payload=%%s\\n' || true", s.kafkaBootstrap, flowID, maxCount),
-							},
+							Args:    []string{script},
 						},
 					},
 				},
@@ -1275,7 +1617,7 @@ func (s *K8sService) probeTopicForCeTypes(ctx context.Context, ns, flowID string
 			_, err = s.clientset.BatchV1().Jobs(ns).Update(ctx, job, metav1.UpdateOptions{})
 		}
 		if err != nil {
-			return fmt.Sprintf("probe error: %v", err)
+			return "", err
 		}
 	}
 
@@ -1301,22 +1643,28 @@ func (s *K8sService) probeTopicForCeTypes(ctx context.Context, ns, flowID string
 			logs = string(logBytes)
 		}
 	}
-
-	if len(logs) > 4000 {
-		logs = logs[:4000] + "\n...(truncated)"
-	}
-	return logs
+	return logs, nil
 }
 
-// FlowChanged checks if flow has changed by comparing code hashes
-func (s *K8sService) FlowChanged(ctx context.Context, ns, flowID string, steps map[string]string) bool {
+// FlowChanged checks if flow has changed by comparing code hashes and event routing
+func (s *K8sService) FlowChanged(ctx context.Context, ns, flowID string, steps []FlowStep) bool {
 	gvr := schema.GroupVersionResource{
 		Group:    "serving.knative.dev",
 		Version:  "v1",
 		Resource: "services",
 	}
-	for stepNameRaw := range steps {
-		ksvcName := fmt.Sprintf("flow%s-%s", flowID, s.safeName(stepNameRaw))
+	existing, err := s.dynamicClient.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("flow_id=%s", flowID),
+	})
+	if err != nil || len(existing.Items) != len(steps) {
+		return true // Steps were added or removed
+	}
+	conns, err := s.loadConnections(strings.TrimPrefix(ns, "user-"), steps, false)
+	if err != nil {
+		return true
+	}
+	for _, step := range steps {
+		ksvcName := fmt.Sprintf("flow%s-%s", flowID, s.safeName(step.Name))
 		res, err := s.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, ksvcName, metav1.GetOptions{})
 		if err != nil {
 			return true // Not found means changed
@@ -1338,10 +1686,28 @@ func (s *K8sService) FlowChanged(ctx context.Context, ns, flowID string, steps m
 			return true
 		}
 		existsHash, _ := ann["flow/code-hash"]
-		wantHash := s.sha256(steps[stepNameRaw])
+		wantHash := s.sha256(step.Code)
 		if existsHash != wantHash {
 			return true
 		}
+		wantRoute := s.getInType(flowID, step) + ">" + s.getOutType(flowID, step)
+		if ann["flow/route"] != wantRoute {
+			return true
+		}
+		if ann["flow/retry"] != s.stepRetryPolicy(step.Params).String() {
+			return true
+		}
+		if ann["flow/runtime"] != s.stepRuntime(step.Params).String() {
+			return true
+		}
+		if ann["flow/connections"] != s.stepConnections(step.Params, conns).Tag {
+			return true
+		}
+		// 빌드된 이미지는 digest로 고정되어 있으므로 태그까지만 비교
+		wantImage := s.plannedImage(s.stepImageSpec(step.Params))
+		if ann["flow/image"] != wantImage && !strings.HasPrefix(ann["flow/image"], wantImage+"@") {
+			return true
+		}
 	}
 	return false
 }
@@ -1395,25 +1761,29 @@ func (s *K8sService) pruneStaleFlowSteps(ctx context.Context, ns, flowID string,
 }
 
 // Utility methods
-func (s *K8sService) getInType(flowID string, idx int) string {
-	if idx == 0 {
+// getInType returns the comma-separated CloudEvent types a step consumes:
+// the kick type for root steps, otherwise the out type of every predecessor.
+func (s *K8sService) getInType(flowID string, step FlowStep) string {
+	if len(step.Predecessors) == 0 {
 		return fmt.Sprintf("flow%s.kick", flowID)
 	}
-	return fmt.Sprintf("flow%s.s%d", flowID, idx)
+	types := make([]string, 0, len(step.Predecessors))
+	for _, pred := range step.Predecessors {
+		types = append(types, s.stepOutType(flowID, pred))
+	}
+	return strings.Join(types, ",")
 }
 
-func (s *K8sService) getOutType(flowID string, idx, total int) string {
-	if idx == total-1 {
-		return "" // Last step has no output
+// getOutType returns the CloudEvent type a step emits; leaf steps emit nothing
+func (s *K8sService) getOutType(flowID string, step FlowStep) string {
+	if len(step.Successors) == 0 {
+		return "" // Leaf step has no output
 	}
-	return fmt.Sprintf("flow%s.s%d", flowID, idx+1)
+	return s.stepOutType(flowID, step.Name)
 }
 
-func (s *K8sService) getInTypeSuffix(idx int) string {
-	if idx == 0 {
-		return "kick"
-	}
-	return fmt.Sprintf("s%d", idx)
+func (s *K8sService) stepOutType(flowID, stepName string) string {
+	return fmt.Sprintf("flow%s.%s.out", flowID, s.safeName(stepName))
 }
 
 func (s *K8sService) safeName(str string) string {
@@ -1467,8 +1837,13 @@ func max(a, b int) int {
 	return b
 }
 
-// DeleteByFlowId deletes all namespaced resources for a flow
+// DeleteByFlowId deletes all namespaced resources for a flow; the caller in
+// ctx must be a deployer of the flow
 func (s *K8sService) DeleteByFlowId(ctx context.Context, namespace, flowID string) (string, error) {
+	if err := s.access.RequireFlowID(ctx, flowID, models.ProjectRoleDeployer); err != nil {
+		return "", err
+	}
+
 	sel := fmt.Sprintf("flow_id=%s", flowID)
 
 	// Delete KSVCs
@@ -1499,6 +1874,16 @@ func (s *K8sService) DeleteByFlowId(ctx context.Context, namespace, flowID strin
 		}
 	}
 
+	// Delete connection Secrets
+	secrets, err := s.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: sel + ",purpose=connection",
+	})
+	if err == nil {
+		for _, secret := range secrets.Items {
+			s.clientset.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+		}
+	}
+
 	// Delete Jobs
 	jobs, err := s.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: sel,
@@ -1535,28 +1920,29 @@ func (s *K8sService) DeleteByFlowId(ctx context.Context, namespace, flowID strin
 	}
 	sinkName := fmt.Sprintf("sink-%s", flowID)
 	s.dynamicClient.Resource(sinkGVR).Namespace(namespace).Delete(ctx, sinkName, metav1.DeleteOptions{})
+	s.dynamicClient.Resource(sinkGVR).Namespace(namespace).Delete(ctx, dlqSinkName(flowID), metav1.DeleteOptions{})
 
 	return fmt.Sprintf("deleted namespaced resources flow_id=%s", flowID), nil
 }
 
 // makeTest extracts code from a test object (similar to makeStep but for single object)
-func (s *K8sService) makeTest(testID int64) (string, error) {
+func (s *K8sService) makeTest(testID int64) (string, map[string]interface{}, error) {
 	obj, err := s.objectRepo.FindByID(testID)
 	if err != nil {
-		return "", fmt.Errorf("object not found: id=%d: %w", testID, err)
+		return "", nil, fmt.Errorf("object not found: id=%d: %w", testID, err)
 	}
 
 	var code string
+	var params map[string]interface{}
 	if len(obj.Params) > 0 {
-		var params map[string]interface{}
 		if err := json.Unmarshal(obj.Params, &params); err != nil {
-			return "", fmt.Errorf("invalid params JSON for object id=%d: %w", testID, err)
+			return "", nil, fmt.Errorf("invalid params JSON for object id=%d: %w", testID, err)
 		}
 		if v, ok := params["code"]; ok && v != nil {
 			code = fmt.Sprintf("%v", v)
 		}
 	}
-	return code, nil
+	return code, params, nil
 }
 
 // UnitTest runs a unit test for a single step
@@ -1573,7 +1959,7 @@ func (s *K8sService) UnitTest(ctx context.Context, dto *models.K8sRequestDTO, ti
 		return nil, fmt.Errorf("test object ID is required")
 	}
 
-	code, err := s.makeTest(*dto.Test)
+	code, params, err := s.makeTest(*dto.Test)
 	if err != nil {
 		return nil, err
 	}
@@ -1586,6 +1972,11 @@ func (s *K8sService) UnitTest(ctx context.Context, dto *models.K8sRequestDTO, ti
 		return nil, fmt.Errorf("test code is empty")
 	}
 
+	image, _, err := s.resolveStepImage(ctx, ns, dto.FlowID, s.stepImageSpec(params))
+	if err != nil {
+		return nil, err
+	}
+
 	codeB64 := base64.StdEncoding.EncodeToString([]byte(code))
 
 	evtB64 := ""
@@ -1699,7 +2090,7 @@ finally:
 					Containers: []corev1.Container{
 						{
 							Name:            "ut",
-							Image:           s.pyImage,
+							Image:           image,
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Env: []corev1.EnvVar{
 								{Name: "CODE_B64", Value: codeB64},
@@ -1876,5 +2267,12 @@ func (s *K8sService) DeleteKafkaTopic(ctx context.Context, kafkaNamespace, flowI
 		Version:  "v1beta2",
 		Resource: "kafkatopics",
 	}
-	return s.dynamicClient.Resource(gvr).Namespace(kafkaNamespace).Delete(ctx, flowID, metav1.DeleteOptions{})
+	if err := s.dynamicClient.Resource(gvr).Namespace(kafkaNamespace).Delete(ctx, flowID, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	err := s.dynamicClient.Resource(gvr).Namespace(kafkaNamespace).Delete(ctx, dlqTopicName(flowID), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil // DLQ 도입 이전에 배포된 플로우
+	}
+	return err
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRoutes(t *testing.T) {
+	s := &K8sService{}
+	tests := []struct {
+		name    string
+		steps   []FlowStep
+		wantErr string
+	}{
+		{
+			name: "fan-in",
+			steps: []FlowStep{
+				{Name: "a", Successors: []string{"c"}},
+				{Name: "b", Successors: []string{"c"}},
+				{Name: "c", Predecessors: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "unknown predecessor",
+			steps: []FlowStep{
+				{Name: "c", Predecessors: []string{"a"}},
+			},
+			wantErr: "unknown predecessor",
+		},
+		{
+			// 선행 스텝이 후속을 모르면 아무 타입도 발행하지 않는다
+			name: "predecessor emits nothing",
+			steps: []FlowStep{
+				{Name: "a", Successors: []string{"c"}},
+				{Name: "b"},
+				{Name: "c", Predecessors: []string{"a", "b"}},
+			},
+			wantErr: `does not receive out="" of 'b'`,
+		},
+		{
+			name: "same service name",
+			steps: []FlowStep{
+				{Name: "Load Data", Successors: []string{"c"}},
+				{Name: "load_data", Successors: []string{"c"}},
+				{Name: "c", Predecessors: []string{"Load Data", "load_data"}},
+			},
+			wantErr: "share the service name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateRoutes("7", tt.steps)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,34 +1,47 @@
 package service
 
 import (
+	"context"
+	"data-pipeline-backend/internal/auth"
 	"data-pipeline-backend/internal/models"
 	"data-pipeline-backend/internal/repository"
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
+// ObjectService manages the objects (nodes) of flows and their edges. The
+// caller in ctx needs viewer on an object's flow to read it and editor to
+// change it.
 type ObjectService struct {
 	objectRepo *repository.ObjectRepository
 	flowRepo   *repository.FlowRepository
+	edgeRepo   *repository.EdgeRepository
+	access     *AccessService
+	versions   *FlowVersionService
 }
 
-func NewObjectService(objectRepo *repository.ObjectRepository, flowRepo *repository.FlowRepository) *ObjectService {
+func NewObjectService(objectRepo *repository.ObjectRepository, flowRepo *repository.FlowRepository, edgeRepo *repository.EdgeRepository,
+	access *AccessService, versions *FlowVersionService) *ObjectService {
 	return &ObjectService{
 		objectRepo: objectRepo,
 		flowRepo:   flowRepo,
+		edgeRepo:   edgeRepo,
+		access:     access,
+		versions:   versions,
 	}
 }
 
-func (s *ObjectService) Create(req *models.ObjectRequestDTO) (*models.ObjectResponseDTO, error) {
+func (s *ObjectService) Create(ctx context.Context, req *models.ObjectRequestDTO) (*models.ObjectResponseDTO, error) {
 	if req.FlowID == nil {
 		return nil, errors.New("플로우 ID는 필수입니다")
 	}
 
-	_, err := s.flowRepo.FindByID(*req.FlowID)
-	if err != nil {
-		if err == repository.ErrFlowNotFound {
-			return nil, errors.New("플로우를 찾을 수 없습니다")
-		}
+	if _, err := s.access.RequireFlow(ctx, *req.FlowID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateStepParams(req.Params); err != nil {
 		return nil, err
 	}
 
@@ -51,110 +64,182 @@ func (s *ObjectService) Create(req *models.ObjectRequestDTO) (*models.ObjectResp
 		label = objectType
 	}
 
+	targets := resolveTargets(req)
+
 	object := &models.Object{
 		Type:   objectType,
 		X:      req.X,
 		Y:      req.Y,
 		Label:  label,
 		Params: paramsJSON,
-		Target: req.Target,
+		Target: firstTarget(targets),
 		FlowID: req.FlowID,
 	}
 
-	if err := s.objectRepo.Create(object); err != nil {
+	if err := s.validateTargets(*req.FlowID, 0, targets); err != nil {
+		return nil, err
+	}
+
+	err := s.objectRepo.InTx(func(tx repository.DBTX) error {
+		txs := s.withTx(tx)
+		if err := txs.objectRepo.Create(object); err != nil {
+			return err
+		}
+		if err := txs.edgeRepo.ReplaceTargets(*req.FlowID, object.ID, targets); err != nil {
+			return err
+		}
+		_, err := txs.versions.RecordSave(*req.FlowID, nil)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return object.ToResponseDTO()
+	dto, err := object.ToResponseDTO()
+	if err != nil {
+		return nil, err
+	}
+	dto.Targets = targets
+	return dto, nil
 }
 
-func (s *ObjectService) FindByID(id int64) (*models.ObjectResponseDTO, error) {
+func (s *ObjectService) FindByID(ctx context.Context, id int64) (*models.ObjectResponseDTO, error) {
 	object, err := s.objectRepo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.requireObject(ctx, object, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
 
-	return object.ToResponseDTO()
-}
+	dto, err := object.ToResponseDTO()
+	if err != nil {
+		return nil, err
+	}
 
-func (s *ObjectService) FindAll() ([]*models.ObjectResponseDTO, error) {
-	objects, err := s.objectRepo.FindAll()
+	targets, err := s.edgeRepo.FindTargets(id)
 	if err != nil {
 		return nil, err
 	}
+	dto.Targets = targets
 
-	var dtos []*models.ObjectResponseDTO
-	for _, object := range objects {
-		dto, err := object.ToResponseDTO()
+	return dto, nil
+}
+
+// List returns the objects of the flows the caller can see; admins see all
+func (s *ObjectService) List(ctx context.Context) ([]*models.ObjectResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+	if user.Admin {
+		return s.findAll()
+	}
+
+	flows, err := s.flowRepo.FindAccessible(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var objects []*models.ObjectResponseDTO
+	for _, flow := range flows {
+		flowObjects, err := s.findByFlow(flow.ID)
 		if err != nil {
 			return nil, err
 		}
-		dtos = append(dtos, dto)
+		objects = append(objects, flowObjects...)
 	}
-
-	return dtos, nil
+	return objects, nil
 }
 
-func (s *ObjectService) FindByFlow(flowID int64) ([]*models.ObjectResponseDTO, error) {
-	_, err := s.flowRepo.FindByID(flowID)
+func (s *ObjectService) findAll() ([]*models.ObjectResponseDTO, error) {
+	objects, err := s.objectRepo.FindAll()
 	if err != nil {
-		if err == repository.ErrFlowNotFound {
-			return nil, errors.New("플로우를 찾을 수 없습니다")
-		}
 		return nil, err
 	}
 
+	edges, err := s.edgeRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectDTOsWithTargets(objects, edges)
+}
+
+func (s *ObjectService) FindByFlow(ctx context.Context, flowID int64) ([]*models.ObjectResponseDTO, error) {
+	if _, err := s.access.RequireFlow(ctx, flowID, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.findByFlow(flowID)
+}
+
+func (s *ObjectService) findByFlow(flowID int64) ([]*models.ObjectResponseDTO, error) {
 	objects, err := s.objectRepo.FindByFlow(flowID)
 	if err != nil {
 		return nil, err
 	}
 
-	var dtos []*models.ObjectResponseDTO
-	for _, object := range objects {
-		dto, err := object.ToResponseDTO()
-		if err != nil {
-			return nil, err
-		}
-		dtos = append(dtos, dto)
+	edges, err := s.edgeRepo.FindByFlow(flowID)
+	if err != nil {
+		return nil, err
 	}
 
-	return dtos, nil
+	return toObjectDTOsWithTargets(objects, edges)
 }
 
-func (s *ObjectService) Update(req *models.ObjectRequestDTO) (*models.ObjectResponseDTO, error) {
+func (s *ObjectService) Update(ctx context.Context, req *models.ObjectRequestDTO) (*models.ObjectResponseDTO, error) {
 	if req.ID == nil {
 		return nil, errors.New("오브젝트 ID는 필수입니다")
 	}
 
+	object, err := s.objectRepo.FindByID(*req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireObject(ctx, object, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+	// 다른 플로우로 옮길 때는 대상 플로우도 편집할 수 있어야 한다
 	if req.FlowID != nil {
-		_, err := s.flowRepo.FindByID(*req.FlowID)
-		if err != nil {
-			if err == repository.ErrFlowNotFound {
-				return nil, errors.New("플로우를 찾을 수 없습니다")
-			}
+		if _, err := s.access.RequireFlow(ctx, *req.FlowID, models.ProjectRoleEditor); err != nil {
 			return nil, err
 		}
 	}
 
-	object, err := s.objectRepo.FindByID(*req.ID)
-	if err != nil {
-		if err == repository.ErrObjectNotFound {
-			return nil, repository.ErrObjectNotFound
+	oldFlowID := object.FlowID
+	moved := (oldFlowID == nil) != (req.FlowID == nil) || (oldFlowID != nil && *oldFlowID != *req.FlowID)
+
+	// target/targets를 모두 생략하면 기존 엣지를 유지한다; 다른 플로우로 옮기면 기존 엣지는 버린다
+	targets := resolveTargets(req)
+	if req.Targets == nil && req.Target == nil && !moved {
+		targets, err = s.edgeRepo.FindTargets(object.ID)
+		if err != nil {
+			return nil, err
 		}
-		return nil, err
 	}
 
 	object.X = req.X
 	object.Y = req.Y
 	object.Label = req.Label
-	object.Target = req.Target
+	object.Target = firstTarget(targets)
 	object.FlowID = req.FlowID
 
+	if object.FlowID == nil && len(targets) > 0 {
+		return nil, errors.New("엣지를 연결하려면 플로우 ID가 필요합니다")
+	}
+	if object.FlowID != nil {
+		if err := s.validateTargets(*object.FlowID, object.ID, targets); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Type != "" {
 		object.Type = req.Type
 	}
 
 	if req.Params != nil {
+		if err := ValidateStepParams(req.Params); err != nil {
+			return nil, err
+		}
 		paramsBytes, err := json.Marshal(req.Params)
 		if err != nil {
 			return nil, errors.New("파라미터 JSON 변환 실패: " + err.Error())
@@ -162,13 +247,198 @@ func (s *ObjectService) Update(req *models.ObjectRequestDTO) (*models.ObjectResp
 		object.Params = json.RawMessage(paramsBytes)
 	}
 
-	if err := s.objectRepo.Update(object); err != nil {
+	err = s.objectRepo.InTx(func(tx repository.DBTX) error {
+		txs := s.withTx(tx)
+		if err := txs.objectRepo.Update(object); err != nil {
+			return err
+		}
+
+		var flowID int64
+		if object.FlowID != nil {
+			flowID = *object.FlowID
+		}
+		if err := txs.edgeRepo.ReplaceTargets(flowID, object.ID, targets); err != nil {
+			return err
+		}
+
+		// 이전 플로우에서 이 오브젝트로 들어오던 엣지를 지우고 그 플로우도 저장 기록을 남긴다
+		if moved {
+			if err := txs.edgeRepo.DeleteIncoming(object.ID); err != nil {
+				return err
+			}
+			if oldFlowID != nil {
+				if _, err := txs.versions.RecordSave(*oldFlowID, nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		if object.FlowID == nil {
+			return nil
+		}
+		_, err := txs.versions.RecordSave(*object.FlowID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dto, err := object.ToResponseDTO()
+	if err != nil {
 		return nil, err
 	}
+	dto.Targets = targets
+	return dto, nil
+}
+
+func (s *ObjectService) Delete(ctx context.Context, id int64) error {
+	object, err := s.objectRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.requireObject(ctx, object, models.ProjectRoleEditor); err != nil {
+		return err
+	}
+
+	return s.objectRepo.InTx(func(tx repository.DBTX) error {
+		txs := s.withTx(tx)
+		if err := txs.objectRepo.Delete(id); err != nil {
+			return err
+		}
+
+		if object.FlowID == nil {
+			return nil
+		}
+		_, err := txs.versions.RecordSave(*object.FlowID, nil)
+		return err
+	})
+}
+
+// withTx returns the service with its repositories running in tx
+func (s *ObjectService) withTx(tx repository.DBTX) *ObjectService {
+	return &ObjectService{
+		objectRepo: s.objectRepo.WithTx(tx),
+		flowRepo:   s.flowRepo.WithTx(tx),
+		edgeRepo:   s.edgeRepo.WithTx(tx),
+		access:     s.access,
+		versions:   s.versions.withTx(tx),
+	}
+}
+
+// requireObject checks the caller's role on the flow of an object; objects
+// outside any flow are left to admins
+func (s *ObjectService) requireObject(ctx context.Context, object *models.Object, role string) error {
+	if object.FlowID == nil {
+		if user := auth.UserFromContext(ctx); user == nil || !user.Admin {
+			return fmt.Errorf("%w: 플로우에 속하지 않은 오브젝트입니다", ErrForbidden)
+		}
+		return nil
+	}
+	_, err := s.access.RequireFlow(ctx, *object.FlowID, role)
+	return err
+}
+
+// validateTargets checks that every target belongs to the flow and that the
+// resulting graph stays acyclic. sourceID is 0 for an object not yet created.
+func (s *ObjectService) validateTargets(flowID, sourceID int64, targets []int64) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	objects, err := s.objectRepo.FindByFlow(flowID)
+	if err != nil {
+		return err
+	}
+	edges, err := s.edgeRepo.FindByFlow(flowID)
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]int64, 0, len(objects)+1)
+	inFlow := make(map[int64]bool, len(objects))
+	for _, o := range objects {
+		nodes = append(nodes, o.ID)
+		inFlow[o.ID] = true
+	}
+
+	for _, target := range targets {
+		if target == sourceID {
+			return errors.New("오브젝트는 자기 자신을 타깃으로 지정할 수 없습니다")
+		}
+		if !inFlow[target] {
+			return fmt.Errorf("타깃 오브젝트 %d 가 같은 플로우에 없습니다", target)
+		}
+	}
 
-	return object.ToResponseDTO()
+	// 새 오브젝트는 아직 들어오는 엣지가 없으므로 순환이 생길 수 없다
+	if sourceID == 0 {
+		return nil
+	}
+
+	var dtos []models.EdgeDTO
+	for _, e := range edges {
+		if e.Source == sourceID {
+			continue
+		}
+		dtos = append(dtos, e.ToDTO())
+	}
+	for _, target := range targets {
+		dtos = append(dtos, models.EdgeDTO{Source: sourceID, Target: target})
+	}
+	if !inFlow[sourceID] {
+		nodes = append(nodes, sourceID)
+	}
+
+	if _, err := NewFlowGraph(nodes, dtos).TopologicalOrder(); err != nil {
+		return fmt.Errorf("엣지를 추가하면 플로우에 순환이 생깁니다: %w", err)
+	}
+	return nil
 }
 
-func (s *ObjectService) Delete(id int64) error {
-	return s.objectRepo.Delete(id)
+// resolveTargets merges the legacy single Target with the Targets list
+func resolveTargets(req *models.ObjectRequestDTO) []int64 {
+	var raw []int64
+	if req.Targets != nil {
+		raw = req.Targets
+	} else if req.Target != nil {
+		raw = []int64{*req.Target}
+	}
+
+	seen := make(map[int64]bool, len(raw))
+	targets := make([]int64, 0, len(raw))
+	for _, t := range raw {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+func firstTarget(targets []int64) *int64 {
+	if len(targets) == 0 {
+		return nil
+	}
+	t := targets[0]
+	return &t
+}
+
+func toObjectDTOsWithTargets(objects []*models.Object, edges []*models.Edge) ([]*models.ObjectResponseDTO, error) {
+	targetsBySource := make(map[int64][]int64)
+	for _, e := range edges {
+		targetsBySource[e.Source] = append(targetsBySource[e.Source], e.Target)
+	}
+
+	var dtos []*models.ObjectResponseDTO
+	for _, object := range objects {
+		dto, err := object.ToResponseDTO()
+		if err != nil {
+			return nil, err
+		}
+		dto.Targets = targetsBySource[object.ID]
+		dtos = append(dtos, dto)
+	}
+
+	return dtos, nil
 }
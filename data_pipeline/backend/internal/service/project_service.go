@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrProjectExists    = errors.New("같은 이름의 프로젝트가 이미 있습니다")
+	ErrLastProjectAdmin = errors.New("프로젝트에는 최소 한 명의 admin이 있어야 합니다")
+)
+
+type ProjectService struct {
+	projectRepo *repository.ProjectRepository
+	userRepo    *repository.UserRepository
+	access      *AccessService
+}
+
+func NewProjectService(projectRepo *repository.ProjectRepository, userRepo *repository.UserRepository, access *AccessService) *ProjectService {
+	return &ProjectService{projectRepo: projectRepo, userRepo: userRepo, access: access}
+}
+
+// Create makes a project with the caller as its admin
+func (s *ProjectService) Create(ctx context.Context, req *models.ProjectRequestDTO) (*models.ProjectResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, fmt.Errorf("%w: 로그인이 필요합니다", ErrForbidden)
+	}
+	if err := validateProject(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.projectRepo.FindByName(strings.TrimSpace(req.Name)); err == nil {
+		return nil, ErrProjectExists
+	}
+
+	project := &models.Project{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		CreatedBy:   &user.ID,
+		Role:        models.ProjectRoleAdmin,
+	}
+	if err := s.projectRepo.Create(project); err != nil {
+		return nil, err
+	}
+	return project.ToResponseDTO(), nil
+}
+
+// FindAll lists the caller's projects; admins see every project
+func (s *ProjectService) FindAll(ctx context.Context) ([]*models.ProjectResponseDTO, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+
+	var projects []*models.Project
+	var err error
+	if user.Admin {
+		projects, err = s.projectRepo.FindAll()
+	} else {
+		projects, err = s.projectRepo.FindByMember(user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]*models.ProjectResponseDTO, 0, len(projects))
+	for _, p := range projects {
+		if user.Admin {
+			p.Role = models.ProjectRoleAdmin
+		}
+		dtos = append(dtos, p.ToResponseDTO())
+	}
+	return dtos, nil
+}
+
+// FindByID returns a project with its members
+func (s *ProjectService) FindByID(ctx context.Context, id int64) (*models.ProjectResponseDTO, error) {
+	if err := s.access.RequireProject(ctx, id, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	project, err := s.projectRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Role, err = s.access.ProjectRole(auth.UserFromContext(ctx), id); err != nil {
+		return nil, err
+	}
+
+	dto := project.ToResponseDTO()
+	if dto.Members, err = s.projectRepo.ListMembers(id); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (s *ProjectService) Update(ctx context.Context, id int64, req *models.ProjectRequestDTO) (*models.ProjectResponseDTO, error) {
+	if err := s.access.RequireProject(ctx, id, models.ProjectRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := validateProject(req); err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if other, err := s.projectRepo.FindByName(strings.TrimSpace(req.Name)); err == nil && other.ID != id {
+		return nil, ErrProjectExists
+	}
+	project.Name = strings.TrimSpace(req.Name)
+	project.Description = req.Description
+	project.Role = models.ProjectRoleAdmin
+	if err := s.projectRepo.Update(project); err != nil {
+		return nil, err
+	}
+	return project.ToResponseDTO(), nil
+}
+
+// Delete removes a project; its flows, datasets and models fall back to their creators
+func (s *ProjectService) Delete(ctx context.Context, id int64) error {
+	if err := s.access.RequireProject(ctx, id, models.ProjectRoleAdmin); err != nil {
+		return err
+	}
+	return s.projectRepo.Delete(id)
+}
+
+func (s *ProjectService) ListMembers(ctx context.Context, id int64) ([]*models.ProjectMember, error) {
+	if err := s.access.RequireProject(ctx, id, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.ListMembers(id)
+}
+
+// SetMember adds a user (who has signed in at least once) or changes its role
+func (s *ProjectService) SetMember(ctx context.Context, id int64, username, role string) ([]*models.ProjectMember, error) {
+	if err := s.access.RequireProject(ctx, id, models.ProjectRoleAdmin); err != nil {
+		return nil, err
+	}
+	if !models.ValidProjectRole(role) {
+		return nil, fmt.Errorf("role은 viewer, editor, deployer, admin 중 하나여야 합니다: %q", role)
+	}
+
+	member, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.ProjectRoleAdmin {
+		if err := s.keepAdmin(id, member.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.projectRepo.SetMember(id, member.ID, role); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.ListMembers(id)
+}
+
+// RemoveMember removes a member; members may also leave on their own
+func (s *ProjectService) RemoveMember(ctx context.Context, id int64, username string) error {
+	member, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if user == nil || user.ID != member.ID {
+		if err := s.access.RequireProject(ctx, id, models.ProjectRoleAdmin); err != nil {
+			return err
+		}
+	}
+	if err := s.keepAdmin(id, member.ID); err != nil {
+		return err
+	}
+	return s.projectRepo.DeleteMember(id, member.ID)
+}
+
+// keepAdmin fails when userID is the last admin of the project
+func (s *ProjectService) keepAdmin(projectID, userID int64) error {
+	role, err := s.projectRepo.FindMemberRole(projectID, userID)
+	if err == repository.ErrProjectMemberNotFound || (err == nil && role != models.ProjectRoleAdmin) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	n, err := s.projectRepo.CountAdmins(projectID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return ErrLastProjectAdmin
+	}
+	return nil
+}
+
+func validateProject(req *models.ProjectRequestDTO) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("프로젝트명은 필수입니다")
+	}
+	if len(name) > 100 {
+		return errors.New("프로젝트명은 100자 이하여야 합니다")
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/staging"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PythonStepService runs the Python steps of /api/workflow/execute on the
+// executor. The data never goes into the code: the input is staged as a
+// file the executor reads in chunks, and the result comes back as another
+// staged file with a meta file describing it.
+type PythonStepService struct {
+	area        *staging.Area
+	areaErr     error
+	executorURL string
+	format      string
+	chunkRows   int
+	timeout     time.Duration
+}
+
+func NewPythonStepService(cfg config.StagingConfig, executorURL string) *PythonStepService {
+	if executorURL == "" {
+		executorURL = "http://jupyter-service:8888"
+	}
+	timeout := time.Duration(cfg.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	s := &PythonStepService{
+		executorURL: strings.TrimRight(executorURL, "/"),
+		format:      cfg.Format,
+		chunkRows:   cfg.ChunkRows,
+		timeout:     timeout,
+	}
+	s.area, s.areaErr = staging.New(cfg.Dir, cfg.KernelDir)
+	if s.areaErr == nil {
+		// 끝나지 못한 실행이 남긴 파일을 정리한다
+		s.area.Sweep(24 * time.Hour)
+	}
+	return s
+}
+
+// PythonStepOptions tune one run
+type PythonStepOptions struct {
+	ChunkRows int // overrides the configured chunk size; 0 = default
+}
+
+// Run executes code over data: an array of records, any JSON value when the
+// step is not chunked, or the output of an earlier step, which is read in
+// place and left to the caller. The user code sees df (a DataFrame of the
+// chunk), data (its records) and chunk_index, and leaves its output in
+// result or df. Code written for a deployed flow step defines handle(evt)
+// instead; it is called for each record, or once for an object input such
+// as a trigger's event, and returns a dict, a list of dicts or None. The
+// caller removes the returned dataset. The output the code printed is
+// returned as logs, also when it failed.
+func (s *PythonStepService) Run(ctx context.Context, data interface{}, code string, opts PythonStepOptions) (*staging.Dataset, string, error) {
+	if s.areaErr != nil {
+		return nil, "", fmt.Errorf("staging directory unavailable: %w", s.areaErr)
+	}
+	chunkRows := s.chunkRows
+	if opts.ChunkRows > 0 {
+		chunkRows = opts.ChunkRows
+	}
+
+	input, err := s.stage(data, chunkRows)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to stage input: %w", err)
+	}
+	if input != data {
+		defer input.Remove()
+	}
+
+	output, err := s.area.NewDataset("jsonl")
+	if err != nil {
+		return nil, "", err
+	}
+	params, err := json.Marshal(map[string]interface{}{
+		"input":       input.KernelPath(),
+		"inputFormat": input.Format,
+		"inputKind":   input.Kind,
+		"output":      output.KernelPath(),
+		"meta":        output.KernelMetaPath(),
+		"chunkRows":   chunkRows,
+		"code":        code,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	// JSON 문자열은 그대로 파이썬 문자열 리터럴이다
+	literal, _ := json.Marshal(string(params))
+
+	logs, err := s.execute(ctx, fmt.Sprintf(pythonStepWrapper, literal))
+	if err != nil {
+		output.Remove()
+		return nil, logs, err
+	}
+	if err := s.area.LoadOutput(output); err != nil {
+		output.Remove()
+		return nil, logs, err
+	}
+	return output, logs, nil
+}
+
+// stage writes data to the staging area unless it is already there
+func (s *PythonStepService) stage(data interface{}, chunkRows int) (*staging.Dataset, error) {
+	if ds, ok := data.(*staging.Dataset); ok {
+		if ds.Kind != staging.KindRecords && chunkRows > 0 {
+			return nil, fmt.Errorf("chunked python steps need an array of objects")
+		}
+		return ds, nil
+	}
+	if records, ok := asRecords(data); ok {
+		return s.area.Stage(records, s.format)
+	}
+	if chunkRows > 0 {
+		return nil, fmt.Errorf("chunked python steps need an array of objects")
+	}
+	return s.area.StageValue(data)
+}
+
+// execute posts code to the executor. Both response shapes are understood:
+// {output, error} and the {stdout, stderr, exit_code} of the script runner.
+// The printed output is returned as logs.
+func (s *PythonStepService) execute(ctx context.Context, code string) (string, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"code":    code,
+		"timeout": int(s.timeout / time.Second),
+	})
+	ctx, cancel := context.WithTimeout(ctx, s.timeout+30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.executorURL+"/api/execute", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Jupyter: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Jupyter returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Output   string `json:"output"`
+		Error    string `json:"error"`
+		Stdout   string `json:"stdout"`
+		Stderr   string `json:"stderr"`
+		ExitCode *int   `json:"exit_code"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse Jupyter response: %w", err)
+	}
+	logs := tailLogs(result.Output + result.Stdout)
+	if result.Error != "" {
+		return logs, fmt.Errorf("%s", result.Error)
+	}
+	if result.ExitCode != nil && *result.ExitCode != 0 {
+		msg := strings.TrimSpace(result.Stderr)
+		if len(msg) > 4000 {
+			// traceback의 끝부분이 원인을 담고 있다
+			msg = "..." + msg[len(msg)-4000:]
+		}
+		if msg == "" {
+			msg = fmt.Sprintf("exit code %d", *result.ExitCode)
+		}
+		return logs, fmt.Errorf("%s", msg)
+	}
+	// 성공한 실행의 stderr는 경고 등이므로 로그에 포함한다
+	return tailLogs(logs + result.Stderr), nil
+}
+
+// maxStepLogs bounds the logs kept for a step
+const maxStepLogs = 64 * 1024
+
+// tailLogs keeps the end of long logs
+func tailLogs(logs string) string {
+	if len(logs) <= maxStepLogs {
+		return logs
+	}
+	return "...\n" + logs[len(logs)-maxStepLogs:]
+}
+
+func asRecords(data interface{}) ([]map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		records := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			records = append(records, m)
+		}
+		return records, true
+	}
+	return nil, false
+}
+
+// pythonStepWrapper runs the user code once per input chunk and appends each
+// chunk's result to the output file. %s is a JSON string with the paths,
+// the chunk size and the code.
+const pythonStepWrapper = `import copy
+import json
+import os
+import pandas as pd
+
+_step = json.loads(%s)
+_code = compile(_step["code"], "<python step>", "exec")
+_chunk_rows = _step["chunkRows"] or None
+
+
+def _input_chunks():
+    path = _step["input"]
+    if _step["inputKind"] == "value":
+        with open(path, encoding="utf-8") as f:
+            yield json.load(f)
+        return
+    if os.path.getsize(path) == 0:
+        yield pd.DataFrame()
+        return
+    if _step["inputFormat"] == "parquet":
+        import pyarrow.parquet as pq
+        pf = pq.ParquetFile(path)
+        if _chunk_rows:
+            for batch in pf.iter_batches(batch_size=_chunk_rows):
+                yield batch.to_pandas()
+        else:
+            yield pf.read().to_pandas()
+        return
+    if _chunk_rows:
+        with pd.read_json(path, lines=True, chunksize=_chunk_rows, dtype=False, convert_dates=False) as reader:
+            yield from reader
+    else:
+        yield pd.read_json(path, lines=True, dtype=False, convert_dates=False)
+
+
+_kind, _rows, _columns = "records", 0, []
+
+
+def _handle(handle, data):
+    out = []
+    for evt in data if isinstance(data, list) else [data]:
+        ret = handle(copy.deepcopy(evt) if isinstance(evt, dict) else {})
+        if ret is None:
+            continue
+        for item in [ret] if isinstance(ret, dict) else ret:
+            if not isinstance(item, dict):
+                raise TypeError("handle() must return a dict, a list of dicts or None")
+            item.pop("__type", None)
+            out.append(item)
+    return out
+
+
+def _add_columns(names):
+    for name in names:
+        name = str(name)
+        if name not in _columns:
+            _columns.append(name)
+
+
+with open(_step["output"], "w", encoding="utf-8") as _out:
+    for _index, _chunk in enumerate(_input_chunks()):
+        if isinstance(_chunk, pd.DataFrame):
+            _df, _data = _chunk, _chunk.to_dict(orient="records")
+        else:
+            _data = _chunk
+            try:
+                _df = pd.DataFrame(_data)
+            except Exception:
+                _df = None
+        _ns = {"pd": pd, "json": json, "df": _df, "data": _data, "chunk_index": _index}
+        exec(_code, _ns)
+
+        if "result" in _ns:
+            _result = _ns["result"]
+        elif callable(_ns.get("handle")):
+            _result = _handle(_ns["handle"], _data)
+        elif _ns.get("df") is not None:
+            _result = _ns["df"]
+        else:
+            _result = _ns.get("data")
+
+        if isinstance(_result, pd.DataFrame):
+            _add_columns(_result.columns)
+            if len(_result):
+                _text = _result.to_json(orient="records", lines=True, date_format="iso", default_handler=str)
+                _out.write(_text if _text.endswith("\n") else _text + "\n")
+            _rows += len(_result)
+        elif isinstance(_result, list) and all(isinstance(r, dict) for r in _result):
+            for _record in _result:
+                _add_columns(_record.keys())
+                _out.write(json.dumps(_record, default=str) + "\n")
+            _rows += len(_result)
+        elif not _chunk_rows:
+            _kind = "value"
+            json.dump(_result, _out, default=str)
+        else:
+            raise TypeError("a chunked python step must return a DataFrame or a list of records")
+
+with open(_step["meta"], "w", encoding="utf-8") as _meta:
+    json.dump({"kind": _kind, "rows": _rows, "columns": _columns}, _meta)
+`
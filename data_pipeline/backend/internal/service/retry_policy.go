@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Retry backoff policies, shared with the KafkaSource delivery spec
+const (
+	BackoffExponential = "exponential"
+	BackoffLinear      = "linear"
+)
+
+// RetryPolicy is a step's retry policy from params.retry. The runner retries
+// USER_HANDLE and emits in-process; if an emit still fails the event is
+// answered with 500 and the KafkaSource redelivers it with the same policy.
+type RetryPolicy struct {
+	MaxAttempts int      // including the first attempt; 1 = no retry
+	Backoff     string   // exponential | linear
+	DelayMs     int      // first backoff delay
+	MaxDelayMs  int      // upper bound of a single backoff delay
+	RetryOn     []string // retryable exception class names; empty = any
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 1,
+		Backoff:     BackoffExponential,
+		DelayMs:     500,
+		MaxDelayMs:  10000,
+	}
+}
+
+// stepRetryPolicy reads params.retry (number of attempts or
+// {maxAttempts, backoff, delayMs, maxDelayMs, retryOn}) and clamps it
+func (s *K8sService) stepRetryPolicy(params map[string]interface{}) RetryPolicy {
+	p := defaultRetryPolicy()
+	raw, ok := params["retry"]
+	if !ok || raw == nil {
+		return p
+	}
+
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		if n, ok := paramInt(v["maxAttempts"]); ok {
+			p.MaxAttempts = n
+		}
+		if b, ok := v["backoff"].(string); ok {
+			switch strings.ToLower(strings.TrimSpace(b)) {
+			case BackoffExponential:
+				p.Backoff = BackoffExponential
+			case BackoffLinear:
+				p.Backoff = BackoffLinear
+			}
+		}
+		if n, ok := paramInt(v["delayMs"]); ok {
+			p.DelayMs = n
+		}
+		if n, ok := paramInt(v["maxDelayMs"]); ok {
+			p.MaxDelayMs = n
+		}
+		switch on := v["retryOn"].(type) {
+		case []interface{}:
+			for _, e := range on {
+				if name, ok := e.(string); ok && strings.TrimSpace(name) != "" {
+					p.RetryOn = append(p.RetryOn, strings.TrimSpace(name))
+				}
+			}
+		case string:
+			for _, name := range strings.Split(on, ",") {
+				if strings.TrimSpace(name) != "" {
+					p.RetryOn = append(p.RetryOn, strings.TrimSpace(name))
+				}
+			}
+		}
+	default:
+		if n, ok := paramInt(v); ok {
+			p.MaxAttempts = n
+		}
+	}
+
+	p.MaxAttempts = clampInt(p.MaxAttempts, 1, 10)
+	p.DelayMs = clampInt(p.DelayMs, 0, 60000)
+	p.MaxDelayMs = clampInt(p.MaxDelayMs, p.DelayMs, 300000)
+	return p
+}
+
+// env is the RUNNER_PY configuration of the policy
+func (p RetryPolicy) env() [][2]string {
+	return [][2]string{
+		{"RETRY_MAX_ATTEMPTS", strconv.Itoa(p.MaxAttempts)},
+		{"RETRY_BACKOFF", p.Backoff},
+		{"RETRY_BACKOFF_MS", strconv.Itoa(p.DelayMs)},
+		{"RETRY_MAX_BACKOFF_MS", strconv.Itoa(p.MaxDelayMs)},
+		{"RETRY_ON", strings.Join(p.RetryOn, ",")},
+	}
+}
+
+// delivery is the matching KafkaSource delivery spec
+func (p RetryPolicy) delivery() map[string]interface{} {
+	return map[string]interface{}{
+		"retry":         int64(p.MaxAttempts - 1),
+		"backoffPolicy": p.Backoff,
+		"backoffDelay":  fmt.Sprintf("PT%gS", float64(p.DelayMs)/1000),
+	}
+}
+
+// String is the policy as recorded in the flow/retry annotation
+func (p RetryPolicy) String() string {
+	s := fmt.Sprintf("%dx %s %d..%dms", p.MaxAttempts, p.Backoff, p.DelayMs, p.MaxDelayMs)
+	if len(p.RetryOn) > 0 {
+		s += " on=" + strings.Join(p.RetryOn, ",")
+	}
+	return s
+}
+
+// paramInt accepts a JSON number or a numeric string
+func paramInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case string:
+		if parsed, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
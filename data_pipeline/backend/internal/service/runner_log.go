@@ -0,0 +1,58 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	runnerLinePattern  = regexp.MustCompile(`^\[([^\]]+)\] (.*)$`)
+	runnerIOPattern    = regexp.MustCompile(`^in=(\S*) out=(\S*) items=(\d+)`)
+	runnerEmitPattern  = regexp.MustCompile(`^emit ce_type=(\S+)`)
+	runnerRetryPattern = regexp.MustCompile(`^retry (\S+) attempt=(\d+) error: (.*)$`)
+	runnerEmitErrorTag = "emit error: "
+	runnerErrorTag     = "error: "
+)
+
+// ParseRunnerLine extracts the structured fields of a RUNNER_PY log line:
+//
+//	[APP] in=<type> out=<t1,t2|-> items=N
+//	[APP] emit ce_type=<type>
+//	[APP] emit error: ... / [APP] error: ...
+//	[APP] retry <handle|emit|dlq> attempt=N error: ...
+//
+// Other lines (user prints, tracebacks) return nil.
+func ParseRunnerLine(line string) *models.RunnerLogFields {
+	m := runnerLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if m == nil {
+		return nil
+	}
+	app, rest := m[1], m[2]
+
+	if io := runnerIOPattern.FindStringSubmatch(rest); io != nil {
+		fields := &models.RunnerLogFields{App: app, Kind: "io", In: io[1]}
+		if io[2] != "" && io[2] != "-" {
+			fields.Out = strings.Split(io[2], ",")
+		}
+		if n, err := strconv.Atoi(io[3]); err == nil {
+			fields.Items = &n
+		}
+		return fields
+	}
+	if emit := runnerEmitPattern.FindStringSubmatch(rest); emit != nil {
+		return &models.RunnerLogFields{App: app, Kind: "emit", CeType: emit[1]}
+	}
+	if retry := runnerRetryPattern.FindStringSubmatch(rest); retry != nil {
+		attempt, _ := strconv.Atoi(retry[2])
+		return &models.RunnerLogFields{App: app, Kind: "retry", Retry: retry[1], Attempt: attempt, Error: retry[3]}
+	}
+	if strings.HasPrefix(rest, runnerEmitErrorTag) {
+		return &models.RunnerLogFields{App: app, Kind: "emit_error", Error: strings.TrimPrefix(rest, runnerEmitErrorTag)}
+	}
+	if strings.HasPrefix(rest, runnerErrorTag) {
+		return &models.RunnerLogFields{App: app, Kind: "error", Error: strings.TrimPrefix(rest, runnerErrorTag)}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/models"
+	"fmt"
+	"strings"
+)
+
+// Runtime names
+const (
+	RuntimeKnative = "knative"
+	RuntimeLocal   = "local"
+)
+
+// FlowRuntime runs a flow's steps on an execution backend. Every backend
+// honours the RUNNER_PY contract: handle(evt) returns dicts (or a list of
+// dicts) and an optional "__type" overrides the emitted event type.
+type FlowRuntime interface {
+	Name() string
+	// Deploy creates or updates the flow's steps and waits until they can receive events
+	Deploy(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int, progress ProgressCallback) (string, error)
+	// Changed reports whether the deployed steps differ from the stored flow
+	Changed(ctx context.Context, dto *models.K8sRequestDTO) (bool, error)
+	Delete(ctx context.Context, dto *models.K8sRequestDTO) (string, error)
+	Status(ctx context.Context, dto *models.K8sRequestDTO) (*models.FlowRuntimeStatusDTO, error)
+	// RunOnce sends a kick event and returns "OK: ..." or "NG: ..." once every leaf step received it
+	RunOnce(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int) string
+	// Logs returns recent log lines per step name
+	Logs(ctx context.Context, dto *models.K8sRequestDTO, tailLines int) (map[string]string, error)
+	// Observe lists what is actually running for the flow, keyed by ksvc name
+	Observe(ctx context.Context, ns, flowID string) ([]models.ObservedStepDTO, error)
+	// StreamLogs follows the step logs of the flow until ctx is done; emit is
+	// always called from the calling goroutine
+	StreamLogs(ctx context.Context, ns, flowID string, tailLines int, emit func(models.StepLogEventDTO)) error
+	// ListDeadLetters returns up to limit of the most recent events a step failed on
+	ListDeadLetters(ctx context.Context, ns, flowID string, limit int) ([]models.DeadLetterDTO, error)
+	// Replay re-publishes dead letters to the flow, each targeted at its failed step
+	Replay(ctx context.Context, ns, flowID string, entries []models.DeadLetterDTO) error
+}
+
+// ValidateRuntimeName checks that a runtime name is supported
+func ValidateRuntimeName(name string) error {
+	switch name {
+	case RuntimeKnative, RuntimeLocal:
+		return nil
+	}
+	return fmt.Errorf("unknown runtime %q (expected %s or %s)", name, RuntimeKnative, RuntimeLocal)
+}
+
+// DeployAndVerify deploys the flow on the runtime and kicks it once
+func DeployAndVerify(ctx context.Context, rt FlowRuntime, dto *models.K8sRequestDTO, timeoutSeconds int, progress ProgressCallback) string {
+	if _, err := rt.Deploy(ctx, dto, timeoutSeconds, progress); err != nil {
+		return fmt.Sprintf("NG: %v", err)
+	}
+
+	v := rt.RunOnce(ctx, dto, timeoutSeconds)
+	if !strings.HasPrefix(v, "OK:") {
+		return v
+	}
+
+	return "OK: deployed & verified"
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KnativeRuntime deploys each step as a Knative Service fed by a KafkaSource
+// on the flow's Strimzi topic.
+type KnativeRuntime struct {
+	k8s                      *K8sService
+	createNamespaceIfMissing bool
+}
+
+func NewKnativeRuntime(k8s *K8sService, createNamespaceIfMissing bool) *KnativeRuntime {
+	return &KnativeRuntime{
+		k8s:                      k8s,
+		createNamespaceIfMissing: createNamespaceIfMissing,
+	}
+}
+
+func (r *KnativeRuntime) Name() string {
+	return RuntimeKnative
+}
+
+func (r *KnativeRuntime) Deploy(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int, progress ProgressCallback) (string, error) {
+	applyMsg, err := r.k8s.Apply(ctx, dto, r.createNamespaceIfMissing, false)
+	if err != nil {
+		return "", fmt.Errorf("apply failed: %w", err)
+	}
+
+	prep := r.k8s.PrepareDeployedFlow(ctx, dto, timeoutSeconds)
+	if !strings.HasPrefix(prep, "OK:") {
+		return "", fmt.Errorf("%s", prep)
+	}
+
+	if progress != nil {
+		progress("apply.ok", applyMsg, true, map[string]interface{}{
+			"flowId": dto.FlowID,
+		})
+	}
+	return applyMsg, nil
+}
+
+func (r *KnativeRuntime) Changed(ctx context.Context, dto *models.K8sRequestDTO) (bool, error) {
+	steps, err := r.k8s.MakeStep(dto.Steps)
+	if err != nil {
+		return false, err
+	}
+	return r.k8s.FlowChanged(ctx, "user-"+dto.User, dto.FlowID, steps), nil
+}
+
+func (r *KnativeRuntime) Delete(ctx context.Context, dto *models.K8sRequestDTO) (string, error) {
+	msg, err := r.k8s.DeleteByFlowId(ctx, "user-"+dto.User, dto.FlowID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.k8s.DeleteKafkaTopic(ctx, r.k8s.kafkaNamespace, dto.FlowID); err != nil {
+		msg += fmt.Sprintf(" (KafkaTopic deletion warning: %v)", err)
+	} else {
+		msg += " + KafkaTopic deleted in ns=" + r.k8s.kafkaNamespace
+	}
+	return msg, nil
+}
+
+func (r *KnativeRuntime) Status(ctx context.Context, dto *models.K8sRequestDTO) (*models.FlowRuntimeStatusDTO, error) {
+	steps, err := r.k8s.MakeStep(dto.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := "user-" + dto.User
+	gvr := schema.GroupVersionResource{
+		Group:    "serving.knative.dev",
+		Version:  "v1",
+		Resource: "services",
+	}
+
+	status := &models.FlowRuntimeStatusDTO{
+		Runtime:   RuntimeKnative,
+		FlowID:    dto.FlowID,
+		Namespace: ns,
+		Ready:     len(steps) > 0,
+	}
+	for _, step := range steps {
+		ksvcName := fmt.Sprintf("flow%s-%s", dto.FlowID, r.k8s.safeName(step.Name))
+		st := models.StepStatusDTO{Name: step.Name}
+
+		res, err := r.k8s.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, ksvcName, metav1.GetOptions{})
+		if err != nil {
+			st.Detail = err.Error()
+		} else {
+			st.Ready = r.k8s.isCRReady(res)
+			st.Revision, _, _ = unstructured.NestedString(res.Object, "status", "latestReadyRevisionName")
+			st.CodeHash, _, _ = unstructured.NestedString(res.Object, "spec", "template", "metadata", "annotations", "flow/code-hash")
+		}
+		if !st.Ready {
+			status.Ready = false
+		}
+		status.Steps = append(status.Steps, st)
+	}
+	return status, nil
+}
+
+func (r *KnativeRuntime) RunOnce(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int) string {
+	return r.k8s.RunOnceAndVerify(ctx, dto, timeoutSeconds)
+}
+
+func (r *KnativeRuntime) Logs(ctx context.Context, dto *models.K8sRequestDTO, tailLines int) (map[string]string, error) {
+	steps, err := r.k8s.MakeStep(dto.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := "user-" + dto.User
+	logs := make(map[string]string, len(steps))
+	for _, step := range steps {
+		ksvcName := fmt.Sprintf("flow%s-%s", dto.FlowID, r.k8s.safeName(step.Name))
+		pods, err := r.k8s.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("app=%s", ksvcName),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var sb strings.Builder
+		for _, pod := range pods.Items {
+			stream, err := r.k8s.clientset.CoreV1().Pods(ns).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container: "app",
+				TailLines: int64Ptr(int64(tailLines)),
+			}).Stream(ctx)
+			if err != nil {
+				continue
+			}
+			b, _ := io.ReadAll(stream)
+			stream.Close()
+			sb.Write(b)
+		}
+		logs[step.Name] = sb.String()
+	}
+	return logs, nil
+}
+
+func (r *KnativeRuntime) Observe(ctx context.Context, ns, flowID string) ([]models.ObservedStepDTO, error) {
+	ksvcGVR := schema.GroupVersionResource{
+		Group:    "serving.knative.dev",
+		Version:  "v1",
+		Resource: "services",
+	}
+	sourceGVR := schema.GroupVersionResource{
+		Group:    "sources.knative.dev",
+		Version:  "v1",
+		Resource: "kafkasources",
+	}
+	selector := metav1.ListOptions{LabelSelector: fmt.Sprintf("flow_id=%s", flowID)}
+
+	ksvcs, err := r.k8s.dynamicClient.Resource(ksvcGVR).Namespace(ns).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KServices: %w", err)
+	}
+	sources, err := r.k8s.dynamicClient.Resource(sourceGVR).Namespace(ns).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KafkaSources: %w", err)
+	}
+
+	// KafkaSource는 sink ref 이름으로 KSVC와 매칭
+	sourceReady := make(map[string]bool)
+	for i := range sources.Items {
+		sinkName, _, _ := unstructured.NestedString(sources.Items[i].Object, "spec", "sink", "ref", "name")
+		sourceReady[sinkName] = r.k8s.isCRReady(&sources.Items[i])
+	}
+
+	observed := make([]models.ObservedStepDTO, 0, len(ksvcs.Items))
+	for i := range ksvcs.Items {
+		res := &ksvcs.Items[i]
+		ann, _, _ := unstructured.NestedStringMap(res.Object, "spec", "template", "metadata", "annotations")
+		st := models.ObservedStepDTO{
+			Name:     res.GetName(),
+			Ready:    r.k8s.isCRReady(res),
+			CodeHash: ann["flow/code-hash"],
+			Route:    ann["flow/route"],
+		}
+		st.Revision, _, _ = unstructured.NestedString(res.Object, "status", "latestReadyRevisionName")
+
+		ready, hasSource := sourceReady[st.Name]
+		st.SourceReady = ready
+		if !hasSource {
+			st.Detail = "KafkaSource not found"
+		}
+		observed = append(observed, st)
+	}
+	return observed, nil
+}
+
+// StreamLogs follows the "app" container of every flow_id-labelled KService
+// pod. New pods (scale-out, new revisions) are picked up by polling.
+func (r *KnativeRuntime) StreamLogs(ctx context.Context, ns, flowID string, tailLines int, emit func(models.StepLogEventDTO)) error {
+	lines := make(chan models.StepLogEventDTO, 256)
+	ended := make(chan string, 16)
+	following := make(map[string]bool)
+	prefix := fmt.Sprintf("flow%s-", flowID)
+
+	poll := func() error {
+		pods, err := r.k8s.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("flow_id=%s,serving.knative.dev/service", flowID),
+		})
+		if err != nil {
+			return err
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning || following[pod.Name] {
+				continue
+			}
+			following[pod.Name] = true
+			step := strings.TrimPrefix(pod.Labels["serving.knative.dev/service"], prefix)
+			go r.followPod(ctx, ns, pod.Name, step, tailLines, lines, ended)
+		}
+		return nil
+	}
+
+	if err := poll(); err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			poll()
+		case podName := <-ended:
+			delete(following, podName)
+		case line := <-lines:
+			emit(line)
+		}
+	}
+}
+
+func (r *KnativeRuntime) followPod(ctx context.Context, ns, podName, step string, tailLines int, lines chan<- models.StepLogEventDTO, ended chan<- string) {
+	defer func() {
+		select {
+		case ended <- podName:
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := r.k8s.clientset.CoreV1().Pods(ns).GetLogs(podName, &corev1.PodLogOptions{
+		Container: "app",
+		Follow:    true,
+		TailLines: int64Ptr(int64(tailLines)),
+	}).Stream(ctx)
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		select {
+		case lines <- models.StepLogEventDTO{
+			Step:   step,
+			Pod:    podName,
+			Line:   line,
+			Time:   time.Now(),
+			Runner: ParseRunnerLine(line),
+		}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// kcatMessage is one message as printed by kcat -J
+type kcatMessage struct {
+	Partition int32   `json:"partition"`
+	Offset    int64   `json:"offset"`
+	Ts        int64   `json:"ts"`
+	Payload   *string `json:"payload"`
+}
+
+// ListDeadLetters reads the tail of the flow's dead-letter topic with a kcat
+// Job. Entry ids are "partition:offset".
+func (r *KnativeRuntime) ListDeadLetters(ctx context.Context, ns, flowID string, limit int) ([]models.DeadLetterDTO, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	jobName := fmt.Sprintf("dlq-%s-%s", flowID, r.k8s.randomString(8))
+	script := fmt.Sprintf("kcat -C -b %s -t %s -o -%d -e -q -J || true", r.k8s.kafkaBootstrap, dlqTopicName(flowID), limit)
+	logs, err := r.k8s.runKcatJob(ctx, ns, flowID, jobName, script, 60)
+	if err != nil {
+		return nil, fmt.Errorf("dlq read job failed: %w", err)
+	}
+
+	entries := []models.DeadLetterDTO{}
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var msg kcatMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.Payload == nil {
+			continue
+		}
+		dl, err := parseDeadLetter(fmt.Sprintf("%d:%d", msg.Partition, msg.Offset), []byte(*msg.Payload))
+		if err != nil {
+			continue
+		}
+		if dl.FailedAt.IsZero() {
+			dl.FailedAt = time.UnixMilli(msg.Ts)
+		}
+		entries = append(entries, dl)
+	}
+
+	// 파티션마다 limit개씩 읽히므로 최신순으로 정렬 후 자른다
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.After(entries[j].FailedAt)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// Replay produces the dead letters back onto the flow topic with a kcat Job
+func (r *KnativeRuntime) Replay(ctx context.Context, ns, flowID string, entries []models.DeadLetterDTO) error {
+	var sb strings.Builder
+	for _, dl := range entries {
+		traceID := dl.CE.TraceID
+		if traceID == "" {
+			traceID = dl.CE.ID
+		}
+		headers := []string{
+			"ce_specversion=1.0",
+			"ce_type=" + dl.CE.Type,
+			"ce_source=/flow/" + flowID + "/replay",
+			"ce_id=" + r.k8s.randomString(16),
+			"ce_traceid=" + traceID,
+			fmt.Sprintf("ce_hops=%d", dl.CE.Hops),
+			"ce_target=" + dl.Step,
+		}
+		if dl.CE.Producer != "" {
+			headers = append(headers, "ce_producer="+dl.CE.Producer)
+		}
+
+		fmt.Fprintf(&sb, "printf '%%s\\n' %s | kcat -P -b %s -t %s", shellQuote(string(dl.Event)), r.k8s.kafkaBootstrap, flowID)
+		for _, h := range headers {
+			sb.WriteString(" -H " + shellQuote(h))
+		}
+		sb.WriteString(" || exit 1\n")
+	}
+	fmt.Fprintf(&sb, "echo \"[REPLAY] sent %d\" >&2", len(entries))
+
+	jobName := fmt.Sprintf("replay-%s-%s", flowID, r.k8s.randomString(8))
+	logs, err := r.k8s.runKcatJob(ctx, ns, flowID, jobName, sb.String(), 60)
+	if err != nil {
+		return fmt.Errorf("replay job failed: %w", err)
+	}
+	if !strings.Contains(logs, "[REPLAY] sent") {
+		return fmt.Errorf("replay job failed: %s", strings.TrimSpace(logs))
+	}
+	return nil
+}
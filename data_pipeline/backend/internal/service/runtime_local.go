@@ -0,0 +1,907 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LOCAL_RUNNER_PY mirrors RUNNER_PY for the local runtime: events arrive as JSON
+// lines on stdin and emitted events are written as JSON lines to stdout.
+// User prints and runner logs go to stderr so they never corrupt the protocol.
+const LOCAL_RUNNER_PY = `import os, sys, json, time, copy, uuid, threading, traceback, importlib.util
+from urllib.request import Request, urlopen
+from collections import OrderedDict
+
+FLOW_ID  = os.environ.get("FLOW_ID","")
+APP_ID   = os.environ.get("APP_ID","step")
+IN_TYPES = [t.strip() for t in os.environ.get("IN_TYPES","").split(",") if t.strip()]
+OUT_TYPE = os.environ.get("OUT_TYPE","").strip()
+MAX_HOPS = int(os.environ.get("MAX_HOPS","5"))
+CODE_PATH = os.environ.get("CODE_PATH","user_code.py")
+TRACE_URL = os.environ.get("TRACE_URL","")
+TRACE_TOKEN = os.environ.get("TRACE_TOKEN","")
+RETRY_MAX_ATTEMPTS = max(1, int(os.environ.get("RETRY_MAX_ATTEMPTS","1")))
+RETRY_BACKOFF = os.environ.get("RETRY_BACKOFF","exponential")
+RETRY_BACKOFF_MS = int(os.environ.get("RETRY_BACKOFF_MS","500"))
+RETRY_MAX_BACKOFF_MS = int(os.environ.get("RETRY_MAX_BACKOFF_MS","10000"))
+RETRY_ON = [t.strip() for t in os.environ.get("RETRY_ON","").split(",") if t.strip()]
+
+PROTO = os.fdopen(os.dup(1), "w", buffering=1)
+os.dup2(2, 1)
+sys.stdout = sys.stderr
+
+def load_user():
+    spec = importlib.util.spec_from_file_location("user_code", CODE_PATH)
+    mod = importlib.util.module_from_spec(spec); spec.loader.exec_module(mod)
+    if not hasattr(mod, "handle"): raise RuntimeError("user_code.handle not found")
+    return mod.handle
+USER_HANDLE = load_user()
+
+seen = OrderedDict()
+def dedupe(key):
+    if key in seen: return True
+    seen[key] = True
+    if len(seen) > 2048: seen.popitem(last=False)
+    return False
+
+def report_span(span):
+    if not TRACE_URL: return
+    span.update({"flow_id": FLOW_ID, "step": APP_ID})
+    def send():
+        try: urlopen(Request(TRACE_URL, data=json.dumps(span).encode("utf-8"), headers={"Content-Type":"application/json", "X-Trace-Token":TRACE_TOKEN}), timeout=3).read()
+        except Exception as e: print(f"[{APP_ID.upper()}] trace error: {e}", flush=True)
+    threading.Thread(target=send, daemon=True).start()
+
+def retryable(exc):
+    if not RETRY_ON: return True
+    return any(c.__name__ in RETRY_ON for c in type(exc).__mro__)
+
+def with_retry(fn, what, check=retryable):
+    attempt = 1
+    while True:
+        try: return fn()
+        except Exception as e:
+            if attempt >= RETRY_MAX_ATTEMPTS or not check(e): raise
+            delay = RETRY_BACKOFF_MS * (2 ** (attempt-1) if RETRY_BACKOFF == "exponential" else attempt)
+            print(f"[{APP_ID.upper()}] retry {what} attempt={attempt} error: {e}", flush=True)
+            time.sleep(min(delay, RETRY_MAX_BACKOFF_MS) / 1000.0)
+            attempt += 1
+
+def dead_letter(evt, exc, ce):
+    entry = {"step": APP_ID, "error": str(exc), "traceback": traceback.format_exc(), "event": evt, "ce": ce, "failed_at": time.time()}
+    PROTO.write(json.dumps({"dlq": entry}) + "\n")
+    PROTO.flush()
+
+print(f"[{APP_ID.upper()}] ready", flush=True)
+for line in sys.stdin:
+    line = line.strip()
+    if not line: continue
+    try: msg = json.loads(line)
+    except Exception: continue
+    ctype    = msg.get("type") or ""
+    evt      = msg.get("data") or {}
+    hops     = int(msg.get("hops") or 0)
+    producer = msg.get("producer") or ""
+    ceid     = msg.get("id") or str(uuid.uuid4())
+    trace_id = msg.get("trace_id") or ceid
+    source   = msg.get("source") or ""
+    target   = msg.get("target") or ""
+    if dedupe(ceid): continue
+    if target and target != APP_ID: continue
+
+    t0 = time.time()
+    span = {"trace_id": trace_id, "event_id": ceid, "producer": producer, "in_type": ctype, "hops": hops, "start": t0}
+    if hops >= MAX_HOPS:
+        if not IN_TYPES or ctype in IN_TYPES:
+            span.update({"status": "dropped", "error": f"max hops {MAX_HOPS} reached", "duration_ms": 0})
+            report_span(span)
+        continue
+    if OUT_TYPE and ctype == OUT_TYPE: continue
+    if producer == APP_ID: continue
+    if IN_TYPES and ctype not in IN_TYPES: continue
+
+    try:
+        out = with_retry(lambda: USER_HANDLE(copy.deepcopy(evt) if isinstance(evt, dict) else {}), "handle")
+        outs = out if isinstance(out, list) else ([out] if out is not None else [])
+        emitted = []
+        emitted_ids = []
+        errors = []
+        for item in outs:
+            if not isinstance(item, dict): continue
+            ALLOW_EMIT = bool(OUT_TYPE)
+            t = item.pop("__type", OUT_TYPE if ALLOW_EMIT else "")
+            if ALLOW_EMIT and t:
+                try:
+                    out_id = str(uuid.uuid4())
+                    PROTO.write(json.dumps({"type": t, "data": item, "trace_id": trace_id, "hops": hops+1,
+                                            "producer": APP_ID, "id": out_id}) + "\n")
+                    PROTO.flush()
+                    emitted.append(t)
+                    emitted_ids.append({"id": out_id, "type": t})
+                    print(f"[{APP_ID.upper()}] emit ce_type={t}", flush=True)
+                except Exception as e:
+                    errors.append(f"emit error: {e}")
+                    print(f"[{APP_ID.upper()}] emit error: {e}", flush=True)
+        out_types = ",".join(emitted) if emitted else (OUT_TYPE or "-")
+        print(f"[{APP_ID.upper()}] in={ctype} out={out_types} items={len(outs)}", flush=True)
+        span.update({"status": "error" if errors else "ok", "error": "; ".join(errors), "items": len(outs),
+                     "emitted": emitted_ids, "duration_ms": (time.time()-t0)*1000})
+        report_span(span)
+    except Exception as e:
+        print(f"[{APP_ID.upper()}] error: {e}", flush=True)
+        span.update({"status": "error", "error": str(e), "duration_ms": (time.time()-t0)*1000})
+        report_span(span)
+        dead_letter(evt, e, {"type": ctype, "id": ceid, "source": source, "traceid": trace_id, "hops": hops, "producer": producer})
+`
+
+const (
+	localLogCapacity        = 2000
+	localDeadLetterCapacity = 1000
+)
+
+// LocalRuntime runs each step as a local Python subprocess. Steps are chained
+// through in-memory queues that play the role of the flow's Kafka topic: every
+// emitted event is offered to every step, and the runner filters by IN_TYPES.
+// params.image / params.requirements are ignored: steps use the host python,
+// and of params.runtimeConfig only env applies. File-mounted connections are
+// written under the flow's temp dir.
+type LocalRuntime struct {
+	planner   *K8sService // step resolution and routing only; has no cluster clients
+	access    *AccessService
+	pythonBin string
+	traceURL  string
+
+	mu          sync.Mutex
+	flows       map[string]*localFlow
+	deadLetters map[string]*localDeadLetters // 재배포 후에도 재처리할 수 있도록 플로우와 별도로 보관
+}
+
+type localFlow struct {
+	flowID string
+	dir    string
+	cancel context.CancelFunc
+	steps  []*localStep
+	dlq    *localDeadLetters
+}
+
+type localStep struct {
+	FlowStep
+	inTypes  []string
+	outType  string
+	codeHash string
+	retry    RetryPolicy
+	runtime  StepRuntime
+	connTag  string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	inbox  chan []byte
+	logs   *lineBuffer
+	ready  chan struct{}
+	exited chan struct{}
+}
+
+func NewLocalRuntime(objectRepo *repository.ObjectRepository, edgeRepo *repository.EdgeRepository, connRepo *repository.ConnectionRepository, access *AccessService, pythonBin, traceURL string) *LocalRuntime {
+	if pythonBin == "" {
+		pythonBin = "python3"
+	}
+	return &LocalRuntime{
+		planner:     &K8sService{objectRepo: objectRepo, edgeRepo: edgeRepo, connRepo: connRepo},
+		access:      access,
+		pythonBin:   pythonBin,
+		traceURL:    traceURL,
+		flows:       make(map[string]*localFlow),
+		deadLetters: make(map[string]*localDeadLetters),
+	}
+}
+
+func (r *LocalRuntime) Name() string {
+	return RuntimeLocal
+}
+
+func (r *LocalRuntime) Deploy(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int, progress ProgressCallback) (string, error) {
+	if err := r.access.RequireFlowID(ctx, dto.FlowID, models.ProjectRoleDeployer); err != nil {
+		return "", err
+	}
+
+	steps, err := r.planner.makeStep(dto.Steps)
+	if err != nil {
+		return "", fmt.Errorf("failed to make steps: %w", err)
+	}
+	if err := r.planner.validateDTO(dto, steps); err != nil {
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+	conns, err := r.planner.loadConnections(dto.User, steps, true)
+	if err != nil {
+		return "", fmt.Errorf("validation failed: %w", err)
+	}
+
+	key := r.key(dto)
+	r.mu.Lock()
+	old := r.flows[key]
+	delete(r.flows, key)
+	r.mu.Unlock()
+	if old != nil {
+		old.stop()
+	}
+
+	flow, err := r.start(dto.FlowID, steps, conns, r.deadLetterQueue(key))
+	if err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
+	for _, st := range flow.steps {
+		select {
+		case <-st.ready:
+		case <-st.exited:
+			flow.stop()
+			return "", fmt.Errorf("step %s exited during startup:\n%s", st.Name, st.logs.tail(50))
+		case <-time.After(time.Until(deadline)):
+			flow.stop()
+			return "", fmt.Errorf("step %s not ready within %ds", st.Name, timeoutSeconds)
+		case <-ctx.Done():
+			flow.stop()
+			return "", ctx.Err()
+		}
+	}
+
+	r.mu.Lock()
+	r.flows[key] = flow
+	r.mu.Unlock()
+
+	msg := fmt.Sprintf("flow %s started locally (%d steps)", dto.FlowID, len(steps))
+	if progress != nil {
+		progress("apply.ok", msg, true, map[string]interface{}{
+			"flowId":  dto.FlowID,
+			"runtime": RuntimeLocal,
+		})
+	}
+	return msg, nil
+}
+
+func (r *LocalRuntime) Changed(ctx context.Context, dto *models.K8sRequestDTO) (bool, error) {
+	steps, err := r.planner.makeStep(dto.Steps)
+	if err != nil {
+		return false, err
+	}
+
+	flow := r.get(dto)
+	if flow == nil || len(flow.steps) != len(steps) {
+		return true, nil
+	}
+	conns, err := r.planner.loadConnections(dto.User, steps, false)
+	if err != nil {
+		return true, nil
+	}
+	for i, step := range steps {
+		st := flow.steps[i]
+		if !st.running() || st.Name != step.Name || st.codeHash != r.planner.sha256(step.Code) ||
+			strings.Join(st.inTypes, ",") != r.planner.getInType(dto.FlowID, step) ||
+			st.outType != r.planner.getOutType(dto.FlowID, step) ||
+			st.retry.String() != r.planner.stepRetryPolicy(step.Params).String() ||
+			st.runtime.String() != r.planner.stepRuntime(step.Params).String() ||
+			st.connTag != r.planner.stepConnections(step.Params, conns).Tag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *LocalRuntime) Delete(ctx context.Context, dto *models.K8sRequestDTO) (string, error) {
+	if err := r.access.RequireFlowID(ctx, dto.FlowID, models.ProjectRoleDeployer); err != nil {
+		return "", err
+	}
+
+	key := r.key(dto)
+	r.mu.Lock()
+	flow := r.flows[key]
+	delete(r.flows, key)
+	r.mu.Unlock()
+
+	if flow == nil {
+		return fmt.Sprintf("flow %s is not running locally", dto.FlowID), nil
+	}
+	flow.stop()
+	return fmt.Sprintf("stopped %d local steps flow_id=%s", len(flow.steps), dto.FlowID), nil
+}
+
+func (r *LocalRuntime) Status(ctx context.Context, dto *models.K8sRequestDTO) (*models.FlowRuntimeStatusDTO, error) {
+	status := &models.FlowRuntimeStatusDTO{
+		Runtime:   RuntimeLocal,
+		FlowID:    dto.FlowID,
+		Namespace: "user-" + dto.User,
+	}
+
+	flow := r.get(dto)
+	if flow == nil {
+		return status, nil
+	}
+
+	status.Ready = len(flow.steps) > 0
+	for _, st := range flow.steps {
+		ss := models.StepStatusDTO{
+			Name:     st.Name,
+			Ready:    st.running(),
+			CodeHash: st.codeHash,
+		}
+		if !ss.Ready {
+			ss.Detail = "process exited"
+			status.Ready = false
+		} else if st.cmd.Process != nil {
+			ss.Detail = fmt.Sprintf("pid %d", st.cmd.Process.Pid)
+		}
+		status.Steps = append(status.Steps, ss)
+	}
+	return status, nil
+}
+
+func (r *LocalRuntime) RunOnce(ctx context.Context, dto *models.K8sRequestDTO, timeoutSeconds int) string {
+	flow := r.get(dto)
+	if flow == nil {
+		return "NG: flow is not running locally"
+	}
+
+	// 킥 이전 로그는 검증에서 제외
+	cursors := make(map[string]int, len(flow.steps))
+	for _, st := range flow.steps {
+		cursors[st.Name] = st.logs.count()
+	}
+
+	kick, _ := json.Marshal(map[string]interface{}{
+		"type":     fmt.Sprintf("flow%s.kick", flow.flowID),
+		"data":     map[string]interface{}{"kick": true},
+		"id":       r.planner.randomString(16),
+		"hops":     0,
+		"producer": "kick",
+	})
+	flow.publish(append(kick, '\n'))
+
+	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
+	byName := make(map[string]*localStep, len(flow.steps))
+	for _, st := range flow.steps {
+		byName[st.Name] = st
+	}
+
+	for _, leaf := range flow.steps {
+		if len(leaf.Successors) > 0 {
+			continue
+		}
+		for _, predName := range leaf.Predecessors {
+			pred := byName[predName]
+			needle := fmt.Sprintf("emit ce_type=%s", pred.outType)
+			if !pred.logs.waitFor(ctx, cursors[pred.Name], deadline, needle) {
+				return fmt.Sprintf("NG: previous step did not emit expected out=%s -> %s", pred.outType, pred.Name)
+			}
+		}
+
+		needles := make([]string, 0, len(leaf.inTypes))
+		for _, t := range leaf.inTypes {
+			needles = append(needles, fmt.Sprintf("in=%s ", t))
+		}
+		if !leaf.logs.waitFor(ctx, cursors[leaf.Name], deadline, needles...) {
+			return fmt.Sprintf("NG: last step did not receive event (no log matched 'in=%s') -> %s\n%s",
+				strings.Join(leaf.inTypes, "|"), leaf.Name, leaf.logs.tail(20))
+		}
+	}
+
+	return "OK: run"
+}
+
+func (r *LocalRuntime) Logs(ctx context.Context, dto *models.K8sRequestDTO, tailLines int) (map[string]string, error) {
+	flow := r.get(dto)
+	if flow == nil {
+		return map[string]string{}, nil
+	}
+
+	logs := make(map[string]string, len(flow.steps))
+	for _, st := range flow.steps {
+		logs[st.Name] = st.logs.tail(tailLines)
+	}
+	return logs, nil
+}
+
+func (r *LocalRuntime) Observe(ctx context.Context, ns, flowID string) ([]models.ObservedStepDTO, error) {
+	r.mu.Lock()
+	flow := r.flows[ns+"/"+flowID]
+	r.mu.Unlock()
+	if flow == nil {
+		return []models.ObservedStepDTO{}, nil
+	}
+
+	observed := make([]models.ObservedStepDTO, 0, len(flow.steps))
+	for _, st := range flow.steps {
+		running := st.running()
+		obs := models.ObservedStepDTO{
+			Name:        fmt.Sprintf("flow%s-%s", flowID, r.planner.safeName(st.Name)),
+			Ready:       running,
+			SourceReady: running,
+			CodeHash:    st.codeHash,
+			Route:       strings.Join(st.inTypes, ",") + ">" + st.outType,
+		}
+		if !running {
+			obs.Detail = "process exited"
+		}
+		observed = append(observed, obs)
+	}
+	return observed, nil
+}
+
+// StreamLogs tails each step's log buffer; a redeploy of the flow is picked up
+// on the next poll.
+func (r *LocalRuntime) StreamLogs(ctx context.Context, ns, flowID string, tailLines int, emit func(models.StepLogEventDTO)) error {
+	var flow *localFlow
+	cursors := make(map[*localStep]int)
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		r.mu.Lock()
+		current := r.flows[ns+"/"+flowID]
+		r.mu.Unlock()
+		if current != flow {
+			flow = current
+			cursors = make(map[*localStep]int)
+			if flow != nil {
+				for _, st := range flow.steps {
+					cursors[st] = st.logs.count() - tailLines
+				}
+			}
+		}
+
+		if flow != nil {
+			for _, st := range flow.steps {
+				lines, next := st.logs.since(cursors[st])
+				for _, line := range lines {
+					emit(models.StepLogEventDTO{
+						Step:   st.Name,
+						Line:   line,
+						Time:   time.Now(),
+						Runner: ParseRunnerLine(line),
+					})
+				}
+				cursors[st] = next
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *LocalRuntime) ListDeadLetters(ctx context.Context, ns, flowID string, limit int) ([]models.DeadLetterDTO, error) {
+	return r.deadLetterQueue(ns + "/" + flowID).list(limit), nil
+}
+
+// Replay publishes the dead letters to the running flow and drops them from the
+// queue; an event that fails again comes back under a new id.
+func (r *LocalRuntime) Replay(ctx context.Context, ns, flowID string, entries []models.DeadLetterDTO) error {
+	r.mu.Lock()
+	flow := r.flows[ns+"/"+flowID]
+	r.mu.Unlock()
+	if flow == nil {
+		return fmt.Errorf("flow %s is not running locally", flowID)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, dl := range entries {
+		traceID := dl.CE.TraceID
+		if traceID == "" {
+			traceID = dl.CE.ID
+		}
+		line, err := json.Marshal(map[string]interface{}{
+			"type":     dl.CE.Type,
+			"data":     dl.Event,
+			"id":       r.planner.randomString(16),
+			"trace_id": traceID,
+			"hops":     dl.CE.Hops,
+			"producer": dl.CE.Producer,
+			"source":   fmt.Sprintf("/flow/%s/replay", flowID),
+			"target":   dl.Step,
+		})
+		if err != nil {
+			return err
+		}
+		flow.publish(append(line, '\n'))
+		ids = append(ids, dl.ID)
+	}
+	flow.dlq.remove(ids)
+	return nil
+}
+
+func (r *LocalRuntime) deadLetterQueue(key string) *localDeadLetters {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q := r.deadLetters[key]
+	if q == nil {
+		q = &localDeadLetters{}
+		r.deadLetters[key] = q
+	}
+	return q
+}
+
+func (r *LocalRuntime) key(dto *models.K8sRequestDTO) string {
+	return "user-" + dto.User + "/" + dto.FlowID
+}
+
+func (r *LocalRuntime) get(dto *models.K8sRequestDTO) *localFlow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flows[r.key(dto)]
+}
+
+// start writes the runner and user code to a temp dir and launches one process per step
+func (r *LocalRuntime) start(flowID string, steps []FlowStep, conns map[string]*connectionSnapshot, dlq *localDeadLetters) (*localFlow, error) {
+	dir, err := os.MkdirTemp("", fmt.Sprintf("flow%s-", flowID))
+	if err != nil {
+		return nil, err
+	}
+	runnerPath := filepath.Join(dir, "runner.py")
+	if err := os.WriteFile(runnerPath, []byte(LOCAL_RUNNER_PY), 0o644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	flow := &localFlow{flowID: flowID, dir: dir, cancel: cancel, dlq: dlq}
+
+	for _, step := range steps {
+		safeStepName := r.planner.safeName(step.Name)
+		codePath := filepath.Join(dir, safeStepName+".py")
+		if err := os.WriteFile(codePath, []byte(step.Code), 0o644); err != nil {
+			flow.stop()
+			return nil, err
+		}
+
+		stepConns := r.planner.stepConnections(step.Params, conns)
+		st := &localStep{
+			FlowStep: step,
+			inTypes:  strings.Split(r.planner.getInType(flowID, step), ","),
+			outType:  r.planner.getOutType(flowID, step),
+			codeHash: r.planner.sha256(step.Code),
+			retry:    r.planner.stepRetryPolicy(step.Params),
+			runtime:  r.planner.stepRuntime(step.Params),
+			connTag:  stepConns.Tag,
+			inbox:    make(chan []byte),
+			logs:     newLineBuffer(localLogCapacity),
+			ready:    make(chan struct{}),
+			exited:   make(chan struct{}),
+		}
+
+		cmd := exec.CommandContext(ctx, r.pythonBin, "-u", runnerPath)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"FLOW_ID="+flowID,
+			"APP_ID="+safeStepName,
+			"IN_TYPES="+strings.Join(st.inTypes, ","),
+			"OUT_TYPE="+st.outType,
+			"MAX_HOPS=5",
+			"CODE_PATH="+codePath,
+			"TRACE_URL="+r.traceURL,
+			"TRACE_TOKEN="+TraceToken(flowID),
+		)
+		for _, kv := range append(st.retry.env(), st.runtime.Env...) {
+			cmd.Env = append(cmd.Env, kv[0]+"="+kv[1])
+		}
+		connEnv, err := writeLocalConnections(filepath.Join(dir, "connections"), stepConns, conns)
+		if err != nil {
+			flow.stop()
+			return nil, err
+		}
+		cmd.Env = append(cmd.Env, connEnv...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			flow.stop()
+			return nil, err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			flow.stop()
+			return nil, err
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			flow.stop()
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			flow.stop()
+			return nil, fmt.Errorf("failed to start step %s: %w", step.Name, err)
+		}
+		st.cmd = cmd
+		st.stdin = stdin
+		flow.steps = append(flow.steps, st)
+
+		var readers sync.WaitGroup
+		readers.Add(2)
+		go func(name string) {
+			defer readers.Done()
+			st.readLogs(stderr, name)
+		}(safeStepName)
+		go func() {
+			defer readers.Done()
+			st.readEvents(stdout, flow)
+		}()
+		go func() {
+			// 파이프를 모두 읽은 뒤에 Wait 해야 마지막 로그가 유실되지 않는다
+			readers.Wait()
+			cmd.Wait()
+			close(st.exited)
+		}()
+		go st.pump(ctx)
+	}
+
+	return flow, nil
+}
+
+// publish offers a newline-terminated event to every step, like a Kafka topic with one consumer group per step
+func (f *localFlow) publish(line []byte) {
+	for _, st := range f.steps {
+		select {
+		case st.inbox <- line:
+		case <-st.exited:
+		}
+	}
+}
+
+func (f *localFlow) stop() {
+	for _, st := range f.steps {
+		if st.stdin != nil {
+			st.stdin.Close()
+		}
+	}
+	f.cancel()
+	for _, st := range f.steps {
+		select {
+		case <-st.exited:
+		case <-time.After(5 * time.Second):
+		}
+	}
+	os.RemoveAll(f.dir)
+}
+
+// pump buffers inbound events without bound so a slow step never blocks publishers
+func (st *localStep) pump(ctx context.Context) {
+	send := make(chan []byte)
+	defer close(send)
+	go func() {
+		for line := range send {
+			// 쓰기 실패(프로세스 종료) 이후에도 계속 비워서 pump가 막히지 않게 한다
+			st.stdin.Write(line)
+		}
+	}()
+
+	var pending [][]byte
+	for {
+		var out chan<- []byte
+		var next []byte
+		if len(pending) > 0 {
+			out = send
+			next = pending[0]
+		}
+		select {
+		case line := <-st.inbox:
+			pending = append(pending, line)
+		case out <- next:
+			pending = pending[1:]
+		case <-ctx.Done():
+			return
+		case <-st.exited:
+			return
+		}
+	}
+}
+
+func (st *localStep) readLogs(stderr io.Reader, appID string) {
+	readyLine := fmt.Sprintf("[%s] ready", strings.ToUpper(appID))
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	isReady := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		st.logs.add(line)
+		if !isReady && strings.Contains(line, readyLine) {
+			isReady = true
+			close(st.ready)
+		}
+	}
+}
+
+func (st *localStep) readEvents(stdout io.Reader, flow *localFlow) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if bytes.HasPrefix(scanner.Bytes(), localDeadLetterPrefix) {
+			var msg struct {
+				DLQ json.RawMessage `json:"dlq"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+				flow.dlq.add(msg.DLQ)
+			}
+			continue
+		}
+		line := append(append([]byte(nil), scanner.Bytes()...), '\n')
+		go flow.publish(line)
+	}
+}
+
+func (st *localStep) running() bool {
+	select {
+	case <-st.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// localDeadLetterPrefix marks a runner protocol line carrying a dead letter
+var localDeadLetterPrefix = []byte(`{"dlq":`)
+
+// localDeadLetters is the in-memory dead-letter queue of a local flow
+type localDeadLetters struct {
+	mu      sync.Mutex
+	seq     int
+	entries []models.DeadLetterDTO
+}
+
+func (q *localDeadLetters) add(payload []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	dl, err := parseDeadLetter(fmt.Sprintf("local:%d", q.seq), payload)
+	if err != nil {
+		return
+	}
+	if dl.FailedAt.IsZero() {
+		dl.FailedAt = time.Now()
+	}
+	q.entries = append(q.entries, dl)
+	if len(q.entries) > localDeadLetterCapacity {
+		q.entries = q.entries[len(q.entries)-localDeadLetterCapacity:]
+	}
+}
+
+// list returns up to limit entries, newest first
+func (q *localDeadLetters) list(limit int) []models.DeadLetterDTO {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit <= 0 || limit > len(q.entries) {
+		limit = len(q.entries)
+	}
+	out := make([]models.DeadLetterDTO, 0, limit)
+	for i := len(q.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, q.entries[i])
+	}
+	return out
+}
+
+func (q *localDeadLetters) remove(ids []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	drop := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	kept := q.entries[:0]
+	for _, dl := range q.entries {
+		if !drop[dl.ID] {
+			kept = append(kept, dl)
+		}
+	}
+	q.entries = kept
+}
+
+// lineBuffer keeps the most recent log lines and a monotonic line counter
+type lineBuffer struct {
+	mu    sync.Mutex
+	cap   int
+	lines []string
+	total int
+}
+
+func newLineBuffer(capacity int) *lineBuffer {
+	return &lineBuffer{cap: capacity}
+}
+
+func (b *lineBuffer) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = append(b.lines, line)
+	if len(b.lines) > b.cap {
+		b.lines = b.lines[len(b.lines)-b.cap:]
+	}
+	b.total++
+}
+
+func (b *lineBuffer) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total
+}
+
+// since returns the retained lines added after the given counter value and
+// the counter to continue from
+func (b *lineBuffer) since(cursor int) ([]string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	first := b.total - len(b.lines)
+	if cursor < first {
+		cursor = first
+	}
+	return append([]string(nil), b.lines[cursor-first:]...), b.total
+}
+
+func (b *lineBuffer) tail(n int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	start := 0
+	if n > 0 && len(b.lines) > n {
+		start = len(b.lines) - n
+	}
+	return strings.Join(b.lines[start:], "\n")
+}
+
+func (b *lineBuffer) waitFor(ctx context.Context, cursor int, deadline time.Time, needles ...string) bool {
+	for time.Now().Before(deadline) {
+		lines, _ := b.since(cursor)
+		for _, line := range lines {
+			for _, needle := range needles {
+				if strings.Contains(line, needle) {
+					return true
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+// writeLocalConnections returns the env of a local step's connections. Like
+// envFrom, keys that do not form a valid env name are skipped; file mounts
+// are written to dir/<name>/<key>.
+func writeLocalConnections(dir string, sc stepConnections, conns map[string]*connectionSnapshot) ([]string, error) {
+	env := []string{"CONNECTIONS_DIR=" + dir}
+	for _, ref := range sc.Refs {
+		conn := conns[ref.Name]
+		if conn == nil {
+			continue
+		}
+		if ref.Mount == models.ConnectionMountFile {
+			connDir := filepath.Join(dir, ref.Name)
+			if err := os.MkdirAll(connDir, 0o700); err != nil {
+				return nil, err
+			}
+			for k, v := range conn.Values {
+				if err := os.WriteFile(filepath.Join(connDir, k), []byte(v), 0o600); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		for k, v := range conn.Values {
+			if name := ref.Prefix + k; stepEnvNamePattern.MatchString(name) {
+				env = append(env, name+"="+v)
+			}
+		}
+	}
+	return env, nil
+}
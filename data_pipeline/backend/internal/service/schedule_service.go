@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	_ "time/tzdata" // 스케줄의 timezone을 이미지의 zoneinfo 없이도 읽는다
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// an on-time firing found later than this counts as missed (catch_up none)
+	scheduleMissGrace = 2 * time.Minute
+	// catch_up all runs at most this many missed firings, the newest ones
+	maxScheduleCatchUp = 100
+)
+
+// cronParser reads standard 5-field expressions and descriptors such as
+// @daily or @every 30m
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ScheduleService manages flow schedules and fires them. Run is the
+// scheduler loop; only the elected leader runs it so that a schedule fires
+// once however many backends there are. Scheduled runs execute as the user
+// who last saved the schedule.
+type ScheduleService struct {
+	repo      *repository.FlowScheduleRepository
+	userRepo  *repository.UserRepository
+	access    *AccessService
+	runs      *WorkflowRunService
+	workflows *WorkflowService
+	admins    map[string]bool
+	interval  time.Duration
+}
+
+func NewScheduleService(repo *repository.FlowScheduleRepository, userRepo *repository.UserRepository, access *AccessService,
+	runs *WorkflowRunService, workflows *WorkflowService, admins []string, interval time.Duration) *ScheduleService {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &ScheduleService{
+		repo:      repo,
+		userRepo:  userRepo,
+		access:    access,
+		runs:      runs,
+		workflows: workflows,
+		admins:    adminSet(admins),
+		interval:  interval,
+	}
+}
+
+// Create adds a schedule to a flow; the caller needs deployer on the flow
+func (s *ScheduleService) Create(ctx context.Context, req *models.FlowScheduleRequestDTO) (*models.FlowSchedule, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, fmt.Errorf("%w: 로그인이 필요합니다", ErrForbidden)
+	}
+	if req.FlowID == nil {
+		return nil, errors.New("f_id는 필수입니다")
+	}
+	if _, err := s.access.RequireFlow(ctx, *req.FlowID, models.ProjectRoleDeployer); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.FlowSchedule{
+		FlowID:        *req.FlowID,
+		Timezone:      "UTC",
+		Enabled:       true,
+		OverlapPolicy: models.ScheduleOverlapSkip,
+		CatchUp:       models.ScheduleCatchUpNone,
+		RunAs:         &user.ID,
+	}
+	applyScheduleRequest(schedule, req)
+	if err := s.reschedule(schedule, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// List returns the schedules of a flow, or of every flow the caller may see
+func (s *ScheduleService) List(ctx context.Context, flowID *int64) ([]*models.FlowSchedule, error) {
+	if flowID != nil {
+		if _, err := s.access.RequireFlow(ctx, *flowID, models.ProjectRoleViewer); err != nil {
+			return nil, err
+		}
+		return s.repo.FindByFlow(*flowID)
+	}
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return []*models.FlowSchedule{}, nil
+	}
+	if user.Admin {
+		return s.repo.FindAll()
+	}
+	return s.repo.FindAccessible(user.ID)
+}
+
+// Get returns a schedule the caller may see
+func (s *ScheduleService) Get(ctx context.Context, id int64) (*models.FlowSchedule, error) {
+	return s.find(ctx, id, models.ProjectRoleViewer)
+}
+
+// Update changes a schedule. The caller becomes the user its runs execute
+// as; a changed cron expression, timezone or a re-enabled schedule starts
+// counting from now.
+func (s *ScheduleService) Update(ctx context.Context, id int64, req *models.FlowScheduleRequestDTO) (*models.FlowSchedule, error) {
+	schedule, err := s.find(ctx, id, models.ProjectRoleDeployer)
+	if err != nil {
+		return nil, err
+	}
+	if req.FlowID != nil && *req.FlowID != schedule.FlowID {
+		return nil, errors.New("스케줄의 flow는 바꿀 수 없습니다")
+	}
+
+	before := *schedule
+	applyScheduleRequest(schedule, req)
+	if user := auth.UserFromContext(ctx); user != nil {
+		schedule.RunAs = &user.ID
+	}
+	timing := schedule.Cron != before.Cron || schedule.Timezone != before.Timezone || (schedule.Enabled && !before.Enabled)
+	if timing || !schedule.Enabled {
+		if err := s.reschedule(schedule, time.Now()); err != nil {
+			return nil, err
+		}
+	} else if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Delete removes a schedule; its runs are kept
+func (s *ScheduleService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.find(ctx, id, models.ProjectRoleDeployer); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *ScheduleService) find(ctx context.Context, id int64, role string) (*models.FlowSchedule, error) {
+	schedule, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.RequireFlow(ctx, schedule.FlowID, role); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func applyScheduleRequest(schedule *models.FlowSchedule, req *models.FlowScheduleRequestDTO) {
+	if req.Name != nil {
+		schedule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Cron != nil {
+		schedule.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	if req.OverlapPolicy != nil {
+		schedule.OverlapPolicy = *req.OverlapPolicy
+	}
+	if req.CatchUp != nil {
+		schedule.CatchUp = *req.CatchUp
+	}
+}
+
+// reschedule validates a schedule and sets its next firing after now,
+// dropping a queued one; disabled schedules have none
+func (s *ScheduleService) reschedule(schedule *models.FlowSchedule, now time.Time) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	schedule.QueuedAt = nil
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		spec, loc, _ := parseSchedule(schedule.Cron, schedule.Timezone)
+		schedule.NextRunAt = nextFiring(spec, loc, now)
+	}
+	return nil
+}
+
+func validateSchedule(schedule *models.FlowSchedule) error {
+	if len(schedule.Name) > 100 {
+		return errors.New("스케줄 이름은 100자 이하여야 합니다")
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if _, _, err := parseSchedule(schedule.Cron, schedule.Timezone); err != nil {
+		return err
+	}
+	switch schedule.OverlapPolicy {
+	case models.ScheduleOverlapSkip, models.ScheduleOverlapQueue, models.ScheduleOverlapCancel:
+	default:
+		return fmt.Errorf("overlap_policy는 skip, queue, cancel 중 하나여야 합니다: %q", schedule.OverlapPolicy)
+	}
+	switch schedule.CatchUp {
+	case models.ScheduleCatchUpNone, models.ScheduleCatchUpLatest, models.ScheduleCatchUpAll:
+	default:
+		return fmt.Errorf("catch_up은 none, latest, all 중 하나여야 합니다: %q", schedule.CatchUp)
+	}
+	return nil
+}
+
+// parseSchedule reads a cron expression in a timezone
+func parseSchedule(expr, timezone string) (cron.Schedule, *time.Location, error) {
+	if expr == "" {
+		return nil, nil, errors.New("cron 표현식은 필수입니다")
+	}
+	if len(expr) > 100 {
+		return nil, nil, errors.New("cron 표현식은 100자 이하여야 합니다")
+	}
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, nil, errors.New("시간대는 cron 표현식이 아니라 timezone으로 지정하세요")
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("알 수 없는 timezone입니다: %q", timezone)
+	}
+	spec, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("잘못된 cron 표현식입니다: %v", err)
+	}
+	return spec, loc, nil
+}
+
+// nextFiring is the first firing after t, in UTC; nil when there is none.
+// Cron fields follow the wall clock of loc: a time repeated when the clocks
+// go back fires once, at its first occurrence, and a time skipped when they
+// go forward fires as much later as they jumped. @every follows elapsed time.
+func nextFiring(spec cron.Schedule, loc *time.Location, t time.Time) *time.Time {
+	if _, ok := spec.(cron.ConstantDelaySchedule); ok {
+		next := spec.Next(t).UTC()
+		return &next
+	}
+	// robfig/cron가 시간대 전환을 건너뛰거나 두 번 세지 않도록 벽시계 시각으로 계산한다
+	for wall := wallClock(t, loc); ; {
+		if wall = spec.Next(wall); wall.IsZero() {
+			return nil
+		}
+		if next := fromWallClock(wall, loc); next.After(t) {
+			return &next
+		}
+	}
+}
+
+// wallClock is the reading of t's clock in loc, as a UTC time
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock is the first instant, in UTC, whose clock in loc reads wall;
+// for a reading skipped by a forward jump, the instant it would be under the
+// offset before the jump
+func fromWallClock(wall time.Time, loc *time.Location) time.Time {
+	approx := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	_, before := approx.Add(-12 * time.Hour).Zone()
+	_, after := approx.Add(12 * time.Hour).Zone()
+	skipped := wall.Add(-time.Duration(before) * time.Second)
+
+	var found *time.Time
+	for _, offset := range []int{before, after} {
+		t := wall.Add(-time.Duration(offset) * time.Second)
+		if wallClock(t, loc).Equal(wall) && (found == nil || t.Before(*found)) {
+			found = &t
+		}
+	}
+	if found == nil {
+		return skipped
+	}
+	return *found
+}
+
+// Run fires due schedules every interval until ctx ends
+func (s *ScheduleService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.fireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ScheduleService) fireDue(ctx context.Context) {
+	due, err := s.repo.FindDue(time.Now().UTC())
+	if err != nil {
+		log.Printf("scheduler: failed to load due schedules: %v", err)
+		return
+	}
+	for _, schedule := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.fire(ctx, schedule, time.Now().UTC()); err != nil {
+			log.Printf("scheduler: schedule %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// fire handles one due schedule: it runs a queued firing once the previous
+// run is over, or picks the due firing by the catch-up mode and applies
+// the overlap policy to it. The schedule's new state is claimed before the
+// run starts, so a firing starts at most once even when leadership moves in
+// the middle of a tick; a leader that dies in between loses that firing.
+func (s *ScheduleService) fire(ctx context.Context, schedule *models.FlowSchedule, now time.Time) error {
+	spec, loc, err := parseSchedule(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		// 검증 이전에 저장된 스케줄은 멈추고 이유를 남긴다
+		msg := err.Error()
+		schedule.NextRunAt, schedule.QueuedAt, schedule.LastError = nil, nil, &msg
+		return s.repo.UpdateState(schedule)
+	}
+	active, err := s.runs.ActiveForSchedule(schedule.ID)
+	if err != nil {
+		return err
+	}
+	readNext, readQueued := schedule.NextRunAt, schedule.QueuedAt
+
+	var firing *time.Time
+	switch {
+	case schedule.QueuedAt != nil:
+		if len(active) > 0 {
+			return nil
+		}
+		firing, schedule.QueuedAt = schedule.QueuedAt, nil
+	case schedule.NextRunAt == nil || schedule.NextRunAt.After(now):
+		return nil
+	default:
+		firing, schedule.NextRunAt = dueFiring(spec, loc, schedule, now)
+		if firing != nil && len(active) > 0 {
+			switch schedule.OverlapPolicy {
+			case models.ScheduleOverlapQueue:
+				schedule.QueuedAt, firing = firing, nil
+			case models.ScheduleOverlapCancel:
+				// 실행 중인 run은 발화를 차지한 뒤에 취소한다
+			default:
+				msg := fmt.Sprintf("skipped the firing of %s: run %d is still active", firing.Format(time.RFC3339), active[0])
+				schedule.LastError, firing = &msg, nil
+			}
+		}
+	}
+
+	// 리더가 틱 도중에 바뀌어도 같은 발화를 두 번 시작하지 않도록 먼저 차지한다
+	claimed, err := s.repo.ClaimFiring(schedule, readNext, readQueued)
+	if err != nil || !claimed {
+		return err
+	}
+	if firing != nil {
+		if schedule.OverlapPolicy == models.ScheduleOverlapCancel {
+			for _, id := range active {
+				if err := s.runs.RequestCancel(id); err != nil {
+					log.Printf("scheduler: schedule %d: failed to cancel run %d: %v", schedule.ID, id, err)
+				}
+			}
+		}
+		s.start(ctx, schedule, now)
+	}
+	return s.repo.UpdateState(schedule)
+}
+
+// dueFiring returns the firing to run now among those from
+// schedule.NextRunAt to now, according to the catch-up mode, and the
+// firing to wait for next
+func dueFiring(spec cron.Schedule, loc *time.Location, schedule *models.FlowSchedule, now time.Time) (*time.Time, *time.Time) {
+	var missed []time.Time
+	for t := schedule.NextRunAt; t != nil && !t.After(now); t = nextFiring(spec, loc, *t) {
+		if missed = append(missed, *t); len(missed) > maxScheduleCatchUp {
+			missed = missed[1:]
+		}
+	}
+	if len(missed) == 0 {
+		return nil, schedule.NextRunAt
+	}
+	latest := missed[len(missed)-1]
+
+	switch schedule.CatchUp {
+	case models.ScheduleCatchUpAll:
+		// 남은 발화는 다음 확인 때 하나씩 실행된다
+		oldest := missed[0]
+		return &oldest, nextFiring(spec, loc, oldest)
+	case models.ScheduleCatchUpLatest:
+		return &latest, nextFiring(spec, loc, now)
+	}
+	if now.Sub(latest) > scheduleMissGrace {
+		return nil, nextFiring(spec, loc, now)
+	}
+	return &latest, nextFiring(spec, loc, now)
+}
+
+// start launches a run of the schedule's flow as its user and records the
+// outcome on the schedule
+func (s *ScheduleService) start(ctx context.Context, schedule *models.FlowSchedule, now time.Time) {
+	schedule.LastRunAt = &now
+	run, err := s.launchRun(ctx, schedule)
+	if err != nil {
+		msg := "failed to start run: " + err.Error()
+		schedule.LastError = &msg
+		log.Printf("scheduler: schedule %d: %s", schedule.ID, msg)
+		return
+	}
+	schedule.LastRunID = &run.ID
+	schedule.LastError = nil
+}
+
+func (s *ScheduleService) launchRun(ctx context.Context, schedule *models.FlowSchedule) (*models.WorkflowRun, error) {
+	ctx, err := runAsContext(ctx, s.userRepo, s.admins, schedule.RunAs)
+	if err != nil {
+		return nil, err
+	}
+
+	id := schedule.ID
+	return s.workflows.Launch(ctx, models.WorkflowLaunch{
+		FlowID:     schedule.FlowID,
+		Trigger:    models.WorkflowTriggerSchedule,
+		ScheduleID: &id,
+	})
+}
+
+// userLookup finds the user a scheduled or triggered run executes as
+type userLookup interface {
+	FindByID(id int64) (*models.User, error)
+}
+
+// runAsContext returns ctx acting as the user a schedule or trigger runs as
+func runAsContext(ctx context.Context, userRepo userLookup, admins map[string]bool, runAs *int64) (context.Context, error) {
+	if runAs == nil {
+		return nil, errors.New("the user it runs as no longer exists")
+	}
+	user, err := userRepo.FindByID(*runAs)
+	if err != nil {
+		return nil, err
+	}
+	user.Admin = admins[user.Username]
+	return auth.WithUser(ctx, user), nil
+}
+
+func adminSet(admins []string) map[string]bool {
+	set := make(map[string]bool, len(admins))
+	for _, a := range admins {
+		set[a] = true
+	}
+	return set
+}
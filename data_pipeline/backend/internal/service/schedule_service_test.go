@@ -0,0 +1,256 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.UTC()
+}
+
+func formatFiring(v *time.Time) string {
+	if v == nil {
+		return "none"
+	}
+	return v.UTC().Format(time.RFC3339)
+}
+
+func TestDueFiring(t *testing.T) {
+	tests := []struct {
+		name       string
+		cron       string
+		timezone   string
+		catchUp    string
+		nextRunAt  string
+		now        string
+		wantFiring string // "none" when nothing fires
+		wantNext   string
+	}{
+		{
+			name:       "not due yet",
+			cron:       "0 * * * *",
+			nextRunAt:  "2026-05-04T10:00:00Z",
+			now:        "2026-05-04T09:59:30Z",
+			wantFiring: "none",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "on time",
+			cron:       "0 * * * *",
+			nextRunAt:  "2026-05-04T09:00:00Z",
+			now:        "2026-05-04T09:00:05Z",
+			wantFiring: "2026-05-04T09:00:00Z",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "late within the grace",
+			cron:       "0 * * * *",
+			nextRunAt:  "2026-05-04T09:00:00Z",
+			now:        "2026-05-04T09:01:30Z",
+			wantFiring: "2026-05-04T09:00:00Z",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "missed firings are skipped",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpNone,
+			nextRunAt:  "2026-05-04T06:00:00Z",
+			now:        "2026-05-04T09:30:00Z",
+			wantFiring: "none",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "missed firings run once",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpLatest,
+			nextRunAt:  "2026-05-04T06:00:00Z",
+			now:        "2026-05-04T09:30:00Z",
+			wantFiring: "2026-05-04T09:00:00Z",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "missed firings run oldest first",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpAll,
+			nextRunAt:  "2026-05-04T06:00:00Z",
+			now:        "2026-05-04T09:30:00Z",
+			wantFiring: "2026-05-04T06:00:00Z",
+			wantNext:   "2026-05-04T07:00:00Z",
+		},
+		{
+			name:       "catch-up is capped",
+			cron:       "* * * * *",
+			catchUp:    models.ScheduleCatchUpAll,
+			nextRunAt:  "2026-05-04T06:00:00Z",
+			now:        "2026-05-04T09:00:00Z",
+			wantFiring: "2026-05-04T07:21:00Z", // the newest 100 of 181
+			wantNext:   "2026-05-04T07:22:00Z",
+		},
+		{
+			name:       "timezone",
+			cron:       "0 9 * * *",
+			timezone:   "Asia/Seoul",
+			nextRunAt:  "2026-05-04T00:00:00Z",
+			now:        "2026-05-04T00:00:10Z",
+			wantFiring: "2026-05-04T00:00:00Z",
+			wantNext:   "2026-05-05T00:00:00Z",
+		},
+		{
+			// 02:30 does not exist on 2026-03-08 in New York; it fires at 03:30 EDT
+			name:       "dst spring forward fires the skipped time",
+			cron:       "30 2 * * *",
+			timezone:   "America/New_York",
+			nextRunAt:  "2026-03-07T07:30:00Z",
+			now:        "2026-03-07T07:30:10Z",
+			wantFiring: "2026-03-07T07:30:00Z",
+			wantNext:   "2026-03-08T07:30:00Z",
+		},
+		{
+			name:       "dst spring forward after the shifted firing",
+			cron:       "30 2 * * *",
+			timezone:   "America/New_York",
+			nextRunAt:  "2026-03-08T07:30:00Z",
+			now:        "2026-03-08T07:30:10Z",
+			wantFiring: "2026-03-08T07:30:00Z",
+			wantNext:   "2026-03-09T06:30:00Z",
+		},
+		{
+			// 01:30 happens twice on 2026-11-01 in New York; only the first fires
+			name:       "dst fall back fires once",
+			cron:       "30 1 * * *",
+			timezone:   "America/New_York",
+			nextRunAt:  "2026-11-01T05:30:00Z",
+			now:        "2026-11-01T05:30:05Z",
+			wantFiring: "2026-11-01T05:30:00Z",
+			wantNext:   "2026-11-02T06:30:00Z",
+		},
+		{
+			name:       "dst fall back catch-up",
+			cron:       "0 * * * *",
+			timezone:   "America/New_York",
+			catchUp:    models.ScheduleCatchUpLatest,
+			nextRunAt:  "2026-11-01T04:00:00Z",
+			now:        "2026-11-01T07:30:00Z",
+			wantFiring: "2026-11-01T07:00:00Z",
+			wantNext:   "2026-11-01T08:00:00Z",
+		},
+		{
+			// the old leader died before storing the schedule; the new one
+			// finds the same firing and still runs it
+			name:       "failover before the firing was stored",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpNone,
+			nextRunAt:  "2026-05-04T09:00:00Z",
+			now:        "2026-05-04T09:00:40Z",
+			wantFiring: "2026-05-04T09:00:00Z",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "failover after the grace drops the firing",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpNone,
+			nextRunAt:  "2026-05-04T09:00:00Z",
+			now:        "2026-05-04T09:05:00Z",
+			wantFiring: "none",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			name:       "failover after the grace with catch-up",
+			cron:       "0 * * * *",
+			catchUp:    models.ScheduleCatchUpLatest,
+			nextRunAt:  "2026-05-04T09:00:00Z",
+			now:        "2026-05-04T09:05:00Z",
+			wantFiring: "2026-05-04T09:00:00Z",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+		{
+			// the old leader stored the firing before it died; nothing is due
+			name:       "failover after the firing was stored",
+			cron:       "0 * * * *",
+			nextRunAt:  "2026-05-04T10:00:00Z",
+			now:        "2026-05-04T09:00:40Z",
+			wantFiring: "none",
+			wantNext:   "2026-05-04T10:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timezone := tt.timezone
+			if timezone == "" {
+				timezone = "UTC"
+			}
+			spec, loc, err := parseSchedule(tt.cron, timezone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			catchUp := tt.catchUp
+			if catchUp == "" {
+				catchUp = models.ScheduleCatchUpNone
+			}
+			next := mustTime(t, tt.nextRunAt)
+			schedule := &models.FlowSchedule{CatchUp: catchUp, NextRunAt: &next}
+
+			firing, gotNext := dueFiring(spec, loc, schedule, mustTime(t, tt.now))
+			if got := formatFiring(firing); got != tt.wantFiring {
+				t.Errorf("firing = %s, want %s", got, tt.wantFiring)
+			}
+			if got := formatFiring(gotNext); got != tt.wantNext {
+				t.Errorf("next = %s, want %s", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+// Catch-up all runs the missed firings one per tick until it is back on time
+func TestDueFiringCatchUpAllDrains(t *testing.T) {
+	spec, loc, err := parseSchedule("0 * * * *", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := mustTime(t, "2026-11-01T04:00:00Z")
+	schedule := &models.FlowSchedule{CatchUp: models.ScheduleCatchUpAll, NextRunAt: &next}
+	now := mustTime(t, "2026-11-01T07:30:00Z")
+
+	var fired []string
+	for i := 0; i < 10; i++ {
+		firing, next := dueFiring(spec, loc, schedule, now)
+		schedule.NextRunAt = next
+		if firing == nil {
+			break
+		}
+		fired = append(fired, formatFiring(firing))
+	}
+	// 01:00 EST repeats the wall clock of 01:00 EDT and does not fire again
+	want := []string{"2026-11-01T04:00:00Z", "2026-11-01T05:00:00Z", "2026-11-01T07:00:00Z"}
+	if len(fired) != len(want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+	for i := range want {
+		if fired[i] != want[i] {
+			t.Fatalf("fired %v, want %v", fired, want)
+		}
+	}
+	if got := formatFiring(schedule.NextRunAt); got != "2026-11-01T08:00:00Z" {
+		t.Fatalf("next = %s, want 2026-11-01T08:00:00Z", got)
+	}
+}
+
+func TestNextFiringEvery(t *testing.T) {
+	spec, loc, err := parseSchedule("@every 90m", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 경과 시간 기준이므로 시간대 전환과 무관하다
+	from := mustTime(t, "2026-11-01T05:00:00Z")
+	if got := formatFiring(nextFiring(spec, loc, from)); got != "2026-11-01T06:30:00Z" {
+		t.Fatalf("next = %s, want 2026-11-01T06:30:00Z", got)
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"data-pipeline-backend/internal/config"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrSecretsKeyMissing is returned when SECRETS_KEY is not configured
+var ErrSecretsKeyMissing = errors.New("SECRETS_KEY가 설정되지 않았습니다")
+
+// secretsAEAD is AES-256-GCM with the configured SECRETS_KEY
+func secretsAEAD() (cipher.AEAD, error) {
+	encoded := config.Get().Secrets.Key
+	if encoded == "" {
+		return nil, ErrSecretsKeyMissing
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("SECRETS_KEY must be a base64 encoded 32-byte key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSecret encrypts plain as nonce || ciphertext
+func sealSecret(plain []byte) ([]byte, error) {
+	aead, err := secretsAEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// openSecret decrypts the output of sealSecret
+func openSecret(sealed []byte) ([]byte, error) {
+	aead, err := secretsAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret (SECRETS_KEY changed?): %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// connectionsMountDir is where file-mounted connections appear, one directory
+// per connection and one file per key. Steps read it from CONNECTIONS_DIR.
+const connectionsMountDir = "/var/run/connections"
+
+// ParseStepConnections reads params.connections: a list of connection names
+// or {name, mount: env|file, prefix}
+func ParseStepConnections(params map[string]interface{}) ([]models.StepConnectionRef, error) {
+	raw, ok := params["connections"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("connections는 배열이어야 합니다")
+	}
+
+	var refs []models.StepConnectionRef
+	seen := make(map[string]bool)
+	for _, e := range list {
+		var ref models.StepConnectionRef
+		switch v := e.(type) {
+		case string:
+			ref.Name = v
+		case map[string]interface{}:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("connections 형식이 올바르지 않습니다: %v", err)
+			}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&ref); err != nil {
+				return nil, fmt.Errorf("connections 형식이 올바르지 않습니다: %v", err)
+			}
+		default:
+			return nil, fmt.Errorf("connections 항목은 이름 또는 {name, mount, prefix}여야 합니다")
+		}
+
+		if !connectionNamePattern.MatchString(ref.Name) {
+			return nil, fmt.Errorf("connections의 커넥션 이름이 올바르지 않습니다: %q", ref.Name)
+		}
+		if seen[ref.Name] {
+			return nil, fmt.Errorf("connections에 %s가 중복되었습니다", ref.Name)
+		}
+		seen[ref.Name] = true
+
+		switch ref.Mount {
+		case "", models.ConnectionMountEnv:
+			ref.Mount = models.ConnectionMountEnv
+			if ref.Prefix == "" {
+				ref.Prefix = connectionEnvPrefix(ref.Name)
+			}
+			if !stepEnvNamePattern.MatchString(ref.Prefix) || strings.HasPrefix(ref.Prefix, "K_") || strings.HasPrefix(ref.Prefix, "RETRY_") {
+				return nil, fmt.Errorf("connections.%s의 prefix가 올바르지 않습니다: %q", ref.Name, ref.Prefix)
+			}
+		case models.ConnectionMountFile:
+			ref.Prefix = ""
+		default:
+			return nil, fmt.Errorf("connections.%s의 mount는 env 또는 file이어야 합니다", ref.Name)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// connectionEnvPrefix is the default env prefix: "my-db" -> "MY_DB_"
+func connectionEnvPrefix(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+func connectionSecretName(flowID, name string) string {
+	return fmt.Sprintf("conn-flow%s-%s", flowID, name)
+}
+
+// connectionSnapshot is a connection as of the deploy. Version changes with
+// every update so that steps roll to a new revision with the new values.
+type connectionSnapshot struct {
+	Version string
+	Values  map[string]string
+}
+
+// loadConnections reads every connection the steps reference, decrypting the
+// values only when withValues is set
+func (s *K8sService) loadConnections(owner string, steps []FlowStep, withValues bool) (map[string]*connectionSnapshot, error) {
+	conns := make(map[string]*connectionSnapshot)
+	for _, step := range steps {
+		refs, err := ParseStepConnections(step.Params)
+		if err != nil {
+			return nil, fmt.Errorf("step '%s': %w", step.Name, err)
+		}
+		for _, ref := range refs {
+			if conns[ref.Name] != nil {
+				continue
+			}
+			if s.connRepo == nil {
+				return nil, fmt.Errorf("connection repository is not configured")
+			}
+			conn, err := s.connRepo.FindByOwnerAndName(owner, ref.Name)
+			if err == repository.ErrConnectionNotFound {
+				return nil, fmt.Errorf("step '%s': connection %q not found", step.Name, ref.Name)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			snap := &connectionSnapshot{Version: strconv.FormatInt(conn.UpdatedAt.UnixMilli(), 10)}
+			if withValues {
+				if snap.Values, err = connectionValues(conn); err != nil {
+					return nil, err
+				}
+			}
+			conns[ref.Name] = snap
+		}
+	}
+	return conns, nil
+}
+
+// stepConnections are the connections a step mounts. Tag is recorded in the
+// flow/connections annotation.
+type stepConnections struct {
+	Refs []models.StepConnectionRef
+	Tag  string
+}
+
+func (s *K8sService) stepConnections(params map[string]interface{}, conns map[string]*connectionSnapshot) stepConnections {
+	refs, _ := ParseStepConnections(params)
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		version := ""
+		if c := conns[ref.Name]; c != nil {
+			version = c.Version
+		}
+		parts = append(parts, fmt.Sprintf("%s:%s:%s@%s", ref.Name, ref.Mount, ref.Prefix, version))
+	}
+	return stepConnections{Refs: refs, Tag: strings.Join(parts, ",")}
+}
+
+// applyConnectionSecrets writes one Secret per referenced connection and
+// removes the flow's connection Secrets that are no longer referenced
+func (s *K8sService) applyConnectionSecrets(ctx context.Context, ns, flowID string, conns map[string]*connectionSnapshot) error {
+	alive := make(map[string]bool, len(conns))
+	for name, conn := range conns {
+		secret := s.buildConnectionSecret(ns, flowID, name, conn.Values)
+		alive[secret.Name] = true
+
+		_, err := s.clientset.CoreV1().Secrets(ns).Create(ctx, secret, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			_, err = s.clientset.CoreV1().Secrets(ns).Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("secret for connection %s: %w", name, err)
+		}
+	}
+
+	secrets, err := s.clientset.CoreV1().Secrets(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("flow_id=%s,purpose=connection", flowID),
+	})
+	if err == nil {
+		for _, secret := range secrets.Items {
+			if !alive[secret.Name] {
+				s.clientset.CoreV1().Secrets(ns).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+			}
+		}
+	}
+	return nil
+}
+
+func (s *K8sService) buildConnectionSecret(ns, flowID, name string, values map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      connectionSecretName(flowID, name),
+			Namespace: ns,
+			Labels: map[string]string{
+				"flow_id":    flowID,
+				"purpose":    "connection",
+				"connection": name,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: values,
+	}
+}
+
+// envFrom exposes the env-mounted connections of a step
+func (sc stepConnections) envFrom(flowID string) []map[string]interface{} {
+	var envFrom []map[string]interface{}
+	for _, ref := range sc.Refs {
+		if ref.Mount != models.ConnectionMountEnv {
+			continue
+		}
+		envFrom = append(envFrom, map[string]interface{}{
+			"prefix":    ref.Prefix,
+			"secretRef": map[string]interface{}{"name": connectionSecretName(flowID, ref.Name)},
+		})
+	}
+	return envFrom
+}
+
+// volumes returns the Secret volumes and mounts of the file-mounted connections
+func (sc stepConnections) volumes(flowID string) ([]map[string]interface{}, []map[string]interface{}) {
+	var volumes, mounts []map[string]interface{}
+	for i, ref := range sc.Refs {
+		if ref.Mount != models.ConnectionMountFile {
+			continue
+		}
+		volName := fmt.Sprintf("conn-%d", i)
+		volumes = append(volumes, map[string]interface{}{
+			"name":   volName,
+			"secret": map[string]interface{}{"secretName": connectionSecretName(flowID, ref.Name)},
+		})
+		mounts = append(mounts, map[string]interface{}{
+			"name":      volName,
+			"mountPath": connectionsMountDir + "/" + ref.Name,
+			"readOnly":  true,
+		})
+	}
+	return volumes, mounts
+}
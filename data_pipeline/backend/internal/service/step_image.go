@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StepImageSpec is what a step asks for in params.image / params.requirements.
+// Requirements are installed on top of the base image.
+type StepImageSpec struct {
+	Base         string
+	Requirements []string
+}
+
+// stepImageSpec reads params.image (base image override) and
+// params.requirements (list or newline separated string). Requirements are
+// trimmed, de-duplicated and sorted so equal sets share one image.
+func (s *K8sService) stepImageSpec(params map[string]interface{}) StepImageSpec {
+	spec := StepImageSpec{Base: s.defaultImage()}
+	if img, ok := params["image"].(string); ok && strings.TrimSpace(img) != "" {
+		spec.Base = strings.TrimSpace(img)
+	}
+
+	var lines []string
+	switch v := params["requirements"].(type) {
+	case []interface{}:
+		for _, e := range v {
+			if str, ok := e.(string); ok {
+				lines = append(lines, str)
+			}
+		}
+	case string:
+		lines = strings.Split(v, "\n")
+	}
+
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		spec.Requirements = append(spec.Requirements, line)
+	}
+	sort.Strings(spec.Requirements)
+	return spec
+}
+
+// Key identifies the dependency set; it is the tag of the built image
+func (p StepImageSpec) Key() string {
+	h := sha256.Sum256([]byte(p.Base + "\n" + strings.Join(p.Requirements, "\n")))
+	return hex.EncodeToString(h[:])[:32]
+}
+
+// merge returns a spec with the requirements of both; bases must match
+func (p StepImageSpec) merge(other StepImageSpec) StepImageSpec {
+	merged := StepImageSpec{Base: p.Base}
+	seen := make(map[string]bool)
+	for _, r := range append(append([]string{}, p.Requirements...), other.Requirements...) {
+		if !seen[r] {
+			seen[r] = true
+			merged.Requirements = append(merged.Requirements, r)
+		}
+	}
+	sort.Strings(merged.Requirements)
+	return merged
+}
+
+func (s *K8sService) defaultImage() string {
+	if s.pyImage != "" {
+		return s.pyImage
+	}
+	return config.Get().Image.Default
+}
+
+// plannedImage is the image reference a spec resolves to, without building:
+// the base image itself or <registry>/flow-step:<key>
+func (s *K8sService) plannedImage(spec StepImageSpec) string {
+	if len(spec.Requirements) == 0 {
+		return spec.Base
+	}
+	return fmt.Sprintf("%s/flow-step:%s", strings.TrimSuffix(config.Get().Image.Registry, "/"), spec.Key())
+}
+
+// pullPolicy keeps the preloaded default image local-only
+func (s *K8sService) pullPolicy(image string) corev1.PullPolicy {
+	if image == s.defaultImage() {
+		return corev1.PullNever
+	}
+	return corev1.PullIfNotPresent
+}
+
+// resolveStepImage returns the image a step runs in and its digest when known.
+// Images with requirements are built once per key with Kaniko and afterwards
+// referenced by digest.
+func (s *K8sService) resolveStepImage(ctx context.Context, ns, flowID string, spec StepImageSpec) (string, string, error) {
+	if strings.ContainsAny(spec.Base, " \t\r\n") {
+		return "", "", fmt.Errorf("invalid image %q", spec.Base)
+	}
+	if len(spec.Requirements) == 0 {
+		return spec.Base, imageDigest(spec.Base), nil
+	}
+
+	cfg := config.Get().Image
+	if cfg.Registry == "" {
+		return "", "", fmt.Errorf("params.requirements needs IMAGE_REGISTRY to build step images")
+	}
+	if s.imageRepo == nil {
+		return "", "", fmt.Errorf("step image repository is not configured")
+	}
+
+	key := spec.Key()
+	ref := s.plannedImage(spec)
+	if cached, err := s.imageRepo.FindByKey(key); err == nil {
+		if cached.Status == models.StepImageStatusReady && cached.Digest != nil {
+			return cached.Image + "@" + *cached.Digest, *cached.Digest, nil
+		}
+	} else if err != repository.ErrStepImageNotFound {
+		return "", "", err
+	}
+
+	reqJSON, _ := json.Marshal(spec.Requirements)
+	img := &models.StepImage{
+		DepKey:       key,
+		BaseImage:    spec.Base,
+		Requirements: reqJSON,
+		Image:        ref,
+		Status:       models.StepImageStatusBuilding,
+	}
+	if err := s.imageRepo.Upsert(img); err != nil {
+		return "", "", err
+	}
+
+	digest, logs, err := s.buildStepImage(ctx, ns, flowID, spec, ref, time.Duration(cfg.BuildTimeoutSec)*time.Second)
+	if len(logs) > 4000 {
+		logs = logs[len(logs)-4000:]
+	}
+	img.Log = &logs
+	if err != nil {
+		img.Status = models.StepImageStatusFailed
+		s.imageRepo.Upsert(img)
+		return "", "", fmt.Errorf("image build failed for %s: %w\n%s", ref, err, logs)
+	}
+
+	img.Status = models.StepImageStatusReady
+	img.Digest = &digest
+	if err := s.imageRepo.Upsert(img); err != nil {
+		return "", "", err
+	}
+	return ref + "@" + digest, digest, nil
+}
+
+// buildStepImage runs a Kaniko Job that installs the requirements on top of
+// the base image and pushes it. The digest comes back as the container's
+// termination message.
+func (s *K8sService) buildStepImage(ctx context.Context, ns, flowID string, spec StepImageSpec, ref string, timeout time.Duration) (string, string, error) {
+	cfg := config.Get().Image
+	name := "build-" + spec.Key()[:16]
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"flow_id": flowID, "purpose": "image-build"},
+		},
+		Data: map[string]string{
+			"Dockerfile":       fmt.Sprintf("FROM %s\nCOPY requirements.txt /tmp/requirements.txt\nRUN pip install --no-cache-dir -r /tmp/requirements.txt\n", spec.Base),
+			"requirements.txt": strings.Join(spec.Requirements, "\n") + "\n",
+		},
+	}
+	if _, err := s.clientset.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", "", err
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: "context",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+			},
+		},
+	}
+	mounts := []corev1.VolumeMount{{Name: "context", MountPath: "/workspace"}}
+	if cfg.PushSecret != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "docker-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: cfg.PushSecret,
+					Items:      []corev1.KeyToPath{{Key: ".dockerconfigjson", Path: "config.json"}},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: "docker-config", MountPath: "/kaniko/.docker"})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"flow_id": flowID, "purpose": "image-build"},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(300),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:  "kaniko",
+							Image: cfg.KanikoImage,
+							Args: []string{
+								"--context=dir:///workspace",
+								"--dockerfile=/workspace/Dockerfile",
+								"--destination=" + ref,
+								"--digest-file=/dev/termination-log",
+								"--cache=true",
+								"--cache-repo=" + strings.TrimSuffix(cfg.Registry, "/") + "/flow-step-cache",
+							},
+							VolumeMounts: mounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	// 같은 키의 빌드가 이미 진행 중이면 그 Job을 기다린다
+	if _, err := s.clientset.BatchV1().Jobs(ns).Create(ctx, job, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return "", "", err
+	}
+
+	deadline := time.Now().Add(timeout)
+	succeeded := false
+	for {
+		j, err := s.clientset.BatchV1().Jobs(ns).Get(ctx, name, metav1.GetOptions{})
+		if err == nil && (j.Status.Succeeded > 0 || j.Status.Failed > 0) {
+			succeeded = j.Status.Succeeded > 0
+			break
+		}
+		if time.Now().After(deadline) {
+			return "", s.jobLogs(ctx, ns, name), fmt.Errorf("build did not finish within %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	logs := s.jobLogs(ctx, ns, name)
+	s.clientset.CoreV1().ConfigMaps(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if !succeeded {
+		// 실패한 Job이 남아 있으면 다음 배포가 재빌드 대신 같은 실패를 읽는다
+		propagation := metav1.DeletePropagationBackground
+		s.clientset.BatchV1().Jobs(ns).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		return "", logs, fmt.Errorf("kaniko job %s failed", name)
+	}
+
+	pods, err := s.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + name})
+	if err != nil || len(pods.Items) == 0 {
+		return "", logs, fmt.Errorf("kaniko pod not found")
+	}
+	for _, cs := range pods.Items[0].Status.ContainerStatuses {
+		if cs.State.Terminated != nil {
+			if digest := strings.TrimSpace(cs.State.Terminated.Message); strings.HasPrefix(digest, "sha256:") {
+				return digest, logs, nil
+			}
+		}
+	}
+	return "", logs, fmt.Errorf("kaniko did not report an image digest")
+}
+
+// jobLogs returns the logs of the first pod of a Job
+func (s *K8sService) jobLogs(ctx context.Context, ns, jobName string) string {
+	pods, err := s.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+	b, err := s.clientset.CoreV1().Pods(ns).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// imageDigest extracts the digest of a digest-pinned reference
+func imageDigest(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"data-pipeline-backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	maxStepScale            = 5
+	maxContainerConcurrency = 1000
+	maxStepTimeoutSeconds   = 600 // Knative max-revision-timeout-seconds 기본값
+)
+
+var stepEnvNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedStepEnv is set by the runner itself; K_* and RETRY_* are reserved too
+var reservedStepEnv = map[string]bool{
+	"FLOW_ID":           true,
+	"APP_ID":            true,
+	"IN_TYPES":          true,
+	"OUT_TYPE":          true,
+	"MAX_HOPS":          true,
+	"DEDUPE_WINDOW_SEC": true,
+	"CODE_PATH":         true,
+	"TRACE_URL":         true,
+	"TRACE_TOKEN":       true,
+	"CONNECTIONS_DIR":   true,
+	"PORT":              true,
+}
+
+// ValidateStepParams checks the typed parts of an object's params before it is saved
+func ValidateStepParams(params map[string]interface{}) error {
+	if _, err := ParseStepRuntimeConfig(params); err != nil {
+		return err
+	}
+	_, err := ParseStepConnections(params)
+	return err
+}
+
+// ParseStepRuntimeConfig reads and validates params.runtimeConfig; nil when absent
+func ParseStepRuntimeConfig(params map[string]interface{}) (*models.StepRuntimeConfig, error) {
+	raw, ok := params["runtimeConfig"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("runtimeConfig 형식이 올바르지 않습니다: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var cfg models.StepRuntimeConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("runtimeConfig 형식이 올바르지 않습니다: %v", err)
+	}
+
+	if cfg.MinScale != nil && (*cfg.MinScale < 0 || *cfg.MinScale > maxStepScale) {
+		return nil, fmt.Errorf("runtimeConfig.minScale은 0~%d 사이여야 합니다", maxStepScale)
+	}
+	if cfg.MaxScale != nil && (*cfg.MaxScale < 1 || *cfg.MaxScale > maxStepScale) {
+		return nil, fmt.Errorf("runtimeConfig.maxScale은 1~%d 사이여야 합니다", maxStepScale)
+	}
+	if cfg.MinScale != nil && cfg.MaxScale != nil && *cfg.MinScale > *cfg.MaxScale {
+		return nil, fmt.Errorf("runtimeConfig.minScale이 maxScale보다 클 수 없습니다")
+	}
+	if cfg.ContainerConcurrency != nil && (*cfg.ContainerConcurrency < 0 || *cfg.ContainerConcurrency > maxContainerConcurrency) {
+		return nil, fmt.Errorf("runtimeConfig.containerConcurrency는 0~%d 사이여야 합니다", maxContainerConcurrency)
+	}
+	if cfg.TimeoutSeconds != nil && (*cfg.TimeoutSeconds < 1 || *cfg.TimeoutSeconds > maxStepTimeoutSeconds) {
+		return nil, fmt.Errorf("runtimeConfig.timeoutSeconds는 1~%d 사이여야 합니다", maxStepTimeoutSeconds)
+	}
+
+	if r := cfg.Resources; r != nil {
+		for _, q := range []struct {
+			field, request, limit string
+		}{
+			{"cpu", r.Requests.CPU, r.Limits.CPU},
+			{"memory", r.Requests.Memory, r.Limits.Memory},
+		} {
+			var request, limit resource.Quantity
+			if q.request != "" {
+				if request, err = resource.ParseQuantity(q.request); err != nil || request.Sign() <= 0 {
+					return nil, fmt.Errorf("runtimeConfig.resources.requests.%s 값이 올바르지 않습니다: %q", q.field, q.request)
+				}
+			}
+			if q.limit != "" {
+				if limit, err = resource.ParseQuantity(q.limit); err != nil || limit.Sign() <= 0 {
+					return nil, fmt.Errorf("runtimeConfig.resources.limits.%s 값이 올바르지 않습니다: %q", q.field, q.limit)
+				}
+			}
+			if q.request != "" && q.limit != "" && request.Cmp(limit) > 0 {
+				return nil, fmt.Errorf("runtimeConfig.resources.requests.%s가 limits보다 클 수 없습니다", q.field)
+			}
+		}
+	}
+
+	for name := range cfg.Env {
+		if !stepEnvNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("runtimeConfig.env 이름이 올바르지 않습니다: %q", name)
+		}
+		if reservedStepEnv[name] || strings.HasPrefix(name, "K_") || strings.HasPrefix(name, "RETRY_") {
+			return nil, fmt.Errorf("runtimeConfig.env %s는 예약된 이름입니다", name)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// StepRuntime is the effective runtime config of a step
+type StepRuntime struct {
+	MinScale             int               `json:"minScale"`
+	MaxScale             int               `json:"maxScale"`
+	ContainerConcurrency int               `json:"containerConcurrency"`
+	TimeoutSeconds       int               `json:"timeoutSeconds,omitempty"` // 0 = Knative default
+	Requests             map[string]string `json:"requests,omitempty"`
+	Limits               map[string]string `json:"limits,omitempty"`
+	Env                  [][2]string       `json:"env,omitempty"` // sorted by name
+}
+
+// stepRuntime resolves params.runtimeConfig on top of the defaults. The
+// legacy params.autoScale still sets maxScale when runtimeConfig does not.
+// Invalid configs fall back to the defaults here; validateDTO rejects them
+// before a deploy.
+func (s *K8sService) stepRuntime(params map[string]interface{}) StepRuntime {
+	rt := StepRuntime{MinScale: 1, MaxScale: s.stepMaxScale(params)}
+	cfg, err := ParseStepRuntimeConfig(params)
+	if err != nil || cfg == nil {
+		return rt
+	}
+
+	if cfg.MinScale != nil {
+		rt.MinScale = *cfg.MinScale
+	}
+	if cfg.MaxScale != nil {
+		rt.MaxScale = *cfg.MaxScale
+	}
+	if rt.MaxScale > 0 && rt.MinScale > rt.MaxScale {
+		rt.MaxScale = rt.MinScale
+	}
+	if cfg.ContainerConcurrency != nil {
+		rt.ContainerConcurrency = *cfg.ContainerConcurrency
+	}
+	if cfg.TimeoutSeconds != nil {
+		rt.TimeoutSeconds = *cfg.TimeoutSeconds
+	}
+	if r := cfg.Resources; r != nil {
+		rt.Requests = resourceAmounts(r.Requests)
+		rt.Limits = resourceAmounts(r.Limits)
+	}
+
+	names := make([]string, 0, len(cfg.Env))
+	for name := range cfg.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rt.Env = append(rt.Env, [2]string{name, cfg.Env[name]})
+	}
+	return rt
+}
+
+func resourceAmounts(a models.ResourceAmounts) map[string]string {
+	m := make(map[string]string)
+	if a.CPU != "" {
+		m["cpu"] = a.CPU
+	}
+	if a.Memory != "" {
+		m["memory"] = a.Memory
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// annotations are the Knative autoscaling annotations of the revision template
+func (rt StepRuntime) annotations() map[string]string {
+	return map[string]string{
+		"autoscaling.knative.dev/minScale": fmt.Sprintf("%d", rt.MinScale),
+		"autoscaling.knative.dev/maxScale": fmt.Sprintf("%d", rt.MaxScale),
+	}
+}
+
+// resources is the container resources block, nil when nothing is set
+func (rt StepRuntime) resources() map[string]interface{} {
+	if rt.Requests == nil && rt.Limits == nil {
+		return nil
+	}
+	res := make(map[string]interface{})
+	if rt.Requests != nil {
+		res["requests"] = rt.Requests
+	}
+	if rt.Limits != nil {
+		res["limits"] = rt.Limits
+	}
+	return res
+}
+
+// String is the config as recorded in the flow/runtime annotation
+func (rt StepRuntime) String() string {
+	b, _ := json.Marshal(rt)
+	return string(b)
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidSpan = errors.New("유효하지 않은 span 입니다")
+	ErrTraceToken  = errors.New("span 수집 토큰이 올바르지 않습니다")
+)
+
+var (
+	fallbackTraceKey     []byte
+	fallbackTraceKeyOnce sync.Once
+)
+
+// traceKey is the HMAC key of the ingest tokens: TRACE_INGEST_KEY, else
+// SECRETS_KEY, else a random key that only this process knows
+func traceKey() []byte {
+	cfg := config.Get()
+	if cfg.Runtime.TraceKey != "" {
+		return []byte(cfg.Runtime.TraceKey)
+	}
+	if cfg.Secrets.Key != "" {
+		return []byte("trace:" + cfg.Secrets.Key)
+	}
+	fallbackTraceKeyOnce.Do(func() {
+		fallbackTraceKey = make([]byte, 32)
+		if _, err := rand.Read(fallbackTraceKey); err != nil {
+			panic(err)
+		}
+		log.Printf("traces: neither TRACE_INGEST_KEY nor SECRETS_KEY is set; span tokens are only valid on this replica")
+	})
+	return fallbackTraceKey
+}
+
+// TraceToken is the token a flow's runners send with their spans in
+// X-Trace-Token; it lets them report spans of that flow only
+func TraceToken(flowID string) string {
+	mac := hmac.New(sha256.New, traceKey())
+	mac.Write([]byte(flowID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validTraceToken reports whether token is the ingest token of flowID
+func validTraceToken(flowID, token string) bool {
+	got, err := hex.DecodeString(token)
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	want, _ := hex.DecodeString(TraceToken(flowID))
+	return hmac.Equal(got, want)
+}
+
+type TraceService struct {
+	traceRepo      *repository.TraceRepository
+	deploymentRepo *repository.DeploymentRepository
+}
+
+func NewTraceService(traceRepo *repository.TraceRepository, deploymentRepo *repository.DeploymentRepository) *TraceService {
+	return &TraceService{
+		traceRepo:      traceRepo,
+		deploymentRepo: deploymentRepo,
+	}
+}
+
+// Ingest stores spans reported by runners and returns how many were stored.
+// token is the runner's TraceToken; every span must be of its flow, or
+// none is stored.
+func (s *TraceService) Ingest(token string, spans []models.SpanIngestDTO) (int, error) {
+	for _, in := range spans {
+		if !validTraceToken(in.FlowID, token) {
+			return 0, ErrTraceToken
+		}
+	}
+
+	stored := 0
+	for i, in := range spans {
+		span, err := s.toSpan(in)
+		if err != nil {
+			return stored, fmt.Errorf("span[%d]: %w", i, err)
+		}
+		if err := s.traceRepo.Create(span); err != nil {
+			return stored, err
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+func (s *TraceService) toSpan(in models.SpanIngestDTO) (*models.TraceSpan, error) {
+	flowID, err := strconv.ParseInt(in.FlowID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: flow_id가 올바르지 않습니다", ErrInvalidSpan)
+	}
+	if in.TraceID == "" || in.EventID == "" || in.Step == "" {
+		return nil, fmt.Errorf("%w: trace_id, event_id, step은 필수입니다", ErrInvalidSpan)
+	}
+
+	status := in.Status
+	if status == "" {
+		status = models.SpanStatusOK
+	}
+	if status != models.SpanStatusOK && status != models.SpanStatusError && status != models.SpanStatusDropped {
+		return nil, fmt.Errorf("%w: 알 수 없는 status %q", ErrInvalidSpan, status)
+	}
+
+	emitted := in.Emitted
+	if emitted == nil {
+		emitted = []models.SpanEmit{}
+	}
+	emittedJSON, err := json.Marshal(emitted)
+	if err != nil {
+		return nil, err
+	}
+
+	sec, frac := math.Modf(in.Start)
+	span := &models.TraceSpan{
+		FlowID:     flowID,
+		TraceID:    in.TraceID,
+		EventID:    in.EventID,
+		Step:       in.Step,
+		Hops:       in.Hops,
+		Items:      in.Items,
+		Emitted:    emittedJSON,
+		Status:     status,
+		StartedAt:  time.Unix(int64(sec), int64(frac*1e9)).UTC(),
+		DurationMs: in.DurationMs,
+	}
+	if in.Start <= 0 {
+		span.StartedAt = time.Now().UTC()
+	}
+	if in.Producer != "" {
+		span.Producer = &in.Producer
+	}
+	if in.InType != "" {
+		span.InType = &in.InType
+	}
+	if in.Error != "" {
+		span.Error = &in.Error
+	}
+	return span, nil
+}
+
+func (s *TraceService) List(flowID int64, limit, offset int) ([]*models.TraceSummaryDTO, error) {
+	return s.traceRepo.ListTraces(flowID, limit, offset)
+}
+
+// Get rebuilds the path of one trace: spans are linked to the span that
+// emitted their event, aggregated per step, and compared with the steps of
+// the last deployment to find steps the event never reached.
+func (s *TraceService) Get(flowID int64, traceID string) (*models.TraceDTO, error) {
+	spans, err := s.traceRepo.FindByTrace(flowID, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, repository.ErrTraceNotFound
+	}
+
+	trace := &models.TraceDTO{
+		FlowID:    flowID,
+		TraceID:   traceID,
+		StartedAt: spans[0].StartedAt,
+		Spans:     make([]models.TraceSpanDTO, 0, len(spans)),
+	}
+
+	parentOf := make(map[string]string)
+	emittedBy := make(map[*models.TraceSpan][]models.SpanEmit, len(spans))
+	for _, sp := range spans {
+		var emitted []models.SpanEmit
+		json.Unmarshal(sp.Emitted, &emitted)
+		emittedBy[sp] = emitted
+		for _, e := range emitted {
+			parentOf[e.ID] = sp.EventID
+		}
+	}
+
+	finished := trace.StartedAt
+	byStep := make(map[string]*models.TraceStepDTO)
+	var order []string
+	for _, sp := range spans {
+		offset := float64(sp.StartedAt.Sub(trace.StartedAt).Microseconds()) / 1000
+		end := sp.StartedAt.Add(time.Duration(sp.DurationMs * float64(time.Millisecond)))
+		if end.After(finished) {
+			finished = end
+		}
+
+		dto := models.TraceSpanDTO{
+			Step:          sp.Step,
+			EventID:       sp.EventID,
+			ParentEventID: parentOf[sp.EventID],
+			Hops:          sp.Hops,
+			Items:         sp.Items,
+			Emitted:       emittedBy[sp],
+			Status:        sp.Status,
+			StartedAt:     sp.StartedAt,
+			OffsetMs:      offset,
+			DurationMs:    sp.DurationMs,
+		}
+		if dto.Emitted == nil {
+			dto.Emitted = []models.SpanEmit{}
+		}
+		if sp.Producer != nil {
+			dto.Producer = *sp.Producer
+		}
+		if sp.InType != nil {
+			dto.InType = *sp.InType
+		}
+		if sp.Error != nil {
+			dto.Error = *sp.Error
+		}
+		trace.Spans = append(trace.Spans, dto)
+
+		st, ok := byStep[sp.Step]
+		if !ok {
+			st = &models.TraceStepDTO{Step: sp.Step, FirstOffsetMs: offset}
+			byStep[sp.Step] = st
+			order = append(order, sp.Step)
+		}
+		st.Spans++
+		st.TotalMs += sp.DurationMs
+		if sp.DurationMs > st.MaxMs {
+			st.MaxMs = sp.DurationMs
+		}
+		switch sp.Status {
+		case models.SpanStatusError:
+			st.Errors++
+		case models.SpanStatusDropped:
+			st.Dropped++
+		}
+	}
+
+	trace.FinishedAt = finished
+	trace.DurationMs = float64(finished.Sub(trace.StartedAt).Microseconds()) / 1000
+
+	slowest := 0.0
+	for _, name := range order {
+		st := byStep[name]
+		trace.Steps = append(trace.Steps, *st)
+		if st.TotalMs > slowest {
+			slowest = st.TotalMs
+			trace.SlowestStep = name
+		}
+	}
+
+	if d, err := s.deploymentRepo.FindLatest(flowID); err == nil {
+		prefix := fmt.Sprintf("flow%d-", flowID)
+		for _, step := range d.ToResponseDTO().Steps {
+			appID := strings.TrimPrefix(step.KSVC, prefix)
+			if _, reached := byStep[appID]; !reached {
+				trace.MissingSteps = append(trace.MissingSteps, appID)
+			}
+		}
+		sort.Strings(trace.MissingSteps)
+	}
+
+	return trace, nil
+}
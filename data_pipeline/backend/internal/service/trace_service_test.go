@@ -0,0 +1,43 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestTraceToken(t *testing.T) {
+	token := TraceToken("12")
+	tests := []struct {
+		name   string
+		flowID string
+		token  string
+		want   bool
+	}{
+		{name: "own flow", flowID: "12", token: token, want: true},
+		{name: "other flow", flowID: "13", token: token},
+		{name: "empty", flowID: "12", token: ""},
+		{name: "not hex", flowID: "12", token: "token"},
+		{name: "truncated", flowID: "12", token: token[:32]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validTraceToken(tt.flowID, tt.token); got != tt.want {
+				t.Fatalf("valid = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestRejectsForeignSpans(t *testing.T) {
+	s := &TraceService{}
+	spans := []models.SpanIngestDTO{
+		{FlowID: "12", Step: "a", TraceID: "t", EventID: "e1"},
+		{FlowID: "13", Step: "b", TraceID: "t", EventID: "e2"},
+	}
+	// 다른 flow의 span이 하나라도 있으면 아무것도 저장하지 않는다
+	stored, err := s.Ingest(TraceToken("12"), spans)
+	if !errors.Is(err, ErrTraceToken) || stored != 0 {
+		t.Fatalf("stored %d, err = %v, want ErrTraceToken", stored, err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"bytes"
 	"context"
+	"data-pipeline-backend/internal/auth"
 	"data-pipeline-backend/internal/models"
 	"data-pipeline-backend/internal/repository"
 	"encoding/json"
@@ -18,6 +19,7 @@ import (
 
 type TrainingService struct {
 	repo            *repository.TrainingRepository
+	access          *AccessService
 	runningJobs     map[int64]*TrainingJob
 	jobStatusCache  map[int64]*models.TrainingStatusDTO
 	mu              sync.RWMutex
@@ -31,9 +33,10 @@ type TrainingJob struct {
 	LogBuffer *bytes.Buffer
 }
 
-func NewTrainingService(repo *repository.TrainingRepository) *TrainingService {
+func NewTrainingService(repo *repository.TrainingRepository, access *AccessService) *TrainingService {
 	return &TrainingService{
 		repo:           repo,
+		access:         access,
 		runningJobs:    make(map[int64]*TrainingJob),
 		jobStatusCache: make(map[int64]*models.TrainingStatusDTO),
 	}
@@ -43,7 +46,19 @@ func NewTrainingService(repo *repository.TrainingRepository) *TrainingService {
 // Training Runs
 // ============================================================
 
-func (s *TrainingService) StartTraining(req *models.TrainingRunRequestDTO) (*models.TrainingRunResponseDTO, error) {
+// StartTraining queues a training run. The caller needs editor on the
+// project the run goes to and viewer on the dataset it trains on.
+func (s *TrainingService) StartTraining(ctx context.Context, req *models.TrainingRunRequestDTO) (*models.TrainingRunResponseDTO, error) {
+	createdBy, err := s.requireCreate(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DatasetID != nil {
+		if _, err := s.GetDataset(ctx, *req.DatasetID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create training run record
 	run := &models.TrainingRun{
 		Name:            req.Name,
@@ -53,6 +68,8 @@ func (s *TrainingService) StartTraining(req *models.TrainingRunRequestDTO) (*mod
 		ModelType:       req.ModelType,
 		Hyperparameters: req.Hyperparameters,
 		DatasetID:       req.DatasetID,
+		CreatedBy:       createdBy,
+		ProjectID:       req.ProjectID,
 	}
 
 	// Set default hyperparameters if not provided
@@ -191,7 +208,12 @@ func (s *TrainingService) executeTraining(run *models.TrainingRun) {
 	s.repo.UpdateTrainingRunComplete(run.RunID, outputPath, metrics)
 }
 
-func (s *TrainingService) GetTrainingStatus(runID int64) (*models.TrainingStatusDTO, error) {
+func (s *TrainingService) GetTrainingStatus(ctx context.Context, runID int64) (*models.TrainingStatusDTO, error) {
+	run, err := s.GetTrainingRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
 	s.mu.RLock()
 	status, exists := s.jobStatusCache[runID]
 	s.mu.RUnlock()
@@ -200,19 +222,23 @@ func (s *TrainingService) GetTrainingStatus(runID int64) (*models.TrainingStatus
 		return status, nil
 	}
 
-	// Get from database
-	run, err := s.repo.GetTrainingRun(runID)
-	if err != nil {
-		return nil, err
-	}
-
 	return &models.TrainingStatusDTO{
 		RunID:  runID,
 		Status: run.Status,
 	}, nil
 }
 
-func (s *TrainingService) CancelTraining(runID int64) error {
+// CancelTraining stops a running training job; the caller needs editor on
+// the run
+func (s *TrainingService) CancelTraining(ctx context.Context, runID int64) error {
+	run, err := s.repo.GetTrainingRun(runID)
+	if err != nil {
+		return err
+	}
+	if err := s.access.RequireResource(ctx, run.ProjectID, run.CreatedBy, models.ProjectRoleEditor); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	job, exists := s.runningJobs[runID]
 	s.mu.Unlock()
@@ -225,12 +251,19 @@ func (s *TrainingService) CancelTraining(runID int64) error {
 	return nil
 }
 
-func (s *TrainingService) ListTrainingRuns(limit, offset int) ([]*models.TrainingRun, error) {
-	return s.repo.ListTrainingRuns(limit, offset)
+func (s *TrainingService) ListTrainingRuns(ctx context.Context, limit, offset int) ([]*models.TrainingRun, error) {
+	return s.repo.ListTrainingRuns(limit, offset, visibleTo(ctx))
 }
 
-func (s *TrainingService) GetTrainingRun(id int64) (*models.TrainingRun, error) {
-	return s.repo.GetTrainingRun(id)
+func (s *TrainingService) GetTrainingRun(ctx context.Context, id int64) (*models.TrainingRun, error) {
+	run, err := s.repo.GetTrainingRun(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireResource(ctx, run.ProjectID, run.CreatedBy, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return run, nil
 }
 
 func (s *TrainingService) updateJobStatus(runID int64, status *models.TrainingStatusDTO) {
@@ -243,7 +276,15 @@ func (s *TrainingService) updateJobStatus(runID int64, status *models.TrainingSt
 // Feedbacks
 // ============================================================
 
-func (s *TrainingService) CreateFeedback(req *models.FeedbackRequestDTO) (*models.Feedback, error) {
+// CreateFeedback stores feedback on generated code; feedback on a flow's
+// node needs editor on the flow
+func (s *TrainingService) CreateFeedback(ctx context.Context, req *models.FeedbackRequestDTO) (*models.Feedback, error) {
+	if req.FlowID != nil {
+		if _, err := s.access.RequireFlow(ctx, *req.FlowID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
 	fb := &models.Feedback{
 		FeedbackType:      req.FeedbackType,
 		InputPrompt:       req.InputPrompt,
@@ -304,13 +345,20 @@ func (s *TrainingService) GetFeedbackStats() (map[string]interface{}, error) {
 // Datasets
 // ============================================================
 
-func (s *TrainingService) CreateDataset(req *models.DatasetRequestDTO) (*models.Dataset, error) {
+func (s *TrainingService) CreateDataset(ctx context.Context, req *models.DatasetRequestDTO) (*models.Dataset, error) {
+	createdBy, err := s.requireCreate(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	ds := &models.Dataset{
 		Name:        req.Name,
 		Version:     req.Version,
 		Description: req.Description,
 		Config:      req.Config,
 		Status:      "building",
+		CreatedBy:   createdBy,
+		ProjectID:   req.ProjectID,
 	}
 
 	if err := s.repo.CreateDataset(ds); err != nil {
@@ -320,13 +368,20 @@ func (s *TrainingService) CreateDataset(req *models.DatasetRequestDTO) (*models.
 	return ds, nil
 }
 
-func (s *TrainingService) BuildDataset(req *models.DatasetBuildRequestDTO) (*models.Dataset, error) {
+func (s *TrainingService) BuildDataset(ctx context.Context, req *models.DatasetBuildRequestDTO) (*models.Dataset, error) {
+	createdBy, err := s.requireCreate(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create dataset record
 	ds := &models.Dataset{
 		Name:        req.Name,
 		Version:     req.Version,
 		Description: req.Description,
 		Status:      "building",
+		CreatedBy:   createdBy,
+		ProjectID:   req.ProjectID,
 	}
 
 	if err := s.repo.CreateDataset(ds); err != nil {
@@ -430,15 +485,30 @@ func (s *TrainingService) writeFeedbacksToJSONL(feedbacks []*models.Feedback, fi
 	return nil
 }
 
-func (s *TrainingService) ListDatasets(limit, offset int) ([]*models.Dataset, error) {
-	return s.repo.ListDatasets(limit, offset)
+func (s *TrainingService) ListDatasets(ctx context.Context, limit, offset int) ([]*models.Dataset, error) {
+	return s.repo.ListDatasets(limit, offset, visibleTo(ctx))
 }
 
-func (s *TrainingService) GetDataset(id int64) (*models.Dataset, error) {
-	return s.repo.GetDataset(id)
+func (s *TrainingService) GetDataset(ctx context.Context, id int64) (*models.Dataset, error) {
+	ds, err := s.repo.GetDataset(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireResource(ctx, ds.ProjectID, ds.CreatedBy, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return ds, nil
 }
 
-func (s *TrainingService) SetActiveDataset(id int64) error {
+// SetActiveDataset makes the dataset the one used for training; deployers only
+func (s *TrainingService) SetActiveDataset(ctx context.Context, id int64) error {
+	ds, err := s.repo.GetDataset(id)
+	if err != nil {
+		return err
+	}
+	if err := s.access.RequireResource(ctx, ds.ProjectID, ds.CreatedBy, models.ProjectRoleDeployer); err != nil {
+		return err
+	}
 	return s.repo.SetActiveDataset(id)
 }
 
@@ -446,7 +516,12 @@ func (s *TrainingService) SetActiveDataset(id int64) error {
 // Models
 // ============================================================
 
-func (s *TrainingService) CreateModel(req *models.ModelRequestDTO) (*models.Model, error) {
+func (s *TrainingService) CreateModel(ctx context.Context, req *models.ModelRequestDTO) (*models.Model, error) {
+	createdBy, err := s.requireCreate(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &models.Model{
 		Name:        req.Name,
 		Version:     req.Version,
@@ -455,6 +530,8 @@ func (s *TrainingService) CreateModel(req *models.ModelRequestDTO) (*models.Mode
 		ModelType:   req.ModelType,
 		ModelPath:   req.ModelPath,
 		AdapterPath: req.AdapterPath,
+		CreatedBy:   createdBy,
+		ProjectID:   req.ProjectID,
 	}
 
 	if err := s.repo.CreateModel(m); err != nil {
@@ -464,25 +541,42 @@ func (s *TrainingService) CreateModel(req *models.ModelRequestDTO) (*models.Mode
 	return m, nil
 }
 
-func (s *TrainingService) ListModels(limit, offset int) ([]*models.Model, error) {
-	return s.repo.ListModels(limit, offset)
+func (s *TrainingService) ListModels(ctx context.Context, limit, offset int) ([]*models.Model, error) {
+	return s.repo.ListModels(limit, offset, visibleTo(ctx))
 }
 
-func (s *TrainingService) GetModel(id int64) (*models.Model, error) {
-	return s.repo.GetModel(id)
+func (s *TrainingService) GetModel(ctx context.Context, id int64) (*models.Model, error) {
+	m, err := s.repo.GetModel(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.RequireResource(ctx, m.ProjectID, m.CreatedBy, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (s *TrainingService) GetActiveModel() (*models.Model, error) {
 	return s.repo.GetActiveModel()
 }
 
-func (s *TrainingService) ActivateModel(req *models.ModelActivateRequestDTO) (*models.ModelDeployResponseDTO, error) {
+// ActivateModel serves the model; deployers may activate, only admins may
+// mark it as production
+func (s *TrainingService) ActivateModel(ctx context.Context, req *models.ModelActivateRequestDTO) (*models.ModelDeployResponseDTO, error) {
 	// Get model
 	model, err := s.repo.GetModel(req.ModelID)
 	if err != nil {
 		return nil, err
 	}
 
+	role := models.ProjectRoleDeployer
+	if req.IsProduction {
+		role = models.ProjectRoleAdmin
+	}
+	if err := s.access.RequireResource(ctx, model.ProjectID, model.CreatedBy, role); err != nil {
+		return nil, err
+	}
+
 	// Generate Ollama model name if not provided
 	ollamaName := req.OllamaName
 	if ollamaName == "" {
@@ -553,7 +647,9 @@ PARAMETER top_k 40
 // Evaluations
 // ============================================================
 
-func (s *TrainingService) RunEvaluation(req *models.EvaluationRequestDTO) (*models.EvaluationResultDTO, error) {
+// RunEvaluation evaluates a model; the caller needs editor on the model and
+// viewer on the dataset
+func (s *TrainingService) RunEvaluation(ctx context.Context, req *models.EvaluationRequestDTO) (*models.EvaluationResultDTO, error) {
 	startTime := time.Now()
 
 	// Get model
@@ -561,6 +657,14 @@ func (s *TrainingService) RunEvaluation(req *models.EvaluationRequestDTO) (*mode
 	if err != nil {
 		return nil, err
 	}
+	if err := s.access.RequireResource(ctx, model.ProjectID, model.CreatedBy, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+	if req.DatasetID != nil {
+		if _, err := s.GetDataset(ctx, *req.DatasetID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Run evaluation (simplified - in production this would run actual tests)
 	eval := &models.Evaluation{
@@ -613,7 +717,11 @@ func (s *TrainingService) RunEvaluation(req *models.EvaluationRequestDTO) (*mode
 	}, nil
 }
 
-func (s *TrainingService) ListEvaluations(modelID int64) ([]*models.Evaluation, error) {
+// ListEvaluations returns the evaluations of a model the caller may see
+func (s *TrainingService) ListEvaluations(ctx context.Context, modelID int64) ([]*models.Evaluation, error) {
+	if _, err := s.GetModel(ctx, modelID); err != nil {
+		return nil, err
+	}
 	return s.repo.ListEvaluationsByModel(modelID)
 }
 
@@ -636,3 +744,31 @@ func derefStr(s *string) string {
 	}
 	return *s
 }
+
+// requireCreate checks that the caller may add a training run, dataset or
+// model to the project (editor) and returns the caller's ID as creator
+func (s *TrainingService) requireCreate(ctx context.Context, projectID *int64) (*int64, error) {
+	if projectID != nil {
+		if err := s.access.RequireProject(ctx, *projectID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+	if user := auth.UserFromContext(ctx); user != nil {
+		return &user.ID, nil
+	}
+	return nil, nil
+}
+
+// visibleTo is the user training runs, datasets and models are listed for;
+// nil for admins
+func visibleTo(ctx context.Context) *int64 {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		var none int64 = -1
+		return &none
+	}
+	if user.Admin {
+		return nil
+	}
+	return &user.ID
+}
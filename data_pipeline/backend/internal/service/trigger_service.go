@@ -0,0 +1,954 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/workspace"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultTriggerMaxActive = 4
+	maxTriggerMaxActive     = 100
+	// firings keep the payload up to this size
+	maxFiringPayload = 64 * 1024
+	// a file trigger looks at no more files than this per poll
+	maxWatchedFiles = 10000
+	// how often a consumer waiting for a free run slot looks again
+	triggerWaitInterval = time.Second
+	// how far a webhook timestamp may be from the server clock
+	webhookTolerance = 5 * time.Minute
+	// how long accepted webhook deliveries are remembered
+	webhookDeliveryRetention = 24 * time.Hour
+)
+
+var (
+	ErrTriggerSignature = errors.New("웹훅 서명이 올바르지 않습니다")
+	ErrTriggerDisabled  = errors.New("비활성화된 트리거입니다")
+	ErrTriggerBusy      = errors.New("트리거의 실행 중인 run이 max_active에 도달했습니다")
+	ErrTriggerDuplicate = errors.New("이미 처리된 웹훅 요청입니다")
+)
+
+// triggerRepository is the storage of triggers and their firings
+type triggerRepository interface {
+	Create(t *models.FlowTrigger) error
+	FindByID(id int64) (*models.FlowTrigger, error)
+	FindByFlow(flowID int64) ([]*models.FlowTrigger, error)
+	FindAll() ([]*models.FlowTrigger, error)
+	FindAccessible(userID int64) ([]*models.FlowTrigger, error)
+	FindEnabled(kind string) ([]*models.FlowTrigger, error)
+	Update(t *models.FlowTrigger) error
+	UpdateState(id int64, state json.RawMessage) error
+	UpdateStatus(id int64, lastFiredAt *time.Time, lastError *string) error
+	Delete(id int64) error
+	CreateFiring(f *models.TriggerFiring) error
+	FindFirings(triggerID int64, limit, offset int) ([]*models.TriggerFiring, error)
+	ClaimDelivery(triggerID int64, deliveryID string, keep time.Duration) (bool, error)
+}
+
+// triggerRuns counts the runs a trigger has going
+type triggerRuns interface {
+	ActiveForTrigger(triggerID int64) ([]int64, error)
+}
+
+// triggerLauncher starts the runs of a trigger
+type triggerLauncher interface {
+	Launch(ctx context.Context, launch models.WorkflowLaunch) (*models.WorkflowRun, error)
+}
+
+// WebhookDelivery is a webhook request as received. Signature is the hex
+// HMAC-SHA256 of "<timestamp>.<body>", optionally as sha256=<hex>, and
+// Timestamp the unix seconds it was signed at. DeliveryID, when given, is
+// the sender's ID of the delivery, the same across its retries.
+type WebhookDelivery struct {
+	Body       []byte
+	Signature  string
+	Timestamp  string
+	DeliveryID string
+	Source     string
+}
+
+// fileTriggerConfig is the config of a file trigger: the location as in a
+// data step (dataSource file with an optional projectId, or s3 with
+// connection, bucket and prefix), the directory watched in it and a glob
+// the file names must match
+type fileTriggerConfig struct {
+	DataSource string `json:"dataSource,omitempty"`
+	ProjectID  *int64 `json:"projectId,omitempty"`
+	Connection string `json:"connection,omitempty"`
+	Bucket     string `json:"bucket,omitempty"`
+	Prefix     string `json:"prefix,omitempty"`
+	Dir        string `json:"dir,omitempty"`
+	Pattern    string `json:"pattern,omitempty"`
+	Recursive  bool   `json:"recursive,omitempty"`
+}
+
+// kafkaTriggerConfig is the config of a Kafka trigger
+type kafkaTriggerConfig struct {
+	Brokers     []string `json:"brokers,omitempty"` // default KAFKA_BOOTSTRAP
+	Topic       string   `json:"topic"`
+	GroupID     string   `json:"groupId,omitempty"`     // default flow-trigger-<id>
+	StartOffset string   `json:"startOffset,omitempty"` // where a new group starts: latest (default) or earliest
+}
+
+// fileTriggerState is what a file trigger has seen: files modified after
+// Since are new, as are those modified at Since that are not in Seen
+type fileTriggerState struct {
+	Ready bool      `json:"ready"`
+	Since time.Time `json:"since"`
+	Seen  []string  `json:"seen,omitempty"`
+}
+
+// TriggerService manages flow triggers and fires them. Webhooks fire on
+// whichever backend receives them; Run watches the file and Kafka triggers
+// and, like the scheduler, runs only on the elected leader. Triggered runs
+// execute as the user who last saved the trigger.
+type TriggerService struct {
+	repo       triggerRepository
+	userRepo   userLookup
+	access     *AccessService
+	workspaces *WorkspaceService
+	runs       triggerRuns
+	workflows  triggerLauncher
+	admins     map[string]bool
+	interval   time.Duration
+	brokers    []string
+}
+
+func NewTriggerService(repo *repository.FlowTriggerRepository, userRepo *repository.UserRepository, access *AccessService,
+	workspaces *WorkspaceService, runs *WorkflowRunService, workflows *WorkflowService, admins []string,
+	interval time.Duration, kafkaBootstrap string) *TriggerService {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &TriggerService{
+		repo:       repo,
+		userRepo:   userRepo,
+		access:     access,
+		workspaces: workspaces,
+		runs:       runs,
+		workflows:  workflows,
+		admins:     adminSet(admins),
+		interval:   interval,
+		brokers:    splitList(kafkaBootstrap),
+	}
+}
+
+// Create adds a trigger to a flow; the caller needs deployer on the flow. A
+// webhook trigger comes back with its key, which is not shown again.
+func (s *TriggerService) Create(ctx context.Context, req *models.FlowTriggerRequestDTO) (*models.FlowTrigger, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, fmt.Errorf("%w: 로그인이 필요합니다", ErrForbidden)
+	}
+	if req.FlowID == nil {
+		return nil, errors.New("f_id는 필수입니다")
+	}
+	if req.Kind == nil {
+		return nil, errors.New("kind는 필수입니다")
+	}
+	if _, err := s.access.RequireFlow(ctx, *req.FlowID, models.ProjectRoleDeployer); err != nil {
+		return nil, err
+	}
+
+	trigger := &models.FlowTrigger{
+		FlowID:    *req.FlowID,
+		Kind:      *req.Kind,
+		Enabled:   true,
+		MaxActive: defaultTriggerMaxActive,
+		RunAs:     &user.ID,
+	}
+	applyTriggerRequest(trigger, req)
+	if err := s.prepare(ctx, trigger, true, true); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(trigger); err != nil {
+		return nil, err
+	}
+	return trigger, nil
+}
+
+// List returns the triggers of a flow, or of every flow the caller may see
+func (s *TriggerService) List(ctx context.Context, flowID *int64) ([]*models.FlowTrigger, error) {
+	if flowID != nil {
+		if _, err := s.access.RequireFlow(ctx, *flowID, models.ProjectRoleViewer); err != nil {
+			return nil, err
+		}
+		return s.repo.FindByFlow(*flowID)
+	}
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return []*models.FlowTrigger{}, nil
+	}
+	if user.Admin {
+		return s.repo.FindAll()
+	}
+	return s.repo.FindAccessible(user.ID)
+}
+
+// Get returns a trigger the caller may see
+func (s *TriggerService) Get(ctx context.Context, id int64) (*models.FlowTrigger, error) {
+	return s.find(ctx, id, models.ProjectRoleViewer)
+}
+
+// Update changes a trigger. The caller becomes the user its runs execute
+// as; a file trigger given a new config or re-enabled only fires for the
+// files that appear from then on.
+func (s *TriggerService) Update(ctx context.Context, id int64, req *models.FlowTriggerRequestDTO) (*models.FlowTrigger, error) {
+	trigger, err := s.find(ctx, id, models.ProjectRoleDeployer)
+	if err != nil {
+		return nil, err
+	}
+	if req.FlowID != nil && *req.FlowID != trigger.FlowID {
+		return nil, errors.New("트리거의 flow는 바꿀 수 없습니다")
+	}
+	if req.Kind != nil && *req.Kind != trigger.Kind {
+		return nil, errors.New("트리거의 kind는 바꿀 수 없습니다")
+	}
+
+	wasEnabled := trigger.Enabled
+	applyTriggerRequest(trigger, req)
+	if user := auth.UserFromContext(ctx); user != nil {
+		trigger.RunAs = &user.ID
+	}
+	rewatch := req.Config != nil || (trigger.Enabled && !wasEnabled)
+	if err := s.prepare(ctx, trigger, rewatch, req.RotateSecret); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(trigger); err != nil {
+		return nil, err
+	}
+	return trigger, nil
+}
+
+// Delete removes a trigger and its firings; its runs are kept
+func (s *TriggerService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.find(ctx, id, models.ProjectRoleDeployer); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// Firings returns the latest firings of a trigger the caller may see
+func (s *TriggerService) Firings(ctx context.Context, id int64, limit, offset int) ([]*models.TriggerFiring, error) {
+	if _, err := s.find(ctx, id, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.FindFirings(id, limit, offset)
+}
+
+func (s *TriggerService) find(ctx context.Context, id int64, role string) (*models.FlowTrigger, error) {
+	trigger, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.RequireFlow(ctx, trigger.FlowID, role); err != nil {
+		return nil, err
+	}
+	return trigger, nil
+}
+
+func applyTriggerRequest(trigger *models.FlowTrigger, req *models.FlowTriggerRequestDTO) {
+	if req.Name != nil {
+		trigger.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Enabled != nil {
+		trigger.Enabled = *req.Enabled
+	}
+	if req.Config != nil {
+		trigger.Config = req.Config
+	}
+	if req.MaxActive != nil {
+		trigger.MaxActive = *req.MaxActive
+	}
+}
+
+// prepare validates a trigger and normalises its config. rewatch restarts
+// what a file trigger has seen from the files there now; rotate gives a
+// webhook trigger a new key, as does its creation.
+func (s *TriggerService) prepare(ctx context.Context, trigger *models.FlowTrigger, rewatch, rotate bool) error {
+	if len(trigger.Name) > 100 {
+		return errors.New("트리거 이름은 100자 이하여야 합니다")
+	}
+	if trigger.MaxActive < 1 || trigger.MaxActive > maxTriggerMaxActive {
+		return fmt.Errorf("max_active는 1에서 %d 사이여야 합니다", maxTriggerMaxActive)
+	}
+
+	var config interface{}
+	switch trigger.Kind {
+	case models.TriggerKindFile:
+		cfg, err := decodeFileTriggerConfig(trigger.Config)
+		if err != nil {
+			return err
+		}
+		if rewatch {
+			state := fileTriggerState{}
+			if trigger.Enabled {
+				files, err := s.listFiles(ctx, cfg)
+				if err != nil {
+					return fmt.Errorf("감시할 위치를 열 수 없습니다: %w", err)
+				}
+				state = baselineFiles(files)
+			}
+			if trigger.State, err = json.Marshal(state); err != nil {
+				return err
+			}
+		}
+		config = cfg
+	case models.TriggerKindWebhook:
+		if len(trigger.Config) > 0 && !bytes.Equal(bytes.TrimSpace(trigger.Config), []byte("{}")) &&
+			!bytes.Equal(bytes.TrimSpace(trigger.Config), []byte("null")) {
+			return errors.New("webhook 트리거는 config를 받지 않습니다")
+		}
+		if rotate || trigger.Secret == nil {
+			if err := generateWebhookSecret(trigger); err != nil {
+				return err
+			}
+		}
+		config = struct{}{}
+	case models.TriggerKindKafka:
+		cfg, err := decodeKafkaTriggerConfig(trigger.Config)
+		if err != nil {
+			return err
+		}
+		if len(cfg.Brokers) == 0 && len(s.brokers) == 0 {
+			return errors.New("brokers가 필요합니다 (KAFKA_BOOTSTRAP이 설정되지 않았습니다)")
+		}
+		config = cfg
+	default:
+		return fmt.Errorf("kind는 file, webhook, kafka 중 하나여야 합니다: %q", trigger.Kind)
+	}
+
+	normalized, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	trigger.Config = normalized
+	return nil
+}
+
+func decodeFileTriggerConfig(raw json.RawMessage) (*fileTriggerConfig, error) {
+	cfg := &fileTriggerConfig{}
+	if err := decodeTriggerConfig(raw, cfg); err != nil {
+		return nil, err
+	}
+	switch cfg.DataSource {
+	case "", "file":
+		cfg.DataSource = "file"
+		cfg.Connection, cfg.Bucket, cfg.Prefix = "", "", ""
+	case "s3":
+		if cfg.Connection == "" {
+			return nil, errors.New("s3 파일 트리거에는 connection이 필요합니다")
+		}
+		cfg.ProjectID = nil
+	default:
+		return nil, fmt.Errorf("dataSource는 file 또는 s3여야 합니다: %q", cfg.DataSource)
+	}
+	if _, err := workspace.Clean(cfg.Dir); err != nil {
+		return nil, fmt.Errorf("잘못된 dir입니다: %q", cfg.Dir)
+	}
+	if _, err := path.Match(cfg.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("잘못된 pattern입니다: %q", cfg.Pattern)
+	}
+	return cfg, nil
+}
+
+func decodeKafkaTriggerConfig(raw json.RawMessage) (*kafkaTriggerConfig, error) {
+	cfg := &kafkaTriggerConfig{}
+	if err := decodeTriggerConfig(raw, cfg); err != nil {
+		return nil, err
+	}
+	cfg.Topic = strings.TrimSpace(cfg.Topic)
+	if cfg.Topic == "" {
+		return nil, errors.New("kafka 트리거에는 topic이 필요합니다")
+	}
+	switch cfg.StartOffset {
+	case "", "latest", "earliest":
+	default:
+		return nil, fmt.Errorf("startOffset은 latest 또는 earliest여야 합니다: %q", cfg.StartOffset)
+	}
+	return cfg, nil
+}
+
+// decodeTriggerConfig reads a config object, refusing unknown fields
+func decodeTriggerConfig(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("잘못된 config입니다: %v", err)
+	}
+	return nil
+}
+
+// generateWebhookSecret gives a webhook trigger a new random key, kept
+// encrypted; the plain key is only set on the returned trigger
+func generateWebhookSecret(trigger *models.FlowTrigger) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	plain := hex.EncodeToString(key)
+	sealed, err := sealSecret([]byte(plain))
+	if err != nil {
+		return err
+	}
+	trigger.Secret, trigger.WebhookSecret = sealed, plain
+	return nil
+}
+
+// Webhook fires a webhook trigger for a delivery signed with its key within
+// webhookTolerance of now. A delivery is fired once: a retry with the same
+// delivery ID, or a replay of the same signature, gets ErrTriggerDuplicate.
+// A JSON object body is the evt; anything else is passed as evt["value"].
+func (s *TriggerService) Webhook(ctx context.Context, id int64, delivery WebhookDelivery) (*models.TriggerFiring, error) {
+	trigger, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.Kind != models.TriggerKindWebhook {
+		return nil, repository.ErrFlowTriggerNotFound
+	}
+	mac, err := verifyWebhook(trigger, delivery, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !trigger.Enabled {
+		return nil, ErrTriggerDisabled
+	}
+
+	evt := eventPayload(delivery.Body)
+	free, err := s.capacity(trigger)
+	if err != nil {
+		return nil, err
+	}
+	if free <= 0 {
+		// 발신자가 다시 보낼 수 있도록 전달 ID는 기록하지 않는다
+		firing := &models.TriggerFiring{TriggerID: trigger.ID, Status: models.TriggerFiringSkipped, Source: delivery.Source, Payload: firingPayload(evt)}
+		msg := ErrTriggerBusy.Error()
+		firing.Error = &msg
+		s.record(firing, nil)
+		return firing, ErrTriggerBusy
+	}
+
+	key := "sha256=" + hex.EncodeToString(mac)
+	if delivery.DeliveryID != "" {
+		key = "id=" + delivery.DeliveryID
+	}
+	fresh, err := s.repo.ClaimDelivery(trigger.ID, key, webhookDeliveryRetention)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrTriggerDuplicate
+	}
+	return s.fire(ctx, trigger, evt, delivery.Source)
+}
+
+// verifyWebhook checks the signature and timestamp of a delivery and
+// returns its MAC
+func verifyWebhook(trigger *models.FlowTrigger, delivery WebhookDelivery, now time.Time) ([]byte, error) {
+	if len(trigger.Secret) == 0 {
+		return nil, ErrTriggerSignature
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(delivery.Timestamp), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 타임스탬프가 없거나 올바르지 않습니다", ErrTriggerSignature)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > webhookTolerance || skew < -webhookTolerance {
+		return nil, fmt.Errorf("%w: 타임스탬프가 허용 범위를 벗어났습니다", ErrTriggerSignature)
+	}
+	key, err := openSecret(trigger.Secret)
+	if err != nil {
+		return nil, err
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(delivery.Signature), "sha256="))
+	if err != nil || len(got) == 0 {
+		return nil, ErrTriggerSignature
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(delivery.Body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return nil, ErrTriggerSignature
+	}
+	return got, nil
+}
+
+// capacity is how many more runs the trigger may start now
+func (s *TriggerService) capacity(trigger *models.FlowTrigger) (int, error) {
+	active, err := s.runs.ActiveForTrigger(trigger.ID)
+	if err != nil {
+		return 0, err
+	}
+	return trigger.MaxActive - len(active), nil
+}
+
+// fire starts a run of the trigger's flow with evt and records the firing.
+// The error is that of the launch; the firing is recorded either way.
+func (s *TriggerService) fire(ctx context.Context, trigger *models.FlowTrigger, evt map[string]interface{}, source string) (*models.TriggerFiring, error) {
+	firing := &models.TriggerFiring{TriggerID: trigger.ID, Source: source, Payload: firingPayload(evt)}
+	now := time.Now().UTC()
+	run, err := s.launchRun(ctx, trigger, evt)
+	if err != nil {
+		msg := "failed to start run: " + err.Error()
+		firing.Status, firing.Error = models.TriggerFiringFailed, &msg
+		log.Printf("triggers: trigger %d: %s", trigger.ID, msg)
+	} else {
+		firing.Status, firing.RunID = models.TriggerFiringStarted, &run.ID
+	}
+	s.record(firing, &now)
+	return firing, err
+}
+
+func (s *TriggerService) launchRun(ctx context.Context, trigger *models.FlowTrigger, evt map[string]interface{}) (*models.WorkflowRun, error) {
+	ctx, err := runAsContext(ctx, s.userRepo, s.admins, trigger.RunAs)
+	if err != nil {
+		return nil, err
+	}
+
+	id := trigger.ID
+	return s.workflows.Launch(ctx, models.WorkflowLaunch{
+		FlowID:    trigger.FlowID,
+		Trigger:   trigger.Kind,
+		TriggerID: &id,
+		Event:     evt,
+	})
+}
+
+// record stores a firing and its outcome on the trigger
+func (s *TriggerService) record(firing *models.TriggerFiring, firedAt *time.Time) {
+	if err := s.repo.CreateFiring(firing); err != nil {
+		log.Printf("triggers: trigger %d: failed to record firing: %v", firing.TriggerID, err)
+	}
+	if err := s.repo.UpdateStatus(firing.TriggerID, firedAt, firing.Error); err != nil {
+		log.Printf("triggers: trigger %d: %v", firing.TriggerID, err)
+	}
+}
+
+// fail records why a watcher could not look at a trigger
+func (s *TriggerService) fail(id int64, err error) {
+	log.Printf("triggers: trigger %d: %v", id, err)
+	msg := err.Error()
+	if err := s.repo.UpdateStatus(id, nil, &msg); err != nil {
+		log.Printf("triggers: trigger %d: %v", id, err)
+	}
+}
+
+// eventPayload turns a webhook body or Kafka message into an evt: a JSON
+// object as it is, any other value under "value"
+func eventPayload(data []byte) map[string]interface{} {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return map[string]interface{}{}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// 큰 정수가 float로 바뀌지 않도록 숫자는 그대로 둔다
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return map[string]interface{}{"value": string(data)}
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{"value": v}
+}
+
+// firingPayload is the payload a firing keeps, nil when too large
+func firingPayload(evt map[string]interface{}) json.RawMessage {
+	data, err := json.Marshal(evt)
+	if err != nil || len(data) > maxFiringPayload {
+		return nil
+	}
+	return data
+}
+
+// Run watches the enabled file and Kafka triggers until ctx ends: watched
+// locations are listed every interval and each Kafka trigger has a
+// consumer, restarted when its settings change
+func (s *TriggerService) Run(ctx context.Context) {
+	consumers := map[int64]*kafkaConsumer{}
+	defer func() {
+		for _, c := range consumers {
+			c.stop()
+		}
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.pollFiles(ctx)
+		s.syncConsumers(ctx, consumers)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TriggerService) pollFiles(ctx context.Context) {
+	triggers, err := s.repo.FindEnabled(models.TriggerKindFile)
+	if err != nil {
+		log.Printf("triggers: failed to load file triggers: %v", err)
+		return
+	}
+	for _, trigger := range triggers {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.pollFileTrigger(ctx, trigger); err != nil {
+			s.fail(trigger.ID, err)
+		}
+	}
+}
+
+// pollFileTrigger fires a file trigger for the new files in its location,
+// oldest first, as far as max_active allows; the rest wait for the next poll
+func (s *TriggerService) pollFileTrigger(ctx context.Context, trigger *models.FlowTrigger) error {
+	free, err := s.capacity(trigger)
+	if err != nil || free <= 0 {
+		return err
+	}
+	cfg, err := decodeFileTriggerConfig(trigger.Config)
+	if err != nil {
+		return err
+	}
+	var state fileTriggerState
+	if len(trigger.State) > 0 {
+		if err := json.Unmarshal(trigger.State, &state); err != nil {
+			state = fileTriggerState{}
+		}
+	}
+
+	userCtx, err := runAsContext(ctx, s.userRepo, s.admins, trigger.RunAs)
+	if err != nil {
+		return err
+	}
+	files, err := s.listFiles(userCtx, cfg)
+	if err != nil {
+		return err
+	}
+	if !state.Ready {
+		return s.saveFileState(trigger.ID, baselineFiles(files))
+	}
+
+	for _, file := range newFiles(files, state) {
+		if free == 0 || ctx.Err() != nil {
+			break
+		}
+		s.fire(ctx, trigger, fileEvent(cfg, file), file.Path)
+		free--
+		// 실패한 발화도 기록되었으므로 다시 발화하지 않는다
+		if file.ModTime.After(state.Since) {
+			state.Since, state.Seen = file.ModTime, nil
+		}
+		state.Seen = append(state.Seen, file.Path)
+		if err := s.saveFileState(trigger.ID, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TriggerService) saveFileState(id int64, state fileTriggerState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateState(id, data)
+}
+
+// listFiles returns the files of a file trigger's location that match its
+// pattern; a directory that does not exist yet has none
+func (s *TriggerService) listFiles(ctx context.Context, cfg *fileTriggerConfig) ([]*workspace.Entry, error) {
+	var ws workspace.Workspace
+	var err error
+	if cfg.DataSource == "s3" {
+		ws, err = s.workspaces.OpenS3(ctx, S3Location{Connection: cfg.Connection, Bucket: cfg.Bucket, Prefix: cfg.Prefix})
+	} else {
+		ws, err = s.workspaces.Open(ctx, cfg.ProjectID, models.ProjectRoleViewer)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+
+	files := []*workspace.Entry{}
+	offset := 0
+	for len(files) < maxWatchedFiles {
+		page, err := ws.List(cfg.Dir, workspace.ListOptions{Recursive: cfg.Recursive, Limit: 1000, Offset: offset})
+		if errors.Is(err, workspace.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			if entry.IsDir {
+				continue
+			}
+			if cfg.Pattern != "" {
+				if ok, _ := path.Match(cfg.Pattern, path.Base(entry.Path)); !ok {
+					continue
+				}
+			}
+			files = append(files, entry)
+		}
+		if page.NextOffset == nil {
+			break
+		}
+		offset = *page.NextOffset
+	}
+	return files, nil
+}
+
+// baselineFiles is the state of a trigger that has seen files
+func baselineFiles(files []*workspace.Entry) fileTriggerState {
+	state := fileTriggerState{Ready: true}
+	for _, file := range files {
+		switch {
+		case file.ModTime.After(state.Since):
+			state.Since, state.Seen = file.ModTime, []string{file.Path}
+		case file.ModTime.Equal(state.Since):
+			state.Seen = append(state.Seen, file.Path)
+		}
+	}
+	return state
+}
+
+// newFiles returns the files state has not seen, oldest first
+func newFiles(files []*workspace.Entry, state fileTriggerState) []*workspace.Entry {
+	seen := make(map[string]bool, len(state.Seen))
+	for _, p := range state.Seen {
+		seen[p] = true
+	}
+	var fresh []*workspace.Entry
+	for _, file := range files {
+		if file.ModTime.After(state.Since) || (file.ModTime.Equal(state.Since) && !seen[file.Path]) {
+			fresh = append(fresh, file)
+		}
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		if !fresh[i].ModTime.Equal(fresh[j].ModTime) {
+			return fresh[i].ModTime.Before(fresh[j].ModTime)
+		}
+		return fresh[i].Path < fresh[j].Path
+	})
+	return fresh
+}
+
+// fileEvent is the evt of a new file: where it is, in the keys a data
+// step's config uses, and its size and modification time
+func fileEvent(cfg *fileTriggerConfig, file *workspace.Entry) map[string]interface{} {
+	evt := map[string]interface{}{
+		"dataSource": cfg.DataSource,
+		"path":       file.Path,
+		"name":       path.Base(file.Path),
+		"size":       file.Size,
+		"modTime":    file.ModTime.UTC().Format(time.RFC3339Nano),
+	}
+	if file.Format != "" {
+		evt["format"] = file.Format
+	}
+	if cfg.ProjectID != nil {
+		evt["projectId"] = *cfg.ProjectID
+	}
+	if cfg.DataSource == "s3" {
+		evt["connection"], evt["bucket"], evt["prefix"] = cfg.Connection, cfg.Bucket, cfg.Prefix
+	}
+	return evt
+}
+
+// kafkaConsumer is the running consumer of a Kafka trigger
+type kafkaConsumer struct {
+	key    string // the settings it runs with
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (c *kafkaConsumer) stop() {
+	c.cancel()
+	<-c.done
+}
+
+func (c *kafkaConsumer) running() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// syncConsumers runs one consumer per enabled Kafka trigger
+func (s *TriggerService) syncConsumers(ctx context.Context, consumers map[int64]*kafkaConsumer) {
+	triggers, err := s.repo.FindEnabled(models.TriggerKindKafka)
+	if err != nil {
+		log.Printf("triggers: failed to load kafka triggers: %v", err)
+		return
+	}
+
+	want := make(map[int64]bool, len(triggers))
+	for _, trigger := range triggers {
+		cfg, err := s.kafkaConfig(trigger)
+		if err != nil {
+			s.fail(trigger.ID, err)
+			continue
+		}
+		want[trigger.ID] = true
+		key := fmt.Sprintf("%s|%s|%s|%s", strings.Join(cfg.Brokers, ","), cfg.Topic, cfg.GroupID, cfg.StartOffset)
+		if c, ok := consumers[trigger.ID]; ok {
+			if c.key == key && c.running() {
+				continue
+			}
+			c.stop()
+		}
+
+		consumerCtx, cancel := context.WithCancel(ctx)
+		c := &kafkaConsumer{key: key, cancel: cancel, done: make(chan struct{})}
+		consumers[trigger.ID] = c
+		go func(id int64) {
+			defer close(c.done)
+			s.consume(consumerCtx, id, cfg)
+		}(trigger.ID)
+	}
+
+	for id, c := range consumers {
+		if !want[id] {
+			c.stop()
+			delete(consumers, id)
+		}
+	}
+}
+
+// kafkaConfig is the config of a Kafka trigger with its defaults filled in
+func (s *TriggerService) kafkaConfig(trigger *models.FlowTrigger) (*kafkaTriggerConfig, error) {
+	cfg, err := decodeKafkaTriggerConfig(trigger.Config)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Brokers) == 0 {
+		cfg.Brokers = s.brokers
+	}
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka trigger has no brokers and KAFKA_BOOTSTRAP is not set")
+	}
+	if cfg.GroupID == "" {
+		cfg.GroupID = fmt.Sprintf("flow-trigger-%d", trigger.ID)
+	}
+	return cfg, nil
+}
+
+// consume fires a Kafka trigger for each message of its topic. A message is
+// committed once its firing is recorded, so a message arriving while
+// max_active runs are active waits in the topic.
+func (s *TriggerService) consume(ctx context.Context, id int64, cfg *kafkaTriggerConfig) {
+	startOffset := kafka.LastOffset
+	if cfg.StartOffset == "earliest" {
+		startOffset = kafka.FirstOffset
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.Brokers,
+		Topic:       cfg.Topic,
+		GroupID:     cfg.GroupID,
+		StartOffset: startOffset,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.fail(id, fmt.Errorf("kafka %s: %w", cfg.Topic, err))
+			if !sleepContext(ctx, s.interval) {
+				return
+			}
+			continue
+		}
+
+		trigger, ok := s.waitCapacity(ctx, id)
+		if !ok {
+			return
+		}
+		source := fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset)
+		s.fire(ctx, trigger, eventPayload(msg.Value), source)
+		if err := reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			s.fail(id, fmt.Errorf("kafka %s: commit %s: %w", cfg.Topic, source, err))
+		}
+	}
+}
+
+// waitCapacity waits until the trigger may start a run and returns it as
+// now stored; false once ctx ends or the trigger is disabled or deleted
+func (s *TriggerService) waitCapacity(ctx context.Context, id int64) (*models.FlowTrigger, bool) {
+	for {
+		trigger, err := s.repo.FindByID(id)
+		if err == repository.ErrFlowTriggerNotFound || (err == nil && !trigger.Enabled) {
+			return nil, false
+		}
+		if err == nil {
+			var free int
+			if free, err = s.capacity(trigger); err == nil && free > 0 {
+				return trigger, true
+			}
+		}
+		if err != nil {
+			log.Printf("triggers: trigger %d: %v", id, err)
+		}
+		if !sleepContext(ctx, triggerWaitInterval) {
+			return nil, false
+		}
+	}
+}
+
+// sleepContext waits for d; false when ctx ended first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
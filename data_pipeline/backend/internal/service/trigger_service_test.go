@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useSecretsKey configures a SECRETS_KEY for the test
+func useSecretsKey(t *testing.T) {
+	t.Helper()
+	// Setenv의 복원 뒤에 다시 읽도록 먼저 등록한다
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("SECRETS_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if _, err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func webhookTrigger(t *testing.T) *models.FlowTrigger {
+	t.Helper()
+	runAs := int64(7)
+	trigger := &models.FlowTrigger{ID: 3, FlowID: 5, Kind: models.TriggerKindWebhook, Enabled: true, MaxActive: 2, RunAs: &runAs}
+	if err := generateWebhookSecret(trigger); err != nil {
+		t.Fatal(err)
+	}
+	return trigger
+}
+
+// signedDelivery is a delivery of body signed with key at ts
+func signedDelivery(key string, ts time.Time, body string) WebhookDelivery {
+	stamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(stamp + "." + body))
+	return WebhookDelivery{
+		Body:      []byte(body),
+		Signature: "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		Timestamp: stamp,
+		Source:    "10.0.0.1:5000",
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	useSecretsKey(t)
+	trigger := webhookTrigger(t)
+	now := time.Unix(1_800_000_000, 0)
+	body := `{"path": "in.csv"}`
+
+	tests := []struct {
+		name     string
+		trigger  *models.FlowTrigger
+		delivery func() WebhookDelivery
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			delivery: func() WebhookDelivery { return signedDelivery(trigger.WebhookSecret, now, body) },
+		},
+		{
+			name: "without the sha256 prefix",
+			delivery: func() WebhookDelivery {
+				d := signedDelivery(trigger.WebhookSecret, now, body)
+				d.Signature = strings.TrimPrefix(d.Signature, "sha256=")
+				return d
+			},
+		},
+		{
+			name:     "slightly early clock",
+			delivery: func() WebhookDelivery { return signedDelivery(trigger.WebhookSecret, now.Add(time.Minute), body) },
+		},
+		{
+			name:     "wrong key",
+			delivery: func() WebhookDelivery { return signedDelivery("other", now, body) },
+			wantErr:  true,
+		},
+		{
+			name: "body changed",
+			delivery: func() WebhookDelivery {
+				d := signedDelivery(trigger.WebhookSecret, now, body)
+				d.Body = []byte(`{"path": "other.csv"}`)
+				return d
+			},
+			wantErr: true,
+		},
+		{
+			name: "timestamp changed",
+			delivery: func() WebhookDelivery {
+				d := signedDelivery(trigger.WebhookSecret, now.Add(-10*time.Minute), body)
+				d.Timestamp = strconv.FormatInt(now.Unix(), 10)
+				return d
+			},
+			wantErr: true,
+		},
+		{
+			name:     "stale",
+			delivery: func() WebhookDelivery { return signedDelivery(trigger.WebhookSecret, now.Add(-6*time.Minute), body) },
+			wantErr:  true,
+		},
+		{
+			name:     "from the future",
+			delivery: func() WebhookDelivery { return signedDelivery(trigger.WebhookSecret, now.Add(6*time.Minute), body) },
+			wantErr:  true,
+		},
+		{
+			name: "no timestamp",
+			delivery: func() WebhookDelivery {
+				d := signedDelivery(trigger.WebhookSecret, now, body)
+				d.Timestamp = ""
+				return d
+			},
+			wantErr: true,
+		},
+		{
+			name: "no signature",
+			delivery: func() WebhookDelivery {
+				d := signedDelivery(trigger.WebhookSecret, now, body)
+				d.Signature = ""
+				return d
+			},
+			wantErr: true,
+		},
+		{
+			name:     "trigger without a key",
+			trigger:  &models.FlowTrigger{Kind: models.TriggerKindWebhook},
+			delivery: func() WebhookDelivery { return signedDelivery(trigger.WebhookSecret, now, body) },
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := tt.trigger
+			if tr == nil {
+				tr = trigger
+			}
+			_, err := verifyWebhook(tr, tt.delivery(), now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTriggerSignature) {
+					t.Fatalf("err = %v, want ErrTriggerSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// fakeTriggerRepo keeps one trigger and what is recorded about it
+type fakeTriggerRepo struct {
+	triggerRepository
+	trigger    *models.FlowTrigger
+	firings    []*models.TriggerFiring
+	deliveries map[string]bool
+}
+
+func (r *fakeTriggerRepo) FindByID(id int64) (*models.FlowTrigger, error) {
+	if r.trigger == nil || r.trigger.ID != id {
+		return nil, repository.ErrFlowTriggerNotFound
+	}
+	copied := *r.trigger
+	return &copied, nil
+}
+
+func (r *fakeTriggerRepo) CreateFiring(f *models.TriggerFiring) error {
+	f.ID = int64(len(r.firings) + 1)
+	r.firings = append(r.firings, f)
+	return nil
+}
+
+func (r *fakeTriggerRepo) UpdateStatus(id int64, lastFiredAt *time.Time, lastError *string) error {
+	return nil
+}
+
+func (r *fakeTriggerRepo) ClaimDelivery(triggerID int64, deliveryID string, keep time.Duration) (bool, error) {
+	if r.deliveries == nil {
+		r.deliveries = map[string]bool{}
+	}
+	key := strconv.FormatInt(triggerID, 10) + "/" + deliveryID
+	if r.deliveries[key] {
+		return false, nil
+	}
+	r.deliveries[key] = true
+	return true, nil
+}
+
+type fakeTriggerRuns struct{ active []int64 }
+
+func (r *fakeTriggerRuns) ActiveForTrigger(triggerID int64) ([]int64, error) {
+	return r.active, nil
+}
+
+var errLaunch = errors.New("flow has no steps")
+
+type fakeTriggerLauncher struct {
+	err      error
+	launched []models.WorkflowLaunch
+}
+
+func (l *fakeTriggerLauncher) Launch(ctx context.Context, launch models.WorkflowLaunch) (*models.WorkflowRun, error) {
+	l.launched = append(l.launched, launch)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &models.WorkflowRun{ID: int64(100 + len(l.launched))}, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) FindByID(id int64) (*models.User, error) {
+	return &models.User{ID: id, Username: "runner"}, nil
+}
+
+func TestWebhook(t *testing.T) {
+	useSecretsKey(t)
+	base := webhookTrigger(t)
+
+	tests := []struct {
+		name       string
+		disabled   bool
+		active     int
+		launchErr  error
+		delivery   func(now time.Time) WebhookDelivery
+		wantErr    error
+		wantStatus string // "" when no firing is recorded
+		wantLaunch bool
+	}{
+		{
+			name:       "started",
+			wantStatus: models.TriggerFiringStarted,
+			wantLaunch: true,
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			wantErr:  ErrTriggerDisabled,
+		},
+		{
+			name:     "bad signature is checked before disabled",
+			disabled: true,
+			delivery: func(now time.Time) WebhookDelivery { return signedDelivery("other", now, `{}`) },
+			wantErr:  ErrTriggerSignature,
+		},
+		{
+			name:       "busy",
+			active:     2,
+			wantErr:    ErrTriggerBusy,
+			wantStatus: models.TriggerFiringSkipped,
+		},
+		{
+			name:       "failed start",
+			launchErr:  errLaunch,
+			wantErr:    errLaunch,
+			wantStatus: models.TriggerFiringFailed,
+			wantLaunch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := *base
+			trigger.Enabled = !tt.disabled
+			repo := &fakeTriggerRepo{trigger: &trigger}
+			launcher := &fakeTriggerLauncher{err: tt.launchErr}
+			s := &TriggerService{
+				repo:      repo,
+				userRepo:  fakeUsers{},
+				runs:      &fakeTriggerRuns{active: make([]int64, tt.active)},
+				workflows: launcher,
+			}
+			delivery := signedDelivery(trigger.WebhookSecret, time.Now(), `{"path": "in.csv"}`)
+			if tt.delivery != nil {
+				delivery = tt.delivery(time.Now())
+			}
+
+			firing, err := s.Webhook(context.Background(), trigger.ID, delivery)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantStatus == "" {
+				if firing != nil || len(repo.firings) != 0 {
+					t.Fatalf("firing = %+v, recorded %d, want none", firing, len(repo.firings))
+				}
+			} else {
+				if firing == nil || firing.Status != tt.wantStatus {
+					t.Fatalf("firing = %+v, want status %s", firing, tt.wantStatus)
+				}
+				if len(repo.firings) != 1 || repo.firings[0] != firing {
+					t.Fatalf("recorded %v, want the firing", repo.firings)
+				}
+				if string(firing.Payload) != `{"path":"in.csv"}` || firing.Source != delivery.Source {
+					t.Fatalf("firing = %+v, want the payload and source of the delivery", firing)
+				}
+				if (tt.wantStatus == models.TriggerFiringStarted) != (firing.RunID != nil) {
+					t.Fatalf("run id = %v with status %s", firing.RunID, firing.Status)
+				}
+				if (tt.wantStatus == models.TriggerFiringStarted) != (firing.Error == nil) {
+					t.Fatalf("error = %v with status %s", firing.Error, firing.Status)
+				}
+			}
+
+			if got := len(launcher.launched) == 1; got != tt.wantLaunch {
+				t.Fatalf("launched %v, want a launch: %v", launcher.launched, tt.wantLaunch)
+			}
+			if tt.wantLaunch {
+				launch := launcher.launched[0]
+				if launch.FlowID != trigger.FlowID || launch.TriggerID == nil || *launch.TriggerID != trigger.ID ||
+					launch.Event["path"] != "in.csv" {
+					t.Fatalf("launch = %+v, want the trigger's flow and event", launch)
+				}
+			}
+			// 바쁠 때는 전달을 기록하지 않아 재전송이 발화할 수 있다
+			if tt.wantErr == ErrTriggerBusy && len(repo.deliveries) != 0 {
+				t.Fatalf("deliveries = %v, want none while busy", repo.deliveries)
+			}
+		})
+	}
+}
+
+func TestWebhookFiresDeliveryOnce(t *testing.T) {
+	useSecretsKey(t)
+	trigger := webhookTrigger(t)
+	repo := &fakeTriggerRepo{trigger: trigger}
+	launcher := &fakeTriggerLauncher{}
+	s := &TriggerService{repo: repo, userRepo: fakeUsers{}, runs: &fakeTriggerRuns{}, workflows: launcher}
+	ctx := context.Background()
+
+	// 같은 서명을 다시 보내면 재생으로 본다
+	replayed := signedDelivery(trigger.WebhookSecret, time.Now(), `{"n": 1}`)
+	if _, err := s.Webhook(ctx, trigger.ID, replayed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Webhook(ctx, trigger.ID, replayed); !errors.Is(err, ErrTriggerDuplicate) {
+		t.Fatalf("replay: err = %v, want ErrTriggerDuplicate", err)
+	}
+
+	// 재시도는 새로 서명되어도 전달 ID가 같으면 한 번만 발화한다
+	first := signedDelivery(trigger.WebhookSecret, time.Now().Add(-time.Minute), `{"n": 2}`)
+	first.DeliveryID = "evt-42"
+	retry := signedDelivery(trigger.WebhookSecret, time.Now(), `{"n": 2}`)
+	retry.DeliveryID = "evt-42"
+	if _, err := s.Webhook(ctx, trigger.ID, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Webhook(ctx, trigger.ID, retry); !errors.Is(err, ErrTriggerDuplicate) {
+		t.Fatalf("retry: err = %v, want ErrTriggerDuplicate", err)
+	}
+
+	if len(launcher.launched) != 2 || len(repo.firings) != 2 {
+		t.Fatalf("launched %d, recorded %d, want 2 of each", len(launcher.launched), len(repo.firings))
+	}
+}
+
+func TestWebhookNotAWebhookTrigger(t *testing.T) {
+	useSecretsKey(t)
+	trigger := webhookTrigger(t)
+	trigger.Kind = models.TriggerKindKafka
+	s := &TriggerService{repo: &fakeTriggerRepo{trigger: trigger}}
+	_, err := s.Webhook(context.Background(), trigger.ID, signedDelivery(trigger.WebhookSecret, time.Now(), `{}`))
+	if !errors.Is(err, repository.ErrFlowTriggerNotFound) {
+		t.Fatalf("err = %v, want ErrFlowTriggerNotFound", err)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// loginTouchInterval throttles last_login_at updates
+const loginTouchInterval = 5 * time.Minute
+
+var ErrUsernameTaken = errors.New("username is already taken by another identity")
+
+type UserService struct {
+	userRepo      *repository.UserRepository
+	usernameClaim string
+	admins        map[string]bool
+}
+
+func NewUserService(userRepo *repository.UserRepository, usernameClaim string, admins []string) *UserService {
+	adminSet := make(map[string]bool, len(admins))
+	for _, a := range admins {
+		adminSet[a] = true
+	}
+	return &UserService{userRepo: userRepo, usernameClaim: usernameClaim, admins: adminSet}
+}
+
+// Resolve maps verified token claims to a user, creating it on first sight.
+// The username is fixed at creation so the user's namespace never moves.
+func (s *UserService) Resolve(claims auth.Claims) (*models.User, error) {
+	sub := claims.String("sub")
+	if sub == "" {
+		return nil, fmt.Errorf("token has no sub claim")
+	}
+	subject := claims.String("iss") + "|" + sub
+
+	var email *string
+	if e := claims.String("email"); e != "" {
+		email = &e
+	}
+
+	name := claims.String(s.usernameClaim)
+	if name == "" && email != nil {
+		name = strings.SplitN(*email, "@", 2)[0]
+	}
+	if name == "" {
+		name = sub
+	}
+	return s.resolve(subject, name, email)
+}
+
+// ResolveDev returns the user every request runs as when auth is disabled
+func (s *UserService) ResolveDev(name string) (*models.User, error) {
+	user, err := s.resolve("local|"+name, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	user.Admin = true
+	return user, nil
+}
+
+func (s *UserService) resolve(subject, name string, email *string) (*models.User, error) {
+	user, err := s.userRepo.FindBySubject(subject)
+	if err == repository.ErrUserNotFound {
+		user, err = s.create(subject, name, email)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	emailChanged := email != nil && (user.Email == nil || *user.Email != *email)
+	if emailChanged || time.Since(user.LastLoginAt) > loginTouchInterval {
+		if email != nil {
+			user.Email = email
+		}
+		if err := s.userRepo.TouchLogin(user); err != nil {
+			return nil, err
+		}
+	}
+
+	user.Admin = s.admins[user.Username]
+	return user, nil
+}
+
+func (s *UserService) create(subject, name string, email *string) (*models.User, error) {
+	username := usernameLabel(name)
+	if username == "" {
+		return nil, fmt.Errorf("cannot derive a username from %q", name)
+	}
+	if _, err := s.userRepo.FindByUsername(username); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUsernameTaken, username)
+	} else if err != repository.ErrUserNotFound {
+		return nil, err
+	}
+
+	user := &models.User{Subject: subject, Username: username, Email: email}
+	if err := s.userRepo.Create(user); err != nil {
+		// 동시에 들어온 첫 요청이 먼저 만들었을 수 있다
+		if existing, findErr := s.userRepo.FindBySubject(subject); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// usernameLabel turns a claim into a DNS label that fits "user-<name>"
+func usernameLabel(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	label := b.String()
+	if len(label) > 57 {
+		label = label[:57]
+	}
+	return strings.Trim(label, "-")
+}
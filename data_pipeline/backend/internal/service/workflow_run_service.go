@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/repository"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrWorkflowRunFinished is returned when cancelling a run that has ended
+var ErrWorkflowRunFinished = errors.New("이미 끝난 실행입니다")
+
+const (
+	workflowRunHeartbeat = 30 * time.Second
+	// a run whose server has not refreshed it for this long is failed
+	workflowRunStaleAfter = 3 * workflowRunHeartbeat
+
+	maxWorkflowRunPage = 200
+)
+
+// WorkflowRunEvent is a change of a running workflow: the run itself when
+// it starts or ends, or one of its steps
+type WorkflowRunEvent struct {
+	Run  *models.WorkflowRun
+	Step *models.WorkflowRunStep
+}
+
+// WorkflowRunService stores workflow runs and publishes their progress to
+// the subscribers of this server. Runs are executed by the server that
+// created them, which keeps their heartbeat fresh; runs left behind by a
+// stopped server are failed by the others.
+type WorkflowRunService struct {
+	repo     *repository.WorkflowRunRepository
+	flowRepo *repository.FlowRepository
+	access   *AccessService
+
+	mu   sync.Mutex
+	live map[int64]*liveWorkflowRun // runs executing here
+}
+
+type liveWorkflowRun struct {
+	subs   []chan WorkflowRunEvent
+	cancel context.CancelFunc
+}
+
+func NewWorkflowRunService(repo *repository.WorkflowRunRepository, flowRepo *repository.FlowRepository, access *AccessService) *WorkflowRunService {
+	return &WorkflowRunService{
+		repo:     repo,
+		flowRepo: flowRepo,
+		access:   access,
+		live:     make(map[int64]*liveWorkflowRun),
+	}
+}
+
+// Create stores a pending run for the caller. Steps are stored as given;
+// a run of a stored flow becomes the flow's latest run.
+func (s *WorkflowRunService) Create(ctx context.Context, run *models.WorkflowRun) error {
+	if user := auth.UserFromContext(ctx); user != nil {
+		run.Actor = user.Username
+		run.CreatedBy = &user.ID
+	}
+	if run.Trigger == "" {
+		run.Trigger = models.WorkflowTriggerManual
+	}
+	if run.FlowID != nil {
+		flow, err := s.flowRepo.FindByID(*run.FlowID)
+		if err != nil {
+			return err
+		}
+		run.ProjectID = flow.ProjectID
+	}
+	run.Status = models.WorkflowRunPending
+	for _, step := range run.Steps {
+		step.Status = models.WorkflowRunPending
+	}
+	if err := s.repo.Create(run); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.live[run.ID] = &liveWorkflowRun{}
+	s.mu.Unlock()
+
+	if run.FlowID != nil {
+		if err := s.flowRepo.UpdateLatestRunID(*run.FlowID, run.ID, run.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start marks a run as running; cancel stops its execution
+func (s *WorkflowRunService) Start(run *models.WorkflowRun, cancel context.CancelFunc) error {
+	s.mu.Lock()
+	if live := s.live[run.ID]; live != nil {
+		live.cancel = cancel
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	run.Status = models.WorkflowRunRunning
+	run.StartedAt = &now
+	err := s.repo.Start(run.ID, now)
+	s.publish(run.ID, WorkflowRunEvent{Run: runSummary(run)})
+	return err
+}
+
+// StepStarted marks a step as running
+func (s *WorkflowRunService) StepStarted(step *models.WorkflowRunStep) error {
+	now := time.Now()
+	step.Status = models.WorkflowRunRunning
+	step.StartedAt = &now
+	return s.UpdateStep(step)
+}
+
+// UpdateStep stores the state of a step
+func (s *WorkflowRunService) UpdateStep(step *models.WorkflowRunStep) error {
+	err := s.repo.UpdateStep(step)
+	copied := *step
+	s.publish(step.RunID, WorkflowRunEvent{Step: &copied})
+	return err
+}
+
+// Finish records the end of a run and closes its subscriptions
+func (s *WorkflowRunService) Finish(run *models.WorkflowRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	if run.StartedAt != nil {
+		ms := now.Sub(*run.StartedAt).Milliseconds()
+		run.DurationMs = &ms
+	}
+	err := s.repo.Finish(run)
+
+	s.mu.Lock()
+	live := s.live[run.ID]
+	delete(s.live, run.ID)
+	s.mu.Unlock()
+	if live == nil {
+		return err
+	}
+
+	event := WorkflowRunEvent{Run: runSummary(run)}
+	for _, ch := range live.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+		close(ch)
+	}
+	return err
+}
+
+// Get returns a run with its steps if the caller may see it
+func (s *WorkflowRunService) Get(ctx context.Context, id int64) (*models.WorkflowRun, error) {
+	run, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	have, err := s.access.ResourceRole(auth.UserFromContext(ctx), run.ProjectID, run.CreatedBy, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(have, models.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Cancel stops a pending or running run; the caller needs editor on it
+func (s *WorkflowRunService) Cancel(ctx context.Context, id int64) (*models.WorkflowRun, error) {
+	run, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	have, err := s.access.ResourceRole(auth.UserFromContext(ctx), run.ProjectID, run.CreatedBy, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(have, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+	if run.Finished() {
+		return nil, ErrWorkflowRunFinished
+	}
+	return run, s.RequestCancel(id)
+}
+
+// RequestCancel stops a run executing here, or flags it for the server
+// executing it, which notices on its next heartbeat. Unlike Cancel it does
+// not check the caller; the scheduler uses it for its own runs.
+func (s *WorkflowRunService) RequestCancel(id int64) error {
+	s.mu.Lock()
+	live := s.live[id]
+	s.mu.Unlock()
+	if live != nil && live.cancel != nil {
+		live.cancel()
+		return nil
+	}
+	return s.repo.RequestCancel(id)
+}
+
+// ActiveForSchedule returns the ids of the pending and running runs of a
+// schedule
+func (s *WorkflowRunService) ActiveForSchedule(scheduleID int64) ([]int64, error) {
+	return s.repo.FindActiveBySchedule(scheduleID)
+}
+
+// ActiveForTrigger returns the ids of the pending and running runs of a
+// flow trigger
+func (s *WorkflowRunService) ActiveForTrigger(triggerID int64) ([]int64, error) {
+	return s.repo.FindActiveByTrigger(triggerID)
+}
+
+// List returns the runs the caller may see, newest first
+func (s *WorkflowRunService) List(ctx context.Context, filter *models.WorkflowRunFilter, limit, offset int) ([]*models.WorkflowRun, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, ErrForbidden
+	}
+	if !user.Admin {
+		filter.UserID = &user.ID
+	}
+	if limit <= 0 || limit > maxWorkflowRunPage {
+		limit = maxWorkflowRunPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	runs, err := s.repo.List(filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	// 목록에서는 요청 본문을 생략한다
+	for _, run := range runs {
+		run.Request = nil
+	}
+	return runs, nil
+}
+
+// Subscribe returns the events of a run executing on this server. The
+// channel is closed when the run finishes; ok is false when the run is not
+// executing here, and the caller has to poll instead.
+func (s *WorkflowRunService) Subscribe(id int64) (events <-chan WorkflowRunEvent, cancel func(), ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	live := s.live[id]
+	if live == nil {
+		return nil, func() {}, false
+	}
+	ch := make(chan WorkflowRunEvent, 64)
+	live.subs = append(live.subs, ch)
+	cancel = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, c := range live.subs {
+			if c == ch {
+				live.subs = append(live.subs[:i], live.subs[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel, true
+}
+
+// publish sends an event to the subscribers of a run without blocking; a
+// subscriber that falls behind misses events and catches up on the final run
+func (s *WorkflowRunService) publish(id int64, event WorkflowRunEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	live := s.live[id]
+	if live == nil {
+		return
+	}
+	for _, ch := range live.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Heartbeat keeps the runs executing here fresh, stops those cancelled on
+// other servers and fails the stale runs of stopped servers, starting with
+// those left by this server's previous life. It runs until the process exits.
+func (s *WorkflowRunService) Heartbeat() {
+	s.failStale()
+	ticker := time.NewTicker(workflowRunHeartbeat)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		ids := make([]int64, 0, len(s.live))
+		for id := range s.live {
+			ids = append(ids, id)
+		}
+		s.mu.Unlock()
+		if len(ids) > 0 {
+			cancelled, err := s.repo.Heartbeat(ids)
+			if err != nil {
+				log.Printf("workflow run heartbeat failed: %v", err)
+			}
+			for _, id := range cancelled {
+				s.mu.Lock()
+				live := s.live[id]
+				s.mu.Unlock()
+				if live != nil && live.cancel != nil {
+					live.cancel()
+				}
+			}
+		}
+		s.failStale()
+	}
+}
+
+func (s *WorkflowRunService) failStale() {
+	n, err := s.repo.FailStale(workflowRunStaleAfter, "interrupted: the server running it stopped")
+	if err != nil {
+		log.Printf("failed to clean up stale workflow runs: %v", err)
+	} else if n > 0 {
+		log.Printf("marked %d stale workflow run(s) as failed", n)
+	}
+}
+
+// runSummary copies a run without its steps and request for an event
+func runSummary(run *models.WorkflowRun) *models.WorkflowRun {
+	copied := *run
+	copied.Steps = nil
+	copied.Request = nil
+	return &copied
+}
@@ -0,0 +1,727 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/dataformat"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/staging"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Workflow step types; "source", "transform" and "sink" are accepted as
+// aliases of the first, second and last
+const (
+	workflowStepData   = "data"
+	workflowStepPython = "python"
+	workflowStepJoin   = "join"
+	workflowStepSave   = "save"
+)
+
+const (
+	defaultWorkflowParallelism = 4
+	maxWorkflowParallelism     = 16
+)
+
+// WorkflowService runs workflows of sources, Python transforms, joins and
+// sinks, for /api/workflow/execute and for schedules and triggers. Each step
+// receives the output of its inputs; independent branches run in parallel
+// and a failed step skips the steps that depend on it. Runs are stored
+// through WorkflowRunService.
+type WorkflowService struct {
+	flows   *FlowService
+	objects *ObjectService
+	data    *DataService
+	python  *PythonStepService
+	runs    *WorkflowRunService
+}
+
+func NewWorkflowService(flows *FlowService, objects *ObjectService, data *DataService, python *PythonStepService,
+	runs *WorkflowRunService) *WorkflowService {
+	return &WorkflowService{
+		flows:   flows,
+		objects: objects,
+		data:    data,
+		python:  python,
+		runs:    runs,
+	}
+}
+
+// WorkflowPlan is a planned workflow. inputs holds the indexes of each
+// step's inputs; steps are kept in an order where inputs come first.
+type WorkflowPlan struct {
+	s           *WorkflowService
+	steps       []models.WorkflowStep
+	inputs      [][]int
+	event       map[string]interface{} // input of the python steps without inputs
+	parallelism int
+	onStart     func(i int)                                   // called as step i starts
+	onStep      func(i int, result models.WorkflowStepResult) // called as step i finishes or is skipped
+}
+
+// Plan resolves the steps of req and checks that they form a DAG
+func (s *WorkflowService) Plan(ctx context.Context, req models.WorkflowExecuteRequest) (*WorkflowPlan, error) {
+	var steps []models.WorkflowStep
+	var err error
+	switch {
+	case len(req.Steps) > 0:
+		steps = req.Steps
+	case req.FlowID != nil:
+		if steps, err = s.flowSteps(ctx, *req.FlowID); err != nil {
+			return nil, err
+		}
+	default:
+		steps = legacyWorkflowSteps(req)
+	}
+
+	index := make(map[string]int, len(steps))
+	for i := range steps {
+		step := &steps[i]
+		if step.ObjectID != nil {
+			if err := s.resolveObjectStep(ctx, step); err != nil {
+				return nil, err
+			}
+		}
+		if step.ID == "" {
+			step.ID = strconv.Itoa(i + 1)
+		}
+		if _, dup := index[step.ID]; dup {
+			return nil, fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		index[step.ID] = i
+		if step.Type = normalizeStepType(step.Type); step.Type == "" {
+			return nil, fmt.Errorf("step %q: unknown type", step.ID)
+		}
+		if step.Name == "" {
+			step.Name = step.ID
+		}
+	}
+
+	inputs := make([][]int, len(steps))
+	ids := make([]int64, len(steps))
+	var edges []models.EdgeDTO
+	for i, step := range steps {
+		ids[i] = int64(i)
+		names := step.Inputs
+		if names == nil && step.Type != workflowStepData && i > 0 {
+			names = []string{steps[i-1].ID}
+		}
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("step %q: unknown input %q", step.ID, name)
+			}
+			if steps[j].Type == workflowStepSave {
+				return nil, fmt.Errorf("step %q: save step %q has no output", step.ID, name)
+			}
+			inputs[i] = append(inputs[i], j)
+			edges = append(edges, models.EdgeDTO{Source: int64(j), Target: int64(i)})
+		}
+		if len(inputs[i]) == 0 && step.Type == workflowStepPython && req.Event != nil {
+			// 트리거의 이벤트가 입력이다
+			continue
+		}
+		if want := stepArity(step.Type); len(inputs[i]) != want {
+			return nil, fmt.Errorf("step %q: %s steps take %d input(s), got %d", step.ID, step.Type, want, len(inputs[i]))
+		}
+	}
+
+	order, err := NewFlowGraph(ids, edges).TopologicalOrder()
+	if err != nil {
+		var cycle *CycleError
+		if errors.As(err, &cycle) {
+			var names []string
+			for _, i := range cycle.Nodes {
+				names = append(names, steps[i].ID)
+			}
+			return nil, fmt.Errorf("workflow steps form a cycle: %s", strings.Join(names, ", "))
+		}
+		return nil, err
+	}
+
+	// 결과는 입력이 앞서는 순서로 보고한다
+	plan := &WorkflowPlan{s: s, event: req.Event, parallelism: req.Parallelism}
+	position := make([]int, len(steps))
+	for p, i := range order {
+		position[i] = p
+	}
+	for _, i := range order {
+		plan.steps = append(plan.steps, steps[i])
+		var in []int
+		for _, j := range inputs[i] {
+			in = append(in, position[j])
+		}
+		plan.inputs = append(plan.inputs, in)
+	}
+	if plan.parallelism <= 0 {
+		plan.parallelism = defaultWorkflowParallelism
+	}
+	plan.parallelism = min(plan.parallelism, maxWorkflowParallelism)
+	return plan, nil
+}
+
+// Start stores record as a run of plan and executes it in the background,
+// detached from ctx but as its user. record is left as created; done
+// receives the results when the run ends.
+func (s *WorkflowService) Start(ctx context.Context, plan *WorkflowPlan, record *models.WorkflowRun) (<-chan models.WorkflowExecuteResponse, error) {
+	record.Steps = make([]*models.WorkflowRunStep, len(plan.steps))
+	for i, step := range plan.steps {
+		record.Steps[i] = &models.WorkflowRunStep{Position: i, StepID: step.ID, Name: step.Name, Type: step.Type}
+	}
+	if err := s.runs.Create(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// 응답으로 나가는 record와 따로 실행 중의 상태를 기록한다
+	state := *record
+	state.Steps = make([]*models.WorkflowRunStep, len(record.Steps))
+	for i, step := range record.Steps {
+		copied := *step
+		state.Steps[i] = &copied
+	}
+
+	runs := s.runs
+	plan.onStart = func(i int) {
+		if err := runs.StepStarted(state.Steps[i]); err != nil {
+			log.Printf("workflow run %d: failed to record step %q: %v", state.ID, state.Steps[i].StepID, err)
+		}
+	}
+	plan.onStep = func(i int, result models.WorkflowStepResult) {
+		step := state.Steps[i]
+		recordStepResult(step, result)
+		if err := runs.UpdateStep(step); err != nil {
+			log.Printf("workflow run %d: failed to record step %q: %v", state.ID, step.StepID, err)
+		}
+	}
+
+	done := make(chan models.WorkflowExecuteResponse, 1)
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		defer cancel()
+		if err := runs.Start(&state, cancel); err != nil {
+			log.Printf("workflow run %d: failed to record start: %v", state.ID, err)
+		}
+		response := plan.execute(ctx)
+		response.RunID = state.ID
+
+		state.Status = models.WorkflowRunSuccess
+		if !response.Success {
+			state.Status = models.WorkflowRunFailed
+			if ctx.Err() != nil {
+				state.Status = models.WorkflowRunCancelled
+				response.Error = "Workflow run cancelled"
+			}
+			state.Error = &response.Error
+		}
+		if response.OutputPath != "" {
+			state.OutputPath = &response.OutputPath
+		}
+		if err := runs.Finish(&state); err != nil {
+			log.Printf("workflow run %d: failed to record result: %v", state.ID, err)
+		}
+		done <- response
+	}()
+	return done, nil
+}
+
+// Launch starts a background run of a stored flow for a schedule or another
+// trigger, as the user in ctx
+func (s *WorkflowService) Launch(ctx context.Context, launch models.WorkflowLaunch) (*models.WorkflowRun, error) {
+	req := models.WorkflowExecuteRequest{FlowID: &launch.FlowID, Event: launch.Event}
+	plan, err := s.Plan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	record := &models.WorkflowRun{
+		FlowID:     &launch.FlowID,
+		ScheduleID: launch.ScheduleID,
+		TriggerID:  launch.TriggerID,
+		Trigger:    launch.Trigger,
+		Request:    body,
+	}
+	if _, err := s.Start(ctx, plan, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// recordStepResult copies the result of a finished step into its record
+func recordStepResult(step *models.WorkflowRunStep, result models.WorkflowStepResult) {
+	now := time.Now()
+	duration := result.DurationMs
+	step.FinishedAt = &now
+	step.DurationMs = &duration
+	switch {
+	case result.Skipped:
+		step.Status = models.WorkflowStepSkipped
+		step.DurationMs = nil
+	case result.Success:
+		step.Status = models.WorkflowRunSuccess
+	default:
+		step.Status = models.WorkflowRunFailed
+	}
+	if result.Rows != nil {
+		rows := int64(*result.Rows)
+		step.Rows = &rows
+	}
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	step.OutputPath = optional(result.Path)
+	step.Message = optional(result.Message)
+	step.Error = optional(result.Error)
+	step.Logs = optional(result.Logs)
+}
+
+// legacyWorkflowSteps turns the Data → Python → Save request into steps
+func legacyWorkflowSteps(req models.WorkflowExecuteRequest) []models.WorkflowStep {
+	return []models.WorkflowStep{
+		{ID: "load", Name: "Load Data", Type: workflowStepData, Config: req.DataConfig},
+		{ID: "python", Name: "Execute Python", Type: workflowStepPython, Code: req.PythonCode, ChunkRows: req.ChunkRows},
+		{ID: "save", Name: "Save Data", Type: workflowStepSave, Config: req.SaveConfig},
+	}
+}
+
+// flowSteps builds the steps of a stored flow from its objects and edges.
+// A flow without edges runs as a chain in object order.
+func (s *WorkflowService) flowSteps(ctx context.Context, flowID int64) ([]models.WorkflowStep, error) {
+	graph, err := s.flows.GetGraph(ctx, flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int64]*models.ObjectResponseDTO, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		nodes[n.ID] = n
+	}
+	preds := make(map[int64][]string)
+	for _, e := range graph.Edges {
+		preds[e.Target] = append(preds[e.Target], strconv.FormatInt(e.Source, 10))
+	}
+
+	steps := make([]models.WorkflowStep, 0, len(graph.Order))
+	for _, id := range graph.Order {
+		n := nodes[id]
+		step := models.WorkflowStep{ID: strconv.FormatInt(id, 10), Name: n.Label, Type: n.Type}
+		applyObjectParams(&step, n.Params)
+		if len(graph.Edges) > 0 {
+			step.Inputs = append([]string{}, preds[id]...)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// resolveObjectStep fills step from its stored object
+func (s *WorkflowService) resolveObjectStep(ctx context.Context, step *models.WorkflowStep) error {
+	object, err := s.objects.FindByID(ctx, *step.ObjectID)
+	if err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return err
+		}
+		return fmt.Errorf("step %q: object %d: %w", step.ID, *step.ObjectID, err)
+	}
+	if step.ID == "" {
+		step.ID = strconv.FormatInt(object.ID, 10)
+	}
+	if step.Type == "" {
+		step.Type = object.Type
+	}
+	if step.Name == "" {
+		step.Name = object.Label
+	}
+	applyObjectParams(step, object.Params)
+	return nil
+}
+
+// applyObjectParams reads the params the node panels store: config for data
+// and save nodes, code for python nodes
+func applyObjectParams(step *models.WorkflowStep, params map[string]interface{}) {
+	if step.Config == nil {
+		step.Config, _ = params["config"].(map[string]interface{})
+	}
+	if step.Code == "" {
+		step.Code, _ = params["code"].(string)
+	}
+	if step.ChunkRows == 0 {
+		if v, ok := params["chunkRows"].(float64); ok {
+			step.ChunkRows = int(v)
+		}
+	}
+}
+
+func normalizeStepType(t string) string {
+	switch strings.ToLower(t) {
+	case "data", "source":
+		return workflowStepData
+	case "python", "transform", "":
+		return workflowStepPython
+	case "join":
+		return workflowStepJoin
+	case "save", "sink":
+		return workflowStepSave
+	}
+	return ""
+}
+
+func stepArity(t string) int {
+	switch t {
+	case workflowStepData:
+		return 0
+	case workflowStepJoin:
+		return 2
+	}
+	return 1
+}
+
+// stepOutput is the data a step hands to its consumers: records or a JSON
+// value in memory, or the staged file of a Python step
+type stepOutput struct {
+	data   interface{}
+	staged *staging.Dataset
+}
+
+// value returns the output for consumers that take either form
+func (o *stepOutput) value() interface{} {
+	if o.staged != nil {
+		return o.staged
+	}
+	return o.data
+}
+
+// load returns the output in memory
+func (o *stepOutput) load() (interface{}, error) {
+	if o.staged != nil {
+		return o.staged.Load()
+	}
+	return o.data, nil
+}
+
+func (o *stepOutput) release() {
+	if o.staged != nil {
+		o.staged.Remove()
+	}
+	o.data, o.staged = nil, nil
+}
+
+// execute runs the steps, each as soon as its inputs are done. Outputs are
+// released once their last consumer has finished.
+func (p *WorkflowPlan) execute(ctx context.Context) models.WorkflowExecuteResponse {
+	started := time.Now()
+	n := len(p.steps)
+	results := make([]models.WorkflowStepResult, n)
+	outputs := make([]*stepOutput, n)
+	done := make([]chan struct{}, n)
+	consumers := make([]int, n)
+	for i := range p.steps {
+		done[i] = make(chan struct{})
+		for _, j := range p.inputs[i] {
+			consumers[j]++
+		}
+	}
+
+	var mu sync.Mutex
+	releaseInputs := func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, j := range p.inputs[i] {
+			if consumers[j]--; consumers[j] == 0 && outputs[j] != nil {
+				outputs[j].release()
+			}
+		}
+	}
+	report := func(i int) {
+		if p.onStep != nil {
+			p.onStep(i, results[i])
+		}
+	}
+
+	sem := make(chan struct{}, p.parallelism)
+	var wg sync.WaitGroup
+	for i := range p.steps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i])
+			step := p.steps[i]
+
+			var inputs []*stepOutput
+			skip := ""
+			for _, j := range p.inputs[i] {
+				<-done[j]
+				if !results[j].Success && skip == "" {
+					skip = fmt.Sprintf("skipped: step %q did not succeed", p.steps[j].ID)
+				}
+				inputs = append(inputs, outputs[j])
+			}
+			if skip == "" {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					skip = "skipped: workflow cancelled"
+				}
+			}
+			if skip != "" {
+				results[i] = models.WorkflowStepResult{ID: step.ID, Step: step.Name, Type: step.Type, Skipped: true, Error: skip}
+				releaseInputs(i)
+				report(i)
+				return
+			}
+
+			if p.onStart != nil {
+				p.onStart(i)
+			}
+			stepStarted := time.Now()
+			result, output := p.runStep(ctx, step, inputs)
+			releaseInputs(i)
+			result.ID, result.Step, result.Type = step.ID, step.Name, step.Type
+			result.DurationMs = time.Since(stepStarted).Milliseconds()
+			mu.Lock()
+			if consumers[i] == 0 && output != nil {
+				output.release()
+			} else {
+				outputs[i] = output
+			}
+			mu.Unlock()
+			results[i] = result
+			report(i)
+		}(i)
+	}
+	wg.Wait()
+
+	response := models.WorkflowExecuteResponse{Success: true, Steps: results}
+	for i, result := range results {
+		if outputs[i] != nil {
+			outputs[i].release()
+		}
+		if result.Success {
+			if p.steps[i].Type == workflowStepSave && result.Path != "" {
+				response.OutputPath = result.Path
+			}
+			continue
+		}
+		if response.Success {
+			response.Success = false
+			response.Error = fmt.Sprintf("Step %q failed: %s", result.Step, result.Error)
+		}
+	}
+	response.DurationMs = time.Since(started).Milliseconds()
+	return response
+}
+
+// runStep executes one step over the outputs of its inputs
+func (p *WorkflowPlan) runStep(ctx context.Context, step models.WorkflowStep, inputs []*stepOutput) (models.WorkflowStepResult, *stepOutput) {
+	s := p.s
+	failed := func(err error) (models.WorkflowStepResult, *stepOutput) {
+		return models.WorkflowStepResult{Success: false, Error: err.Error()}, nil
+	}
+
+	switch step.Type {
+	case workflowStepData:
+		resp := s.data.Load(ctx, step.Config)
+		if !resp.Success {
+			return models.WorkflowStepResult{Success: false, Message: resp.Preview, Error: resp.Error}, nil
+		}
+		return models.WorkflowStepResult{Success: true, Message: resp.Preview, Rows: rowCount(resp.Data)}, &stepOutput{data: resp.Data}
+
+	case workflowStepPython:
+		var input interface{} = p.event
+		if len(inputs) > 0 {
+			input = inputs[0].value()
+			if step.Code == "" {
+				// 그대로 넘기는 출력은 입력 파일을 소유하지 않으므로 메모리로 읽는다
+				data, err := inputs[0].load()
+				if err != nil {
+					return failed(err)
+				}
+				input = data
+			}
+		}
+		return s.runPython(ctx, input, step.Code, step.ChunkRows)
+
+	case workflowStepJoin:
+		var sides [2][]map[string]interface{}
+		for k := range sides {
+			data, err := inputs[k].load()
+			if err != nil {
+				return failed(err)
+			}
+			records, ok := toRecords(data)
+			if !ok {
+				return failed(errors.New("join inputs must be arrays of objects"))
+			}
+			sides[k] = records
+		}
+		joined, err := joinRecords(sides[0], sides[1], step.Config)
+		if err != nil {
+			return failed(err)
+		}
+		rows := len(joined)
+		return models.WorkflowStepResult{
+			Success: true,
+			Message: fmt.Sprintf("Joined %d and %d rows into %d", len(sides[0]), len(sides[1]), rows),
+			Rows:    &rows,
+		}, &stepOutput{data: joined}
+
+	case workflowStepSave:
+		resp := s.data.Save(ctx, inputs[0].value(), step.Config)
+		return models.WorkflowStepResult{Success: resp.Success, Message: resp.Message, Error: resp.Error, Path: resp.Path}, nil
+	}
+	return failed(fmt.Errorf("unknown step type %s", step.Type))
+}
+
+// runPython executes code over data on the Python executor. The data is
+// staged as a file rather than embedded in the code; without code it is
+// passed through.
+func (s *WorkflowService) runPython(ctx context.Context, data interface{}, code string, chunkRows int) (models.WorkflowStepResult, *stepOutput) {
+	if code == "" {
+		return models.WorkflowStepResult{
+			Success: true,
+			Message: "No Python code to execute, data passed through",
+			Rows:    rowCount(data),
+		}, &stepOutput{data: data}
+	}
+
+	output, logs, err := s.python.Run(ctx, data, code, PythonStepOptions{ChunkRows: chunkRows})
+	if err != nil {
+		return models.WorkflowStepResult{Success: false, Error: err.Error(), Logs: logs}, nil
+	}
+
+	result := models.WorkflowStepResult{Success: true, Message: "Python code executed successfully", Logs: logs}
+	if output.Kind == staging.KindRecords {
+		result.Message = fmt.Sprintf("Python code executed successfully (%d rows)", output.Rows)
+		result.Rows = &output.Rows
+	}
+	return result, &stepOutput{staged: output}
+}
+
+// rowCount is the number of records in data, or nil when it is not an array
+func rowCount(data interface{}) *int {
+	var n int
+	switch v := data.(type) {
+	case []interface{}:
+		n = len(v)
+	case []map[string]interface{}:
+		n = len(v)
+	default:
+		return nil
+	}
+	return &n
+}
+
+// joinRecords joins left and right on the config.on columns (a list or a
+// comma separated string). config.how is inner (default), left, right or
+// outer. Non-key right columns that also exist on the left get
+// config.suffix, "_right" by default. Null keys never match.
+func joinRecords(left, right []map[string]interface{}, config map[string]interface{}) ([]map[string]interface{}, error) {
+	var on []string
+	switch v := config["on"].(type) {
+	case string:
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				on = append(on, k)
+			}
+		}
+	case []interface{}:
+		for _, k := range v {
+			if s, ok := k.(string); ok && s != "" {
+				on = append(on, s)
+			}
+		}
+	}
+	if len(on) == 0 {
+		return nil, errors.New("join needs the key columns in config.on")
+	}
+	how, _ := config["how"].(string)
+	if how == "" {
+		how = "inner"
+	}
+	if how != "inner" && how != "left" && how != "right" && how != "outer" {
+		return nil, fmt.Errorf("unknown join type %q (expected inner, left, right or outer)", how)
+	}
+	suffix, _ := config["suffix"].(string)
+	if suffix == "" {
+		suffix = "_right"
+	}
+
+	isKey := make(map[string]bool, len(on))
+	for _, k := range on {
+		isKey[k] = true
+	}
+	leftColumns := make(map[string]bool)
+	for _, c := range dataformat.ColumnsOf(left) {
+		leftColumns[c] = true
+	}
+	keyOf := func(rec map[string]interface{}) (string, bool) {
+		values := make([]interface{}, len(on))
+		for i, k := range on {
+			if values[i] = rec[k]; values[i] == nil {
+				return "", false
+			}
+		}
+		b, err := json.Marshal(values)
+		return string(b), err == nil
+	}
+	merge := func(l, r map[string]interface{}) map[string]interface{} {
+		out := make(map[string]interface{}, len(l)+len(r))
+		for k, v := range l {
+			out[k] = v
+		}
+		for k, v := range r {
+			switch {
+			case isKey[k]:
+				if _, ok := out[k]; !ok {
+					out[k] = v
+				}
+			case leftColumns[k]:
+				out[k+suffix] = v
+			default:
+				out[k] = v
+			}
+		}
+		return out
+	}
+
+	index := make(map[string][]int)
+	for i, rec := range right {
+		if key, ok := keyOf(rec); ok {
+			index[key] = append(index[key], i)
+		}
+	}
+	matched := make([]bool, len(right))
+	joined := []map[string]interface{}{}
+	for _, l := range left {
+		var hits []int
+		if key, ok := keyOf(l); ok {
+			hits = index[key]
+		}
+		for _, i := range hits {
+			joined = append(joined, merge(l, right[i]))
+			matched[i] = true
+		}
+		if len(hits) == 0 && (how == "left" || how == "outer") {
+			joined = append(joined, merge(l, nil))
+		}
+	}
+	if how == "right" || how == "outer" {
+		for i, r := range right {
+			if !matched[i] {
+				joined = append(joined, merge(nil, r))
+			}
+		}
+	}
+	return joined, nil
+}
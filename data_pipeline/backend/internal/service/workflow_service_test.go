@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/models"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func planIDs(plan *WorkflowPlan) []string {
+	ids := make([]string, len(plan.steps))
+	for i, step := range plan.steps {
+		ids[i] = step.ID
+	}
+	return ids
+}
+
+func TestPlanWorkflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       models.WorkflowExecuteRequest
+		wantOrder []string
+		wantErr   string
+	}{
+		{
+			name: "chain by list order",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "load", Type: "source"},
+				{ID: "clean", Type: "transform"},
+				{ID: "out", Type: "sink"},
+			}},
+			wantOrder: []string{"load", "clean", "out"},
+		},
+		{
+			name: "inputs come first",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "out", Type: "save", Inputs: []string{"joined"}},
+				{ID: "joined", Type: "join", Inputs: []string{"a", "b"}},
+				{ID: "b", Type: "data"},
+				{ID: "a", Type: "data"},
+			}},
+			wantOrder: []string{"b", "a", "joined", "out"},
+		},
+		{
+			name: "legacy request",
+			req: models.WorkflowExecuteRequest{
+				DataConfig: map[string]interface{}{"dataSource": "manual"},
+				PythonCode: "result = data",
+			},
+			wantOrder: []string{"load", "python", "save"},
+		},
+		{
+			name: "cycle",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "python", Inputs: []string{"c"}},
+				{ID: "b", Type: "python", Inputs: []string{"a"}},
+				{ID: "c", Type: "python", Inputs: []string{"b"}},
+			}},
+			wantErr: "form a cycle",
+		},
+		{
+			name: "self loop",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "python", Inputs: []string{"a"}},
+			}},
+			wantErr: "form a cycle: a",
+		},
+		{
+			name: "join with one input",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "data"},
+				{ID: "j", Type: "join", Inputs: []string{"a"}},
+			}},
+			wantErr: `step "j": join steps take 2 input(s), got 1`,
+		},
+		{
+			name: "data step with an input",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "data"},
+				{ID: "b", Type: "data", Inputs: []string{"a"}},
+			}},
+			wantErr: `step "b": data steps take 0 input(s), got 1`,
+		},
+		{
+			name: "python step without inputs",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "p", Type: "python", Inputs: []string{}},
+			}},
+			wantErr: `step "p": python steps take 1 input(s), got 0`,
+		},
+		{
+			name: "python step reads the event",
+			req: models.WorkflowExecuteRequest{
+				Steps: []models.WorkflowStep{{ID: "p", Type: "python"}},
+				Event: map[string]interface{}{"path": "in.csv"},
+			},
+			wantOrder: []string{"p"},
+		},
+		{
+			name: "save step as input",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "data"},
+				{ID: "s", Type: "save"},
+				{ID: "p", Type: "python"},
+			}},
+			wantErr: `save step "s" has no output`,
+		},
+		{
+			name: "unknown input",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "p", Type: "python", Inputs: []string{"missing"}},
+			}},
+			wantErr: `unknown input "missing"`,
+		},
+		{
+			name: "duplicate id",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "data"},
+				{ID: "a", Type: "python"},
+			}},
+			wantErr: `duplicate step id "a"`,
+		},
+		{
+			name: "unknown type",
+			req: models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+				{ID: "a", Type: "pivot"},
+			}},
+			wantErr: `step "a": unknown type`,
+		},
+	}
+
+	s := &WorkflowService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := s.Plan(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := planIDs(plan); !reflect.DeepEqual(got, tt.wantOrder) {
+				t.Fatalf("order = %v, want %v", got, tt.wantOrder)
+			}
+			for i, in := range plan.inputs {
+				for _, j := range in {
+					if j >= i {
+						t.Fatalf("step %q reads %q, which runs after it", plan.steps[i].ID, plan.steps[j].ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPlanWorkflowParallelism(t *testing.T) {
+	s := &WorkflowService{}
+	steps := []models.WorkflowStep{{ID: "a", Type: "data"}}
+	for _, tc := range []struct{ give, want int }{
+		{0, defaultWorkflowParallelism},
+		{-1, defaultWorkflowParallelism},
+		{2, 2},
+		{100, maxWorkflowParallelism},
+	} {
+		plan, err := s.Plan(context.Background(), models.WorkflowExecuteRequest{Steps: steps, Parallelism: tc.give})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.parallelism != tc.want {
+			t.Errorf("parallelism %d: got %d, want %d", tc.give, plan.parallelism, tc.want)
+		}
+	}
+}
+
+func TestExecuteWorkflowSkipsDependents(t *testing.T) {
+	s := &WorkflowService{data: &DataService{}}
+	manual := func(data string) map[string]interface{} {
+		return map[string]interface{}{"dataSource": "manual", "manualData": data}
+	}
+	plan, err := s.Plan(context.Background(), models.WorkflowExecuteRequest{Steps: []models.WorkflowStep{
+		{ID: "users", Type: "data", Config: manual(`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`)},
+		{ID: "orders", Type: "data", Config: manual(`[{"id": 1, "total": 10}]`)},
+		{ID: "joined", Type: "join", Inputs: []string{"users", "orders"}, Config: map[string]interface{}{"on": "id"}},
+		{ID: "pass", Type: "python", Inputs: []string{"joined"}},
+		{ID: "broken", Type: "data", Config: manual(`not json`)},
+		{ID: "after", Type: "python", Inputs: []string{"broken"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var reported []string
+	plan.onStep = func(i int, result models.WorkflowStepResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, result.ID)
+	}
+	response := plan.execute(context.Background())
+
+	results := make(map[string]models.WorkflowStepResult)
+	for _, r := range response.Steps {
+		results[r.ID] = r
+	}
+	if response.Success || !strings.Contains(response.Error, `"broken"`) {
+		t.Fatalf("response = %+v, want a failure of step broken", response)
+	}
+	if r := results["pass"]; !r.Success || r.Rows == nil || *r.Rows != 1 {
+		t.Fatalf("pass = %+v, want 1 joined row", r)
+	}
+	if r := results["after"]; !r.Skipped || !strings.Contains(r.Error, `"broken"`) {
+		t.Fatalf("after = %+v, want it skipped", r)
+	}
+	if len(reported) != len(plan.steps) {
+		t.Fatalf("reported %v, want every step once", reported)
+	}
+}
+
+func TestJoinRecords(t *testing.T) {
+	type rec = map[string]interface{}
+	users := []rec{
+		{"id": 1.0, "name": "a"},
+		{"id": 2.0, "name": "b"},
+		{"name": "no id"},
+		{"id": nil, "name": "null id"},
+	}
+	orders := []rec{
+		{"id": 1.0, "name": "first", "total": 10.0},
+		{"id": 1.0, "name": "second", "total": 20.0},
+		{"id": 3.0, "name": "orphan", "total": 30.0},
+		{"id": nil, "name": "null", "total": 0.0},
+	}
+
+	tests := []struct {
+		name    string
+		left    []rec
+		right   []rec
+		config  rec
+		want    []rec
+		wantErr string
+	}{
+		{
+			name:   "inner",
+			left:   users,
+			right:  orders,
+			config: rec{"on": "id"},
+			want: []rec{
+				{"id": 1.0, "name": "a", "name_right": "first", "total": 10.0},
+				{"id": 1.0, "name": "a", "name_right": "second", "total": 20.0},
+			},
+		},
+		{
+			name:   "left keeps rows with missing and null keys",
+			left:   users,
+			right:  orders,
+			config: rec{"on": []interface{}{"id"}, "how": "left", "suffix": "_order"},
+			want: []rec{
+				{"id": 1.0, "name": "a", "name_order": "first", "total": 10.0},
+				{"id": 1.0, "name": "a", "name_order": "second", "total": 20.0},
+				{"id": 2.0, "name": "b"},
+				{"name": "no id"},
+				{"id": nil, "name": "null id"},
+			},
+		},
+		{
+			name:   "right",
+			left:   users[:2],
+			right:  orders[1:3],
+			config: rec{"on": "id", "how": "right"},
+			want: []rec{
+				{"id": 1.0, "name": "a", "name_right": "second", "total": 20.0},
+				{"id": 3.0, "name_right": "orphan", "total": 30.0},
+			},
+		},
+		{
+			name:   "outer",
+			left:   users[:2],
+			right:  orders[2:3],
+			config: rec{"on": "id", "how": "outer"},
+			want: []rec{
+				{"id": 1.0, "name": "a"},
+				{"id": 2.0, "name": "b"},
+				{"id": 3.0, "name_right": "orphan", "total": 30.0},
+			},
+		},
+		{
+			name:   "composite key",
+			left:   []rec{{"a": 1.0, "b": "x", "l": true}, {"a": 1.0, "b": "y", "l": true}},
+			right:  []rec{{"a": 1.0, "b": "y", "r": true}},
+			config: rec{"on": "a, b"},
+			want:   []rec{{"a": 1.0, "b": "y", "l": true, "r": true}},
+		},
+		{
+			name:   "key missing on one side",
+			left:   []rec{{"id": 1.0}},
+			right:  []rec{{"other": 1.0}},
+			config: rec{"on": "id"},
+			want:   []rec{},
+		},
+		{
+			name:    "no key columns",
+			config:  rec{"on": " , "},
+			wantErr: "config.on",
+		},
+		{
+			name:    "unknown join type",
+			config:  rec{"on": "id", "how": "cross"},
+			wantErr: `unknown join type "cross"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := joinRecords(tt.left, tt.right, tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("joined = %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"data-pipeline-backend/internal/auth"
+	"data-pipeline-backend/internal/config"
+	"data-pipeline-backend/internal/datasource"
+	"data-pipeline-backend/internal/models"
+	"data-pipeline-backend/internal/objectstore"
+	"data-pipeline-backend/internal/repository"
+	"data-pipeline-backend/internal/workspace"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// WorkspaceService opens the file workspace of the data endpoints: the
+// caller's own (users/<name>), a project's (projects/<id>) or a bucket
+// reached through one of the caller's connections
+type WorkspaceService struct {
+	access   *AccessService
+	connRepo *repository.ConnectionRepository
+}
+
+func NewWorkspaceService(access *AccessService, connRepo *repository.ConnectionRepository) *WorkspaceService {
+	return &WorkspaceService{access: access, connRepo: connRepo}
+}
+
+// Open returns the caller's workspace, or the project's when projectID is
+// set and the caller holds role in it. The caller closes it.
+func (s *WorkspaceService) Open(ctx context.Context, projectID *int64, role string) (workspace.Workspace, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, ErrForbidden
+	}
+
+	cfg := config.Get().Workspace
+	dir := filepath.Join(cfg.Root, "users", user.Username)
+	if projectID != nil {
+		if err := s.access.RequireProject(ctx, *projectID, role); err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(cfg.Root, "projects", strconv.FormatInt(*projectID, 10))
+	}
+
+	ws, err := workspace.OpenLocal(dir, int64(cfg.QuotaMB)*1024*1024)
+	if err != nil {
+		return nil, fmt.Errorf("워크스페이스를 열 수 없습니다: %w", err)
+	}
+	return ws, nil
+}
+
+// S3Location selects a bucket and key prefix through a stored connection.
+// Bucket and Prefix override the connection's values.
+type S3Location struct {
+	Connection string
+	Bucket     string
+	Prefix     string
+}
+
+// OpenS3 returns the workspace at an S3-compatible location, using the
+// endpoint and credentials of the caller's connection. Recognised values:
+// endpoint, region, bucket, prefix, accessKey, secretKey, sessionToken (or
+// the AWS_* environment names).
+func (s *WorkspaceService) OpenS3(ctx context.Context, loc S3Location) (workspace.Workspace, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, ErrForbidden
+	}
+	if loc.Connection == "" {
+		return nil, errors.New("connection is required for s3")
+	}
+	if s.connRepo == nil {
+		return nil, errors.New("connection repository is not configured")
+	}
+
+	conn, err := s.connRepo.FindByOwnerAndName(user.Username, loc.Connection)
+	if err == repository.ErrConnectionNotFound {
+		return nil, fmt.Errorf("connection %q not found", loc.Connection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err := connectionValues(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := objectstore.New(objectstore.Config{
+		Endpoint:     connectionValue(values, "endpoint", "AWS_ENDPOINT_URL", "S3_ENDPOINT"),
+		Region:       connectionValue(values, "region", "AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKey:    connectionValue(values, "accessKey", "AWS_ACCESS_KEY_ID"),
+		SecretKey:    connectionValue(values, "secretKey", "AWS_SECRET_ACCESS_KEY"),
+		SessionToken: connectionValue(values, "sessionToken", "AWS_SESSION_TOKEN"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connection %q: %w", loc.Connection, err)
+	}
+
+	bucket := loc.Bucket
+	if bucket == "" {
+		bucket = connectionValue(values, "bucket", "S3_BUCKET")
+	}
+	prefix := loc.Prefix
+	if prefix == "" {
+		prefix = connectionValue(values, "prefix", "S3_PREFIX")
+	}
+	return workspace.OpenS3(ctx, client, bucket, prefix)
+}
+
+// DatabaseLocation selects a database through a stored connection. SQLite
+// files are paths in the caller's workspace, or the project's when ProjectID
+// is set (with role Viewer for reads, Editor for writes).
+type DatabaseLocation struct {
+	Connection string
+	ProjectID  *int64
+	Write      bool
+}
+
+// OpenDatabase connects to the database of the caller's connection. The
+// kind is the connection type or its driver value (postgres, mysql, sqlite).
+// Recognised values: dsn, host, port, user, password, database, sslmode and,
+// for sqlite, path. The caller closes the database.
+func (s *WorkspaceService) OpenDatabase(ctx context.Context, loc DatabaseLocation) (*datasource.Database, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, ErrForbidden
+	}
+	if loc.Connection == "" {
+		return nil, errors.New("databaseConnection is required")
+	}
+	if s.connRepo == nil {
+		return nil, errors.New("connection repository is not configured")
+	}
+
+	conn, err := s.connRepo.FindByOwnerAndName(user.Username, loc.Connection)
+	if err == repository.ErrConnectionNotFound {
+		return nil, fmt.Errorf("connection %q not found", loc.Connection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err := connectionValues(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := datasource.NormalizeKind(connectionValue(values, "driver", "DB_DRIVER"))
+	if kind == "" {
+		kind = datasource.NormalizeKind(conn.Type)
+	}
+	params := datasource.Params{
+		DSN:      connectionValue(values, "dsn", "url", "DATABASE_URL"),
+		Host:     connectionValue(values, "host", "DB_HOST", "PGHOST", "MYSQL_HOST"),
+		Port:     connectionValue(values, "port", "DB_PORT", "PGPORT", "MYSQL_PORT"),
+		User:     connectionValue(values, "user", "username", "DB_USER", "PGUSER", "MYSQL_USER"),
+		Password: connectionValue(values, "password", "DB_PASSWORD", "PGPASSWORD", "MYSQL_PASSWORD"),
+		Database: connectionValue(values, "database", "dbname", "DB_NAME", "PGDATABASE", "MYSQL_DATABASE"),
+		SSLMode:  connectionValue(values, "sslmode", "PGSSLMODE"),
+		ReadOnly: !loc.Write,
+	}
+
+	if kind == datasource.SQLite {
+		// 파일 경로는 워크스페이스 안으로 한정한다
+		role := models.ProjectRoleViewer
+		if loc.Write {
+			role = models.ProjectRoleEditor
+		}
+		ws, err := s.Open(ctx, loc.ProjectID, role)
+		if err != nil {
+			return nil, err
+		}
+		defer ws.Close()
+		local, ok := ws.(*workspace.Local)
+		if !ok {
+			return nil, errors.New("sqlite needs a local workspace")
+		}
+		name := connectionValue(values, "path", "file", "database")
+		if params.Path, err = local.HostPath(name, loc.Write); err != nil {
+			return nil, fmt.Errorf("connection %q: %w", loc.Connection, err)
+		}
+	}
+
+	dsn, err := datasource.DSN(kind, params)
+	if err != nil {
+		return nil, fmt.Errorf("connection %q: %w", loc.Connection, err)
+	}
+	return datasource.Open(ctx, kind, dsn)
+}
+
+// connectionValue returns the first of keys set in values
+func connectionValue(values map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := values[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,251 @@
+// Package staging holds the datasets passed between the backend and the
+// Python executor. Both see the staging directory, possibly at different
+// paths (a shared volume), so steps exchange file references instead of
+// inlining data into code or stdout.
+package staging
+
+import (
+	"bufio"
+	"crypto/rand"
+	"data-pipeline-backend/internal/dataformat"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Dataset kinds recorded in the meta file of an output
+const (
+	KindRecords = "records" // the data file holds one JSON object per line
+	KindValue   = "value"   // the data file holds one JSON document
+)
+
+// Area is a staging directory
+type Area struct {
+	dir       string
+	kernelDir string
+}
+
+// New opens (and creates) the staging directory dir; kernelDir is where the
+// executor sees it, "" for the same path
+func New(dir, kernelDir string) (*Area, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if kernelDir == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		kernelDir = abs
+	}
+	return &Area{dir: dir, kernelDir: kernelDir}, nil
+}
+
+// Dataset is a staged file. Input datasets are written by the backend,
+// output datasets by the executor together with a meta file.
+type Dataset struct {
+	ID      string   `json:"id"`
+	Format  string   `json:"format"` // jsonl, parquet, or json for a value
+	Kind    string   `json:"kind"`
+	Rows    int      `json:"rows"`
+	Columns []string `json:"columns,omitempty"`
+
+	area *Area
+}
+
+// NewDataset reserves a dataset name without creating its file
+func (a *Area) NewDataset(format string) (*Dataset, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	id := time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+	return &Dataset{ID: id, Format: format, Kind: KindRecords, area: a}, nil
+}
+
+// Stage writes records as a new input dataset
+func (a *Area) Stage(records []map[string]interface{}, format string) (*Dataset, error) {
+	if format == "" {
+		format = dataformat.JSONL
+	}
+	if format != dataformat.JSONL && format != dataformat.Parquet {
+		return nil, fmt.Errorf("unsupported staging format: %s", format)
+	}
+	ds, err := a.NewDataset(format)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(ds.path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriterSize(f, 256*1024)
+	if len(records) > 0 || format == dataformat.JSONL {
+		err = dataformat.Write(bw, format, records, dataformat.WriteOptions{})
+	}
+	if flushErr := bw.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ds.Remove()
+		return nil, err
+	}
+	ds.Rows = len(records)
+	ds.Columns = dataformat.ColumnsOf(records)
+	return ds, nil
+}
+
+// StageValue writes a JSON value that is not an array of records
+func (a *Area) StageValue(v interface{}) (*Dataset, error) {
+	ds, err := a.NewDataset(dataformat.JSON)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(ds.path(), b, 0644); err != nil {
+		ds.Remove()
+		return nil, err
+	}
+	ds.Kind = KindValue
+	return ds, nil
+}
+
+// LoadOutput reads the meta file the executor wrote for ds
+func (a *Area) LoadOutput(ds *Dataset) error {
+	b, err := os.ReadFile(ds.metaPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("python step wrote no output")
+	}
+	if err != nil {
+		return err
+	}
+	var meta struct {
+		Kind    string   `json:"kind"`
+		Rows    int      `json:"rows"`
+		Columns []string `json:"columns"`
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return fmt.Errorf("invalid output metadata: %w", err)
+	}
+	if meta.Kind != KindRecords && meta.Kind != KindValue {
+		return fmt.Errorf("invalid output kind %q", meta.Kind)
+	}
+	ds.Kind, ds.Rows, ds.Columns = meta.Kind, meta.Rows, meta.Columns
+	return nil
+}
+
+// Sweep removes staged files older than maxAge, left behind by steps that
+// did not finish
+func (a *Area) Sweep(maxAge time.Duration) error {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(a.dir, e.Name()))
+		}
+	}
+	return nil
+}
+
+func (d *Dataset) fileName() string {
+	return d.ID + "." + d.Format
+}
+
+func (d *Dataset) path() string {
+	return filepath.Join(d.area.dir, d.fileName())
+}
+
+func (d *Dataset) metaPath() string {
+	return filepath.Join(d.area.dir, d.ID+".meta.json")
+}
+
+// KernelPath is the data file as the executor sees it
+func (d *Dataset) KernelPath() string {
+	return path.Join(d.area.kernelDir, d.fileName())
+}
+
+// KernelMetaPath is the meta file as the executor sees it
+func (d *Dataset) KernelMetaPath() string {
+	return path.Join(d.area.kernelDir, d.ID+".meta.json")
+}
+
+// Open reads the records of a records dataset, one at a time
+func (d *Dataset) Open() (dataformat.Reader, error) {
+	if d.Kind != KindRecords {
+		return nil, dataformat.ErrNotTabular
+	}
+	f, err := os.Open(d.path())
+	if err != nil {
+		return nil, err
+	}
+	rd, err := dataformat.NewReader(f, d.Format, dataformat.ReadOptions{})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileReader{Reader: rd, f: f}, nil
+}
+
+// Load reads the whole dataset: []interface{} of records, or the value
+func (d *Dataset) Load() (interface{}, error) {
+	if d.Kind == KindValue {
+		f, err := os.Open(d.path())
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		var v interface{}
+		if err := json.NewDecoder(bufio.NewReader(f)).Decode(&v); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid python output: %w", err)
+		}
+		return v, nil
+	}
+
+	rd, err := d.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	records := make([]interface{}, 0, d.Rows)
+	for {
+		row, err := rd.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid python output: %w", err)
+		}
+		records = append(records, row)
+	}
+}
+
+// Remove deletes the data and meta files
+func (d *Dataset) Remove() {
+	os.Remove(d.path())
+	os.Remove(d.metaPath())
+}
+
+type fileReader struct {
+	dataformat.Reader
+	f *os.File
+}
+
+func (r *fileReader) Close() error {
+	r.Reader.Close()
+	return r.f.Close()
+}
@@ -0,0 +1,295 @@
+package workspace
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sniffBytes is how much of a file is read to detect its format
+const sniffBytes = 512
+
+// Local is a workspace in a directory of the backend host. Every access goes
+// through an os.Root, so symlinks pointing outside the directory fail.
+type Local struct {
+	root  *os.Root
+	dir   string
+	quota int64
+}
+
+// OpenLocal opens (and creates) the workspace directory dir; quota is in
+// bytes, 0 for none
+func OpenLocal(dir string, quota int64) (*Local, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{root: root, dir: dir, quota: quota}, nil
+}
+
+func (l *Local) Close() error {
+	return l.root.Close()
+}
+
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	p, err := Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := l.root.Open(p)
+	if err != nil {
+		return nil, localError(err)
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, errors.New(name + " is a directory")
+	}
+	return f, nil
+}
+
+func (l *Local) Create(name string) (io.WriteCloser, error) {
+	p, err := Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	if p == "." {
+		return nil, errors.New("file name is required")
+	}
+
+	remaining := int64(-1)
+	if l.quota > 0 {
+		used, err := l.Usage()
+		if err != nil {
+			return nil, err
+		}
+		// 덮어쓰는 파일의 크기는 다시 쓸 수 있다
+		if info, err := l.root.Stat(p); err == nil && info.Mode().IsRegular() {
+			used -= info.Size()
+		}
+		if remaining = l.quota - used; remaining < 0 {
+			return nil, ErrQuotaExceeded
+		}
+	}
+
+	if err := l.mkdirAll(path.Dir(p)); err != nil {
+		return nil, localError(err)
+	}
+	f, err := l.root.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, localError(err)
+	}
+	return &quotaWriter{f: f, remaining: remaining, remove: func() { l.root.Remove(p) }}, nil
+}
+
+// HostPath returns the path of name on the backend host, for libraries that
+// open files themselves (e.g. SQLite). The file, or its directory when it
+// does not exist yet and create is set, must resolve inside the workspace.
+func (l *Local) HostPath(name string, create bool) (string, error) {
+	p, err := Clean(name)
+	if err != nil {
+		return "", err
+	}
+	if p == "." {
+		return "", errors.New("file name is required")
+	}
+	info, err := l.root.Stat(p)
+	switch {
+	case err == nil && info.IsDir():
+		return "", errors.New(name + " is a directory")
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist) && create:
+		if err := l.mkdirAll(path.Dir(p)); err != nil {
+			return "", localError(err)
+		}
+		// 아직 없는 파일이 밖을 가리키는 링크일 수 있다
+		if _, err := l.root.Lstat(p); err == nil {
+			return "", ErrOutsideWorkspace
+		}
+	default:
+		return "", localError(err)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(p)), nil
+}
+
+// mkdirAll creates dir and its parents inside the root
+func (l *Local) mkdirAll(dir string) error {
+	if dir == "." {
+		return nil
+	}
+	cur := ""
+	for _, part := range strings.Split(dir, "/") {
+		cur = path.Join(cur, part)
+		err := l.root.Mkdir(cur, 0755)
+		if err == nil || errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Stat(name string) (*Entry, error) {
+	p, err := Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := l.root.Stat(p)
+	if err != nil {
+		return nil, localError(err)
+	}
+	return l.entry(p, info), nil
+}
+
+func (l *Local) List(dir string, opts ListOptions) (*Page, error) {
+	p, err := Clean(dir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	fsys := l.root.FS()
+	page := &Page{Entries: []*Entry{}}
+	index := 0
+	// collect returns false once the page is full and one more entry exists
+	collect := func(name string, d fs.DirEntry) bool {
+		if index >= opts.Offset+opts.Limit {
+			next := index
+			page.NextOffset = &next
+			return false
+		}
+		info, err := d.Info()
+		if err == nil && d.Type()&fs.ModeSymlink != 0 {
+			// 링크는 대상 기준으로 보여주고, 루트 밖을 가리키면 숨긴다
+			info, err = l.root.Stat(name)
+		}
+		if err != nil {
+			return true
+		}
+		if index >= opts.Offset {
+			page.Entries = append(page.Entries, l.entry(name, info))
+		}
+		index++
+		return true
+	}
+
+	if !opts.Recursive {
+		entries, err := fs.ReadDir(fsys, p)
+		if err != nil {
+			return nil, localError(err)
+		}
+		for _, d := range entries {
+			if !collect(path.Join(p, d.Name()), d) {
+				break
+			}
+		}
+		return page, nil
+	}
+
+	err = fs.WalkDir(fsys, p, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == p {
+				return err
+			}
+			return nil // 읽을 수 없거나 루트 밖을 가리키는 항목은 건너뛴다
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !collect(name, d) {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, localError(err)
+	}
+	return page, nil
+}
+
+func (l *Local) Usage() (int64, error) {
+	var total int64
+	err := fs.WalkDir(l.root.FS(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// entry describes a file; the format of files without a known extension is
+// sniffed from their first bytes
+func (l *Local) entry(name string, info fs.FileInfo) *Entry {
+	e := &Entry{Path: name, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+	if e.IsDir {
+		e.Size = 0
+		return e
+	}
+	if e.Format = DetectFormat(name, nil); e.Format == "" && info.Mode().IsRegular() {
+		if f, err := l.root.Open(name); err == nil {
+			head := make([]byte, sniffBytes)
+			n, _ := io.ReadFull(f, head)
+			f.Close()
+			e.Format = DetectFormat(name, head[:n])
+		}
+	}
+	return e
+}
+
+// localError maps os errors to the package errors
+func localError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case strings.Contains(err.Error(), "path escapes from parent"):
+		return ErrOutsideWorkspace
+	}
+	return err
+}
+
+// quotaWriter fails writes past the remaining quota (-1 for none) and
+// removes the partial file on Close
+type quotaWriter struct {
+	f         *os.File
+	remaining int64
+	exceeded  bool
+	remove    func()
+}
+
+func (w *quotaWriter) Write(b []byte) (int, error) {
+	if w.exceeded {
+		return 0, ErrQuotaExceeded
+	}
+	if w.remaining >= 0 {
+		if int64(len(b)) > w.remaining {
+			w.exceeded = true
+			return 0, ErrQuotaExceeded
+		}
+		w.remaining -= int64(len(b))
+	}
+	return w.f.Write(b)
+}
+
+func (w *quotaWriter) Close() error {
+	err := w.f.Close()
+	if w.exceeded {
+		w.remove()
+		return ErrQuotaExceeded
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package workspace
+
+import (
+	"context"
+	"data-pipeline-backend/internal/objectstore"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// S3 is a workspace below a key prefix of an S3-compatible bucket. Keys are
+// the prefix joined with the cleaned path, so reads and writes cannot leave
+// the prefix. Directories are key prefixes ending in '/'. There is no quota.
+type S3 struct {
+	ctx    context.Context
+	client *objectstore.Client
+	bucket string
+	prefix string // "" or ends with '/'
+}
+
+// OpenS3 returns the workspace bucket/prefix; requests use ctx
+func OpenS3(ctx context.Context, client *objectstore.Client, bucket, prefix string) (*S3, error) {
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	p, err := Clean(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if p == "." {
+		p = ""
+	} else {
+		p += "/"
+	}
+	return &S3{ctx: ctx, client: client, bucket: bucket, prefix: p}, nil
+}
+
+func (s *S3) Close() error {
+	return nil
+}
+
+// key maps a workspace path to an object key; the root has no key
+func (s *S3) key(name string) (string, string, error) {
+	p, err := Clean(name)
+	if err != nil {
+		return "", "", err
+	}
+	if p == "." {
+		return "", "", errors.New("file name is required")
+	}
+	return p, s.prefix + p, nil
+}
+
+func (s *S3) Open(name string) (io.ReadCloser, error) {
+	_, key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := s.client.GetObject(s.ctx, s.bucket, key)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return body, nil
+}
+
+// Create uploads on Close, as a multipart upload when the output is large
+func (s *S3) Create(name string) (io.WriteCloser, error) {
+	_, key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.NewUploader(s.ctx, s.bucket, key, 0), nil
+}
+
+func (s *S3) Stat(name string) (*Entry, error) {
+	p, key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.HeadObject(s.ctx, s.bucket, key)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return &Entry{Path: p, Size: info.Size, ModTime: info.LastModified, Format: DetectFormat(p, nil)}, nil
+}
+
+// List pages through ListObjectsV2. Without Recursive, sub-prefixes are
+// returned as directories, sorted together with the objects by key.
+func (s *S3) List(dir string, opts ListOptions) (*Page, error) {
+	p, err := Clean(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := s.prefix
+	if p != "." {
+		prefix += p + "/"
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	in := objectstore.ListInput{Prefix: prefix}
+	if !opts.Recursive {
+		in.Delimiter = "/"
+	}
+
+	page := &Page{Entries: []*Entry{}}
+	index := 0
+	for {
+		out, err := s.client.ListObjects(s.ctx, s.bucket, in)
+		if err != nil {
+			return nil, s3Error(err)
+		}
+
+		// 한 응답 안에서 객체와 하위 prefix를 키 순서로 합친다
+		var entries []*Entry
+		for _, o := range out.Objects {
+			rel := strings.TrimPrefix(o.Key, s.prefix)
+			if strings.HasSuffix(o.Key, "/") {
+				continue // 디렉터리 표시용 빈 객체
+			}
+			entries = append(entries, &Entry{Path: rel, Size: o.Size, ModTime: o.LastModified, Format: DetectFormat(rel, nil)})
+		}
+		for _, cp := range out.CommonPrefixes {
+			entries = append(entries, &Entry{Path: strings.TrimSuffix(strings.TrimPrefix(cp, s.prefix), "/"), IsDir: true})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+		for _, e := range entries {
+			if index >= opts.Offset+opts.Limit {
+				next := index
+				page.NextOffset = &next
+				return page, nil
+			}
+			if index >= opts.Offset {
+				page.Entries = append(page.Entries, e)
+			}
+			index++
+		}
+		if out.NextContinuationToken == "" {
+			return page, nil
+		}
+		in.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+func (s *S3) Usage() (int64, error) {
+	var total int64
+	in := objectstore.ListInput{Prefix: s.prefix}
+	for {
+		out, err := s.client.ListObjects(s.ctx, s.bucket, in)
+		if err != nil {
+			return 0, s3Error(err)
+		}
+		for _, o := range out.Objects {
+			total += o.Size
+		}
+		if out.NextContinuationToken == "" {
+			return total, nil
+		}
+		in.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+func s3Error(err error) error {
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package workspace
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOutsideWorkspace is returned for paths that leave the workspace root
+	ErrOutsideWorkspace = errors.New("path is outside the workspace")
+	// ErrQuotaExceeded is returned when a write would exceed the quota
+	ErrQuotaExceeded = errors.New("workspace quota exceeded")
+	// ErrNotFound is returned for missing files
+	ErrNotFound = errors.New("file not found")
+)
+
+// Workspace is the file storage of one user or project. Paths are relative
+// to its root, use '/' and may start with '/'; they never resolve outside it.
+type Workspace interface {
+	// Open reads a file
+	Open(name string) (io.ReadCloser, error)
+	// Create replaces a file, creating its parent directories. Writes fail
+	// with ErrQuotaExceeded once the workspace would grow past its quota.
+	Create(name string) (io.WriteCloser, error)
+	Stat(name string) (*Entry, error)
+	// List returns the files of dir, or of every directory below it when
+	// recursive, in a stable order
+	List(dir string, opts ListOptions) (*Page, error)
+	// Usage is the total size of the stored files in bytes
+	Usage() (int64, error)
+	Close() error
+}
+
+// Entry describes a stored file or directory
+type Entry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir,omitempty"`
+	Format  string    `json:"format,omitempty"`
+}
+
+type ListOptions struct {
+	Recursive bool
+	Limit     int
+	Offset    int
+}
+
+// Page is one page of a listing
+type Page struct {
+	Entries    []*Entry `json:"entries"`
+	NextOffset *int     `json:"nextOffset,omitempty"` // nil on the last page
+}
+
+// Clean makes name relative to the workspace root; "" and "/" are the root
+// itself (".")
+func Clean(name string) (string, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", ErrOutsideWorkspace
+	}
+	p := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	if p == "/" {
+		return ".", nil
+	}
+	p = strings.TrimPrefix(p, "/")
+	// path.Clean은 루트 위의 ..를 없애므로 원래 입력에서 확인한다
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if part == ".." {
+			return "", ErrOutsideWorkspace
+		}
+	}
+	return p, nil
+}
+
+// formatsByExt maps file extensions to the format names of the data endpoints
+var formatsByExt = map[string]string{
+	".csv":     "csv",
+	".tsv":     "tsv",
+	".json":    "json",
+	".jsonl":   "jsonl",
+	".ndjson":  "jsonl",
+	".parquet": "parquet",
+	".xlsx":    "xlsx",
+	".txt":     "text",
+}
+
+// compressionExts are stripped before the format extension is looked up
+var compressionExts = map[string]bool{".gz": true, ".gzip": true, ".zst": true, ".zstd": true}
+
+// DetectFormat guesses the data format of a file from its extension (after
+// a .gz or .zst suffix), then from its first bytes; "" when unknown. head
+// must already be decompressed.
+func DetectFormat(name string, head []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	if compressionExts[ext] {
+		name = strings.TrimSuffix(name, path.Ext(name))
+		ext = strings.ToLower(path.Ext(name))
+	}
+	if f, ok := formatsByExt[ext]; ok {
+		return f
+	}
+
+	trimmed := strings.TrimLeft(string(head), " \t\r\n\ufeff")
+	firstLine, rest, multiline := strings.Cut(trimmed, "\n")
+	switch {
+	case strings.HasPrefix(string(head), "PAR1"):
+		return "parquet"
+	case strings.HasPrefix(string(head), "PK\x03\x04"):
+		return "xlsx"
+	case strings.HasPrefix(trimmed, "{") && multiline && strings.HasSuffix(strings.TrimSpace(firstLine), "}") &&
+		strings.HasPrefix(strings.TrimSpace(rest), "{"):
+		// 한 줄에 객체 하나씩
+		return "jsonl"
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		return "json"
+	case multiline && strings.Contains(firstLine, "\t"):
+		return "tsv"
+	case multiline && strings.Contains(firstLine, ","):
+		return "csv"
+	}
+	return ""
+}
@@ -21,8 +21,12 @@ STORAGE_DIR = "stored_scripts"
 if not os.path.exists(STORAGE_DIR):
     os.makedirs(STORAGE_DIR)
 
+# 요청이 정할 수 있는 실행 시간의 상한 (초)
+MAX_TIMEOUT = int(os.environ.get("EXECUTOR_MAX_TIMEOUT_SEC", "600"))
+
 class CodeRequest(BaseModel):
     code: str
+    timeout: int = 30  # 초; 데이터 스텝은 스테이징 파일을 읽느라 더 길게 요청한다
 
 @app.post("/api/execute")
 async def execute_python_code(request: CodeRequest):
@@ -41,7 +45,7 @@ async def execute_python_code(request: CodeRequest):
             ["python3", file_path],
             capture_output=True,
             text=True,
-            timeout=30  # 무한 루프 방지
+            timeout=max(1, min(request.timeout, MAX_TIMEOUT))  # 무한 루프 방지
         )
 
         # 4. 실행 흐름 로그 출력 (서버 터미널 확인용)
@@ -19,7 +19,7 @@ export interface DataNodePanelProps {
 export interface DataNodeConfig {
   dataSource: 'file' | 'database' | 'api' | 'manual'
   filePath?: string
-  fileFormat?: 'csv' | 'json' | 'parquet' | 'excel'
+  fileFormat?: 'csv' | 'tsv' | 'json' | 'jsonl' | 'parquet' | 'excel'
   databaseConnection?: string
   databaseQuery?: string
   apiUrl?: string
@@ -216,7 +216,9 @@ function DataNodePanel({
                   className="data-node-panel-select"
                 >
                   <option value="csv">CSV</option>
+                  <option value="tsv">TSV</option>
                   <option value="json">JSON</option>
+                  <option value="jsonl">JSON Lines</option>
                   <option value="parquet">Parquet</option>
                   <option value="excel">Excel</option>
                 </select>
@@ -18,7 +18,7 @@ export interface SaveNodePanelProps {
 export interface SaveNodeConfig {
   saveFormat: 'file' | 'database' | 'api'
   filePath?: string
-  fileFormat?: 'csv' | 'json' | 'parquet' | 'excel'
+  fileFormat?: 'csv' | 'tsv' | 'json' | 'jsonl' | 'parquet' | 'excel'
   databaseConnection?: string
   databaseTable?: string
   databaseMode?: 'append' | 'overwrite' | 'upsert'
@@ -184,7 +184,9 @@ function SaveNodePanel({
                   className="save-node-panel-select"
                 >
                   <option value="csv">CSV</option>
+                  <option value="tsv">TSV</option>
                   <option value="json">JSON</option>
+                  <option value="jsonl">JSON Lines</option>
                   <option value="parquet">Parquet</option>
                   <option value="excel">Excel</option>
                 </select>
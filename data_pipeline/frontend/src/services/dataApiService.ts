@@ -27,24 +27,103 @@ export interface DataSaveResponse {
   error?: string
 }
 
+export interface WorkflowStep {
+  id: string
+  type?: 'data' | 'python' | 'join' | 'save'
+  name?: string
+  objectId?: number
+  inputs?: string[]
+  config?: Record<string, any>
+  code?: string
+  chunkRows?: number
+}
+
 export interface WorkflowExecuteRequest {
-  dataConfig: Record<string, any>
-  pythonCode: string
-  saveConfig: Record<string, any>
+  steps?: WorkflowStep[]
+  flowId?: number
+  // Python steps without inputs receive it as evt, as with a flow trigger
+  event?: Record<string, any>
+  parallelism?: number
+  dataConfig?: Record<string, any>
+  pythonCode?: string
+  saveConfig?: Record<string, any>
+  chunkRows?: number
 }
 
 export interface WorkflowStepResult {
+  id?: string
   step: string
+  type?: string
   success: boolean
+  skipped?: boolean
   message: string
   error?: string
+  path?: string
+  rows?: number
+  logs?: string
+  durationMs?: number
 }
 
 export interface WorkflowExecuteResponse {
+  runId?: number
   success: boolean
   steps: WorkflowStepResult[]
   error?: string
   outputPath?: string
+  durationMs?: number
+}
+
+export type WorkflowRunStatus = 'pending' | 'running' | 'success' | 'failed' | 'cancelled'
+
+export interface WorkflowRunStep {
+  run_id: number
+  position: number
+  step_id: string
+  name: string
+  type: string
+  status: WorkflowRunStatus | 'skipped'
+  rows?: number
+  output_path?: string
+  message?: string
+  error?: string
+  logs?: string
+  started_at?: string
+  finished_at?: string
+  duration_ms?: number
+}
+
+export interface WorkflowRun {
+  run_id: number
+  f_id?: number
+  p_id?: number
+  s_id?: number
+  t_id?: number
+  actor: string
+  trigger: string
+  status: WorkflowRunStatus
+  request?: WorkflowExecuteRequest
+  output_path?: string
+  error?: string
+  created_at: string
+  started_at?: string
+  finished_at?: string
+  duration_ms?: number
+  steps?: WorkflowRunStep[]
+}
+
+export interface WorkflowRunFilter {
+  flowId?: number
+  status?: WorkflowRunStatus
+  trigger?: string
+  limit?: number
+  offset?: number
+}
+
+export interface WorkflowRunHandlers {
+  onRun?: (run: WorkflowRun) => void
+  onStep?: (step: WorkflowRunStep) => void
+  onDone?: (status: WorkflowRunStatus) => void
+  onError?: (error: Error) => void
 }
 
 export interface FilePreviewRequest {
@@ -151,7 +230,8 @@ export async function previewFile(request: FilePreviewRequest): Promise<DataLoad
 export async function executeWorkflow(request: WorkflowExecuteRequest): Promise<WorkflowExecuteResponse> {
   try {
     const baseUrl = config.apiUrl || '/api'
-    const url = `${baseUrl}/workflow/execute`
+    // 결과를 기다리는 실행; 백그라운드 실행은 startWorkflowRun
+    const url = `${baseUrl}/workflow/execute?wait=true`
     
     const response = await fetch(url, {
       method: 'POST',
@@ -182,9 +262,100 @@ export async function executeWorkflow(request: WorkflowExecuteRequest): Promise<
   }
 }
 
+export async function startWorkflowRun(request: WorkflowExecuteRequest): Promise<WorkflowRun> {
+  const baseUrl = config.apiUrl || '/api'
+  const response = await fetch(`${baseUrl}/workflow/execute`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(request),
+  })
+  if (!response.ok) {
+    const error = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(error.error || `HTTP ${response.status}`)
+  }
+  return response.json()
+}
+
+export async function listWorkflowRuns(filter: WorkflowRunFilter = {}): Promise<WorkflowRun[]> {
+  const baseUrl = config.apiUrl || '/api'
+  const params = new URLSearchParams()
+  Object.entries(filter).forEach(([key, value]) => {
+    if (value !== undefined && value !== '') params.set(key, String(value))
+  })
+  const response = await fetch(`${baseUrl}/workflow/runs?${params}`)
+  if (!response.ok) {
+    const error = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(error.error || `HTTP ${response.status}`)
+  }
+  return response.json()
+}
+
+export async function getWorkflowRun(id: number): Promise<WorkflowRun> {
+  const baseUrl = config.apiUrl || '/api'
+  const response = await fetch(`${baseUrl}/workflow/runs/${id}`)
+  if (!response.ok) {
+    const error = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(error.error || `HTTP ${response.status}`)
+  }
+  return response.json()
+}
+
+// cancelWorkflowRun stops a pending or running run; it ends as cancelled
+export async function cancelWorkflowRun(id: number): Promise<WorkflowRun> {
+  const baseUrl = config.apiUrl || '/api'
+  const response = await fetch(`${baseUrl}/workflow/runs/${id}/cancel`, { method: 'POST' })
+  if (!response.ok) {
+    const error = await response.json().catch(() => ({ error: response.statusText }))
+    throw new Error(error.error || `HTTP ${response.status}`)
+  }
+  return response.json()
+}
+
+// watchWorkflowRun follows a run until it finishes; close() the returned
+// source to stop early
+export function watchWorkflowRun(id: number, handlers: WorkflowRunHandlers): EventSource {
+  const baseUrl = config.apiUrl || '/api'
+  const source = new EventSource(`${baseUrl}/workflow/runs/${id}/events`)
+  const parse = (event: MessageEvent) => {
+    try {
+      return JSON.parse(event.data)
+    } catch (e) {
+      log.warn('Failed to parse SSE event', { data: event.data, error: e })
+      return null
+    }
+  }
+
+  source.addEventListener('run', (event) => {
+    const run = parse(event as MessageEvent)
+    if (run) handlers.onRun?.(run)
+  })
+  source.addEventListener('step', (event) => {
+    const step = parse(event as MessageEvent)
+    if (step) handlers.onStep?.(step)
+  })
+  source.addEventListener('done', (event) => {
+    source.close()
+    handlers.onDone?.(parse(event as MessageEvent)?.status)
+  })
+  source.onerror = () => {
+    // 서버가 닫은 스트림은 done 이후이므로 재연결하지 않는다
+    if (source.readyState === EventSource.CLOSED) return
+    source.close()
+    handlers.onError?.(new Error('Workflow run stream disconnected'))
+  }
+  return source
+}
+
 export const dataApiService = {
   loadData,
   saveData,
   previewFile,
   executeWorkflow,
+  startWorkflowRun,
+  listWorkflowRuns,
+  getWorkflowRun,
+  cancelWorkflowRun,
+  watchWorkflowRun,
 }
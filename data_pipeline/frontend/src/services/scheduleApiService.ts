@@ -0,0 +1,99 @@
+/**
+ * Flow Schedule API Service
+ */
+
+import { config } from '../config/env'
+import { log } from '../utils/logger'
+import type { ApiResponse } from './flowApiService'
+
+export type ScheduleOverlapPolicy = 'skip' | 'queue' | 'cancel'
+export type ScheduleCatchUp = 'none' | 'latest' | 'all'
+
+export interface FlowSchedule {
+  s_id: number
+  f_id: number
+  name: string
+  cron: string
+  timezone: string
+  enabled: boolean
+  overlap_policy: ScheduleOverlapPolicy
+  catch_up: ScheduleCatchUp
+  next_run_at?: string
+  queued_at?: string
+  last_run_id?: number
+  last_run_at?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+}
+
+// 수정 시 생략한 필드는 그대로 유지된다
+export interface FlowScheduleRequest {
+  f_id?: number
+  name?: string
+  cron?: string
+  timezone?: string
+  enabled?: boolean
+  overlap_policy?: ScheduleOverlapPolicy
+  catch_up?: ScheduleCatchUp
+}
+
+async function request<T>(path: string, init: RequestInit, action: string): Promise<ApiResponse<T>> {
+  try {
+    const baseUrl = config.apiUrl || '/api'
+    const response = await fetch(`${baseUrl}${path}`, {
+      ...init,
+      headers: {
+        'Content-Type': 'application/json',
+      },
+    })
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ error: response.statusText }))
+      return { success: false, message: error.error || `HTTP ${response.status}` }
+    }
+
+    const data: T = await response.json()
+    return { success: true, data }
+  } catch (error) {
+    log.error(`Failed to ${action}`, error)
+    return { success: false, message: error instanceof Error ? error.message : '알 수 없는 오류' }
+  }
+}
+
+export async function getSchedules(flowId?: number): Promise<ApiResponse<FlowSchedule[]>> {
+  const query = flowId !== undefined ? `?flowId=${flowId}` : ''
+  return request<FlowSchedule[]>(`/schedules${query}`, { method: 'GET' }, 'fetch schedules')
+}
+
+export async function getSchedule(id: number): Promise<ApiResponse<FlowSchedule>> {
+  return request<FlowSchedule>(`/schedules/${id}`, { method: 'GET' }, 'fetch schedule')
+}
+
+export async function createSchedule(schedule: FlowScheduleRequest): Promise<ApiResponse<FlowSchedule>> {
+  return request<FlowSchedule>(
+    '/schedules',
+    { method: 'POST', body: JSON.stringify(schedule) },
+    'create schedule',
+  )
+}
+
+export async function updateSchedule(id: number, schedule: FlowScheduleRequest): Promise<ApiResponse<FlowSchedule>> {
+  return request<FlowSchedule>(
+    `/schedules/${id}`,
+    { method: 'PUT', body: JSON.stringify(schedule) },
+    'update schedule',
+  )
+}
+
+export async function deleteSchedule(id: number): Promise<ApiResponse<{ message: string }>> {
+  return request<{ message: string }>(`/schedules/${id}`, { method: 'DELETE' }, 'delete schedule')
+}
+
+export const scheduleApiService = {
+  getSchedules,
+  getSchedule,
+  createSchedule,
+  updateSchedule,
+  deleteSchedule,
+}
@@ -0,0 +1,137 @@
+/**
+ * Flow Trigger API Service
+ */
+
+import { config } from '../config/env'
+import { log } from '../utils/logger'
+import type { ApiResponse } from './flowApiService'
+
+export type TriggerKind = 'file' | 'webhook' | 'kafka'
+export type TriggerFiringStatus = 'started' | 'skipped' | 'failed'
+
+// file: 데이터 스텝과 같은 위치 키에 감시할 dir, pattern, recursive
+export interface FileTriggerConfig {
+  dataSource?: 'file' | 's3'
+  projectId?: number
+  connection?: string
+  bucket?: string
+  prefix?: string
+  dir?: string
+  pattern?: string
+  recursive?: boolean
+}
+
+export interface KafkaTriggerConfig {
+  brokers?: string[]
+  topic: string
+  groupId?: string
+  startOffset?: 'latest' | 'earliest'
+}
+
+export interface FlowTrigger {
+  t_id: number
+  f_id: number
+  name: string
+  kind: TriggerKind
+  enabled: boolean
+  config: FileTriggerConfig | KafkaTriggerConfig | Record<string, never>
+  max_active: number
+  last_fired_at?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+  webhook_path?: string
+  // 생성하거나 rotate_secret 한 직후에만 온다
+  webhook_secret?: string
+}
+
+// 수정 시 생략한 필드는 그대로 유지된다
+export interface FlowTriggerRequest {
+  f_id?: number
+  name?: string
+  kind?: TriggerKind
+  enabled?: boolean
+  config?: FileTriggerConfig | KafkaTriggerConfig
+  max_active?: number
+  rotate_secret?: boolean
+}
+
+export interface TriggerFiring {
+  fi_id: number
+  t_id: number
+  run_id?: number
+  status: TriggerFiringStatus
+  source: string
+  payload?: Record<string, any>
+  error?: string
+  fired_at: string
+}
+
+async function request<T>(path: string, init: RequestInit, action: string): Promise<ApiResponse<T>> {
+  try {
+    const baseUrl = config.apiUrl || '/api'
+    const response = await fetch(`${baseUrl}${path}`, {
+      ...init,
+      headers: {
+        'Content-Type': 'application/json',
+      },
+    })
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ error: response.statusText }))
+      return { success: false, message: error.error || `HTTP ${response.status}` }
+    }
+
+    const data: T = await response.json()
+    return { success: true, data }
+  } catch (error) {
+    log.error(`Failed to ${action}`, error)
+    return { success: false, message: error instanceof Error ? error.message : '알 수 없는 오류' }
+  }
+}
+
+export async function getTriggers(flowId?: number): Promise<ApiResponse<FlowTrigger[]>> {
+  const query = flowId !== undefined ? `?flowId=${flowId}` : ''
+  return request<FlowTrigger[]>(`/triggers${query}`, { method: 'GET' }, 'fetch triggers')
+}
+
+export async function getTrigger(id: number): Promise<ApiResponse<FlowTrigger>> {
+  return request<FlowTrigger>(`/triggers/${id}`, { method: 'GET' }, 'fetch trigger')
+}
+
+export async function createTrigger(trigger: FlowTriggerRequest): Promise<ApiResponse<FlowTrigger>> {
+  return request<FlowTrigger>(
+    '/triggers',
+    { method: 'POST', body: JSON.stringify(trigger) },
+    'create trigger',
+  )
+}
+
+export async function updateTrigger(id: number, trigger: FlowTriggerRequest): Promise<ApiResponse<FlowTrigger>> {
+  return request<FlowTrigger>(
+    `/triggers/${id}`,
+    { method: 'PUT', body: JSON.stringify(trigger) },
+    'update trigger',
+  )
+}
+
+export async function deleteTrigger(id: number): Promise<ApiResponse<{ message: string }>> {
+  return request<{ message: string }>(`/triggers/${id}`, { method: 'DELETE' }, 'delete trigger')
+}
+
+export async function getTriggerFirings(id: number, limit = 50, offset = 0): Promise<ApiResponse<TriggerFiring[]>> {
+  return request<TriggerFiring[]>(
+    `/triggers/${id}/firings?limit=${limit}&offset=${offset}`,
+    { method: 'GET' },
+    'fetch trigger firings',
+  )
+}
+
+export const triggerApiService = {
+  getTriggers,
+  getTrigger,
+  createTrigger,
+  updateTrigger,
+  deleteTrigger,
+  getTriggerFirings,
+}